@@ -0,0 +1,157 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+const (
+	// apiKeyPrefix marks a bearer credential as an API key rather than a
+	// PASETO access token.
+	apiKeyPrefix = "glc_"
+	// apiKeyBytes is the randomness in an API key.
+	apiKeyBytes = 32
+	// apiKeyDisplayLength is how much of a key is kept in clear so users can
+	// tell their keys apart.
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string    `json:"name" binding:"required,max=100"`
+	Scopes    []string  `json:"scopes" binding:"required,min=1"`
+	ExpiresAt time.Time `json:"expires_at" binding:"required"`
+}
+
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey APIKeyResponse `json:"api_key"`
+	// Key is only returned once; the server keeps a hash of it.
+	Key string `json:"key"`
+}
+
+func toAPIKeyResponse(apiKey db.ApiKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: nullTimePtr(apiKey.LastUsedAt),
+		RevokedAt:  nullTimePtr(apiKey.RevokedAt),
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
+
+func (server *Server) createAPIKey(ctx *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scopes, err := policy.ParseScopes(req.Scopes)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.ExpiresAt.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	if req.ExpiresAt.After(time.Now().Add(server.config.APIKeyMaxDuration)) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "expires_at is further away than API keys may live"})
+		return
+	}
+
+	secret, err := util.RandomToken(apiKeyBytes)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate API key"})
+		return
+	}
+	key := apiKeyPrefix + secret
+
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+	apiKey, err := server.store.CreateAPIKey(ctx.Request.Context(), db.CreateAPIKeyParams{
+		UserID:    user.ID,
+		Name:      req.Name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   util.HashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create API key"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, CreateAPIKeyResponse{
+		APIKey: toAPIKeyResponse(apiKey),
+		Key:    key,
+	})
+}
+
+func (server *Server) listAPIKeys(ctx *gin.Context) {
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	apiKeys, err := server.store.ListAPIKeysByUser(ctx.Request.Context(), user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list API keys"})
+		return
+	}
+
+	rsp := make([]APIKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		rsp[i] = toAPIKeyResponse(apiKey)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"api_keys": rsp,
+		"count":    len(rsp),
+	})
+}
+
+func (server *Server) revokeAPIKey(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID"})
+		return
+	}
+
+	// Keys belonging to someone else look the same as missing ones.
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+	apiKey, err := server.store.RevokeAPIKey(ctx.Request.Context(), db.RevokeAPIKeyParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "API key not found or already revoked"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke API key"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"api_key": toAPIKeyResponse(apiKey),
+	})
+}
@@ -0,0 +1,511 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+func randomAPIKey(t *testing.T, user db.User, scopes ...policy.Scope) (string, db.ApiKey) {
+	secret, err := util.RandomToken(apiKeyBytes)
+	require.NoError(t, err)
+	key := apiKeyPrefix + secret
+
+	granted := make([]string, len(scopes))
+	for i, scope := range scopes {
+		granted[i] = string(scope)
+	}
+
+	return key, db.ApiKey{
+		ID:        gofakeit.Int64(),
+		UserID:    user.ID,
+		Name:      gofakeit.AppName(),
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   util.HashToken(key),
+		Scopes:    granted,
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestCreateAPIKeyAPI(t *testing.T) {
+	user := randomUserForSessions()
+	expiresAt := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"name":       "deploy hook",
+				"scopes":     []string{"posts:read", "POSTS:WRITE", "posts:read"},
+				"expires_at": expiresAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, "deploy hook", arg.Name)
+						require.Equal(t, []string{"posts:read", "posts:write"}, arg.Scopes)
+						require.True(t, strings.HasPrefix(arg.Prefix, apiKeyPrefix))
+						require.WithinDuration(t, expiresAt, arg.ExpiresAt, time.Second)
+						return db.ApiKey{
+							ID:        1,
+							UserID:    arg.UserID,
+							Name:      arg.Name,
+							Prefix:    arg.Prefix,
+							KeyHash:   arg.KeyHash,
+							Scopes:    arg.Scopes,
+							ExpiresAt: arg.ExpiresAt,
+							CreatedAt: time.Now(),
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var rsp CreateAPIKeyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.True(t, strings.HasPrefix(rsp.Key, apiKeyPrefix))
+				require.Equal(t, rsp.Key[:apiKeyDisplayLength], rsp.APIKey.Prefix)
+				require.Equal(t, []string{"posts:read", "posts:write"}, rsp.APIKey.Scopes)
+				require.NotContains(t, recorder.Body.String(), util.HashToken(rsp.Key))
+			},
+		},
+		{
+			name: "UnknownScope",
+			body: gin.H{
+				"name":       "deploy hook",
+				"scopes":     []string{"posts:destroy"},
+				"expires_at": expiresAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoScopes",
+			body: gin.H{
+				"name":       "deploy hook",
+				"scopes":     []string{},
+				"expires_at": expiresAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiryInThePast",
+			body: gin.H{
+				"name":       "deploy hook",
+				"scopes":     []string{"posts:read"},
+				"expires_at": time.Now().Add(-time.Hour),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiryTooFarAway",
+			body: gin.H{
+				"name":       "deploy hook",
+				"scopes":     []string{"posts:read"},
+				"expires_at": time.Now().Add(2 * 365 * 24 * time.Hour),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"name":       "deploy hook",
+				"scopes":     []string{"media:write"},
+				"expires_at": expiresAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/tokens", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListAPIKeysAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := randomUserForSessions()
+	_, first := randomAPIKey(t, user, policy.ScopePostsRead)
+	_, second := randomAPIKey(t, user, policy.ScopeMediaWrite)
+	second.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	store := mockdb.NewMockStore(ctrl)
+	expectAuthUser(store, user)
+	store.EXPECT().
+		ListAPIKeysByUser(gomock.Any(), gomock.Eq(user.ID)).
+		Times(1).
+		Return([]db.ApiKey{first, second}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/tokens", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), first.KeyHash)
+
+	var rsp struct {
+		APIKeys []APIKeyResponse `json:"api_keys"`
+		Count   int              `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, 2, rsp.Count)
+	require.Equal(t, first.Prefix, rsp.APIKeys[0].Prefix)
+	require.Nil(t, rsp.APIKeys[0].RevokedAt)
+	require.NotNil(t, rsp.APIKeys[1].RevokedAt)
+}
+
+func TestRevokeAPIKeyAPI(t *testing.T) {
+	user := randomUserForSessions()
+	_, apiKey := randomAPIKey(t, user, policy.ScopePostsRead)
+
+	testCases := []struct {
+		name          string
+		id            string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			id:   fmt.Sprint(apiKey.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				revoked := apiKey
+				revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+				expectAuthUser(store, user)
+				store.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Eq(db.RevokeAPIKeyParams{ID: apiKey.ID, UserID: user.ID})).
+					Times(1).
+					Return(revoked, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "revoked_at")
+			},
+		},
+		{
+			name: "NotFound",
+			id:   fmt.Sprint(apiKey.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   "abc",
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().RevokeAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, "/api/v1/tokens/"+tc.id, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAPIKeyAuthorization(t *testing.T) {
+	user := randomUserForSessions()
+	key, apiKey := randomAPIKey(t, user, policy.ScopePostsWrite)
+
+	// An empty JSON body passes authorization and then fails validation, so
+	// a 400 means the key was accepted.
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          io.Reader
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "ScopeGranted",
+			method: http.MethodPost,
+			url:    "/api/v1/posts",
+			body:   strings.NewReader("{}"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Eq(util.HashToken(key))).Times(1).Return(apiKey, nil)
+				expectAuthUser(store, user)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "ScopeMissing",
+			method: http.MethodPost,
+			url:    "/api/v1/media",
+			body:   strings.NewReader("{}"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+				expectAuthUser(store, user)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "media:write")
+			},
+		},
+		{
+			name:   "ReadScopeMissing",
+			method: http.MethodGet,
+			url:    "/api/v1/posts",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+				expectAuthUser(store, user)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().ListPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "posts:read")
+			},
+		},
+		{
+			name:   "AccountEndpoint",
+			method: http.MethodGet,
+			url:    "/api/v1/sessions",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+				expectAuthUser(store, user)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().ListSessionsByUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "Revoked",
+			method: http.MethodPost,
+			url:    "/api/v1/posts",
+			body:   strings.NewReader("{}"),
+			buildStubs: func(store *mockdb.MockStore) {
+				revoked := apiKey
+				revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(revoked, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "Expired",
+			method: http.MethodPost,
+			url:    "/api/v1/posts",
+			body:   strings.NewReader("{}"),
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := apiKey
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(expired, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "CreatedBeforePasswordChange",
+			method: http.MethodPost,
+			url:    "/api/v1/posts",
+			body:   strings.NewReader("{}"),
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := user
+				changed.PasswordChangedAt = apiKey.CreatedAt.Add(time.Minute)
+
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+				expectAuthUser(store, changed)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "UnknownKey",
+			method: http.MethodPost,
+			url:    "/api/v1/posts",
+			body:   strings.NewReader("{}"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(tc.method, tc.url, tc.body)
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, key))
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAPIKeyCannotManageAPIKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := randomUserForSessions()
+	key, apiKey := randomAPIKey(t, user, policy.Scopes...)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+	expectAuthUser(store, user)
+	store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"name":       "escalate",
+		"scopes":     []string{"users:write"},
+		"expires_at": time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/tokens", bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, key))
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
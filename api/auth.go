@@ -1,59 +1,256 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
 	"github.com/go-live-cms/go-live-cms/token"
+	"github.com/go-live-cms/go-live-cms/util"
 )
 
 const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload"
+	authorizationAPIKeyKey  = "authorization_api_key"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// authMiddleware rejects requests without a valid access token or API key. It
+// loads the caller from the store so role changes take effect immediately, and
+// stores it in the context under authorizationUserKey for the handlers that
+// need it.
+func authMiddleware(tokenMaker token.Maker, store db.Store, revoker token.Revoker) gin.HandlerFunc {
 	return gin.HandlerFunc(func(ctx *gin.Context) {
-		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
-
-		if len(authorizationHeader) == 0 {
-			err := errors.New("authorization header is not provided")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		if !authorizeRequest(ctx, tokenMaker, store, revoker, ctx.GetHeader(authorizationHeaderKey)) {
 			return
 		}
+		ctx.Next()
+	})
+}
 
-		fields := strings.Fields(authorizationHeader)
-		if len(fields) < 2 {
-			err := errors.New("invalid authorization header format")
+// mfaEnrollmentMiddleware authenticates like authMiddleware but also accepts
+// the "mfa_pending" token a login returns, so staff whose role requires 2FA
+// can enrol before they are given an access token.
+func mfaEnrollmentMiddleware(tokenMaker token.Maker, store db.Store, revoker token.Revoker) gin.HandlerFunc {
+	return gin.HandlerFunc(func(ctx *gin.Context) {
+		payload, err := verifyAuthorizationHeader(tokenMaker, ctx.GetHeader(authorizationHeaderKey), "access", "mfa_pending")
+		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
 
-		authorizationType := strings.ToLower(fields[0])
-		if authorizationType != authorizationTypeBearer {
-			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		if !setAuthorizedUser(ctx, store, revoker, payload) {
 			return
 		}
+		ctx.Next()
+	})
+}
 
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
-		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+// optionalAuthMiddleware lets anonymous requests through but authenticates
+// callers that send credentials the same way authMiddleware does. Invalid
+// credentials are still rejected rather than silently ignored.
+func optionalAuthMiddleware(tokenMaker token.Maker, store db.Store, revoker token.Revoker) gin.HandlerFunc {
+	return gin.HandlerFunc(func(ctx *gin.Context) {
+		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 && cookieCredential(ctx, accessTokenCookieName) == "" {
+			ctx.Next()
 			return
 		}
 
-		if payload.TokenType != "access" {
-			err := errors.New("invalid token type")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		if !authorizeRequest(ctx, tokenMaker, store, revoker, authorizationHeader) {
 			return
 		}
-
-		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	})
 }
+
+// authorizeRequest authenticates the bearer credential in authorizationHeader,
+// which is either an API key or an access token. Without the header, the
+// access token cookie set in cookie mode is accepted instead. It aborts the
+// request and returns false when the caller cannot be authorized.
+func authorizeRequest(ctx *gin.Context, tokenMaker token.Maker, store db.Store, revoker token.Revoker, authorizationHeader string) bool {
+	if len(authorizationHeader) == 0 {
+		if accessToken := cookieCredential(ctx, accessTokenCookieName); accessToken != "" {
+			return authorizeCookie(ctx, tokenMaker, store, revoker, accessToken)
+		}
+	}
+
+	credential, err := bearerCredential(authorizationHeader)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if strings.HasPrefix(credential, apiKeyPrefix) {
+		return setAPIKeyUser(ctx, store, credential)
+	}
+
+	payload, err := verifyToken(tokenMaker, credential, "access")
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
+	}
+	return setAuthorizedUser(ctx, store, revoker, payload)
+}
+
+// authorizeCookie authenticates an access token sent as a cookie. Browsers
+// attach cookies to cross-site requests too, so requests that can change
+// state must also prove they came from the admin UI with a CSRF token.
+func authorizeCookie(ctx *gin.Context, tokenMaker token.Maker, store db.Store, revoker token.Revoker, accessToken string) bool {
+	if !isSafeMethod(ctx.Request.Method) && !validCSRFToken(ctx) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing or invalid CSRF token"})
+		return false
+	}
+
+	payload, err := verifyToken(tokenMaker, accessToken, "access")
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
+	}
+	return setAuthorizedUser(ctx, store, revoker, payload)
+}
+
+// setAuthorizedUser loads the user a verified token belongs to and stores both
+// in the context. Revoked tokens, and tokens issued before the user's last
+// password change, are refused, so a logout or reset takes effect at once. It
+// aborts the request and returns false when the caller cannot be authorized.
+func setAuthorizedUser(ctx *gin.Context, store db.Store, revoker token.Revoker, payload *token.Payload) bool {
+	revoked, err := token.IsPayloadRevoked(ctx.Request.Context(), revoker, payload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token revocation"})
+		return false
+	}
+	if revoked {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+		return false
+	}
+
+	user, ok := loadAuthorizedUser(ctx, store, payload.UserID)
+	if !ok {
+		return false
+	}
+
+	if payload.IssuedAt.Before(user.PasswordChangedAt) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token was issued before the last password change"})
+		return false
+	}
+
+	ctx.Set(authorizationPayloadKey, payload)
+	ctx.Set(authorizationUserKey, user)
+	return true
+}
+
+// setAPIKeyUser authorizes a request made with an API key. The key acts as its
+// owner, limited to its scopes, and stops working when the owner's password
+// changes so a reset also locks out keys created with a stolen password.
+// Handlers that read authorizationPayloadKey get a payload describing the key.
+func setAPIKeyUser(ctx *gin.Context, store db.Store, key string) bool {
+	apiKey, err := store.GetAPIKeyByHash(ctx.Request.Context(), util.HashToken(key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+			return false
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to get API key"})
+		return false
+	}
+
+	if apiKey.RevokedAt.Valid {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key has been revoked"})
+		return false
+	}
+	if time.Now().After(apiKey.ExpiresAt) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key has expired"})
+		return false
+	}
+
+	user, ok := loadAuthorizedUser(ctx, store, apiKey.UserID)
+	if !ok {
+		return false
+	}
+
+	if apiKey.CreatedAt.Before(user.PasswordChangedAt) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key was created before the last password change"})
+		return false
+	}
+
+	if err := store.TouchAPIKey(ctx.Request.Context(), apiKey.ID); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to record API key use"})
+		return false
+	}
+
+	ctx.Set(authorizationPayloadKey, &token.Payload{
+		UserID:    user.ID,
+		Username:  user.Username,
+		IssuedAt:  apiKey.CreatedAt,
+		ExpiredAt: apiKey.ExpiresAt,
+		TokenType: "api_key",
+	})
+	ctx.Set(authorizationUserKey, user)
+	ctx.Set(authorizationAPIKeyKey, apiKey)
+	return true
+}
+
+// loadAuthorizedUser fetches the user a credential belongs to, aborting the
+// request when that fails.
+func loadAuthorizedUser(ctx *gin.Context, store db.Store, userID int64) (db.User, bool) {
+	user, err := store.GetUser(ctx.Request.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user no longer exists"})
+			return db.User{}, false
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return db.User{}, false
+	}
+	return user, true
+}
+
+// verifyAuthorizationHeader checks a bearer token and that its type is one of
+// tokenTypes. API keys are not accepted here.
+func verifyAuthorizationHeader(tokenMaker token.Maker, authorizationHeader string, tokenTypes ...string) (*token.Payload, error) {
+	credential, err := bearerCredential(authorizationHeader)
+	if err != nil {
+		return nil, err
+	}
+	return verifyToken(tokenMaker, credential, tokenTypes...)
+}
+
+// bearerCredential extracts the credential from a "Bearer <credential>" header.
+func bearerCredential(authorizationHeader string) (string, error) {
+	if len(authorizationHeader) == 0 {
+		return "", errors.New("authorization header is not provided")
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		return "", errors.New("invalid authorization header format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		return "", fmt.Errorf("unsupported authorization type %s", authorizationType)
+	}
+
+	return fields[1], nil
+}
+
+func verifyToken(tokenMaker token.Maker, accessToken string, tokenTypes ...string) (*token.Payload, error) {
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tokenType := range tokenTypes {
+		if payload.TokenType == tokenType {
+			return payload, nil
+		}
+	}
+	return nil, errors.New("invalid token type")
+}
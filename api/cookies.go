@@ -0,0 +1,109 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+// In cookie mode the tokens travel in HttpOnly cookies the admin UI cannot
+// read. The CSRF cookie is readable on purpose: the UI echoes it in the
+// X-CSRF-Token header, which a cross-site form or script cannot do.
+const (
+	accessTokenCookieName  = "glc_access_token"
+	refreshTokenCookieName = "glc_refresh_token"
+	csrfCookieName         = "glc_csrf_token"
+	csrfHeaderKey          = "X-CSRF-Token"
+
+	accessTokenCookiePath = "/api/v1"
+	// The refresh token is only needed by the refresh and logout endpoints.
+	refreshTokenCookiePath = "/api/v1/auth"
+	csrfCookiePath         = "/"
+
+	csrfTokenBytes = 32
+)
+
+// parseSameSite maps AUTH_COOKIE_SAME_SITE to its cookie attribute.
+func parseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "", "strict":
+		return http.SameSiteStrictMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("unknown SameSite mode %q", value)
+	}
+}
+
+// setAuthCookies stores a login's tokens in cookies along with a fresh CSRF
+// token, which it returns so the response body can carry it too.
+func (server *Server) setAuthCookies(ctx *gin.Context, accessToken string, accessExpiresAt time.Time, refreshToken string, refreshExpiresAt time.Time) (string, error) {
+	csrfToken, err := util.RandomToken(csrfTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	server.setCookie(ctx, accessTokenCookieName, accessToken, accessTokenCookiePath, accessExpiresAt, true)
+	server.setCookie(ctx, refreshTokenCookieName, refreshToken, refreshTokenCookiePath, refreshExpiresAt, true)
+	server.setCookie(ctx, csrfCookieName, csrfToken, csrfCookiePath, refreshExpiresAt, false)
+	return csrfToken, nil
+}
+
+// clearAuthCookies expires every cookie setAuthCookies sets.
+func (server *Server) clearAuthCookies(ctx *gin.Context) {
+	server.setCookie(ctx, accessTokenCookieName, "", accessTokenCookiePath, time.Unix(0, 0), true)
+	server.setCookie(ctx, refreshTokenCookieName, "", refreshTokenCookiePath, time.Unix(0, 0), true)
+	server.setCookie(ctx, csrfCookieName, "", csrfCookiePath, time.Unix(0, 0), false)
+}
+
+func (server *Server) setCookie(ctx *gin.Context, name, value, path string, expiresAt time.Time, httpOnly bool) {
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   server.config.AuthCookieDomain,
+		Expires:  expiresAt,
+		Secure:   server.config.AuthCookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: server.authCookieSameSite,
+	})
+}
+
+// isSafeMethod reports whether method cannot change state, so it needs no
+// CSRF token.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// validCSRFToken reports whether the request echoes its CSRF cookie in the
+// X-CSRF-Token header.
+func validCSRFToken(ctx *gin.Context) bool {
+	cookie, err := ctx.Cookie(csrfCookieName)
+	if err != nil || cookie == "" {
+		return false
+	}
+	header := ctx.GetHeader(csrfHeaderKey)
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+// cookieCredential returns the token stored in a cookie, or "" when the
+// request does not carry it.
+func cookieCredential(ctx *gin.Context, name string) string {
+	value, err := ctx.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,415 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+func newCookieTestServer(t *testing.T, store db.Store) *Server {
+	server := newTestServer(t, store)
+	server.config.AuthCookiesEnabled = true
+	server.config.AuthCookieDomain = "example.com"
+	return server
+}
+
+func responseCookies(recorder *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range recorder.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	return cookies
+}
+
+// addCookieAuthorization authenticates request the way the admin UI does in
+// cookie mode, echoing csrfToken in the header unless it is empty.
+func addCookieAuthorization(t *testing.T, request *http.Request, server *Server, user db.User, sessionID uuid.UUID, csrfToken string) {
+	accessToken, err := server.tokenMaker.CreateToken(sessionID, user.ID, user.Username, time.Minute)
+	require.NoError(t, err)
+
+	request.AddCookie(&http.Cookie{Name: accessTokenCookieName, Value: accessToken})
+	request.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "csrf-cookie-value"})
+	if csrfToken != "" {
+		request.Header.Set(csrfHeaderKey, csrfToken)
+	}
+}
+
+func TestCookieLoginAPI(t *testing.T) {
+	user := randomUserForSessions()
+	password := "testPassword123"
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user.HashedPassword = hashedPassword
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).
+		Times(1).
+		Return(db.UserMfa{}, sql.ErrNoRows)
+	store.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(randomSession(user), nil)
+
+	server := newCookieTestServer(t, store)
+	recorder := postLogin(t, server, user.Username, password)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp LoginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Empty(t, rsp.AccessToken)
+	require.Empty(t, rsp.RefreshToken)
+	require.NotEmpty(t, rsp.CSRFToken)
+	require.Equal(t, user.Username, rsp.User.Username)
+
+	cookies := responseCookies(recorder)
+
+	access := cookies[accessTokenCookieName]
+	require.NotNil(t, access)
+	require.True(t, access.HttpOnly)
+	require.True(t, access.Secure)
+	require.Equal(t, http.SameSiteStrictMode, access.SameSite)
+	require.Equal(t, "example.com", access.Domain)
+	require.Equal(t, accessTokenCookiePath, access.Path)
+	payload, err := server.tokenMaker.VerifyToken(access.Value)
+	require.NoError(t, err)
+	require.Equal(t, "access", payload.TokenType)
+
+	refresh := cookies[refreshTokenCookieName]
+	require.NotNil(t, refresh)
+	require.True(t, refresh.HttpOnly)
+	require.Equal(t, refreshTokenCookiePath, refresh.Path)
+	payload, err = server.tokenMaker.VerifyToken(refresh.Value)
+	require.NoError(t, err)
+	require.Equal(t, "refresh", payload.TokenType)
+
+	csrf := cookies[csrfCookieName]
+	require.NotNil(t, csrf)
+	require.False(t, csrf.HttpOnly)
+	require.Equal(t, rsp.CSRFToken, csrf.Value)
+}
+
+func TestCookieAuthMiddleware(t *testing.T) {
+	user := randomUserForSessions()
+	session := randomSession(user)
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "SafeMethodWithoutCSRF",
+			method: http.MethodGet,
+			url:    "/api/v1/sessions",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addCookieAuthorization(t, request, server, user, session.ID, "")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					ListSessionsByUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return([]db.Session{session}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "UnsafeMethodWithCSRF",
+			method: http.MethodPut,
+			url:    "/api/v1/sessions/block",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addCookieAuthorization(t, request, server, user, session.ID, "csrf-cookie-value")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "UnsafeMethodWithoutCSRF",
+			method: http.MethodPut,
+			url:    "/api/v1/sessions/block",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addCookieAuthorization(t, request, server, user, session.ID, "")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "UnsafeMethodWithWrongCSRF",
+			method: http.MethodPut,
+			url:    "/api/v1/sessions/block",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addCookieAuthorization(t, request, server, user, session.ID, "attacker-value")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			// A bearer header cannot be forged cross-site, so it needs no
+			// CSRF token even when cookies are sent along.
+			name:   "AuthorizationHeaderTakesPrecedence",
+			method: http.MethodPut,
+			url:    "/api/v1/sessions/block",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addCookieAuthorization(t, request, server, user, session.ID, "")
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidCookie",
+			method: http.MethodGet,
+			url:    "/api/v1/sessions",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				request.AddCookie(&http.Cookie{Name: accessTokenCookieName, Value: "invalid"})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newCookieTestServer(t, store)
+
+			var body *bytes.Reader
+			if tc.method == http.MethodGet {
+				body = bytes.NewReader(nil)
+			} else {
+				data, err := json.Marshal(gin.H{"session_id": session.ID})
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			}
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(tc.method, tc.url, body)
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			tc.setupAuth(t, request, server)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCookieRenewAccessTokenAPI(t *testing.T) {
+	user := randomUserForSessions()
+	session := randomSession(user)
+
+	testCases := []struct {
+		name          string
+		csrfToken     string
+		buildStubs    func(store *mockdb.MockStore, refreshToken string)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			csrfToken: "csrf-cookie-value",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				current := session
+				current.RefreshToken = refreshToken
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(current, nil)
+				store.EXPECT().
+					RotateSessionRefreshToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RotateSessionRefreshTokenParams) (db.Session, error) {
+						rotated := current
+						rotated.RefreshToken = arg.NewRefreshToken
+						return rotated, nil
+					})
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp RenewAccessTokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Empty(t, rsp.AccessToken)
+				require.Empty(t, rsp.RefreshToken)
+				require.NotEmpty(t, rsp.CSRFToken)
+
+				cookies := responseCookies(recorder)
+				require.Contains(t, cookies, accessTokenCookieName)
+				require.Contains(t, cookies, refreshTokenCookieName)
+				require.Equal(t, rsp.CSRFToken, cookies[csrfCookieName].Value)
+				require.NotEqual(t, "csrf-cookie-value", rsp.CSRFToken)
+			},
+		},
+		{
+			name:      "MissingCSRF",
+			csrfToken: "",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Empty(t, responseCookies(recorder))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newCookieTestServer(t, store)
+
+			refreshToken, err := server.tokenMaker.CreateRefreshToken(session.ID, user.ID, user.Username, time.Hour)
+			require.NoError(t, err)
+			tc.buildStubs(store, refreshToken)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+			require.NoError(t, err)
+			request.Header.Set("User-Agent", session.UserAgent)
+			request.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: refreshToken})
+			request.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "csrf-cookie-value"})
+			if tc.csrfToken != "" {
+				request.Header.Set(csrfHeaderKey, tc.csrfToken)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
+
+func TestCookieLogoutAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := randomUserForSessions()
+	session := randomSession(user)
+	store := mockdb.NewMockStore(ctrl)
+	server := newCookieTestServer(t, store)
+
+	refreshToken, err := server.tokenMaker.CreateRefreshToken(session.ID, user.ID, user.Username, time.Hour)
+	require.NoError(t, err)
+	session.RefreshToken = refreshToken
+
+	expectAuthUser(store, user)
+	store.EXPECT().
+		GetSession(gomock.Any(), gomock.Eq(session.ID)).
+		Times(1).
+		Return(session, nil)
+	store.EXPECT().
+		BlockSession(gomock.Any(), gomock.Eq(session.ID)).
+		Times(1).
+		Return(nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	require.NoError(t, err)
+	addCookieAuthorization(t, request, server, user, session.ID, "csrf-cookie-value")
+	request.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: refreshToken})
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	cookies := responseCookies(recorder)
+	for _, name := range []string{accessTokenCookieName, refreshTokenCookieName, csrfCookieName} {
+		require.Contains(t, cookies, name)
+		require.Empty(t, cookies[name].Value)
+		require.True(t, cookies[name].Expires.Before(time.Now()), name)
+	}
+}
+
+func TestParseSameSite(t *testing.T) {
+	testCases := []struct {
+		value    string
+		sameSite http.SameSite
+		ok       bool
+	}{
+		{"", http.SameSiteStrictMode, true},
+		{"strict", http.SameSiteStrictMode, true},
+		{"Lax", http.SameSiteLaxMode, true},
+		{"none", http.SameSiteNoneMode, true},
+		{"sometimes", 0, false},
+	}
+
+	for _, tc := range testCases {
+		sameSite, err := parseSameSite(tc.value)
+		if !tc.ok {
+			require.Error(t, err, tc.value)
+			continue
+		}
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.sameSite, sameSite, tc.value)
+	}
+}
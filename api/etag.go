@@ -0,0 +1,63 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag formats a row version as a strong entity tag.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", etag(version))
+}
+
+// setWeakETag marks a response whose body holds more than the versioned row,
+// such as a post with its relations embedded, so the tag is never taken for
+// that exact representation or sent back in If-Match.
+func setWeakETag(c *gin.Context, version int64) {
+	c.Header("ETag", "W/"+etag(version))
+}
+
+// ifMatchVersion reads the If-Match header into the version an update or
+// delete must still find in the database. A missing header or "*" places no
+// condition. The comparison happens in SQL, so a concurrent writer cannot
+// slip in between the check and the write. It writes the error response and
+// returns false when the header cannot match any version.
+func ifMatchVersion(c *gin.Context) (sql.NullInt64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return sql.NullInt64{}, true
+	}
+
+	if strings.Contains(header, ",") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must name a single ETag"})
+		return sql.NullInt64{}, false
+	}
+
+	// Weak tags never match under the strong comparison If-Match requires.
+	if strings.HasPrefix(header, "W/") || len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		preconditionFailed(c)
+		return sql.NullInt64{}, false
+	}
+
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil {
+		preconditionFailed(c)
+		return sql.NullInt64{}, false
+	}
+
+	return sql.NullInt64{Int64: version, Valid: true}, true
+}
+
+// preconditionFailed reports that If-Match named a version the resource has
+// moved past.
+func preconditionFailed(c *gin.Context) {
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "resource was modified by someone else, reload and try again"})
+}
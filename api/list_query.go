@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/listquery"
+)
+
+// postListSchema leaves status unfilterable: it has its own ?status=
+// parameter, which postStatusFilter checks against the caller's role.
+var postListSchema = listquery.Schema{
+	Fields: map[string]listquery.Field{
+		"id":           {Type: listquery.Int, Column: "id", Filterable: true, Sortable: true},
+		"title":        {Type: listquery.String, Column: "title", Filterable: true, Sortable: true},
+		"user_id":      {Type: listquery.Int, Column: "user_id", Filterable: true},
+		"author_id":    {Type: listquery.Int, Exists: "EXISTS (SELECT 1 FROM user_posts up WHERE up.post_id = posts.id AND up.user_id %s)", Filterable: true},
+		"taxonomy_id":  {Type: listquery.Int, Exists: "EXISTS (SELECT 1 FROM posts_taxonomies pt WHERE pt.post_id = posts.id AND pt.taxonomy_id %s)", Filterable: true},
+		"language":     {Type: listquery.String, Column: "language::text", Filterable: true},
+		"status":       {Type: listquery.String, Column: "status"},
+		"created_at":   {Type: listquery.Time, Column: "created_at", Filterable: true, Sortable: true},
+		"changed_at":   {Type: listquery.Time, Column: "changed_at", Filterable: true, Sortable: true},
+		"published_at": {Type: listquery.Time, Column: "published_at", Filterable: true, Sortable: true, Nullable: true},
+	},
+	DefaultSort: []listquery.Sort{{Field: "id", Desc: true}},
+	Tiebreaker:  "id",
+}
+
+var userListSchema = listquery.Schema{
+	Fields: map[string]listquery.Field{
+		"id":         {Type: listquery.Int, Column: "id", Filterable: true, Sortable: true},
+		"username":   {Type: listquery.String, Column: "username", Filterable: true, Sortable: true},
+		"full_name":  {Type: listquery.String, Column: "full_name", Filterable: true, Sortable: true},
+		"email":      {Type: listquery.String, Column: "email", Filterable: true, Sortable: true},
+		"role":       {Type: listquery.String, Column: "role", Filterable: true, Sortable: true},
+		"created_at": {Type: listquery.Time, Column: "created_at", Filterable: true, Sortable: true},
+	},
+	DefaultSort: []listquery.Sort{{Field: "id"}},
+	Tiebreaker:  "id",
+}
+
+var mediaListSchema = listquery.Schema{
+	Fields: map[string]listquery.Field{
+		"id":         {Type: listquery.Int, Column: "id", Filterable: true, Sortable: true},
+		"name":       {Type: listquery.String, Column: "name", Filterable: true, Sortable: true},
+		"mime_type":  {Type: listquery.String, Column: "mime_type", Filterable: true, Sortable: true},
+		"user_id":    {Type: listquery.Int, Column: "user_id", Filterable: true},
+		"size":       {Type: listquery.Int, Column: "size", Filterable: true, Sortable: true},
+		"created_at": {Type: listquery.Time, Column: "created_at", Filterable: true, Sortable: true},
+		"changed_at": {Type: listquery.Time, Column: "changed_at", Filterable: true, Sortable: true},
+	},
+	DefaultSort: []listquery.Sort{{Field: "created_at", Desc: true}},
+	Tiebreaker:  "id",
+}
+
+var taxonomyListSchema = listquery.Schema{
+	Fields: map[string]listquery.Field{
+		"id":   {Type: listquery.Int, Column: "id", Filterable: true, Sortable: true},
+		"name": {Type: listquery.String, Column: "name", Filterable: true, Sortable: true},
+	},
+	DefaultSort: []listquery.Sort{{Field: "name"}},
+	Tiebreaker:  "id",
+}
+
+const (
+	countExact     = "exact"
+	countEstimated = "estimated"
+	countNone      = "none"
+)
+
+// listPage is one page of a list request: its filters and sort, and the
+// offset or cursor it starts from.
+type listPage struct {
+	query  listquery.Query
+	limit  int32
+	offset int32
+	count  string
+}
+
+// parseListPage reads the limit, offset, cursor and count parameters and
+// the filter and sort parameters a list endpoint accepts. It writes the
+// error response and returns false when they are invalid.
+func parseListPage(c *gin.Context, schema listquery.Schema) (listPage, bool) {
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, err := strconv.ParseInt(limitStr, 10, 32)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return listPage{}, false
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.ParseInt(offsetStr, 10, 32)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+		return listPage{}, false
+	}
+
+	count := c.DefaultQuery("count", countExact)
+	if count != countExact && count != countEstimated && count != countNone {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid count parameter"})
+		return listPage{}, false
+	}
+
+	query, err := listquery.Parse(c.Request.URL.Query(), schema)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return listPage{}, false
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		if offset != 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset cannot be combined with cursor"})
+			return listPage{}, false
+		}
+		if err := query.SetCursor(cursor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return listPage{}, false
+		}
+	}
+
+	return listPage{query: query, limit: int32(limit), offset: int32(offset), count: count}, true
+}
+
+// params asks for one row more than the page holds, which tells whether
+// there is a next page.
+func (p listPage) params() db.ListFilteredParams {
+	return db.ListFilteredParams{
+		Filter: p.query.Filter(),
+		Limit:  p.limit + 1,
+		Offset: p.offset,
+	}
+}
+
+// pageRows trims the extra row that params asked for, puts the rows of a
+// backward page back in list order, and returns the cursors of the pages on
+// either side. key gives a row's value for each sortable field.
+func pageRows[T any](p listPage, rows []T, key func(T) map[string]any) ([]T, gin.H) {
+	more := len(rows) > int(p.limit)
+	if more {
+		rows = rows[:p.limit]
+	}
+	if p.query.Backward() {
+		slices.Reverse(rows)
+	}
+
+	cursors := gin.H{"next_cursor": nil, "prev_cursor": nil}
+	if !p.query.Keyset() || len(rows) == 0 {
+		return rows, cursors
+	}
+	hasNext, hasPrev := more, p.query.HasCursor() || p.offset > 0
+	if p.query.Backward() {
+		hasNext, hasPrev = true, more
+	}
+	if hasNext {
+		cursors["next_cursor"] = p.query.Cursor(key(rows[len(rows)-1]), false)
+	}
+	if hasPrev {
+		cursors["prev_cursor"] = p.query.Cursor(key(rows[0]), true)
+	}
+	return rows, cursors
+}
+
+// listTotal returns the meta fields for the total the count parameter asks
+// for. An exact total runs count; none skips the total. An estimate reads
+// the table statistics, which know nothing of filters, so it is only given
+// when the list has no conditions at all: neither the client's filters nor
+// the ones imposed on what the caller may see. Otherwise the total is exact.
+func (server *Server) listTotal(ctx context.Context, p listPage, table string, count func() (int64, error)) (gin.H, error) {
+	switch {
+	case p.count == countNone:
+		return gin.H{}, nil
+	case p.count == countEstimated && len(p.query.Conditions) == 0:
+		total, err := server.store.EstimateTableRows(ctx, table)
+		return gin.H{"total": total, "total_estimated": true}, err
+	default:
+		total, err := count()
+		return gin.H{"total": total}, err
+	}
+}
+
+// meta builds a list response's meta block from the page and extra fields.
+func (p listPage) meta(count int, extra ...gin.H) gin.H {
+	meta := gin.H{
+		"limit":  p.limit,
+		"offset": p.offset,
+		"count":  count,
+	}
+	for _, fields := range extra {
+		for key, value := range fields {
+			meta[key] = value
+		}
+	}
+	return meta
+}
+
+func postKey(post db.Post) map[string]any {
+	return map[string]any{
+		"id":         post.ID,
+		"title":      post.Title,
+		"created_at": post.CreatedAt,
+		"changed_at": post.ChangedAt,
+	}
+}
+
+func userKey(user db.User) map[string]any {
+	return map[string]any{
+		"id":         user.ID,
+		"username":   user.Username,
+		"full_name":  user.FullName,
+		"email":      user.Email,
+		"role":       user.Role,
+		"created_at": user.CreatedAt,
+	}
+}
+
+func mediaKey(media db.Medium) map[string]any {
+	return map[string]any{
+		"id":         media.ID,
+		"name":       media.Name,
+		"mime_type":  media.MimeType,
+		"size":       media.Size,
+		"created_at": media.CreatedAt,
+		"changed_at": media.ChangedAt,
+	}
+}
+
+func mediaWithCountKey(media db.ListMediaWithPostCountRow) map[string]any {
+	return map[string]any{
+		"id":         media.ID,
+		"name":       media.Name,
+		"mime_type":  media.MimeType,
+		"size":       media.Size,
+		"created_at": media.CreatedAt,
+		"changed_at": media.ChangedAt,
+	}
+}
+
+func taxonomyKey(taxonomy db.Taxonomy) map[string]any {
+	return map[string]any{"id": taxonomy.ID, "name": taxonomy.Name}
+}
+
+func taxonomyWithCountKey(taxonomy db.ListTaxonomiesWithPostCountRow) map[string]any {
+	return map[string]any{"id": taxonomy.ID, "name": taxonomy.Name}
+}
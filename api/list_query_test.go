@@ -0,0 +1,334 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/listquery"
+	"github.com/go-live-cms/go-live-cms/policy"
+)
+
+// countFilter is the filter a list's total is counted with, which has no
+// ORDER BY.
+func countFilter(filter db.ListFilter) db.ListFilter {
+	filter.OrderBy = ""
+	return filter
+}
+
+func postCursor(t *testing.T, values url.Values, post db.Post, before bool) string {
+	query, err := listquery.Parse(values, postListSchema)
+	require.NoError(t, err)
+	return query.Cursor(postKey(post), before)
+}
+
+type listMeta struct {
+	Total          *int64  `json:"total"`
+	TotalEstimated bool    `json:"total_estimated"`
+	Count          int     `json:"count"`
+	NextCursor     *string `json:"next_cursor"`
+	PrevCursor     *string `json:"prev_cursor"`
+}
+
+func TestListPostsPagination(t *testing.T) {
+	user := randomUserForPosts()
+	posts := make([]db.Post, 3)
+	for i := range posts {
+		posts[i] = randomPost(user)
+		posts[i].ID = int64(30 - i)
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "FirstPage",
+			query: url.Values{"limit": {"2"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(posts, nil)
+				store.EXPECT().
+					CountPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(3), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				meta := requireListMeta(t, recorder)
+				require.Equal(t, 2, meta.Count)
+				require.Equal(t, int64(3), *meta.Total)
+				require.Equal(t, postCursor(t, nil, posts[1], false), *meta.NextCursor)
+				require.Nil(t, meta.PrevCursor)
+			},
+		},
+		{
+			name:  "NextPage",
+			query: url.Values{"limit": {"2"}, "cursor": {postCursor(t, nil, posts[1], false)}, "count": {"none"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: db.ListFilter{
+							Where:   "status = $1 AND ((id < $2))",
+							Args:    []interface{}{policy.PostStatusPublished, posts[1].ID},
+							OrderBy: "id DESC",
+						},
+						Limit: 3,
+					}).
+					Times(1).
+					Return(posts[2:], nil)
+				store.EXPECT().
+					CountPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				meta := requireListMeta(t, recorder)
+				require.Equal(t, 1, meta.Count)
+				require.Nil(t, meta.Total)
+				require.Nil(t, meta.NextCursor)
+				require.Equal(t, postCursor(t, nil, posts[2], true), *meta.PrevCursor)
+			},
+		},
+		{
+			name:  "PreviousPage",
+			query: url.Values{"limit": {"2"}, "cursor": {postCursor(t, nil, posts[2], true)}, "count": {"none"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: db.ListFilter{
+							Where:   "status = $1 AND ((id > $2))",
+							Args:    []interface{}{policy.PostStatusPublished, posts[2].ID},
+							OrderBy: "id ASC",
+						},
+						Limit: 3,
+					}).
+					Times(1).
+					Return([]db.Post{posts[1], posts[0]}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostResponse `json:"posts"`
+					Meta  listMeta       `json:"meta"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Posts, 2)
+				require.Equal(t, posts[0].ID, response.Posts[0].ID)
+				require.Equal(t, posts[1].ID, response.Posts[1].ID)
+				require.Nil(t, response.Meta.PrevCursor)
+				require.Equal(t, postCursor(t, nil, posts[1], false), *response.Meta.NextCursor)
+			},
+		},
+		{
+			// Anonymous callers only see published posts, which the table
+			// statistics cannot count.
+			name:  "EstimatedCountIsExactForPublishedOnly",
+			query: url.Values{"count": {"estimated"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(posts, nil)
+				store.EXPECT().
+					EstimateTableRows(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CountPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(3), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				meta := requireListMeta(t, recorder)
+				require.Equal(t, int64(3), *meta.Total)
+				require.False(t, meta.TotalEstimated)
+				require.Nil(t, meta.NextCursor)
+			},
+		},
+		{
+			name:  "NullableSortHasNoCursors",
+			query: url.Values{"sort": {"-published_at"}, "count": {"none"}, "limit": {"2"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(posts, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				meta := requireListMeta(t, recorder)
+				require.Nil(t, meta.NextCursor)
+			},
+		},
+		{
+			name:  "InvalidCount",
+			query: url.Values{"count": {"approximate"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "CursorWithOffset",
+			query: url.Values{"cursor": {postCursor(t, nil, posts[1], false)}, "offset": {"10"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "CursorForAnotherSort",
+			query: url.Values{"cursor": {postCursor(t, nil, posts[1], false)}, "sort": {"title"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "MalformedCursor",
+			query: url.Values{"cursor": {"not-a-cursor"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/posts?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestEstimatedListTotal(t *testing.T) {
+	admin := randomUserForPosts()
+	admin.Role = policy.RoleAdmin
+	posts := []db.Post{randomPost(admin)}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Unfiltered",
+			query: url.Values{"count": {"estimated"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EstimateTableRows(gomock.Any(), "posts").
+					Times(1).
+					Return(int64(1200), nil)
+				store.EXPECT().
+					CountPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				meta := requireListMeta(t, recorder)
+				require.Equal(t, int64(1200), *meta.Total)
+				require.True(t, meta.TotalEstimated)
+			},
+		},
+		{
+			name:  "Filtered",
+			query: url.Values{"count": {"estimated"}, "filter[user_id]": {"7"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EstimateTableRows(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CountPostsFiltered(gomock.Any(), db.ListFilter{Where: "user_id = $1", Args: []interface{}{int64(7)}}).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				meta := requireListMeta(t, recorder)
+				require.Equal(t, int64(1), *meta.Total)
+				require.False(t, meta.TotalEstimated)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			expectAuthUser(store, admin)
+			store.EXPECT().
+				ListPostsFiltered(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(posts, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/posts?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func requireListMeta(t *testing.T, recorder *httptest.ResponseRecorder) listMeta {
+	var response struct {
+		Meta listMeta `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response.Meta
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-live-cms/go-live-cms/lockout"
+)
+
+type LockoutResponse struct {
+	Kind          string    `json:"kind"`
+	Subject       string    `json:"subject"`
+	Failures      int32     `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	LockedUntil   time.Time `json:"locked_until"`
+}
+
+func toLockoutResponse(entry lockout.Lockout) LockoutResponse {
+	return LockoutResponse{
+		Kind:          string(entry.Kind),
+		Subject:       entry.Subject,
+		Failures:      entry.Failures,
+		LastFailureAt: entry.LastFailureAt,
+		LockedUntil:   entry.LockedUntil,
+	}
+}
+
+// respondTooManyAttempts rejects a login that came before its wait was over.
+func respondTooManyAttempts(ctx *gin.Context, wait time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed login attempts, try again later"})
+}
+
+func (server *Server) listLockouts(ctx *gin.Context) {
+	lockouts, err := server.loginGuard.Lockouts(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list lockouts"})
+		return
+	}
+
+	rsp := make([]LockoutResponse, len(lockouts))
+	for i, entry := range lockouts {
+		rsp[i] = toLockoutResponse(entry)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"lockouts": rsp,
+		"count":    len(rsp),
+	})
+}
+
+// deleteLockout lifts a lockout, or any backoff, on a username, IP, or a
+// user's second factor.
+func (server *Server) deleteLockout(ctx *gin.Context) {
+	kind := lockout.Kind(ctx.Param("kind"))
+	if !slices.Contains(lockout.Kinds, kind) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "kind must be username, ip, mfa_user or mfa_token"})
+		return
+	}
+
+	key := lockout.Key{Kind: kind, Subject: ctx.Param("subject")}
+	if err := server.loginGuard.Reset(ctx.Request.Context(), key); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove lockout"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "lockout removed"})
+}
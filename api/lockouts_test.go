@@ -0,0 +1,326 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/lockout"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+// loginClientIP is the address httptest requests come from.
+const loginClientIP = "192.0.2.1"
+
+func postLogin(t *testing.T, server *Server, username, password string) *httptest.ResponseRecorder {
+	data, err := json.Marshal(gin.H{"username": username, "password": password})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(data))
+	request.Header.Set("Content-Type", "application/json")
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// postLoginVia logs in as if through a proxy that forwarded the request for
+// forwardedFor.
+func postLoginVia(t *testing.T, server *Server, forwardedFor, username, password string) *httptest.ResponseRecorder {
+	data, err := json.Marshal(gin.H{"username": username, "password": password})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(data))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Forwarded-For", forwardedFor)
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestLoginLockoutAPI(t *testing.T) {
+	user := randomUserForSessions()
+	password := "testPassword123"
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user.HashedPassword = hashedPassword
+
+	t.Run("Backoff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().
+			GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+			Times(1).
+			Return(user, nil)
+
+		server := newTestServer(t, store)
+
+		recorder := postLogin(t, server, user.Username, "wrongpassword")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+		// The second guess comes before the backoff is over, so the
+		// password is not even checked.
+		recorder = postLogin(t, server, user.Username, password)
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		require.Equal(t, "1", recorder.Header().Get("Retry-After"))
+	})
+
+	t.Run("LockedOut", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().
+			GetUserByUsername(gomock.Any(), gomock.Any()).
+			Times(0)
+
+		server := newTestServer(t, store)
+		for i := 0; i < 5; i++ {
+			_, err := server.loginGuard.Fail(context.Background(), lockout.UsernameKey(user.Username))
+			require.NoError(t, err)
+		}
+
+		recorder := postLogin(t, server, user.Username, password)
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		retryAfter, err := strconv.Atoi(recorder.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		require.InDelta(t, (15 * time.Minute).Seconds(), retryAfter, 2)
+	})
+
+	t.Run("IPLockedOut", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().
+			GetUserByUsername(gomock.Any(), gomock.Any()).
+			Times(0)
+
+		server := newTestServer(t, store)
+		for i := 0; i < 20; i++ {
+			_, err := server.loginGuard.Fail(context.Background(), lockout.IPKey(loginClientIP))
+			require.NoError(t, err)
+		}
+
+		recorder := postLogin(t, server, user.Username, password)
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	})
+
+	t.Run("ForwardedForIgnored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().
+			GetUserByUsername(gomock.Any(), gomock.Any()).
+			Times(0)
+
+		server := newTestServer(t, store)
+		for i := 0; i < 20; i++ {
+			_, err := server.loginGuard.Fail(context.Background(), lockout.IPKey(loginClientIP))
+			require.NoError(t, err)
+		}
+
+		recorder := postLoginVia(t, server, "203.0.113.9", user.Username, password)
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	})
+
+	t.Run("TrustedProxyForwardedFor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().
+			GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+			Times(1).
+			Return(user, nil)
+
+		config := newTestServer(t, store).config
+		config.TrustedProxies = "192.0.2.0/24"
+		server, err := NewServer(config, store, nil)
+		require.NoError(t, err)
+
+		// The proxy itself is locked out, but the client behind it is not.
+		for i := 0; i < 20; i++ {
+			_, err := server.loginGuard.Fail(context.Background(), lockout.IPKey(loginClientIP))
+			require.NoError(t, err)
+		}
+
+		recorder := postLoginVia(t, server, "203.0.113.9", user.Username, "wrongpassword")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+		// The failure was counted against the forwarded address.
+		wait, err := server.loginGuard.Check(context.Background(), lockout.IPKey("203.0.113.9"))
+		require.NoError(t, err)
+		require.Positive(t, wait)
+	})
+
+	t.Run("SuccessClearsUsername", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().
+			GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+			Times(3).
+			Return(user, nil)
+		store.EXPECT().
+			GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).
+			Times(1).
+			Return(db.UserMfa{}, sql.ErrNoRows)
+		store.EXPECT().
+			CreateSession(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(randomSession(user), nil)
+
+		// Without a backoff delay the guesses can come back to back.
+		counter := lockout.NewMemoryCounter()
+		server := newTestServer(t, store)
+		server.loginGuard = lockout.NewGuard(counter, map[lockout.Kind]lockout.Policy{
+			lockout.KindUsername: {MaxFailures: 3, LockoutDuration: 15 * time.Minute, Window: time.Hour},
+			lockout.KindIP:       {MaxFailures: 10, LockoutDuration: 15 * time.Minute, Window: time.Hour},
+		})
+
+		for i := 0; i < 2; i++ {
+			recorder := postLogin(t, server, user.Username, "wrongpassword")
+			require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		}
+		recorder := postLogin(t, server, user.Username, password)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		entry, err := counter.Get(context.Background(), lockout.UsernameKey(user.Username))
+		require.NoError(t, err)
+		require.Zero(t, entry.Failures)
+
+		entry, err = counter.Get(context.Background(), lockout.IPKey(loginClientIP))
+		require.NoError(t, err)
+		require.Equal(t, int32(2), entry.Failures)
+	})
+}
+
+func TestLockoutsAPI(t *testing.T) {
+	admin := randomUserNew()
+	admin.Role = policy.RoleAdmin
+	moderator := randomUserNew()
+	moderator.ID = admin.ID + 1
+	moderator.Role = policy.RoleModerator
+
+	lockUsername := func(t *testing.T, server *Server, username string) {
+		for i := 0; i < 5; i++ {
+			_, err := server.loginGuard.Fail(context.Background(), lockout.UsernameKey(username))
+			require.NoError(t, err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		user          db.User
+		setup         func(t *testing.T, server *Server)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "List",
+			method: http.MethodGet,
+			url:    "/api/v1/lockouts",
+			user:   admin,
+			setup: func(t *testing.T, server *Server) {
+				lockUsername(t, server, "alice")
+				_, err := server.loginGuard.Fail(context.Background(), lockout.UsernameKey("bob"))
+				require.NoError(t, err)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Lockouts []LockoutResponse `json:"lockouts"`
+					Count    int               `json:"count"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, 1, rsp.Count)
+				require.Equal(t, "username", rsp.Lockouts[0].Kind)
+				require.Equal(t, "alice", rsp.Lockouts[0].Subject)
+				require.Equal(t, int32(5), rsp.Lockouts[0].Failures)
+				require.WithinDuration(t, time.Now().Add(15*time.Minute), rsp.Lockouts[0].LockedUntil, 2*time.Second)
+			},
+		},
+		{
+			name:   "Unlock",
+			method: http.MethodDelete,
+			url:    "/api/v1/lockouts/username/alice",
+			user:   admin,
+			setup: func(t *testing.T, server *Server) {
+				lockUsername(t, server, "alice")
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				wait, err := server.loginGuard.Check(context.Background(), lockout.UsernameKey("alice"))
+				require.NoError(t, err)
+				require.Zero(t, wait)
+			},
+		},
+		{
+			name:   "InvalidKind",
+			method: http.MethodDelete,
+			url:    "/api/v1/lockouts/email/alice",
+			user:   admin,
+			setup:  func(t *testing.T, server *Server) {},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "Forbidden",
+			method: http.MethodDelete,
+			url:    "/api/v1/lockouts/username/alice",
+			user:   moderator,
+			setup: func(t *testing.T, server *Server) {
+				lockUsername(t, server, "alice")
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				wait, err := server.loginGuard.Check(context.Background(), lockout.UsernameKey("alice"))
+				require.NoError(t, err)
+				require.Positive(t, wait)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			expectAuthUser(store, tc.user)
+
+			server := newTestServer(t, store)
+			tc.setup(t, server)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(tc.method, tc.url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.ID, tc.user.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
@@ -1,13 +1,19 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/search"
 )
 
 type CreateMediaRequest struct {
@@ -33,9 +39,18 @@ type MediaResponse struct {
 	Alt         string    `json:"alt"`
 	MediaPath   string    `json:"media_path"`
 	UserID      int64     `json:"user_id"`
+	Size        int64     `json:"size"`
+	MimeType    string    `json:"mime_type"`
+	Checksum    string    `json:"checksum"`
+	Width       *int32    `json:"width,omitempty"`
+	Height      *int32    `json:"height,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	ChangedAt   time.Time `json:"changed_at"`
+	Version     int64     `json:"version"`
 	PostCount   *int64    `json:"post_count,omitempty"`
+
+	Renditions map[string]RenditionResponse `json:"renditions,omitempty"`
+	Srcset     []SrcsetEntry                `json:"srcset,omitempty"`
 }
 
 type PopularMediaResponse struct {
@@ -45,48 +60,94 @@ type PopularMediaResponse struct {
 	Alt         string    `json:"alt"`
 	MediaPath   string    `json:"media_path"`
 	UserID      int64     `json:"user_id"`
+	Size        int64     `json:"size"`
+	MimeType    string    `json:"mime_type"`
+	Checksum    string    `json:"checksum"`
+	Width       *int32    `json:"width,omitempty"`
+	Height      *int32    `json:"height,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	ChangedAt   time.Time `json:"changed_at"`
+	Version     int64     `json:"version"`
 	PostCount   int64     `json:"post_count"`
 }
 
-func toMediaResponse(media db.Medium) MediaResponse {
+func (server *Server) toMediaResponse(ctx context.Context, media db.Medium) MediaResponse {
 	return MediaResponse{
 		ID:          media.ID,
 		Name:        media.Name,
 		Description: media.Description,
 		Alt:         media.Alt,
-		MediaPath:   media.MediaPath,
+		MediaPath:   server.mediaPath(ctx, media.StorageKey, media.MediaPath),
 		UserID:      media.UserID,
+		Size:        media.Size,
+		MimeType:    media.MimeType,
+		Checksum:    media.Checksum,
+		Width:       nullInt32Ptr(media.Width),
+		Height:      nullInt32Ptr(media.Height),
 		CreatedAt:   media.CreatedAt,
 		ChangedAt:   media.ChangedAt,
+		Version:     media.Version,
+	}
+}
+
+// mediaPath resolves the public URL for stored files at response time, so
+// presigned URLs are fresh on every read. Rows without a storage key keep the
+// caller-supplied path.
+func (server *Server) mediaPath(ctx context.Context, storageKey string, fallback string) string {
+	if storageKey == "" {
+		return fallback
+	}
+	url, err := server.storage.URL(ctx, storageKey)
+	if err != nil {
+		log.Printf("failed to build URL for stored file %s: %v", storageKey, err)
+		return fallback
+	}
+	return url
+}
+
+func nullInt32Ptr(value sql.NullInt32) *int32 {
+	if !value.Valid {
+		return nil
 	}
+	return &value.Int32
 }
 
-func toMediaWithCountResponse(row db.ListMediaWithPostCountRow) MediaResponse {
+func (server *Server) toMediaWithCountResponse(ctx context.Context, row db.ListMediaWithPostCountRow) MediaResponse {
 	return MediaResponse{
 		ID:          row.ID,
 		Name:        row.Name,
 		Description: row.Description,
 		Alt:         row.Alt,
-		MediaPath:   row.MediaPath,
+		MediaPath:   server.mediaPath(ctx, row.StorageKey, row.MediaPath),
 		UserID:      row.UserID,
+		Size:        row.Size,
+		MimeType:    row.MimeType,
+		Checksum:    row.Checksum,
+		Width:       nullInt32Ptr(row.Width),
+		Height:      nullInt32Ptr(row.Height),
 		CreatedAt:   row.CreatedAt,
 		ChangedAt:   row.ChangedAt,
+		Version:     row.Version,
 		PostCount:   &row.PostCount,
 	}
 }
 
-func toPopularMediaResponse(row db.GetPopularMediaRow) PopularMediaResponse {
+func (server *Server) toPopularMediaResponse(ctx context.Context, row db.GetPopularMediaRow) PopularMediaResponse {
 	return PopularMediaResponse{
 		ID:          row.ID,
 		Name:        row.Name,
 		Description: row.Description,
 		Alt:         row.Alt,
-		MediaPath:   row.MediaPath,
+		MediaPath:   server.mediaPath(ctx, row.StorageKey, row.MediaPath),
 		UserID:      row.UserID,
+		Size:        row.Size,
+		MimeType:    row.MimeType,
+		Checksum:    row.Checksum,
+		Width:       nullInt32Ptr(row.Width),
+		Height:      nullInt32Ptr(row.Height),
 		CreatedAt:   row.CreatedAt,
 		ChangedAt:   row.ChangedAt,
+		Version:     row.Version,
 		PostCount:   row.PostCount,
 	}
 }
@@ -98,7 +159,7 @@ func (server *Server) createMedia(c *gin.Context) {
 		return
 	}
 
-	userID := int64(1)
+	userID := authActor(c).UserID
 
 	if req.PostID != nil {
 
@@ -110,13 +171,15 @@ func (server *Server) createMedia(c *gin.Context) {
 		}
 
 		result, err := server.store.CreateMediaAndLinkTx(c.Request.Context(), db.CreateMediaAndLinkTxParams{
-			Name:        req.Name,
-			Description: req.Description,
-			Alt:         req.Alt,
-			MediaPath:   req.MediaPath,
-			UserID:      userID,
-			PostID:      *req.PostID,
-			Order:       order,
+			CreateMediaParams: db.CreateMediaParams{
+				Name:        req.Name,
+				Description: req.Description,
+				Alt:         req.Alt,
+				MediaPath:   req.MediaPath,
+				UserID:      userID,
+			},
+			PostID: *req.PostID,
+			Order:  order,
 		})
 		if err != nil {
 			if containsString(err.Error(), "post not found") {
@@ -128,7 +191,7 @@ func (server *Server) createMedia(c *gin.Context) {
 		}
 
 		c.JSON(http.StatusCreated, gin.H{
-			"media":      toMediaResponse(result.Media),
+			"media":      server.toMediaResponse(c.Request.Context(), result.Media),
 			"post_media": result.PostMedia,
 		})
 	} else {
@@ -146,7 +209,7 @@ func (server *Server) createMedia(c *gin.Context) {
 		}
 
 		c.JSON(http.StatusCreated, gin.H{
-			"media": toMediaResponse(media),
+			"media": server.toMediaResponse(c.Request.Context(), media),
 		})
 	}
 }
@@ -169,97 +232,72 @@ func (server *Server) getMediaByID(c *gin.Context) {
 		return
 	}
 
+	responses := []MediaResponse{server.toMediaResponse(c.Request.Context(), media)}
+	if err := server.attachRenditions(c.Request.Context(), responses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get media renditions"})
+		return
+	}
+
+	setETag(c, media.Version)
 	c.JSON(http.StatusOK, gin.H{
-		"media": toMediaResponse(media),
+		"media": responses[0],
 	})
 }
 
 func (server *Server) getMedia(c *gin.Context) {
+	withCounts := c.DefaultQuery("with_counts", "false") == "true"
 
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-	withCounts := c.DefaultQuery("with_counts", "false")
-
-	limit, err := strconv.ParseInt(limitStr, 10, 32)
-	if err != nil || limit <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
-		return
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset, err := strconv.ParseInt(offsetStr, 10, 32)
-	if err != nil || offset < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+	page, ok := parseListPage(c, mediaListSchema)
+	if !ok {
 		return
 	}
 
-	if withCounts == "true" {
-
-		media, err := server.store.ListMediaWithPostCount(c.Request.Context(), db.ListMediaWithPostCountParams{
-			Limit:  int32(limit),
-			Offset: int32(offset),
-		})
+	var (
+		mediaResponses []MediaResponse
+		cursors        gin.H
+	)
+	if withCounts {
+		media, err := server.store.ListMediaWithPostCountFiltered(c.Request.Context(), page.params())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list media"})
 			return
 		}
+		media, cursors = pageRows(page, media, mediaWithCountKey)
 
-		mediaResponses := make([]MediaResponse, len(media))
+		mediaResponses = make([]MediaResponse, len(media))
 		for i, m := range media {
-			mediaResponses[i] = toMediaWithCountResponse(m)
+			mediaResponses[i] = server.toMediaWithCountResponse(c.Request.Context(), m)
 		}
-
-		totalCount, err := server.store.CountTotalMedia(c.Request.Context())
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count total media"})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"media": mediaResponses,
-			"meta": gin.H{
-				"total":       totalCount,
-				"limit":       limit,
-				"offset":      offset,
-				"count":       len(mediaResponses),
-				"with_counts": true,
-			},
-		})
 	} else {
-
-		media, err := server.store.ListMedia(c.Request.Context(), db.ListMediaParams{
-			Limit:  int32(limit),
-			Offset: int32(offset),
-		})
+		media, err := server.store.ListMediaFiltered(c.Request.Context(), page.params())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list media"})
 			return
 		}
+		media, cursors = pageRows(page, media, mediaKey)
 
-		mediaResponses := make([]MediaResponse, len(media))
+		mediaResponses = make([]MediaResponse, len(media))
 		for i, m := range media {
-			mediaResponses[i] = toMediaResponse(m)
-		}
-
-		total, err := server.store.CountTotalMedia(c.Request.Context())
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count total media"})
-			return
+			mediaResponses[i] = server.toMediaResponse(c.Request.Context(), m)
 		}
+	}
+	if err := server.attachRenditions(c.Request.Context(), mediaResponses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get media renditions"})
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"media": mediaResponses,
-			"meta": gin.H{
-				"limit":       limit,
-				"offset":      offset,
-				"count":       len(mediaResponses),
-				"with_counts": false,
-				"total":       total,
-			},
-		})
+	total, err := server.listTotal(c.Request.Context(), page, "media", func() (int64, error) {
+		return server.store.CountMediaFiltered(c.Request.Context(), page.query.CountFilter())
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count total media"})
+		return
 	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"media": mediaResponses,
+		"meta":  page.meta(len(mediaResponses), total, cursors, gin.H{"with_counts": withCounts}),
+	})
 }
 
 func (server *Server) getPopularMedia(c *gin.Context) {
@@ -282,7 +320,7 @@ func (server *Server) getPopularMedia(c *gin.Context) {
 
 	mediaResponses := make([]PopularMediaResponse, len(media))
 	for i, m := range media {
-		mediaResponses[i] = toPopularMediaResponse(m)
+		mediaResponses[i] = server.toPopularMediaResponse(c.Request.Context(), m)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -319,11 +357,16 @@ func (server *Server) searchMedia(c *gin.Context) {
 		return
 	}
 
-	media, err := server.store.SearchMediaByName(c.Request.Context(), db.SearchMediaByNameParams{
-		Column1: sql.NullString{String: query, Valid: true},
-		Limit:   int32(limit),
-		Offset:  int32(offset),
-	})
+	var media []db.Medium
+	if server.searchIndex != nil {
+		media, err = server.searchMediaInIndex(c.Request.Context(), query, int(limit), int(offset))
+	} else {
+		media, err = server.store.SearchMediaByName(c.Request.Context(), db.SearchMediaByNameParams{
+			Column1: sql.NullString{String: query, Valid: true},
+			Limit:   int32(limit),
+			Offset:  int32(offset),
+		})
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search media"})
 		return
@@ -331,7 +374,11 @@ func (server *Server) searchMedia(c *gin.Context) {
 
 	mediaResponses := make([]MediaResponse, len(media))
 	for i, m := range media {
-		mediaResponses[i] = toMediaResponse(m)
+		mediaResponses[i] = server.toMediaResponse(c.Request.Context(), m)
+	}
+	if err := server.attachRenditions(c.Request.Context(), mediaResponses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get media renditions"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -393,7 +440,11 @@ func (server *Server) getMediaByUser(c *gin.Context) {
 
 	mediaResponses := make([]MediaResponse, len(media))
 	for i, m := range media {
-		mediaResponses[i] = toMediaResponse(m)
+		mediaResponses[i] = server.toMediaResponse(c.Request.Context(), m)
+	}
+	if err := server.attachRenditions(c.Request.Context(), mediaResponses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get media renditions"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -415,13 +466,8 @@ func (server *Server) getMediaByPost(c *gin.Context) {
 		return
 	}
 
-	post, err := server.store.GetPost(c.Request.Context(), postID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post"})
+	post, ok := server.getViewablePost(c, postID)
+	if !ok {
 		return
 	}
 
@@ -433,7 +479,11 @@ func (server *Server) getMediaByPost(c *gin.Context) {
 
 	mediaResponses := make([]MediaResponse, len(media))
 	for i, m := range media {
-		mediaResponses[i] = toMediaResponse(m)
+		mediaResponses[i] = server.toMediaResponse(c.Request.Context(), m)
+	}
+	if err := server.attachRenditions(c.Request.Context(), mediaResponses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get media renditions"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -460,6 +510,11 @@ func (server *Server) updateMedia(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	existingMedia, err := server.store.GetMedia(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -470,12 +525,19 @@ func (server *Server) updateMedia(c *gin.Context) {
 		return
 	}
 
+	if err := policy.CanModifyMedia(authActor(c), existingMedia); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
 	updateParams := db.UpdateMediaParams{
 		ID:          id,
 		Name:        existingMedia.Name,
 		Description: existingMedia.Description,
 		Alt:         existingMedia.Alt,
 		MediaPath:   existingMedia.MediaPath,
+
+		ExpectedVersion: expectedVersion,
 	}
 
 	if req.Name != "" {
@@ -493,12 +555,23 @@ func (server *Server) updateMedia(c *gin.Context) {
 
 	updatedMedia, err := server.store.UpdateMedia(c.Request.Context(), updateParams)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			preconditionFailed(c)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update media"})
 		return
 	}
 
+	responses := []MediaResponse{server.toMediaResponse(c.Request.Context(), updatedMedia)}
+	if err := server.attachRenditions(c.Request.Context(), responses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get media renditions"})
+		return
+	}
+
+	setETag(c, updatedMedia.Version)
 	c.JSON(http.StatusOK, gin.H{
-		"media": toMediaResponse(updatedMedia),
+		"media": responses[0],
 	})
 }
 
@@ -510,7 +583,12 @@ func (server *Server) deleteMedia(c *gin.Context) {
 		return
 	}
 
-	_, err = server.store.GetMedia(c.Request.Context(), id)
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	media, err := server.store.GetMedia(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
@@ -520,22 +598,71 @@ func (server *Server) deleteMedia(c *gin.Context) {
 		return
 	}
 
-	userID := int64(1)
+	if err := policy.CanModifyMedia(authActor(c), media); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	renditionKeys, err := server.renditionKeys(c.Request.Context(), media)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get media renditions"})
+		return
+	}
 
+	// The transaction re-checks that the media still belongs to the owner we authorized against.
 	err = server.store.DeleteMediaTx(c.Request.Context(), db.DeleteMediaTxParams{
-		MediaID: id,
-		UserID:  userID,
+		MediaID:         id,
+		UserID:          media.UserID,
+		ExpectedVersion: expectedVersion,
 	})
 	if err != nil {
-		if containsString(err.Error(), "permission denied") {
-			c.JSON(http.StatusForbidden, gin.H{"error": "you can only delete your own media"})
+		if errors.Is(err, sql.ErrNoRows) {
+			preconditionFailed(c)
+			return
+		}
+		if containsString(err.Error(), "does not own") {
+			c.JSON(http.StatusConflict, gin.H{"error": "media ownership changed, please retry"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete media"})
 		return
 	}
 
+	if media.StorageKey != "" {
+		server.removeStoredFile(c, media.StorageKey)
+	}
+	for _, key := range renditionKeys {
+		server.removeStoredFile(c, key)
+	}
+	if strings.HasPrefix(media.MimeType, "image/") {
+		server.removeStoredPrefix(c, transformPrefix(media.ID))
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "media deleted successfully",
 	})
 }
+
+// searchMediaInIndex returns the media matching query in the SEARCH_DRIVER
+// index, in ranked order.
+func (server *Server) searchMediaInIndex(ctx context.Context, query string, limit, offset int) ([]db.Medium, error) {
+	result, err := server.searchIndex.Query(ctx, search.KindMedia, search.Query{Text: query, Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := server.store.ListMediaByIDs(ctx, hitIDs(result.Hits))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]db.Medium, len(rows))
+	for _, m := range rows {
+		byID[m.ID] = m
+	}
+	media := make([]db.Medium, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		if m, ok := byID[hit.ID]; ok {
+			media = append(media, m)
+		}
+	}
+	return media, nil
+}
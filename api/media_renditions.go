@@ -0,0 +1,217 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/imaging"
+)
+
+// Images above this many pixels are stored as-is; decoding them to build
+// renditions would need too much memory per request.
+const maxRenditionPixels = 50_000_000
+
+type RenditionResponse struct {
+	URL      string `json:"url"`
+	Width    int32  `json:"width"`
+	Height   int32  `json:"height"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+type SrcsetEntry struct {
+	URL      string `json:"url"`
+	Width    int32  `json:"width"`
+	MimeType string `json:"mime_type"`
+}
+
+// storeRenditions renders the configured sizes of an uploaded image and puts
+// them next to the original. It returns the rows to insert and every key it
+// stored, also on error, so the caller can clean up. Images that cannot be
+// decoded simply get no renditions.
+func (server *Server) storeRenditions(ctx context.Context, file io.ReadSeeker, key string, info uploadInfo) ([]db.CreateMediaRenditionParams, []string, error) {
+	if info.format == "" || !info.width.Valid || !info.height.Valid {
+		return nil, nil, nil
+	}
+	if int64(info.width.Int32)*int64(info.height.Int32) > maxRenditionPixels {
+		log.Printf("skipping renditions for %s: image is %dx%d", key, info.width.Int32, info.height.Int32)
+		return nil, nil, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	img, format, err := image.Decode(file)
+	if err != nil {
+		log.Printf("skipping renditions for %s: %v", key, err)
+		return nil, nil, nil
+	}
+
+	renditions, err := imaging.Generate(img, format, server.renditionSpecs, server.config.MediaRenditionsWebP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base := strings.TrimSuffix(key, path.Ext(key))
+	params := make([]db.CreateMediaRenditionParams, 0, len(renditions))
+	keys := make([]string, 0, len(renditions))
+	for _, rendition := range renditions {
+		renditionKey := fmt.Sprintf("%s_%s%s", base, rendition.Name, imaging.Extension(rendition.Format))
+		_, err := server.storage.Put(ctx, renditionKey, bytes.NewReader(rendition.Data), int64(len(rendition.Data)), rendition.MimeType)
+		if err != nil {
+			return nil, keys, err
+		}
+		keys = append(keys, renditionKey)
+
+		params = append(params, db.CreateMediaRenditionParams{
+			Name:       rendition.Name,
+			Format:     rendition.Format,
+			StorageKey: renditionKey,
+			MimeType:   rendition.MimeType,
+			Width:      int32(rendition.Width),
+			Height:     int32(rendition.Height),
+			Size:       int64(len(rendition.Data)),
+		})
+	}
+	return params, keys, nil
+}
+
+// attachRenditions loads the renditions of every image in responses with a
+// single query and fills in their renditions and srcset.
+func (server *Server) attachRenditions(ctx context.Context, responses []MediaResponse) error {
+	var ids []int64
+	for _, response := range responses {
+		if strings.HasPrefix(response.MimeType, "image/") {
+			ids = append(ids, response.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	renditions, err := server.store.ListMediaRenditions(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byMedia := make(map[int64][]db.MediaRendition)
+	for _, rendition := range renditions {
+		byMedia[rendition.MediaID] = append(byMedia[rendition.MediaID], rendition)
+	}
+	for i := range responses {
+		server.applyRenditions(ctx, &responses[i], byMedia[responses[i].ID])
+	}
+	return nil
+}
+
+// applyRenditions keys renditions by name, with a "_webp" suffix for WebP
+// copies, and builds a srcset list that includes the original.
+func (server *Server) applyRenditions(ctx context.Context, response *MediaResponse, renditions []db.MediaRendition) {
+	if len(renditions) == 0 {
+		return
+	}
+
+	response.Renditions = make(map[string]RenditionResponse, len(renditions))
+	response.Srcset = make([]SrcsetEntry, 0, len(renditions)+1)
+	for _, rendition := range renditions {
+		url := server.mediaPath(ctx, rendition.StorageKey, "")
+		if url == "" {
+			continue
+		}
+
+		name := rendition.Name
+		if rendition.Format == imaging.FormatWebP {
+			name += "_webp"
+		}
+		response.Renditions[name] = RenditionResponse{
+			URL:      url,
+			Width:    rendition.Width,
+			Height:   rendition.Height,
+			MimeType: rendition.MimeType,
+			Size:     rendition.Size,
+		}
+		response.Srcset = append(response.Srcset, SrcsetEntry{
+			URL:      url,
+			Width:    rendition.Width,
+			MimeType: rendition.MimeType,
+		})
+	}
+
+	if response.Width != nil {
+		response.Srcset = append(response.Srcset, SrcsetEntry{
+			URL:      response.MediaPath,
+			Width:    *response.Width,
+			MimeType: response.MimeType,
+		})
+	}
+
+	sort.SliceStable(response.Srcset, func(i, j int) bool {
+		if response.Srcset[i].MimeType != response.Srcset[j].MimeType {
+			return response.Srcset[i].MimeType < response.Srcset[j].MimeType
+		}
+		return response.Srcset[i].Width < response.Srcset[j].Width
+	})
+}
+
+// renditionKeys lists the stored renditions of a media item so their files
+// can be removed together with it.
+func (server *Server) renditionKeys(ctx context.Context, media db.Medium) ([]string, error) {
+	if media.StorageKey == "" || !strings.HasPrefix(media.MimeType, "image/") {
+		return nil, nil
+	}
+	renditions, err := server.store.ListMediaRenditions(ctx, []int64{media.ID})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(renditions))
+	for i, rendition := range renditions {
+		keys[i] = rendition.StorageKey
+	}
+	return keys, nil
+}
+
+// userMediaFiles lists the stored files of every media item a user owns: the
+// originals and renditions by key, and the prefixes their cached transforms
+// are kept under. Deleting the user deletes the rows, so it is read first.
+func (server *Server) userMediaFiles(ctx context.Context, userID int64) (keys, prefixes []string, err error) {
+	ids, err := server.store.ListMediaIDsByUser(ctx, userID)
+	if err != nil || len(ids) == 0 {
+		return nil, nil, err
+	}
+	media, err := server.store.ListMediaByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var images []int64
+	for _, medium := range media {
+		if medium.StorageKey == "" {
+			continue
+		}
+		keys = append(keys, medium.StorageKey)
+		if strings.HasPrefix(medium.MimeType, "image/") {
+			images = append(images, medium.ID)
+			prefixes = append(prefixes, transformPrefix(medium.ID))
+		}
+	}
+	if len(images) == 0 {
+		return keys, prefixes, nil
+	}
+
+	renditions, err := server.store.ListMediaRenditions(ctx, images)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, rendition := range renditions {
+		keys = append(keys, rendition.StorageKey)
+	}
+	return keys, prefixes, nil
+}
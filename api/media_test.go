@@ -17,6 +17,7 @@ import (
 
 	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
 	"github.com/go-live-cms/go-live-cms/token"
 )
 
@@ -57,8 +58,17 @@ func TestCreateMediaAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				arg := db.CreateMediaParams{
+					Name:        media.Name,
+					Description: media.Description,
+					Alt:         media.Alt,
+					MediaPath:   media.MediaPath,
+					UserID:      user.ID,
+				}
 				store.EXPECT().
-					CreateMedia(gomock.Any(), gomock.Any()).
+					CreateMedia(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
 					Return(media, nil)
 			},
@@ -81,6 +91,8 @@ func TestCreateMediaAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					CreateMediaAndLinkTx(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -129,6 +141,8 @@ func TestCreateMediaAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					CreateMedia(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -149,6 +163,8 @@ func TestCreateMediaAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					CreateMedia(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -170,6 +186,8 @@ func TestCreateMediaAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					CreateMediaAndLinkTx(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -324,6 +342,8 @@ func TestListMediaAPI(t *testing.T) {
 		}
 	}
 
+	mediaFilter := db.ListFilter{Where: "TRUE", OrderBy: "created_at DESC, id DESC"}
+
 	testCases := []struct {
 		name          string
 		query         string
@@ -335,14 +355,15 @@ func TestListMediaAPI(t *testing.T) {
 			query: "?limit=5&offset=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListMedia(gomock.Any(), db.ListMediaParams{
-						Limit:  5,
+					ListMediaFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: mediaFilter,
+						Limit:  6,
 						Offset: 0,
 					}).
 					Times(1).
 					Return(media, nil)
 				store.EXPECT().
-					CountTotalMedia(gomock.Any()).
+					CountMediaFiltered(gomock.Any(), countFilter(mediaFilter)).
 					Times(1).
 					Return(int64(100), nil)
 			},
@@ -356,14 +377,15 @@ func TestListMediaAPI(t *testing.T) {
 			query: "?limit=5&offset=0&with_counts=true",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListMediaWithPostCount(gomock.Any(), db.ListMediaWithPostCountParams{
-						Limit:  5,
+					ListMediaWithPostCountFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: mediaFilter,
+						Limit:  6,
 						Offset: 0,
 					}).
 					Times(1).
 					Return(mediaWithCount, nil)
 				store.EXPECT().
-					CountTotalMedia(gomock.Any()).
+					CountMediaFiltered(gomock.Any(), countFilter(mediaFilter)).
 					Times(1).
 					Return(int64(150), nil)
 			},
@@ -377,7 +399,7 @@ func TestListMediaAPI(t *testing.T) {
 			query: "?limit=5&offset=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListMedia(gomock.Any(), gomock.Any()).
+					ListMediaFiltered(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return([]db.Medium{}, sql.ErrConnDone)
 			},
@@ -385,12 +407,50 @@ func TestListMediaAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name:  "FilterAndSort",
+			query: "?filter[name][contains]=logo&sort=-size",
+			buildStubs: func(store *mockdb.MockStore) {
+				filter := db.ListFilter{
+					Where:   "name ILIKE $1",
+					Args:    []interface{}{"%logo%"},
+					OrderBy: "size DESC, id DESC",
+				}
+				store.EXPECT().
+					ListMediaFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: filter,
+						Limit:  11,
+						Offset: 0,
+					}).
+					Times(1).
+					Return(media, nil)
+				store.EXPECT().
+					CountMediaFiltered(gomock.Any(), countFilter(filter)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidFilterOperator",
+			query: "?filter[name][gte]=logo",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListMediaFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name:  "InvalidLimit",
 			query: "?limit=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListMedia(gomock.Any(), gomock.Any()).
+					ListMediaFiltered(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -578,6 +638,9 @@ func TestSearchMediaAPI(t *testing.T) {
 func TestUpdateMediaAPI(t *testing.T) {
 	user := randomUserForPosts()
 	media := randomMedia()
+	media.UserID = user.ID
+	stranger := randomUserForPosts()
+	stranger.ID = user.ID + 1
 	newName := gofakeit.Word()
 	newDescription := gofakeit.Sentence(10)
 
@@ -600,6 +663,7 @@ func TestUpdateMediaAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
 
 				store.EXPECT().
 					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
@@ -650,6 +714,8 @@ func TestUpdateMediaAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
 					Times(1).
@@ -659,6 +725,31 @@ func TestUpdateMediaAPI(t *testing.T) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
+		{
+			name:    "NotOwnerForbidden",
+			mediaID: media.ID,
+			body: gin.H{
+				"name": newName,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, stranger.ID, stranger.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, stranger)
+
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+					Times(1).
+					Return(media, nil)
+
+				store.EXPECT().
+					UpdateMedia(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -692,6 +783,12 @@ func TestUpdateMediaAPI(t *testing.T) {
 func TestDeleteMediaAPI(t *testing.T) {
 	user := randomUserForPosts()
 	media := randomMedia()
+	media.UserID = user.ID
+	stranger := randomUserForPosts()
+	stranger.ID = user.ID + 1
+	moderator := randomUserForPosts()
+	moderator.ID = user.ID + 2
+	moderator.Role = policy.RoleModerator
 
 	testCases := []struct {
 		name          string
@@ -707,6 +804,8 @@ func TestDeleteMediaAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
 					Times(1).
@@ -745,6 +844,8 @@ func TestDeleteMediaAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
 					Times(1).
@@ -757,10 +858,60 @@ func TestDeleteMediaAPI(t *testing.T) {
 		{
 			name:    "PermissionDenied",
 			mediaID: media.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, stranger.ID, stranger.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, stranger)
+
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+					Times(1).
+					Return(media, nil)
+
+				store.EXPECT().
+					DeleteMediaTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:    "ModeratorCanDelete",
+			mediaID: media.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, moderator.ID, moderator.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, moderator)
+
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+					Times(1).
+					Return(media, nil)
+
+				store.EXPECT().
+					DeleteMediaTx(gomock.Any(), gomock.Eq(db.DeleteMediaTxParams{
+						MediaID: media.ID,
+						UserID:  media.UserID,
+					})).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "OwnershipChanged",
+			mediaID: media.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
 					Times(1).
@@ -769,10 +920,10 @@ func TestDeleteMediaAPI(t *testing.T) {
 				store.EXPECT().
 					DeleteMediaTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(fmt.Errorf("permission denied: user does not own this media"))
+					Return(fmt.Errorf("user %d does not own media %d", user.ID, media.ID))
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Equal(t, http.StatusConflict, recorder.Code)
 			},
 		},
 	}
@@ -880,12 +1031,16 @@ func TestGetMediaByUserAPI(t *testing.T) {
 func TestGetMediaByPostAPI(t *testing.T) {
 	user := randomUserForPosts()
 	post := randomPost(user)
+	draft := randomPost(user)
+	draft.Status = policy.PostStatusDraft
+	draft.PublishedAt = sql.NullTime{}
 	media := randomMedia()
 	postMedia := []db.Medium{media}
 
 	testCases := []struct {
 		name          string
 		postID        int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
@@ -908,6 +1063,50 @@ func TestGetMediaByPostAPI(t *testing.T) {
 				requireBodyMatchMediaByPost(t, recorder.Body.String(), post, postMedia)
 			},
 		},
+		{
+			name:   "DraftHiddenFromAnonymous",
+			postID: draft.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return(draft, nil)
+
+				store.EXPECT().
+					GetMediaByPost(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "DraftVisibleToAuthor",
+			postID: draft.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return(draft, nil)
+
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return([]db.UserPost{{PostID: draft.ID, UserID: user.ID}}, nil)
+
+				store.EXPECT().
+					GetMediaByPost(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return(postMedia, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
 		{
 			name:   "PostNotFound",
 			postID: post.ID,
@@ -940,6 +1139,9 @@ func TestGetMediaByPostAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
+			if tc.setupAuth != nil {
+				tc.setupAuth(t, request, server.tokenMaker)
+			}
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
 		})
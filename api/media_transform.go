@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-live-cms/go-live-cms/imaging"
+	"github.com/go-live-cms/go-live-cms/storage"
+)
+
+// Transformed images never change for a given signed URL, so caches may keep
+// them for as long as they like.
+const transformCacheControl = "public, max-age=31536000, immutable"
+
+// minTransformKeySize is the shortest MEDIA_TRANSFORM_KEY accepted.
+const minTransformKeySize = 32
+
+// transformSigningKey derives the key for transform signatures from
+// MEDIA_TRANSFORM_KEY, so a leaked signature cannot be replayed as anything
+// else.
+func transformSigningKey(transformKey string) []byte {
+	mac := hmac.New(sha256.New, []byte(transformKey))
+	mac.Write([]byte("media-transform"))
+	return mac.Sum(nil)
+}
+
+// transformMedia resizes, crops and converts an image on demand. Requests
+// must carry a signature from signMediaTransform; results are cached in the
+// storage backend under transforms/<media id>/.
+func (server *Server) transformMedia(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid media ID"})
+		return
+	}
+
+	transform, err := imaging.ParseTransform(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !imaging.Verify(server.transformKey, id, transform, c.Query("sig")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid signature"})
+		return
+	}
+
+	media, err := server.store.GetMedia(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get media"})
+		return
+	}
+	if media.StorageKey == "" || !strings.HasPrefix(media.MimeType, "image/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "media is not an image"})
+		return
+	}
+
+	format := transform.Format
+	if format == "" {
+		format = imaging.PrimaryFormat(strings.TrimPrefix(media.MimeType, "image/"))
+	}
+	sum := sha256.Sum256([]byte(media.StorageKey + "?" + transform.Canonical() + "&resolved=" + format))
+	hash := hex.EncodeToString(sum[:16])
+	etag := `"` + hash + `"`
+	lastModified := media.CreatedAt.UTC().Truncate(time.Second)
+
+	c.Header("Cache-Control", transformCacheControl)
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	key := transformPrefix(media.ID) + "/" + hash + imaging.Extension(format)
+	if reader, info, err := server.storage.Get(c.Request.Context(), key); err == nil {
+		defer reader.Close()
+		c.DataFromReader(http.StatusOK, info.Size, info.ContentType, reader, map[string]string{
+			"X-Content-Type-Options": "nosniff",
+		})
+		return
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("failed to read cached transform %s: %v", key, err)
+	}
+
+	if media.Width.Valid && media.Height.Valid && int64(media.Width.Int32)*int64(media.Height.Int32) > maxRenditionPixels {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is too large to transform"})
+		return
+	}
+
+	reader, _, err := server.storage.Get(c.Request.Context(), media.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+		return
+	}
+	src, _, err := image.Decode(reader)
+	reader.Close()
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "failed to decode image"})
+		return
+	}
+
+	data, err := imaging.EncodeQuality(imaging.Apply(src, transform), format, transform.Quality)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode image"})
+		return
+	}
+
+	mimeType := imaging.MimeType(format)
+	if _, err := server.storage.Put(c.Request.Context(), key, bytes.NewReader(data), int64(len(data)), mimeType); err != nil {
+		log.Printf("failed to cache transform %s: %v", key, err)
+	}
+
+	c.DataFromReader(http.StatusOK, int64(len(data)), mimeType, io.NopCloser(bytes.NewReader(data)), map[string]string{
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// transformPrefix is where the cached transforms of a media item are stored,
+// so deleting the item can remove them all without knowing their options.
+func transformPrefix(mediaID int64) string {
+	return fmt.Sprintf("transforms/%d", mediaID)
+}
+
+// notModified evaluates If-None-Match and, when that is absent,
+// If-Modified-Since.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !lastModified.After(since)
+	}
+	return false
+}
+
+type SignTransformResponse struct {
+	URL       string `json:"url"`
+	Signature string `json:"signature"`
+}
+
+// signMediaTransform returns a signed transform URL for the given query
+// parameters. Only users who may add media can mint signatures, which keeps
+// anonymous clients from requesting arbitrary sizes.
+func (server *Server) signMediaTransform(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid media ID"})
+		return
+	}
+
+	transform, err := imaging.ParseTransform(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := server.store.GetMedia(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get media"})
+		return
+	}
+
+	c.JSON(http.StatusOK, server.transformURL(id, transform))
+}
+
+func (server *Server) transformURL(mediaID int64, transform imaging.Transform) SignTransformResponse {
+	signature := imaging.Sign(server.transformKey, mediaID, transform)
+	query := transform.Query()
+	query.Set("sig", signature)
+	return SignTransformResponse{
+		URL:       fmt.Sprintf("/api/v1/media/%d/transform?%s", mediaID, query.Encode()),
+		Signature: signature,
+	}
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/imaging"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+func TestTransformMediaAPI(t *testing.T) {
+	media := randomMedia()
+	media.StorageKey = "2026/01/landscape.png"
+	media.MimeType = "image/png"
+	media.Width = sql.NullInt32{Int32: 400, Valid: true}
+	media.Height = sql.NullInt32{Int32: 300, Valid: true}
+	media.CreatedAt = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	content := randomPNG(t, 400, 300)
+
+	cover := imaging.Transform{Width: 100, Height: 100, Fit: imaging.FitCover, Format: imaging.FormatWebP, Quality: 80}
+
+	testCases := []struct {
+		name          string
+		query         func(server *Server) string
+		header        http.Header
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server)
+	}{
+		{
+			name: "OK",
+			query: func(server *Server) string {
+				return server.transformURL(media.ID, cover).URL
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+					Times(1).
+					Return(media, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "image/webp", recorder.Header().Get("Content-Type"))
+				require.Equal(t, transformCacheControl, recorder.Header().Get("Cache-Control"))
+				require.NotEmpty(t, recorder.Header().Get("ETag"))
+				require.Equal(t, "Fri, 02 Jan 2026 03:04:05 GMT", recorder.Header().Get("Last-Modified"))
+
+				img, err := webp.Decode(bytes.NewReader(recorder.Body.Bytes()))
+				require.NoError(t, err)
+				require.Equal(t, image.Rect(0, 0, 100, 100), img.Bounds())
+			},
+		},
+		{
+			name: "DefaultFormat",
+			query: func(server *Server) string {
+				return server.transformURL(media.ID, imaging.Transform{Width: 200, Fit: imaging.FitContain, Quality: 80}).URL
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+					Times(1).
+					Return(media, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "image/png", recorder.Header().Get("Content-Type"))
+
+				config, _, err := image.DecodeConfig(bytes.NewReader(recorder.Body.Bytes()))
+				require.NoError(t, err)
+				require.Equal(t, 200, config.Width)
+				require.Equal(t, 150, config.Height)
+			},
+		},
+		{
+			name: "NotModified",
+			query: func(server *Server) string {
+				return server.transformURL(media.ID, cover).URL
+			},
+			header: http.Header{"If-Modified-Since": {"Sat, 03 Jan 2026 00:00:00 GMT"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+					Times(1).
+					Return(media, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusNotModified, recorder.Code)
+				require.Empty(t, recorder.Body.Bytes())
+			},
+		},
+		{
+			name: "InvalidSignature",
+			query: func(server *Server) string {
+				return fmt.Sprintf("/api/v1/media/%d/transform?w=100&sig=deadbeef", media.ID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "TamperedSize",
+			query: func(server *Server) string {
+				signed := server.transformURL(media.ID, imaging.Transform{Width: 100, Fit: imaging.FitContain, Quality: 80})
+				return fmt.Sprintf("/api/v1/media/%d/transform?w=4000&sig=%s", media.ID, signed.Signature)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidParameters",
+			query: func(server *Server) string {
+				return fmt.Sprintf("/api/v1/media/%d/transform?w=100&fit=stretch", media.ID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			query: func(server *Server) string {
+				return server.transformURL(media.ID, cover).URL
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+					Times(1).
+					Return(db.Medium{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NotAnImage",
+			query: func(server *Server) string {
+				return server.transformURL(media.ID, cover).URL
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				document := media
+				document.MimeType = "application/pdf"
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+					Times(1).
+					Return(document, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			_, err := server.storage.Put(context.Background(), media.StorageKey, bytes.NewReader(content), int64(len(content)), "image/png")
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, tc.query(server), nil)
+			require.NoError(t, err)
+			for name, values := range tc.header {
+				request.Header[name] = values
+			}
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server)
+		})
+	}
+}
+
+func TestTransformMediaServesCachedResult(t *testing.T) {
+	media := randomMedia()
+	media.StorageKey = "2026/01/landscape.png"
+	media.MimeType = "image/png"
+	content := randomPNG(t, 64, 48)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+		Times(2).
+		Return(media, nil)
+
+	server := newTestServer(t, store)
+	_, err := server.storage.Put(context.Background(), media.StorageKey, bytes.NewReader(content), int64(len(content)), "image/png")
+	require.NoError(t, err)
+
+	url := server.transformURL(media.ID, imaging.Transform{Width: 32, Fit: imaging.FitContain, Format: imaging.FormatJPEG, Quality: 70}).URL
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	first := recorder.Body.Bytes()
+	etag := recorder.Header().Get("ETag")
+
+	// With the original gone, the second response can only come from the cache.
+	require.NoError(t, server.storage.Delete(context.Background(), media.StorageKey))
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "image/jpeg", recorder.Header().Get("Content-Type"))
+	require.Equal(t, etag, recorder.Header().Get("ETag"))
+	require.Equal(t, first, recorder.Body.Bytes())
+}
+
+func TestSignMediaTransformAPI(t *testing.T) {
+	user := randomUserForPosts()
+	media := randomMedia()
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server)
+	}{
+		{
+			name:  "OK",
+			query: "w=300&fmt=webp",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+					Times(1).
+					Return(media, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response SignTransformResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				transform := imaging.Transform{Width: 300, Fit: imaging.FitContain, Format: imaging.FormatWebP, Quality: imaging.DefaultTransformQuality}
+				require.True(t, imaging.Verify(server.transformKey, media.ID, transform, response.Signature))
+				require.Contains(t, response.URL, fmt.Sprintf("/api/v1/media/%d/transform?", media.ID))
+				require.Contains(t, response.URL, "sig="+response.Signature)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: "w=300",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidParameters",
+			query: "w=99999",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					GetMedia(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/media/%d/transform/sign?%s", media.ID, tc.query), nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server)
+		})
+	}
+}
+
+func TestNewServerRejectsInsecureKeys(t *testing.T) {
+	config := newTestServer(t, nil).config
+
+	insecure := config
+	insecure.MediaTransformKey = util.InsecureDefaultKey
+	_, err := NewServer(insecure, nil, nil)
+	require.Error(t, err)
+
+	insecure = config
+	insecure.TokenSymmetricKey = util.InsecureDefaultKey
+	_, err = NewServer(insecure, nil, nil)
+	require.Error(t, err)
+
+	missing := config
+	missing.MediaTransformKey = ""
+	_, err = NewServer(missing, nil, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/storage"
+	_ "golang.org/x/image/webp"
+)
+
+const defaultMaxUploadSize = 32 << 20
+
+var allowedUploadTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"video/mp4":       true,
+	"video/webm":      true,
+	"audio/mpeg":      true,
+}
+
+type UploadMediaRequest struct {
+	Name        string `form:"name" binding:"omitempty,min=2,max=255"`
+	Description string `form:"description" binding:"omitempty,max=500"`
+	Alt         string `form:"alt" binding:"omitempty,min=2,max=255"`
+	PostID      *int64 `form:"post_id" binding:"omitempty"`
+	Order       *int32 `form:"order" binding:"omitempty,min=0"`
+}
+
+type uploadInfo struct {
+	mimeType string
+	format   string
+	checksum string
+	width    sql.NullInt32
+	height   sql.NullInt32
+}
+
+// uploadMedia accepts a multipart "file" part, stores it through the configured
+// storage backend and records the file metadata on a new media row.
+func (server *Server) uploadMedia(c *gin.Context) {
+	maxSize := server.config.MediaMaxUploadSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxUploadSize
+	}
+	// Leave headroom for the other form fields and multipart boundaries.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	var req UploadMediaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file exceeds the %d byte upload limit", maxSize)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file exceeds the %d byte upload limit", maxSize)})
+		return
+	}
+	if fileHeader.Size == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is empty"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	info, err := inspectUpload(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+		return
+	}
+	if !allowedUploadTypes[info.mimeType] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("unsupported file type %s", info.mimeType)})
+		return
+	}
+
+	key, err := storage.NewKey(time.Now(), info.mimeType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store file"})
+		return
+	}
+	if _, err := server.storage.Put(c.Request.Context(), key, file, fileHeader.Size, info.mimeType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store file"})
+		return
+	}
+
+	storedKeys := []string{key}
+	removeStoredFiles := func() {
+		for _, storedKey := range storedKeys {
+			server.removeStoredFile(c, storedKey)
+		}
+	}
+
+	mediaPath, err := server.storage.URL(c.Request.Context(), key)
+	if err != nil {
+		removeStoredFiles()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store file"})
+		return
+	}
+
+	renditions, renditionKeys, err := server.storeRenditions(c.Request.Context(), file, key, info)
+	storedKeys = append(storedKeys, renditionKeys...)
+	if err != nil {
+		removeStoredFiles()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store image renditions"})
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(fileHeader.Filename), filepath.Ext(fileHeader.Filename))
+	}
+	alt := req.Alt
+	if alt == "" {
+		alt = name
+	}
+
+	var order int32
+	if req.Order != nil {
+		order = *req.Order
+	}
+
+	result, err := server.store.CreateMediaWithRenditionsTx(c.Request.Context(), db.CreateMediaWithRenditionsTxParams{
+		CreateMediaParams: db.CreateMediaParams{
+			Name:        name,
+			Description: req.Description,
+			Alt:         alt,
+			MediaPath:   mediaPath,
+			UserID:      authActor(c).UserID,
+			StorageKey:  key,
+			Size:        fileHeader.Size,
+			MimeType:    info.mimeType,
+			Checksum:    info.checksum,
+			Width:       info.width,
+			Height:      info.height,
+		},
+		PostID:     req.PostID,
+		Order:      order,
+		Renditions: renditions,
+	})
+	if err != nil {
+		removeStoredFiles()
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create media"})
+		return
+	}
+
+	response := server.toMediaResponse(c.Request.Context(), result.Media)
+	server.applyRenditions(c.Request.Context(), &response, result.Renditions)
+
+	body := gin.H{"media": response}
+	if result.PostMedia != nil {
+		body["post_media"] = result.PostMedia
+	}
+	c.JSON(http.StatusCreated, body)
+}
+
+// inspectUpload sniffs the MIME type, hashes the content and reads image
+// dimensions, leaving the file positioned at its start.
+func inspectUpload(file io.ReadSeeker) (uploadInfo, error) {
+	var info uploadInfo
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return info, err
+	}
+	info.mimeType, _, _ = strings.Cut(http.DetectContentType(header[:n]), ";")
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return info, err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return info, err
+	}
+	info.checksum = hex.EncodeToString(hash.Sum(nil))
+
+	if strings.HasPrefix(info.mimeType, "image/") {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return info, err
+		}
+		if config, format, err := image.DecodeConfig(file); err == nil {
+			info.format = format
+			info.width = sql.NullInt32{Int32: int32(config.Width), Valid: true}
+			info.height = sql.NullInt32{Int32: int32(config.Height), Valid: true}
+		}
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	return info, err
+}
+
+// removeStoredFile deletes an object whose media row is gone or was never
+// written. Failures only leave an orphaned file behind, so they are logged.
+func (server *Server) removeStoredFile(c *gin.Context, key string) {
+	err := server.storage.Delete(c.Request.Context(), key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("failed to delete stored file %s: %v", key, err)
+	}
+}
+
+func (server *Server) removeStoredPrefix(c *gin.Context, prefix string) {
+	if err := server.storage.DeletePrefix(c.Request.Context(), prefix); err != nil {
+		log.Printf("failed to delete stored files under %s: %v", prefix, err)
+	}
+}
+
+// serveStoredFile streams files kept by the local storage driver. They share
+// the API's origin and its cookies, so only the types uploads are accepted as
+// are served as themselves, and only images are shown inline.
+func (server *Server) serveStoredFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	reader, info, err := server.storage.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer reader.Close()
+
+	contentType := info.ContentType
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || !allowedUploadTypes[mediaType] {
+		contentType = "application/octet-stream"
+	}
+	headers := map[string]string{
+		"X-Content-Type-Options": "nosniff",
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		headers["Content-Disposition"] = mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)})
+	}
+
+	c.DataFromReader(http.StatusOK, info.Size, contentType, reader, headers)
+}
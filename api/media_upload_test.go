@@ -0,0 +1,616 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/storage"
+	"github.com/go-live-cms/go-live-cms/token"
+)
+
+// randomPNG draws a noisy gradient, which compresses more like a real upload
+// than a clean one and lets the lossless WebP renditions beat PNG.
+func randomPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			n := uint8(rand.Intn(8))
+			img.Set(x, y, color.RGBA{R: uint8(x/4) + n, G: uint8(y/3) + n, B: 200 + n, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func newUploadRequest(t *testing.T, fields map[string]string, filename string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	if filename != "" {
+		part, err := writer.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/media/upload", &body)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func TestUploadMediaAPI(t *testing.T) {
+	user := randomUserForPosts()
+	pngData := randomPNG(t, 4, 3)
+	largePNG := randomPNG(t, 800, 600)
+	checksum := sha256.Sum256(pngData)
+	var storedKey string
+
+	testCases := []struct {
+		name          string
+		fields        map[string]string
+		filename      string
+		content       []byte
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, backend storage.Backend)
+	}{
+		{
+			name:     "OK",
+			fields:   map[string]string{"description": "A tiny test image"},
+			filename: "Holiday Photo.png",
+			content:  pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMediaWithRenditionsTxParams) (db.CreateMediaWithRenditionsTxResult, error) {
+						require.Nil(t, arg.PostID)
+						require.Empty(t, arg.Renditions)
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, "Holiday Photo", arg.Name)
+						require.Equal(t, "Holiday Photo", arg.Alt)
+						require.Equal(t, "A tiny test image", arg.Description)
+						require.Equal(t, "image/png", arg.MimeType)
+						require.Equal(t, int64(len(pngData)), arg.Size)
+						require.Equal(t, hex.EncodeToString(checksum[:]), arg.Checksum)
+						require.Equal(t, int32(4), arg.Width.Int32)
+						require.Equal(t, int32(3), arg.Height.Int32)
+						require.Regexp(t, `^\d{4}/\d{2}/[0-9a-f]{32}\.png$`, arg.StorageKey)
+						require.Equal(t, "/uploads/"+arg.StorageKey, arg.MediaPath)
+						storedKey = arg.StorageKey
+
+						return db.CreateMediaWithRenditionsTxResult{
+							Media: db.Medium{
+								ID:         1,
+								Name:       arg.Name,
+								Alt:        arg.Alt,
+								MediaPath:  arg.MediaPath,
+								UserID:     arg.UserID,
+								StorageKey: arg.StorageKey,
+								Size:       arg.Size,
+								MimeType:   arg.MimeType,
+								Checksum:   arg.Checksum,
+								Width:      arg.Width,
+								Height:     arg.Height,
+							},
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var response struct {
+					Media MediaResponse `json:"media"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, "image/png", response.Media.MimeType)
+				require.Equal(t, int64(len(pngData)), response.Media.Size)
+				require.NotNil(t, response.Media.Width)
+				require.Equal(t, int32(4), *response.Media.Width)
+
+				reader, info, err := backend.Get(context.Background(), storedKey)
+				require.NoError(t, err)
+				defer reader.Close()
+				stored, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				require.Equal(t, pngData, stored)
+				require.Equal(t, int64(len(pngData)), info.Size)
+			},
+		},
+		{
+			name:     "KeyExtensionFollowsSniffedType",
+			filename: "page.html",
+			content:  pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMediaWithRenditionsTxParams) (db.CreateMediaWithRenditionsTxResult, error) {
+						require.Regexp(t, `^\d{4}/\d{2}/[0-9a-f]{32}\.png$`, arg.StorageKey)
+						return db.CreateMediaWithRenditionsTxResult{
+							Media: db.Medium{ID: 1, Name: arg.Name, StorageKey: arg.StorageKey, MimeType: arg.MimeType},
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name:     "WithPostLink",
+			fields:   map[string]string{"name": "Cover", "post_id": "7", "order": "2"},
+			filename: "cover.png",
+			content:  pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMediaWithRenditionsTxParams) (db.CreateMediaWithRenditionsTxResult, error) {
+						require.NotNil(t, arg.PostID)
+						require.Equal(t, int64(7), *arg.PostID)
+						require.Equal(t, int32(2), arg.Order)
+						require.Equal(t, "Cover", arg.Name)
+						require.Equal(t, user.ID, arg.UserID)
+						return db.CreateMediaWithRenditionsTxResult{
+							Media:     db.Medium{ID: 1, Name: arg.Name, UserID: arg.UserID},
+							PostMedia: &db.PostMedium{PostID: *arg.PostID, MediaID: 1, Order: arg.Order},
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var response struct {
+					PostMedia db.PostMedium `json:"post_media"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, int64(7), response.PostMedia.PostID)
+			},
+		},
+		{
+			name:     "PostNotFound",
+			fields:   map[string]string{"post_id": "7"},
+			filename: "cover.png",
+			content:  pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateMediaWithRenditionsTxResult{}, fmt.Errorf("post 7 not found: %w", sql.ErrNoRows))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Renditions",
+			filename: "landscape.png",
+			content:  largePNG,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMediaWithRenditionsTxParams) (db.CreateMediaWithRenditionsTxResult, error) {
+						require.Len(t, arg.Renditions, 4)
+						storedKey = arg.StorageKey
+
+						result := db.CreateMediaWithRenditionsTxResult{
+							Media: db.Medium{
+								ID:         1,
+								Name:       arg.Name,
+								MediaPath:  arg.MediaPath,
+								UserID:     arg.UserID,
+								StorageKey: arg.StorageKey,
+								Size:       arg.Size,
+								MimeType:   arg.MimeType,
+								Width:      arg.Width,
+								Height:     arg.Height,
+							},
+						}
+						for i, rendition := range arg.Renditions {
+							result.Renditions = append(result.Renditions, db.MediaRendition{
+								ID:         int64(i + 1),
+								MediaID:    1,
+								Name:       rendition.Name,
+								Format:     rendition.Format,
+								StorageKey: rendition.StorageKey,
+								MimeType:   rendition.MimeType,
+								Width:      rendition.Width,
+								Height:     rendition.Height,
+								Size:       rendition.Size,
+							})
+						}
+						return result, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var response struct {
+					Media MediaResponse `json:"media"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Media.Renditions, 4)
+				require.Len(t, response.Media.Srcset, 5)
+
+				thumbnail := response.Media.Renditions["thumbnail"]
+				require.Equal(t, int32(150), thumbnail.Width)
+				require.Equal(t, int32(112), thumbnail.Height)
+				require.Equal(t, "image/png", thumbnail.MimeType)
+				require.Equal(t, "image/webp", response.Media.Renditions["medium_webp"].MimeType)
+				require.Equal(t, int32(640), response.Media.Renditions["medium_webp"].Width)
+
+				base := strings.TrimSuffix(storedKey, ".png")
+				for _, key := range []string{base + "_thumbnail.png", base + "_medium.png", base + "_thumbnail.webp", base + "_medium.webp"} {
+					_, err := backend.Stat(context.Background(), key)
+					require.NoError(t, err, key)
+				}
+			},
+		},
+		{
+			name:     "NoAuthorization",
+			filename: "cover.png",
+			content:  pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "MissingFile",
+			fields: map[string]string{"name": "Cover"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UnsupportedType",
+			filename: "notes.txt",
+			content:  []byte("just some plain text"),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+		{
+			name:     "TooLarge",
+			filename: "huge.png",
+			content:  append(append([]byte{}, pngData...), make([]byte, 1<<20)...),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalErrorRemovesFile",
+			filename: "cover.png",
+			content:  pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreateMediaWithRenditionsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMediaWithRenditionsTxParams) (db.CreateMediaWithRenditionsTxResult, error) {
+						storedKey = arg.StorageKey
+						return db.CreateMediaWithRenditionsTxResult{}, fmt.Errorf("connection refused")
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, backend storage.Backend) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+				_, err := backend.Stat(context.Background(), storedKey)
+				require.ErrorIs(t, err, storage.ErrNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newUploadRequest(t, tc.fields, tc.filename, tc.content)
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, server.storage)
+		})
+	}
+}
+
+func TestDeleteMediaRemovesStoredFile(t *testing.T) {
+	user := randomUserForPosts()
+	media := randomMedia()
+	media.UserID = user.ID
+	media.StorageKey = "2026/01/cover.png"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	expectAuthUser(store, user)
+	store.EXPECT().
+		GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+		Times(1).
+		Return(media, nil)
+	store.EXPECT().
+		DeleteMediaTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil)
+
+	server := newTestServer(t, store)
+	content := randomPNG(t, 2, 2)
+	_, err := server.storage.Put(context.Background(), media.StorageKey, bytes.NewReader(content), int64(len(content)), "image/png")
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/media/%d", media.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	_, err = server.storage.Stat(context.Background(), media.StorageKey)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestDeleteMediaRemovesCachedTransforms(t *testing.T) {
+	user := randomUserForPosts()
+	media := randomMedia()
+	media.UserID = user.ID
+	media.StorageKey = "2026/01/cover.png"
+	media.MimeType = "image/png"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	expectAuthUser(store, user)
+	store.EXPECT().
+		GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+		Times(1).
+		Return(media, nil)
+	store.EXPECT().
+		ListMediaRenditions(gomock.Any(), []int64{media.ID}).
+		Times(1).
+		Return([]db.MediaRendition{}, nil)
+	store.EXPECT().
+		DeleteMediaTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil)
+
+	server := newTestServer(t, store)
+	content := randomPNG(t, 2, 2)
+	transformKey := transformPrefix(media.ID) + "/0123456789abcdef.jpg"
+	for _, key := range []string{media.StorageKey, transformKey} {
+		_, err := server.storage.Put(context.Background(), key, bytes.NewReader(content), int64(len(content)), "image/png")
+		require.NoError(t, err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/media/%d", media.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	_, err = server.storage.Stat(context.Background(), transformKey)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestServeStoredFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	content := randomPNG(t, 2, 2)
+	_, err := server.storage.Put(context.Background(), "2026/01/logo.png", bytes.NewReader(content), int64(len(content)), "image/png")
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/uploads/2026/01/logo.png", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "image/png", recorder.Header().Get("Content-Type"))
+	require.Equal(t, "nosniff", recorder.Header().Get("X-Content-Type-Options"))
+	require.Empty(t, recorder.Header().Get("Content-Disposition"))
+	require.Equal(t, content, recorder.Body.Bytes())
+
+	// Files stored under a key with a dangerous extension are never rendered.
+	for _, key := range []string{"2026/01/page.html", "2026/01/icon.svg"} {
+		_, err = server.storage.Put(context.Background(), key, bytes.NewReader(content), int64(len(content)), "image/png")
+		require.NoError(t, err)
+
+		recorder = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodGet, "/uploads/"+key, nil)
+		require.NoError(t, err)
+		server.router.ServeHTTP(recorder, request)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "application/octet-stream", recorder.Header().Get("Content-Type"))
+		require.Contains(t, recorder.Header().Get("Content-Disposition"), "attachment")
+	}
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/uploads/2026/01/missing.png", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestMediaPathResolvedFromStorage(t *testing.T) {
+	media := randomMedia()
+	media.StorageKey = "2026/01/cover.png"
+	media.MediaPath = "https://expired.example.com/cover.png"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+		Times(1).
+		Return(media, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/media/%d", media.ID), nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response struct {
+		Media MediaResponse `json:"media"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, "/uploads/2026/01/cover.png", response.Media.MediaPath)
+}
+
+func TestGetMediaIncludesRenditions(t *testing.T) {
+	media := randomMedia()
+	media.StorageKey = "2026/01/cover.png"
+	media.MimeType = "image/png"
+	media.Width = sql.NullInt32{Int32: 1600, Valid: true}
+	media.Height = sql.NullInt32{Int32: 1200, Valid: true}
+
+	renditions := []db.MediaRendition{
+		{ID: 1, MediaID: media.ID, Name: "medium", Format: "png", StorageKey: "2026/01/cover_medium.png", MimeType: "image/png", Width: 640, Height: 480, Size: 2048},
+		{ID: 2, MediaID: media.ID, Name: "thumbnail", Format: "png", StorageKey: "2026/01/cover_thumbnail.png", MimeType: "image/png", Width: 150, Height: 112, Size: 512},
+		{ID: 3, MediaID: media.ID, Name: "thumbnail", Format: "webp", StorageKey: "2026/01/cover_thumbnail.webp", MimeType: "image/webp", Width: 150, Height: 112, Size: 400},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetMedia(gomock.Any(), gomock.Eq(media.ID)).
+		Times(1).
+		Return(media, nil)
+	store.EXPECT().
+		ListMediaRenditions(gomock.Any(), gomock.Eq([]int64{media.ID})).
+		Times(1).
+		Return(renditions, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/media/%d", media.ID), nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response struct {
+		Media MediaResponse `json:"media"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, "/uploads/2026/01/cover_thumbnail.png", response.Media.Renditions["thumbnail"].URL)
+	require.Equal(t, "/uploads/2026/01/cover_thumbnail.webp", response.Media.Renditions["thumbnail_webp"].URL)
+
+	require.Equal(t, []SrcsetEntry{
+		{URL: "/uploads/2026/01/cover_thumbnail.png", Width: 150, MimeType: "image/png"},
+		{URL: "/uploads/2026/01/cover_medium.png", Width: 640, MimeType: "image/png"},
+		{URL: "/uploads/2026/01/cover.png", Width: 1600, MimeType: "image/png"},
+		{URL: "/uploads/2026/01/cover_thumbnail.webp", Width: 150, MimeType: "image/webp"},
+	}, response.Media.Srcset)
+}
@@ -0,0 +1,515 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/lockout"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/token"
+	"github.com/go-live-cms/go-live-cms/totp"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes a user gets at a time.
+	recoveryCodeCount = 10
+	// recoveryCodeBytes is the randomness in a recovery code; 10 bytes encode
+	// to 16 base32 characters.
+	recoveryCodeBytes = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type MFAPendingResponse struct {
+	MFARequired           bool      `json:"mfa_required"`
+	MFAToken              string    `json:"mfa_token"`
+	MFATokenExpiresAt     time.Time `json:"mfa_token_expires_at"`
+	MFAEnrollmentRequired bool      `json:"mfa_enrollment_required"`
+}
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFAStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+type SetupTOTPResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type ConfirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	// Login is set when enrolment finished a login that was waiting for it.
+	Login *LoginUserResponse `json:"login,omitempty"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (server *Server) roleRequiresMFA(role string) bool {
+	role = policy.NormalizeRole(role)
+	for _, required := range server.mfaRequiredRoles {
+		if required == role {
+			return true
+		}
+	}
+	return false
+}
+
+// newMFAPending answers a correct password with a short-lived token that
+// /auth/mfa/verify exchanges for a session once the second factor checks out.
+// When enrollmentRequired is set the user's role demands 2FA they have not set
+// up yet, and the token is only good for enrolling.
+func (server *Server) newMFAPending(user db.User, enrollmentRequired bool) (MFAPendingResponse, error) {
+	mfaToken, err := server.tokenMaker.CreateMFAToken(user.ID, user.Username, server.config.MFATokenDuration)
+	if err != nil {
+		return MFAPendingResponse{}, errors.New("failed to create mfa token")
+	}
+
+	return MFAPendingResponse{
+		MFARequired:           true,
+		MFAToken:              mfaToken,
+		MFATokenExpiresAt:     time.Now().Add(server.config.MFATokenDuration),
+		MFAEnrollmentRequired: enrollmentRequired,
+	}, nil
+}
+
+// verifyMFA is the second step of a login: it checks a TOTP or recovery code
+// against the user named by the mfa_pending token and starts the session.
+// Wrong codes are counted per user and per token like password failures, and
+// the token is burned once it succeeds or runs out of attempts, so a fresh
+// password login does not buy a fresh set of guesses.
+func (server *Server) verifyMFA(ctx *gin.Context) {
+	var req VerifyMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(req.MFAToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid mfa token"})
+		return
+	}
+	if payload.TokenType != "mfa_pending" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token type"})
+		return
+	}
+	revoked, err := server.revoker.IsRevoked(ctx.Request.Context(), payload.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check token revocation"})
+		return
+	}
+	if revoked {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+		return
+	}
+
+	mfaKeys := []lockout.Key{lockout.MFAUserKey(payload.UserID), lockout.MFATokenKey(payload.ID)}
+	wait, err := server.loginGuard.Check(ctx.Request.Context(), mfaKeys...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check login attempts"})
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(ctx, wait)
+		return
+	}
+
+	user, err := server.store.GetUser(ctx.Request.Context(), payload.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user no longer exists"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return
+	}
+	if payload.IssuedAt.Before(user.PasswordChangedAt) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "token was issued before the last password change"})
+		return
+	}
+
+	mfa, ok := server.loadEnabledMFA(ctx, user.ID)
+	if !ok {
+		return
+	}
+
+	valid, err := server.checkMFACode(ctx.Request.Context(), mfa, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check code"})
+		return
+	}
+	if !valid {
+		server.failMFA(ctx, payload, mfaKeys)
+		return
+	}
+
+	if err := server.loginGuard.Reset(ctx.Request.Context(), mfaKeys[0]); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset login attempts"})
+		return
+	}
+	if err := server.revoker.Revoke(ctx.Request.Context(), payload.ID, payload.ExpiredAt); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke mfa token"})
+		return
+	}
+
+	rsp, err := server.createLoginSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// failMFA records a wrong second-factor code and rejects it. The token's own
+// policy has no backoff, so any wait on it means it is used up and is revoked.
+func (server *Server) failMFA(ctx *gin.Context, payload *token.Payload, keys []lockout.Key) {
+	if _, err := server.loginGuard.Fail(ctx.Request.Context(), keys[0]); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record login attempt"})
+		return
+	}
+	tokenWait, err := server.loginGuard.Fail(ctx.Request.Context(), keys[1])
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record login attempt"})
+		return
+	}
+	if tokenWait > 0 {
+		if err := server.revoker.Revoke(ctx.Request.Context(), payload.ID, payload.ExpiredAt); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke mfa token"})
+			return
+		}
+	}
+	ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+}
+
+func (server *Server) getMFAStatus(ctx *gin.Context) {
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	rsp := MFAStatusResponse{
+		Required: server.roleRequiresMFA(user.Role),
+	}
+
+	mfa, err := server.store.GetUserMFA(ctx.Request.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get two-factor settings"})
+		return
+	}
+	rsp.Enabled = err == nil && mfa.EnabledAt.Valid
+
+	if rsp.Enabled {
+		rsp.RecoveryCodesRemaining, err = server.store.CountUnusedMFARecoveryCodes(ctx.Request.Context(), user.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count recovery codes"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// setupTOTP starts enrolment by generating a secret for the authenticator
+// app. Calling it again before confirming replaces the secret.
+func (server *Server) setupTOTP(ctx *gin.Context) {
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate secret"})
+		return
+	}
+
+	_, err = server.store.UpsertUserMFASecret(ctx.Request.Context(), db.UpsertUserMFASecretParams{
+		UserID:     user.ID,
+		TotpSecret: secret,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save secret"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SetupTOTPResponse{
+		Secret: secret,
+		URI:    totp.URI(server.config.MFAIssuer, user.Email, secret),
+	})
+}
+
+// confirmTOTP enables 2FA once the user proves their app produces valid
+// codes, and hands out recovery codes. They are only ever shown here.
+func (server *Server) confirmTOTP(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	mfa, err := server.store.GetUserMFA(ctx.Request.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "two-factor enrolment has not been started"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get two-factor settings"})
+		return
+	}
+	if mfa.EnabledAt.Valid {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+		return
+	}
+
+	step, err := totp.Validate(mfa.TotpSecret, req.Code, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid code"})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+
+	_, err = server.store.ConfirmMFAEnrollmentTx(ctx.Request.Context(), db.ConfirmMFAEnrollmentTxParams{
+		UserID:             user.ID,
+		Step:               step,
+		RecoveryCodeHashes: hashes,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+
+	rsp := ConfirmTOTPResponse{RecoveryCodes: codes}
+	if authPayload.TokenType == "mfa_pending" {
+		if err := server.revoker.Revoke(ctx.Request.Context(), authPayload.ID, authPayload.ExpiredAt); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke mfa token"})
+			return
+		}
+		login, err := server.createLoginSession(ctx, user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		rsp.Login = &login
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// disableTOTP turns 2FA off after checking a current code. Users whose role
+// requires 2FA cannot turn it off.
+func (server *Server) disableTOTP(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+	if server.roleRequiresMFA(user.Role) {
+		err := fmt.Errorf("two-factor authentication is mandatory for role %s", user.Role)
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	mfa, ok := server.loadEnabledMFA(ctx, user.ID)
+	if !ok {
+		return
+	}
+
+	if !server.checkCurrentMFACode(ctx, mfa, req.Code) {
+		return
+	}
+
+	err := server.store.DisableMFATx(ctx.Request.Context(), user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// regenerateRecoveryCodes replaces every recovery code, used or not, after
+// checking a current code.
+func (server *Server) regenerateRecoveryCodes(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	mfa, ok := server.loadEnabledMFA(ctx, user.ID)
+	if !ok {
+		return
+	}
+
+	if !server.checkCurrentMFACode(ctx, mfa, req.Code) {
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+
+	err = server.store.ReplaceMFARecoveryCodesTx(ctx.Request.Context(), db.ReplaceMFARecoveryCodesTxParams{
+		UserID:     user.ID,
+		CodeHashes: hashes,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save recovery codes"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// loadEnabledMFA returns the user's 2FA settings, answering the request itself
+// and returning false when 2FA is not enabled or cannot be read.
+func (server *Server) loadEnabledMFA(ctx *gin.Context, userID int64) (db.UserMfa, bool) {
+	mfa, err := server.store.GetUserMFA(ctx.Request.Context(), userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get two-factor settings"})
+		return db.UserMfa{}, false
+	}
+	if err != nil || !mfa.EnabledAt.Valid {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is not enabled"})
+		return db.UserMfa{}, false
+	}
+	return mfa, true
+}
+
+// checkCurrentMFACode checks the code a signed-in user confirms a change to
+// their 2FA settings with. Wrong codes count against the same per-user limit
+// as verifyMFA, so a stolen session cannot guess its way past it. It answers
+// the request itself and returns false unless the code is valid.
+func (server *Server) checkCurrentMFACode(ctx *gin.Context, mfa db.UserMfa, code string) bool {
+	key := lockout.MFAUserKey(mfa.UserID)
+	wait, err := server.loginGuard.Check(ctx.Request.Context(), key)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check login attempts"})
+		return false
+	}
+	if wait > 0 {
+		respondTooManyAttempts(ctx, wait)
+		return false
+	}
+
+	valid, err := server.checkMFACode(ctx.Request.Context(), mfa, code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check code"})
+		return false
+	}
+	if !valid {
+		if _, err := server.loginGuard.Fail(ctx.Request.Context(), key); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record login attempt"})
+			return false
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return false
+	}
+
+	if err := server.loginGuard.Reset(ctx.Request.Context(), key); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset login attempts"})
+		return false
+	}
+	return true
+}
+
+// checkMFACode accepts either a TOTP code or an unused recovery code. Both are
+// single use: a TOTP code's time step is recorded and a recovery code is
+// marked used.
+func (server *Server) checkMFACode(ctx context.Context, mfa db.UserMfa, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		step, err := totp.Validate(mfa.TotpSecret, code, time.Now())
+		if err != nil {
+			return false, nil
+		}
+
+		rows, err := server.store.UseUserMFAStep(ctx, db.UseUserMFAStepParams{
+			UserID:       mfa.UserID,
+			LastUsedStep: step,
+		})
+		if err != nil {
+			return false, err
+		}
+		return rows > 0, nil
+	}
+
+	_, err := server.store.ConsumeMFARecoveryCode(ctx, db.ConsumeMFARecoveryCodeParams{
+		UserID:   mfa.UserID,
+		CodeHash: util.HashToken(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// newRecoveryCodes returns recovery codes formatted for display together with
+// the hashes that are stored in their place.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		buf := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+		codes[i] = fmt.Sprintf("%s-%s-%s-%s", raw[0:4], raw[4:8], raw[8:12], raw[12:16])
+		hashes[i] = util.HashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces so codes can be typed
+// the way they were printed or not.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,699 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/lockout"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/totp"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+func randomEnabledMFA(t *testing.T, user db.User) db.UserMfa {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	return db.UserMfa{
+		UserID:     user.ID,
+		TotpSecret: secret,
+		EnabledAt:  sql.NullTime{Time: time.Now(), Valid: true},
+		CreatedAt:  time.Now(),
+	}
+}
+
+func TestLoginUserMFAAPI(t *testing.T) {
+	password := "testPassword123"
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user := randomUserForSessions()
+	user.HashedPassword = hashedPassword
+	admin := user
+	admin.Role = policy.RoleAdmin
+
+	testCases := []struct {
+		name               string
+		user               db.User
+		requiredRoles      []string
+		mfa                *db.UserMfa
+		enrollmentRequired bool
+	}{
+		{
+			name: "Enabled",
+			user: user,
+			mfa:  &db.UserMfa{UserID: user.ID, EnabledAt: sql.NullTime{Time: time.Now(), Valid: true}},
+		},
+		{
+			name:               "RequiredByRoleNotEnrolled",
+			user:               admin,
+			requiredRoles:      []string{policy.RoleAdmin},
+			enrollmentRequired: true,
+		},
+		{
+			name:               "RequiredByRolePendingEnrolment",
+			user:               admin,
+			requiredRoles:      []string{policy.RoleAdmin},
+			mfa:                &db.UserMfa{UserID: user.ID},
+			enrollmentRequired: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(tc.user.Username)).
+				Times(1).
+				Return(tc.user, nil)
+			if tc.mfa != nil {
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(tc.user.ID)).Times(1).Return(*tc.mfa, nil)
+			} else {
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(tc.user.ID)).Times(1).Return(db.UserMfa{}, sql.ErrNoRows)
+			}
+			store.EXPECT().
+				CreateSession(gomock.Any(), gomock.Any()).
+				Times(0)
+
+			server := newTestServer(t, store)
+			server.mfaRequiredRoles = tc.requiredRoles
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"username": tc.user.Username, "password": password})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var rsp MFAPendingResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+			require.True(t, rsp.MFARequired)
+			require.Equal(t, tc.enrollmentRequired, rsp.MFAEnrollmentRequired)
+			require.NotContains(t, recorder.Body.String(), "access_token")
+
+			payload, err := server.tokenMaker.VerifyToken(rsp.MFAToken)
+			require.NoError(t, err)
+			require.Equal(t, "mfa_pending", payload.TokenType)
+			require.Equal(t, tc.user.ID, payload.UserID)
+		})
+	}
+}
+
+func TestVerifyMFAAPI(t *testing.T) {
+	user := randomUserForSessions()
+	mfa := randomEnabledMFA(t, user)
+
+	testCases := []struct {
+		name          string
+		code          func(t *testing.T) string
+		useAccess     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "TOTPCode",
+			code: func(t *testing.T) string {
+				code, err := totp.Code(mfa.TotpSecret, time.Now())
+				require.NoError(t, err)
+				return code
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(mfa, nil)
+				store.EXPECT().
+					UseUserMFAStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UseUserMFAStepParams) (int64, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.InDelta(t, totp.Step(time.Now()), arg.LastUsedStep, 1)
+						return 1, nil
+					})
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(randomSession(user), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLoginResponse(t, recorder.Body.String(), user)
+			},
+		},
+		{
+			name: "ReplayedTOTPCode",
+			code: func(t *testing.T) string {
+				code, err := totp.Code(mfa.TotpSecret, time.Now())
+				require.NoError(t, err)
+				return code
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(mfa, nil)
+				store.EXPECT().UseUserMFAStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			code: func(t *testing.T) string {
+				return "ABCD-EFGH-IJKL-MNOP"
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(mfa, nil)
+				store.EXPECT().
+					ConsumeMFARecoveryCode(gomock.Any(), gomock.Eq(db.ConsumeMFARecoveryCodeParams{
+						UserID:   user.ID,
+						CodeHash: util.HashToken("abcdefghijklmnop"),
+					})).
+					Times(1).
+					Return(db.MfaRecoveryCode{UserID: user.ID}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(randomSession(user), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UsedRecoveryCode",
+			code: func(t *testing.T) string {
+				return "abcd-efgh-ijkl-mnop"
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(mfa, nil)
+				store.EXPECT().ConsumeMFARecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.MfaRecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "AccessTokenInsteadOfMFAToken",
+			code: func(t *testing.T) string {
+				return "123456"
+			},
+			useAccess: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			code: func(t *testing.T) string {
+				return "123456"
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserMfa{}, sql.ErrNoRows)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			mfaToken, err := server.tokenMaker.CreateMFAToken(user.ID, user.Username, time.Minute)
+			require.NoError(t, err)
+			if tc.useAccess {
+				mfaToken, err = server.tokenMaker.CreateToken(uuid.New(), user.ID, user.Username, time.Minute)
+				require.NoError(t, err)
+			}
+
+			data, err := json.Marshal(gin.H{"mfa_token": mfaToken, "code": tc.code(t)})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/mfa/verify", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVerifyMFAAttemptLimits(t *testing.T) {
+	user := randomUserForSessions()
+	mfa := randomEnabledMFA(t, user)
+	goodCode := "abcd-efgh-ijkl-mnop"
+	badCode := "zzzz-zzzz-zzzz-zzzz"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).AnyTimes().Return(user, nil)
+	store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).AnyTimes().Return(mfa, nil)
+	store.EXPECT().
+		ConsumeMFARecoveryCode(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, arg db.ConsumeMFARecoveryCodeParams) (db.MfaRecoveryCode, error) {
+			if arg.CodeHash != util.HashToken(normalizeRecoveryCode(goodCode)) {
+				return db.MfaRecoveryCode{}, sql.ErrNoRows
+			}
+			return db.MfaRecoveryCode{UserID: user.ID}, nil
+		})
+	store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).AnyTimes().Return(randomSession(user), nil)
+
+	server := newTestServer(t, store)
+	// No backoff between attempts, so only the limits are exercised.
+	server.loginGuard = lockout.NewGuard(lockout.NewMemoryCounter(), map[lockout.Kind]lockout.Policy{
+		lockout.KindMFAUser:  {MaxFailures: 5, LockoutDuration: 15 * time.Minute, Window: time.Hour},
+		lockout.KindMFAToken: {MaxFailures: 3, LockoutDuration: 5 * time.Minute, Window: 5 * time.Minute},
+	})
+
+	newMFAToken := func() string {
+		mfaToken, err := server.tokenMaker.CreateMFAToken(user.ID, user.Username, time.Minute)
+		require.NoError(t, err)
+		return mfaToken
+	}
+	verify := func(mfaToken, code string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(gin.H{"mfa_token": mfaToken, "code": code})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/mfa/verify", bytes.NewReader(data))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// A successful verification uses the token up.
+	first := newMFAToken()
+	require.Equal(t, http.StatusOK, verify(first, goodCode).Code)
+	require.Equal(t, http.StatusUnauthorized, verify(first, goodCode).Code)
+
+	// Three wrong codes burn a token, even for a right code afterwards.
+	second := newMFAToken()
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusUnauthorized, verify(second, badCode).Code)
+	}
+	recorder := verify(second, goodCode)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "revoked")
+
+	// A fresh token still counts against the user, who is locked out after
+	// five wrong codes in total.
+	third := newMFAToken()
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusUnauthorized, verify(third, badCode).Code)
+	}
+	recorder = verify(newMFAToken(), goodCode)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+}
+
+func TestSetupTOTPAPI(t *testing.T) {
+	user := randomUserForSessions()
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					UpsertUserMFASecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpsertUserMFASecretParams) (db.UserMfa, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						return db.UserMfa{UserID: arg.UserID, TotpSecret: arg.TotpSecret}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp SetupTOTPResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.Secret)
+				require.Contains(t, rsp.URI, "otpauth://totp/")
+				require.Contains(t, rsp.URI, "secret="+rsp.Secret)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					UpsertUserMFASecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UserMfa{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/mfa/totp/setup", nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestConfirmTOTPAPI(t *testing.T) {
+	user := randomUserForSessions()
+	pending := randomEnabledMFA(t, user)
+	pending.EnabledAt = sql.NullTime{}
+
+	testCases := []struct {
+		name          string
+		pendingLogin  bool
+		code          func(t *testing.T) string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			code: func(t *testing.T) string {
+				code, err := totp.Code(pending.TotpSecret, time.Now())
+				require.NoError(t, err)
+				return code
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().
+					ConfirmMFAEnrollmentTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ConfirmMFAEnrollmentTxParams) (db.UserMfa, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Len(t, arg.RecoveryCodeHashes, recoveryCodeCount)
+						return pending, nil
+					})
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ConfirmTOTPResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.RecoveryCodes, recoveryCodeCount)
+				require.Nil(t, rsp.Login)
+			},
+		},
+		{
+			name:         "CompletesPendingLogin",
+			pendingLogin: true,
+			code: func(t *testing.T) string {
+				code, err := totp.Code(pending.TotpSecret, time.Now())
+				require.NoError(t, err)
+				return code
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().ConfirmMFAEnrollmentTx(gomock.Any(), gomock.Any()).Times(1).Return(pending, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(randomSession(user), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ConfirmTOTPResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotNil(t, rsp.Login)
+				require.NotEmpty(t, rsp.Login.AccessToken)
+			},
+		},
+		{
+			name: "WrongCode",
+			code: func(t *testing.T) string {
+				code, err := totp.Code(pending.TotpSecret, time.Now().Add(-time.Hour))
+				require.NoError(t, err)
+				return code
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().ConfirmMFAEnrollmentTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotStarted",
+			code: func(t *testing.T) string {
+				return "123456"
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserMfa{}, sql.ErrNoRows)
+				store.EXPECT().ConfirmMFAEnrollmentTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"code": tc.code(t)})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/mfa/totp/confirm", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			if tc.pendingLogin {
+				mfaToken, err := server.tokenMaker.CreateMFAToken(user.ID, user.Username, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, mfaToken))
+			} else {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDisableTOTPAPI(t *testing.T) {
+	user := randomUserForSessions()
+	mfa := randomEnabledMFA(t, user)
+
+	testCases := []struct {
+		name          string
+		requiredRoles []string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(mfa, nil)
+				store.EXPECT().UseUserMFAStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().DisableMFATx(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:          "RequiredByRole",
+			requiredRoles: []string{policy.RoleUser},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().DisableMFATx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.mfaRequiredRoles = tc.requiredRoles
+			recorder := httptest.NewRecorder()
+
+			code, err := totp.Code(mfa.TotpSecret, time.Now())
+			require.NoError(t, err)
+			data, err := json.Marshal(gin.H{"code": code})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodDelete, "/api/v1/auth/mfa/totp", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestChangeMFASettingsAttemptLimits(t *testing.T) {
+	user := randomUserForSessions()
+	mfa := randomEnabledMFA(t, user)
+	badCode := "zzzz-zzzz-zzzz-zzzz"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).AnyTimes().Return(user, nil)
+	store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).AnyTimes().Return(mfa, nil)
+	store.EXPECT().
+		ConsumeMFARecoveryCode(gomock.Any(), gomock.Any()).
+		Times(5).
+		Return(db.MfaRecoveryCode{}, sql.ErrNoRows)
+	store.EXPECT().DisableMFATx(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().ReplaceMFARecoveryCodesTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	server.loginGuard = lockout.NewGuard(lockout.NewMemoryCounter(), map[lockout.Kind]lockout.Policy{
+		lockout.KindMFAUser: {MaxFailures: 5, LockoutDuration: 15 * time.Minute, Window: time.Hour},
+	})
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(gin.H{"code": badCode})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(method, path, bytes.NewReader(data))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// Both endpoints count against the user's second-factor limit.
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusUnauthorized, send(http.MethodDelete, "/api/v1/auth/mfa/totp").Code)
+	}
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusUnauthorized, send(http.MethodPost, "/api/v1/auth/mfa/recovery-codes").Code)
+	}
+
+	recorder := send(http.MethodDelete, "/api/v1/auth/mfa/totp")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusTooManyRequests, send(http.MethodPost, "/api/v1/auth/mfa/recovery-codes").Code)
+}
+
+func TestMFAPendingTokenIsNotAnAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := randomUserForSessions()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().ListSessionsByUser(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	mfaToken, err := server.tokenMaker.CreateMFAToken(user.ID, user.Username, time.Minute)
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/sessions", nil)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, mfaToken))
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		require.Regexp(t, `^[a-z2-7]{4}(-[a-z2-7]{4}){3}$`, code)
+		require.Equal(t, hashes[i], util.HashToken(normalizeRecoveryCode(code)))
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+}
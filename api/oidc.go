@@ -0,0 +1,393 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/oidc"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+const (
+	oidcStateBytes = 32
+	oidcNonceBytes = 32
+	// oidcUsernameAttempts bounds how many suffixed usernames are tried when
+	// the one derived from the ID token is taken.
+	oidcUsernameAttempts = 5
+
+	// oidcStateCookieName ties a login to the browser that started it. It
+	// must survive the cross-site redirect back from the provider, so it is
+	// always SameSite=Lax whatever the auth cookies use.
+	oidcStateCookieName = "glc_oidc_state"
+)
+
+var (
+	errOIDCMissingEmail = errors.New("identity provider did not share an email address")
+	errOIDCEmailTaken   = errors.New("an account with this email address already exists; sign in with a password and ask an administrator to link it")
+)
+
+// newOIDCProviders builds the configured identity providers, keyed by name.
+func newOIDCProviders(config util.Config) (map[string]*oidc.Provider, error) {
+	configs, err := oidc.ParseConfigs(config.OIDCProviders)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make(map[string]*oidc.Provider, len(configs))
+	for _, providerConfig := range configs {
+		roles := []string{providerConfig.DefaultRole}
+		for _, role := range providerConfig.Roles {
+			roles = append(roles, role)
+		}
+		if _, err := policy.ParseRoles(strings.Join(roles, ",")); err != nil {
+			return nil, fmt.Errorf("provider %q: %w", providerConfig.Name, err)
+		}
+
+		providers[providerConfig.Name] = oidc.NewProvider(providerConfig, nil)
+	}
+	return providers, nil
+}
+
+func (server *Server) oidcRedirectURI(provider string) string {
+	return strings.TrimSuffix(server.config.OIDCRedirectBaseURL, "/") + "/" + provider + "/callback"
+}
+
+// oidcLogin sends the browser to the identity provider. The state, nonce and
+// PKCE verifier are kept server-side until the provider redirects back, and
+// a hash of the state is also set in a cookie so the callback only completes
+// in the browser that started the login.
+func (server *Server) oidcLogin(ctx *gin.Context) {
+	name := ctx.Param("provider")
+	provider, ok := server.oidcProviders[name]
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "unknown identity provider"})
+		return
+	}
+
+	state, err := util.RandomToken(oidcStateBytes)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+	nonce, err := util.RandomToken(oidcNonceBytes)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx.Request.Context(), server.oidcRedirectURI(name), state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "identity provider is unavailable"})
+		return
+	}
+
+	// Logins that were never finished are cleared here rather than by a
+	// background job.
+	if err := server.store.DeleteExpiredOIDCLoginStates(ctx.Request.Context()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+	err = server.store.CreateOIDCLoginState(ctx.Request.Context(), db.CreateOIDCLoginStateParams{
+		StateHash:    util.HashToken(state),
+		Provider:     name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(server.config.OIDCStateDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+
+	server.setOIDCStateCookie(ctx, util.HashToken(state), time.Now().Add(server.config.OIDCStateDuration))
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// oidcCallback finishes the login the provider redirected back from. In
+// cookie mode the browser is sent back to the web app, signed in or on its
+// way to the second factor; otherwise it responds exactly like loginUser.
+func (server *Server) oidcCallback(ctx *gin.Context) {
+	name := ctx.Param("provider")
+	provider, ok := server.oidcProviders[name]
+	if !ok {
+		server.oidcFail(ctx, http.StatusNotFound, "unknown identity provider")
+		return
+	}
+
+	if errorCode := ctx.Query("error"); errorCode != "" {
+		server.oidcFail(ctx, http.StatusUnauthorized, "identity provider refused the login: "+errorCode)
+		return
+	}
+	state := ctx.Query("state")
+	code := ctx.Query("code")
+	if state == "" || code == "" {
+		server.oidcFail(ctx, http.StatusBadRequest, "state and code are required")
+		return
+	}
+
+	// Without this check anyone could send a victim the callback URL of a
+	// login they started themselves and sign the victim in as them.
+	stateCookie := cookieCredential(ctx, oidcStateCookieName)
+	server.setOIDCStateCookie(ctx, "", time.Unix(0, 0))
+	if subtle.ConstantTimeCompare([]byte(stateCookie), []byte(util.HashToken(state))) != 1 {
+		server.oidcFail(ctx, http.StatusBadRequest, "login was not started in this browser")
+		return
+	}
+
+	loginState, err := server.store.ConsumeOIDCLoginState(ctx.Request.Context(), util.HashToken(state))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			server.oidcFail(ctx, http.StatusBadRequest, "login request is invalid or has expired")
+			return
+		}
+		server.oidcFail(ctx, http.StatusInternalServerError, "failed to get login request")
+		return
+	}
+	if loginState.Provider != name {
+		server.oidcFail(ctx, http.StatusBadRequest, "login request is invalid or has expired")
+		return
+	}
+
+	rawIDToken, err := provider.Exchange(ctx.Request.Context(), server.oidcRedirectURI(name), code, loginState.CodeVerifier)
+	if err != nil {
+		server.oidcFail(ctx, http.StatusUnauthorized, "failed to exchange authorization code")
+		return
+	}
+	idToken, err := provider.Verify(ctx.Request.Context(), rawIDToken, loginState.Nonce)
+	if err != nil {
+		server.oidcFail(ctx, http.StatusUnauthorized, "invalid ID token")
+		return
+	}
+
+	user, err := server.findOrCreateOIDCUser(ctx.Request.Context(), provider.Config, idToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, errOIDCMissingEmail):
+			server.oidcFail(ctx, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, errOIDCEmailTaken):
+			server.oidcFail(ctx, http.StatusConflict, err.Error())
+		default:
+			server.oidcFail(ctx, http.StatusInternalServerError, "failed to sign in user")
+		}
+		return
+	}
+
+	// A just-in-time account whose address the provider has not verified
+	// is held to the same rule as a password login.
+	if !user.EmailVerifiedAt.Valid {
+		server.oidcFail(ctx, http.StatusForbidden, "email address has not been verified")
+		return
+	}
+
+	if !server.config.AuthCookiesEnabled {
+		server.completeLogin(ctx, user)
+		return
+	}
+
+	pending, _, err := server.beginLogin(ctx, user)
+	if err != nil {
+		server.oidcFail(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if pending != nil {
+		// The token goes in the fragment so it never reaches a server log.
+		fragment := url.Values{"mfa_token": {pending.MFAToken}}
+		if pending.MFAEnrollmentRequired {
+			fragment.Set("mfa_enrollment_required", "true")
+		}
+		ctx.Redirect(http.StatusFound, server.webURL("/login")+"#"+fragment.Encode())
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, server.webURL("/gl-admin"))
+}
+
+// oidcFail rejects a callback. The browser arrived by redirect, so in cookie
+// mode it is sent back to the login page with the reason instead of being
+// left on a JSON error.
+func (server *Server) oidcFail(ctx *gin.Context, status int, message string) {
+	if server.config.AuthCookiesEnabled {
+		ctx.Redirect(http.StatusFound, server.webURL("/login")+"?"+url.Values{"error": {message}}.Encode())
+		return
+	}
+	ctx.JSON(status, gin.H{"error": message})
+}
+
+// setOIDCStateCookie sets, or with an empty value clears, the state cookie.
+// It is scoped to the path the provider redirects back to.
+func (server *Server) setOIDCStateCookie(ctx *gin.Context, value string, expiresAt time.Time) {
+	path := "/"
+	if redirectURL, err := url.Parse(server.config.OIDCRedirectBaseURL); err == nil && redirectURL.Path != "" {
+		path = redirectURL.Path
+	}
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    value,
+		Path:     path,
+		Expires:  expiresAt,
+		Secure:   server.config.AuthCookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// webURL returns the address of path in the web app.
+func (server *Server) webURL(path string) string {
+	return strings.TrimSuffix(server.config.WebBaseURL, "/") + path
+}
+
+// findOrCreateOIDCUser returns the user an ID token belongs to. Returning
+// users are found through their linked identity. A new identity is linked to
+// the account with the same email only when both the provider and that
+// account have verified the address, so nobody can pre-register someone
+// else's email and wait for them to sign in; otherwise a new account is
+// created just in time.
+func (server *Server) findOrCreateOIDCUser(ctx context.Context, config oidc.Config, idToken *oidc.IDToken) (db.User, error) {
+	identity, err := server.store.GetUserIdentity(ctx, db.GetUserIdentityParams{
+		Provider: config.Name,
+		Subject:  idToken.Subject,
+	})
+	if err == nil {
+		if err := server.store.TouchUserIdentity(ctx, db.TouchUserIdentityParams{
+			ID:    identity.ID,
+			Email: idToken.Email,
+		}); err != nil {
+			return db.User{}, err
+		}
+		return server.store.GetUser(ctx, identity.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return db.User{}, err
+	}
+
+	if idToken.Email == "" {
+		return db.User{}, errOIDCMissingEmail
+	}
+
+	existing, err := server.store.GetUserByEmail(ctx, idToken.Email)
+	switch {
+	case err == nil && idToken.EmailVerified && existing.EmailVerifiedAt.Valid:
+		_, err = server.store.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+			UserID:   existing.ID,
+			Provider: config.Name,
+			Subject:  idToken.Subject,
+			Email:    idToken.Email,
+		})
+		return existing, err
+	case err == nil:
+		return db.User{}, errOIDCEmailTaken
+	case !errors.Is(err, sql.ErrNoRows):
+		return db.User{}, err
+	}
+
+	// The account never gets a usable password; its owner signs in through
+	// the provider, or sets one with a password reset.
+	password, err := util.RandomToken(32)
+	if err != nil {
+		return db.User{}, err
+	}
+	hashedPassword, err := util.HashPassword(password)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	var emailVerifiedAt sql.NullTime
+	if idToken.EmailVerified {
+		emailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	username := oidcUsername(idToken)
+	fullName := idToken.Name
+	if fullName == "" {
+		fullName = username
+	}
+
+	for attempt := 0; ; attempt++ {
+		candidate := username
+		if attempt > 0 {
+			candidate = fmt.Sprintf("%s%04d", username, rand.IntN(10000))
+		}
+
+		result, err := server.store.CreateOIDCUserTx(ctx, db.CreateOIDCUserTxParams{
+			CreateUserParams: db.CreateUserParams{
+				Username:        candidate,
+				FullName:        fullName,
+				Email:           idToken.Email,
+				HashedPassword:  hashedPassword,
+				Role:            oidcRole(config, idToken),
+				EmailVerifiedAt: emailVerifiedAt,
+			},
+			Provider: config.Name,
+			Subject:  idToken.Subject,
+		})
+		if err == nil {
+			return result.User, nil
+		}
+		if !isUniqueViolation(err) || attempt+1 == oidcUsernameAttempts {
+			return db.User{}, err
+		}
+	}
+}
+
+// oidcRole maps the values of the provider's role claim to the most
+// privileged matching role, falling back to the provider's default role.
+func oidcRole(config oidc.Config, idToken *oidc.IDToken) string {
+	var roles []string
+	if config.RoleClaim != "" {
+		for _, value := range idToken.StringsClaim(config.RoleClaim) {
+			if role, ok := config.Roles[value]; ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	if role := policy.HighestRole(roles); role != "" {
+		return role
+	}
+	if config.DefaultRole != "" {
+		return policy.NormalizeRole(config.DefaultRole)
+	}
+	return policy.RoleUser
+}
+
+// oidcUsername derives a username that passes the same alphanum check as
+// registration, from the preferred username or else the email address.
+func oidcUsername(idToken *oidc.IDToken) string {
+	source := idToken.PreferredUsername
+	if source == "" {
+		source, _, _ = strings.Cut(idToken.Email, "@")
+	}
+
+	var b strings.Builder
+	for _, r := range source {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+
+	username := b.String()
+	if len(username) > 40 {
+		username = username[:40]
+	}
+	if len(username) < 3 {
+		username = "user" + username
+	}
+	return username
+}
@@ -0,0 +1,576 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/oidc"
+	"github.com/go-live-cms/go-live-cms/oidc/oidctest"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+// newOIDCTestServer returns a test server with one provider, "corp", backed
+// by an in-process fake identity provider.
+func newOIDCTestServer(t *testing.T, store db.Store) (*Server, *oidctest.Provider) {
+	fake := oidctest.NewProvider("cms", "secret")
+	t.Cleanup(fake.Close)
+
+	server := newTestServer(t, store)
+	server.config.OIDCProviders = fmt.Sprintf(
+		`[{"name":"corp","issuer":%q,"client_id":"cms","client_secret":"secret","role_claim":"groups","roles":{"cms-admins":"admin","cms-editors":"moderator"}}]`,
+		fake.Issuer,
+	)
+
+	providers, err := newOIDCProviders(server.config)
+	require.NoError(t, err)
+	server.oidcProviders = providers
+	return server, fake
+}
+
+// startOIDCLogin calls the login endpoint and returns the provider URL it
+// redirected to along with the login state it stored and the state cookie
+// it set.
+func startOIDCLogin(t *testing.T, server *Server, store *mockdb.MockStore) (string, db.OidcLoginState, *http.Cookie) {
+	var loginState db.OidcLoginState
+	store.EXPECT().DeleteExpiredOIDCLoginStates(gomock.Any()).Times(1).Return(nil)
+	store.EXPECT().
+		CreateOIDCLoginState(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, arg db.CreateOIDCLoginStateParams) error {
+			loginState = db.OidcLoginState{
+				StateHash:    arg.StateHash,
+				Provider:     arg.Provider,
+				Nonce:        arg.Nonce,
+				CodeVerifier: arg.CodeVerifier,
+				ExpiresAt:    arg.ExpiresAt,
+				CreatedAt:    time.Now(),
+			}
+			return nil
+		})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/auth/oidc/corp/login", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusFound, recorder.Code)
+
+	stateCookie := responseCookies(recorder)[oidcStateCookieName]
+	require.NotNil(t, stateCookie)
+	return recorder.Header().Get("Location"), loginState, stateCookie
+}
+
+func TestOIDCLoginRedirect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server, fake := newOIDCTestServer(t, store)
+
+	location, loginState, stateCookie := startOIDCLogin(t, server, store)
+	require.True(t, strings.HasPrefix(location, fake.Issuer+"/authorize?"))
+
+	authURL, err := url.Parse(location)
+	require.NoError(t, err)
+	query := authURL.Query()
+	require.Equal(t, "code", query.Get("response_type"))
+	require.Equal(t, "cms", query.Get("client_id"))
+	require.Equal(t, "http://localhost:8080/api/v1/auth/oidc/corp/callback", query.Get("redirect_uri"))
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.Equal(t, oidc.CodeChallenge(loginState.CodeVerifier), query.Get("code_challenge"))
+	require.Equal(t, loginState.Nonce, query.Get("nonce"))
+
+	// Only a hash of the state is stored.
+	require.Equal(t, util.HashToken(query.Get("state")), loginState.StateHash)
+	require.Equal(t, "corp", loginState.Provider)
+	require.WithinDuration(t, time.Now().Add(10*time.Minute), loginState.ExpiresAt, time.Minute)
+
+	require.Equal(t, loginState.StateHash, stateCookie.Value)
+	require.Equal(t, "/api/v1/auth/oidc", stateCookie.Path)
+	require.True(t, stateCookie.HttpOnly)
+	require.Equal(t, http.SameSiteLaxMode, stateCookie.SameSite)
+}
+
+func TestOIDCLoginUnknownProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateOIDCLoginState(gomock.Any(), gomock.Any()).Times(0)
+	server, _ := newOIDCTestServer(t, store)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/auth/oidc/other/login", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestOIDCCallbackAPI(t *testing.T) {
+	user := randomUserForSessions()
+	user.Email = "ada@example.com"
+	identity := db.UserIdentity{
+		ID:       1,
+		UserID:   user.ID,
+		Provider: "corp",
+		Subject:  "idp-user-1",
+		Email:    user.Email,
+	}
+	claims := map[string]any{
+		"sub":                identity.Subject,
+		"email":              user.Email,
+		"email_verified":     true,
+		"name":               "Ada Lovelace",
+		"preferred_username": "ada.lovelace",
+		"groups":             []string{"staff", "cms-editors", "cms-admins"},
+	}
+
+	expectSession := func(store *mockdb.MockStore, user db.User) {
+		store.EXPECT().GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserMfa{}, sql.ErrNoRows)
+		store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(randomSession(user), nil)
+	}
+
+	testCases := []struct {
+		name          string
+		claims        map[string]any
+		cookies       bool
+		query         func(query url.Values)
+		stateCookie   func(cookie *http.Cookie) *http.Cookie
+		buildStubs    func(store *mockdb.MockStore, loginState db.OidcLoginState)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "NewUser",
+			claims: claims,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).Times(1).Return(loginState, nil)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Eq(db.GetUserIdentityParams{Provider: "corp", Subject: identity.Subject})).
+					Times(1).
+					Return(db.UserIdentity{}, sql.ErrNoRows)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(db.User{}, sql.ErrNoRows)
+
+				created := user
+				created.Username = "adalovelace"
+				created.Role = policy.RoleAdmin
+				store.EXPECT().
+					CreateOIDCUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateOIDCUserTxParams) (db.CreateOIDCUserTxResult, error) {
+						require.Equal(t, "adalovelace", arg.Username)
+						require.Equal(t, "Ada Lovelace", arg.FullName)
+						require.Equal(t, user.Email, arg.Email)
+						require.Equal(t, policy.RoleAdmin, arg.Role)
+						require.True(t, arg.EmailVerifiedAt.Valid)
+						require.NotEmpty(t, arg.HashedPassword)
+						require.Equal(t, "corp", arg.Provider)
+						require.Equal(t, identity.Subject, arg.Subject)
+						return db.CreateOIDCUserTxResult{User: created, Identity: identity}, nil
+					})
+				expectSession(store, created)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp LoginUserResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+				require.Equal(t, "adalovelace", rsp.User.Username)
+			},
+		},
+		{
+			name:   "ReturningUser",
+			claims: claims,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(identity, nil)
+				store.EXPECT().
+					TouchUserIdentity(gomock.Any(), gomock.Eq(db.TouchUserIdentityParams{ID: identity.ID, Email: user.Email})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().CreateOIDCUserTx(gomock.Any(), gomock.Any()).Times(0)
+				expectSession(store, user)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLoginResponse(t, recorder.Body.String(), user)
+			},
+		},
+		{
+			name:   "LinksVerifiedEmail",
+			claims: claims,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(db.UserIdentity{}, sql.ErrNoRows)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreateUserIdentity(gomock.Any(), gomock.Eq(db.CreateUserIdentityParams{
+						UserID:   user.ID,
+						Provider: "corp",
+						Subject:  identity.Subject,
+						Email:    user.Email,
+					})).
+					Times(1).
+					Return(identity, nil)
+				store.EXPECT().CreateOIDCUserTx(gomock.Any(), gomock.Any()).Times(0)
+				expectSession(store, user)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLoginResponse(t, recorder.Body.String(), user)
+			},
+		},
+		{
+			name: "UnverifiedEmailTaken",
+			claims: map[string]any{
+				"sub":            identity.Subject,
+				"email":          user.Email,
+				"email_verified": false,
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(db.UserIdentity{}, sql.ErrNoRows)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CreateUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "UnverifiedLocalAccountNotLinked",
+			claims: claims,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				unverified := user
+				unverified.EmailVerifiedAt = sql.NullTime{}
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(db.UserIdentity{}, sql.ErrNoRows)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(unverified, nil)
+				store.EXPECT().CreateUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "UnverifiedNewUser",
+			claims: map[string]any{
+				"sub":            identity.Subject,
+				"email":          "grace@example.com",
+				"email_verified": false,
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(db.UserIdentity{}, sql.ErrNoRows)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+
+				created := user
+				created.EmailVerifiedAt = sql.NullTime{}
+				store.EXPECT().
+					CreateOIDCUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOIDCUserTxResult{User: created}, nil)
+				store.EXPECT().GetUserMFA(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:    "CookieModeRedirectsToAdmin",
+			claims:  claims,
+			cookies: true,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(identity, nil)
+				store.EXPECT().TouchUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				expectSession(store, user)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusFound, recorder.Code)
+				require.Equal(t, "http://localhost:4321/gl-admin", recorder.Header().Get("Location"))
+
+				cookies := responseCookies(recorder)
+				require.NotEmpty(t, cookies[accessTokenCookieName].Value)
+				require.NotEmpty(t, cookies[refreshTokenCookieName].Value)
+				require.NotEmpty(t, cookies[csrfCookieName].Value)
+				require.Empty(t, cookies[oidcStateCookieName].Value)
+			},
+		},
+		{
+			name:    "CookieModeMFA",
+			claims:  claims,
+			cookies: true,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(identity, nil)
+				store.EXPECT().TouchUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().
+					GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(db.UserMfa{UserID: user.ID, EnabledAt: sql.NullTime{Time: time.Now(), Valid: true}}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusFound, recorder.Code)
+
+				location, err := url.Parse(recorder.Header().Get("Location"))
+				require.NoError(t, err)
+				require.Equal(t, "/login", location.Path)
+				fragment, err := url.ParseQuery(location.Fragment)
+				require.NoError(t, err)
+				require.NotEmpty(t, fragment.Get("mfa_token"))
+				require.NotContains(t, responseCookies(recorder), accessTokenCookieName)
+			},
+		},
+		{
+			name:    "CookieModeError",
+			claims:  claims,
+			cookies: true,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(db.OidcLoginState{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusFound, recorder.Code)
+
+				location, err := url.Parse(recorder.Header().Get("Location"))
+				require.NoError(t, err)
+				require.Equal(t, "/login", location.Path)
+				require.Equal(t, "login request is invalid or has expired", location.Query().Get("error"))
+			},
+		},
+		{
+			name: "DefaultRoleAndUsernameTaken",
+			claims: map[string]any{
+				"sub":            identity.Subject,
+				"email":          "grace@example.com",
+				"email_verified": true,
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(db.UserIdentity{}, sql.ErrNoRows)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+
+				var usernames []string
+				created := user
+				store.EXPECT().
+					CreateOIDCUserTx(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(func(_ any, arg db.CreateOIDCUserTxParams) (db.CreateOIDCUserTxResult, error) {
+						require.Equal(t, policy.RoleUser, arg.Role)
+						usernames = append(usernames, arg.Username)
+						if len(usernames) == 1 {
+							require.Equal(t, "grace", arg.Username)
+							return db.CreateOIDCUserTxResult{}, fmt.Errorf("duplicate key value violates unique constraint")
+						}
+						require.Regexp(t, `^grace\d{4}$`, arg.Username)
+						created.Username = arg.Username
+						return db.CreateOIDCUserTxResult{User: created}, nil
+					})
+				expectSession(store, user)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "MFAEnabled",
+			claims: claims,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(identity, nil)
+				store.EXPECT().TouchUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().
+					GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(db.UserMfa{UserID: user.ID, EnabledAt: sql.NullTime{Time: time.Now(), Valid: true}}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "mfa_token")
+				require.NotContains(t, recorder.Body.String(), "refresh_token")
+			},
+		},
+		{
+			name:   "NonceMismatch",
+			claims: claims,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				loginState.Nonce = "some-other-login"
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "StateFromOtherProvider",
+			claims: claims,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				loginState.Provider = "other"
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "ReplayedState",
+			claims: claims,
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(db.OidcLoginState{}, sql.ErrNoRows)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "WrongCode",
+			claims: claims,
+			query: func(query url.Values) {
+				query.Set("code", "forged")
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(1).Return(loginState, nil)
+				store.EXPECT().GetUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			// The victim of a login CSRF follows a callback URL for a login
+			// they never started.
+			name:   "StateCookieMissing",
+			claims: claims,
+			stateCookie: func(cookie *http.Cookie) *http.Cookie {
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "not started in this browser")
+			},
+		},
+		{
+			name:   "StateCookieForAnotherLogin",
+			claims: claims,
+			stateCookie: func(cookie *http.Cookie) *http.Cookie {
+				return &http.Cookie{Name: cookie.Name, Value: util.HashToken("another-state")}
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "ProviderError",
+			claims: claims,
+			query: func(query url.Values) {
+				query.Del("code")
+				query.Set("error", "access_denied")
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "access_denied")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server, fake := newOIDCTestServer(t, store)
+			server.config.AuthCookiesEnabled = tc.cookies
+
+			location, loginState, stateCookie := startOIDCLogin(t, server, store)
+			code, err := fake.Authorize(location, tc.claims)
+			require.NoError(t, err)
+
+			authURL, err := url.Parse(location)
+			require.NoError(t, err)
+			query := url.Values{}
+			query.Set("state", authURL.Query().Get("state"))
+			query.Set("code", code)
+			if tc.query != nil {
+				tc.query(query)
+			}
+
+			tc.buildStubs(store, loginState)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/auth/oidc/corp/callback?"+query.Encode(), nil)
+			require.NoError(t, err)
+			if tc.stateCookie != nil {
+				stateCookie = tc.stateCookie(stateCookie)
+			}
+			if stateCookie != nil {
+				request.AddCookie(stateCookie)
+			}
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestNewOIDCProvidersRejectsUnknownRoles(t *testing.T) {
+	_, err := newOIDCProviders(util.Config{
+		OIDCProviders: `[{"name":"corp","issuer":"https://idp.example.com","client_id":"cms","roles":{"cms-admins":"superuser"}}]`,
+	})
+	require.Error(t, err)
+
+	providers, err := newOIDCProviders(util.Config{})
+	require.NoError(t, err)
+	require.Empty(t, providers)
+}
+
+func TestOIDCUsername(t *testing.T) {
+	testCases := []struct {
+		idToken  oidc.IDToken
+		username string
+	}{
+		{oidc.IDToken{PreferredUsername: "ada.lovelace"}, "adalovelace"},
+		{oidc.IDToken{Email: "grace.hopper@example.com"}, "gracehopper"},
+		{oidc.IDToken{PreferredUsername: "żółć", Email: "x@example.com"}, "user"},
+		{oidc.IDToken{PreferredUsername: strings.Repeat("a", 60)}, strings.Repeat("a", 40)},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.username, oidcUsername(&tc.idToken))
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/mailer"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+// passwordResetTokenBytes is the amount of randomness in a password reset
+// token.
+const passwordResetTokenBytes = 32
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6,max=72"`
+}
+
+// forgotPassword answers the same way whether or not the address belongs to
+// an account, so it cannot be used to probe for users.
+func (server *Server) forgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accepted := gin.H{"message": "if the address belongs to an account, a password reset email is on its way"}
+
+	user, err := server.store.GetUserByEmail(c.Request.Context(), req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusAccepted, accepted)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return
+	}
+
+	token, err := util.RandomToken(passwordResetTokenBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create password reset token"})
+		return
+	}
+
+	_, err = server.store.ReissuePasswordResetTx(c.Request.Context(), db.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(server.config.PasswordResetDuration),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create password reset token"})
+		return
+	}
+
+	if err := server.sendPasswordResetEmail(c.Request.Context(), user, token); err != nil {
+		log.Printf("failed to send password reset email to user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// resetPassword sets a new password from an emailed token. Every existing
+// session of the user is blocked and access tokens issued before the reset
+// stop working, so whoever held the old password is logged out.
+func (server *Server) resetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	user, err := server.store.ResetPasswordTx(c.Request.Context(), db.ResetPasswordTxParams{
+		TokenHash:      util.HashToken(req.Token),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "password reset token is invalid or has expired"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	if err := server.revokeUserTokens(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke access tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "password has been reset, sign in with the new password",
+	})
+}
+
+func (server *Server) sendPasswordResetEmail(ctx context.Context, user db.User, token string) error {
+	link := server.webTokenLink("/reset-password", token)
+
+	return server.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to reset the password of your account. Choose a new one here:\n\n%s\n\nThe link works once. If you did not ask for this, ignore this email.\n",
+			user.FullName, link,
+		),
+	})
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+const (
+	includeTaxonomies = "taxonomies"
+	includeMedia      = "media"
+	includeAuthors    = "authors"
+)
+
+// postIncludes is the set of related resources a post request embeds, from
+// ?include=taxonomies,media,authors.
+type postIncludes map[string]bool
+
+// parsePostIncludes reads the include parameter. It writes the error response
+// and returns false when it names an unknown relation.
+func parsePostIncludes(c *gin.Context) (postIncludes, bool) {
+	includes := postIncludes{}
+	param := c.Query("include")
+	if param == "" {
+		return includes, true
+	}
+
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case includeTaxonomies, includeMedia, includeAuthors:
+			includes[name] = true
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid include %q: must be one of taxonomies, media, authors", name),
+			})
+			return nil, false
+		}
+	}
+	return includes, true
+}
+
+// embed loads each included relation for all of the posts with one query and
+// attaches it to their responses, so a page of posts costs at most four
+// queries more however long it is: one per relation, and one for the
+// renditions of embedded images.
+func (includes postIncludes) embed(ctx context.Context, server *Server, posts []PostResponse) error {
+	if len(includes) == 0 || len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(posts))
+	index := make(map[int64]*PostResponse, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+		index[posts[i].ID] = &posts[i]
+	}
+
+	if includes[includeTaxonomies] {
+		rows, err := server.store.GetTaxonomiesByPostIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			post := index[row.PostID]
+			post.Taxonomies = append(post.Taxonomies, toTaxonomyResponse(db.Taxonomy{
+				ID:          row.ID,
+				Name:        row.Name,
+				Description: row.Description,
+				Version:     row.Version,
+			}))
+		}
+	}
+
+	if includes[includeMedia] {
+		rows, err := server.store.GetMediaByPostIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		media := make([]MediaResponse, len(rows))
+		for i, row := range rows {
+			media[i] = server.toMediaResponse(ctx, db.Medium{
+				ID:          row.ID,
+				Name:        row.Name,
+				Description: row.Description,
+				Alt:         row.Alt,
+				MediaPath:   row.MediaPath,
+				UserID:      row.UserID,
+				CreatedAt:   row.CreatedAt,
+				ChangedAt:   row.ChangedAt,
+				StorageKey:  row.StorageKey,
+				Size:        row.Size,
+				MimeType:    row.MimeType,
+				Checksum:    row.Checksum,
+				Width:       row.Width,
+				Height:      row.Height,
+				Version:     row.Version,
+			})
+		}
+		if err := server.attachRenditions(ctx, media); err != nil {
+			return err
+		}
+		for i, row := range rows {
+			post := index[row.PostID]
+			post.Media = append(post.Media, media[i])
+		}
+	}
+
+	if includes[includeAuthors] {
+		rows, err := server.store.ListPostAuthorsByPostIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			post := index[row.PostID]
+			post.Authors = append(post.Authors, toUserResponse(db.User{
+				ID:              row.ID,
+				Username:        row.Username,
+				FullName:        row.FullName,
+				Email:           row.Email,
+				CreatedAt:       row.CreatedAt,
+				Role:            row.Role,
+				Version:         row.Version,
+				EmailVerifiedAt: row.EmailVerifiedAt,
+			}))
+		}
+	}
+
+	return nil
+}
+
+// embedResults embeds the included relations in search results.
+func (includes postIncludes) embedResults(ctx context.Context, server *Server, results []PostSearchResult) error {
+	posts := make([]PostResponse, len(results))
+	for i := range results {
+		posts[i] = results[i].PostResponse
+	}
+	if err := includes.embed(ctx, server, posts); err != nil {
+		return err
+	}
+	for i := range results {
+		results[i].PostResponse = posts[i]
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+func TestPostIncludesAPI(t *testing.T) {
+	user := randomUserForPosts()
+	first := randomPost(user)
+	first.ID = 1
+	second := randomPost(user)
+	second.ID = 2
+	taxonomy := randomTaxonomy()
+	media := randomMedia()
+
+	taxonomyRow := func(postID int64) db.GetTaxonomiesByPostIDsRow {
+		return db.GetTaxonomiesByPostIDsRow{
+			PostID:      postID,
+			ID:          taxonomy.ID,
+			Name:        taxonomy.Name,
+			Description: taxonomy.Description,
+		}
+	}
+	mediaRow := func(postID int64) db.GetMediaByPostIDsRow {
+		return db.GetMediaByPostIDsRow{
+			PostID:    postID,
+			ID:        media.ID,
+			Name:      media.Name,
+			MediaPath: media.MediaPath,
+			UserID:    media.UserID,
+			CreatedAt: media.CreatedAt,
+			ChangedAt: media.ChangedAt,
+		}
+	}
+	authorRow := func(postID int64) db.ListPostAuthorsByPostIDsRow {
+		return db.ListPostAuthorsByPostIDsRow{
+			PostID:         postID,
+			ID:             user.ID,
+			Username:       user.Username,
+			FullName:       user.FullName,
+			Email:          user.Email,
+			HashedPassword: user.HashedPassword,
+			Role:           user.Role,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "GetPostWithAllIncludes",
+			url:  fmt.Sprintf("/api/v1/posts/%d?include=taxonomies,media,authors", first.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(first.ID)).
+					Times(1).
+					Return(first, nil)
+				store.EXPECT().
+					GetTaxonomiesByPostIDs(gomock.Any(), []int64{first.ID}).
+					Times(1).
+					Return([]db.GetTaxonomiesByPostIDsRow{taxonomyRow(first.ID)}, nil)
+				store.EXPECT().
+					GetMediaByPostIDs(gomock.Any(), []int64{first.ID}).
+					Times(1).
+					Return([]db.GetMediaByPostIDsRow{mediaRow(first.ID)}, nil)
+				store.EXPECT().
+					ListPostAuthorsByPostIDs(gomock.Any(), []int64{first.ID}).
+					Times(1).
+					Return([]db.ListPostAuthorsByPostIDsRow{authorRow(first.ID)}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Post PostResponse `json:"post"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, first.ID, response.Post.ID)
+				require.Len(t, response.Post.Taxonomies, 1)
+				require.Equal(t, taxonomy.Name, response.Post.Taxonomies[0].Name)
+				require.Len(t, response.Post.Media, 1)
+				require.Equal(t, media.MediaPath, response.Post.Media[0].MediaPath)
+				require.Len(t, response.Post.Authors, 1)
+				require.Equal(t, user.Username, response.Post.Authors[0].Username)
+				require.NotContains(t, recorder.Body.String(), user.HashedPassword)
+				require.Equal(t, "W/"+etag(first.Version), recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name: "EmbeddedImageRenditions",
+			url:  fmt.Sprintf("/api/v1/posts/%d?include=media", first.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				row := mediaRow(first.ID)
+				row.MimeType = "image/png"
+				row.StorageKey = "2026/01/cover.png"
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(first.ID)).
+					Times(1).
+					Return(first, nil)
+				store.EXPECT().
+					GetMediaByPostIDs(gomock.Any(), []int64{first.ID}).
+					Times(1).
+					Return([]db.GetMediaByPostIDsRow{row}, nil)
+				store.EXPECT().
+					ListMediaRenditions(gomock.Any(), []int64{media.ID}).
+					Times(1).
+					Return([]db.MediaRendition{
+						{ID: 1, MediaID: media.ID, Name: "thumbnail", Format: "png", StorageKey: "2026/01/cover_thumbnail.png", MimeType: "image/png", Width: 150, Height: 112, Size: 512},
+					}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Post PostResponse `json:"post"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Post.Media, 1)
+				require.Equal(t, "/uploads/2026/01/cover_thumbnail.png", response.Post.Media[0].Renditions["thumbnail"].URL)
+				require.NotEmpty(t, response.Post.Media[0].Srcset)
+			},
+		},
+		{
+			name: "ListPostsBatchesIncludes",
+			url:  "/api/v1/posts?include=taxonomies,authors&count=none",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Post{first, second}, nil)
+				store.EXPECT().
+					GetTaxonomiesByPostIDs(gomock.Any(), []int64{first.ID, second.ID}).
+					Times(1).
+					Return([]db.GetTaxonomiesByPostIDsRow{taxonomyRow(second.ID)}, nil)
+				store.EXPECT().
+					ListPostAuthorsByPostIDs(gomock.Any(), []int64{first.ID, second.ID}).
+					Times(1).
+					Return([]db.ListPostAuthorsByPostIDsRow{authorRow(first.ID), authorRow(second.ID)}, nil)
+				store.EXPECT().
+					GetMediaByPostIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostResponse `json:"posts"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Posts, 2)
+				require.Empty(t, response.Posts[0].Taxonomies)
+				require.Len(t, response.Posts[1].Taxonomies, 1)
+				require.Len(t, response.Posts[0].Authors, 1)
+				require.Len(t, response.Posts[1].Authors, 1)
+				require.Empty(t, response.Posts[0].Media)
+			},
+		},
+		{
+			name: "NoIncludes",
+			url:  fmt.Sprintf("/api/v1/posts/%d", first.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(first.ID)).
+					Times(1).
+					Return(first, nil)
+				store.EXPECT().
+					GetTaxonomiesByPostIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					GetMediaByPostIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					ListPostAuthorsByPostIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), `"taxonomies"`)
+				require.NotContains(t, recorder.Body.String(), `"authors"`)
+				require.Equal(t, etag(first.Version), recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name: "PostsByUser",
+			url:  fmt.Sprintf("/api/v1/posts/user/%d?include=authors", user.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetPostsByUserWithMedia(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.GetPostsByUserWithMediaRow{{ID: first.ID, Title: first.Title, UserID: user.ID}}, nil)
+				store.EXPECT().
+					ListPostAuthorsByPostIDs(gomock.Any(), []int64{first.ID}).
+					Times(1).
+					Return([]db.ListPostAuthorsByPostIDsRow{authorRow(first.ID)}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostResponse `json:"posts"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Posts, 1)
+				require.Len(t, response.Posts[0].Authors, 1)
+				require.Equal(t, user.Username, response.Posts[0].Authors[0].Username)
+			},
+		},
+		{
+			name: "TaxonomyPosts",
+			url:  fmt.Sprintf("/api/v1/taxonomies/%d/posts?include=taxonomies", taxonomy.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
+					Times(1).
+					Return(taxonomy, nil)
+				store.EXPECT().
+					GetTaxonomyPosts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Post{first}, nil)
+				store.EXPECT().
+					GetTaxonomiesByPostIDs(gomock.Any(), []int64{first.ID}).
+					Times(1).
+					Return([]db.GetTaxonomiesByPostIDsRow{taxonomyRow(first.ID)}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostResponse `json:"posts"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Posts, 1)
+				require.Len(t, response.Posts[0].Taxonomies, 1)
+				require.Equal(t, taxonomy.Name, response.Posts[0].Taxonomies[0].Name)
+			},
+		},
+		{
+			name: "SearchPosts",
+			url:  "/api/v1/posts/search?q=go&include=authors",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.SearchPostsRow{{ID: first.ID, Title: first.Title, UserID: user.ID}}, nil)
+				store.EXPECT().
+					CountSearchPosts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				store.EXPECT().
+					ListPostAuthorsByPostIDs(gomock.Any(), []int64{first.ID}).
+					Times(1).
+					Return([]db.ListPostAuthorsByPostIDsRow{authorRow(first.ID)}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostSearchResult `json:"posts"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Posts, 1)
+				require.Equal(t, first.ID, response.Posts[0].ID)
+				require.Len(t, response.Posts[0].Authors, 1)
+			},
+		},
+		{
+			name: "SearchUnknownInclude",
+			url:  "/api/v1/posts/search?q=go&include=comments",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownInclude",
+			url:  fmt.Sprintf("/api/v1/posts/%d?include=media,comments", first.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "comments")
+			},
+		},
+		{
+			name: "EmptyInclude",
+			url:  "/api/v1/posts?include=",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Post{}, nil)
+				store.EXPECT().
+					CountPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "IncludeError",
+			url:  fmt.Sprintf("/api/v1/posts/%d?include=media", first.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(first.ID)).
+					Times(1).
+					Return(first, nil)
+				store.EXPECT().
+					GetMediaByPostIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
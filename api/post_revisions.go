@@ -0,0 +1,293 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/diff"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/token"
+)
+
+type PostRevisionResponse struct {
+	PostID       int64     `json:"post_id"`
+	Revision     int32     `json:"revision"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Content      string    `json:"content"`
+	Url          string    `json:"url"`
+	UserID       *int64    `json:"user_id"`
+	Username     string    `json:"username"`
+	RestoredFrom *int32    `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func toPostRevisionResponse(revision db.PostRevision) PostRevisionResponse {
+	response := PostRevisionResponse{
+		PostID:      revision.PostID,
+		Revision:    revision.Revision,
+		Title:       revision.Title,
+		Description: revision.Description,
+		Content:     revision.Content,
+		Url:         revision.Url,
+		Username:    revision.Username,
+		CreatedAt:   revision.CreatedAt,
+	}
+	if revision.UserID.Valid {
+		response.UserID = &revision.UserID.Int64
+	}
+	if revision.RestoredFrom.Valid {
+		response.RestoredFrom = &revision.RestoredFrom.Int32
+	}
+	return response
+}
+
+// PostRevisionDiffResponse holds a line-level diff of every field a revision
+// snapshots.
+type PostRevisionDiffResponse struct {
+	PostID  int64                  `json:"post_id"`
+	From    int32                  `json:"from"`
+	To      int32                  `json:"to"`
+	Changed bool                   `json:"changed"`
+	Fields  map[string][]diff.Line `json:"fields"`
+}
+
+// editablePost loads the post named by the :id parameter and checks that the
+// caller may edit it. Revisions can contain unpublished drafts, so only
+// authors and staff may read them. It writes the error response and returns
+// false on failure.
+func (server *Server) editablePost(c *gin.Context) (db.Post, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		return db.Post{}, false
+	}
+
+	post, err := server.store.GetPost(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return db.Post{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post"})
+		return db.Post{}, false
+	}
+
+	authors, err := server.store.ListPostAuthors(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post authors"})
+		return db.Post{}, false
+	}
+
+	if err := policy.CanEditPost(authActor(c), post, authors); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return db.Post{}, false
+	}
+
+	return post, true
+}
+
+// getRevision loads revision number raw of postID, writing the error response
+// and returning false on failure.
+func (server *Server) getRevision(c *gin.Context, postID int64, raw string) (db.PostRevision, bool) {
+	number, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || number <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return db.PostRevision{}, false
+	}
+
+	revision, err := server.store.GetPostRevision(c.Request.Context(), db.GetPostRevisionParams{
+		PostID:   postID,
+		Revision: int32(number),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+			return db.PostRevision{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get revision"})
+		return db.PostRevision{}, false
+	}
+
+	return revision, true
+}
+
+func (server *Server) listPostRevisions(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, err := strconv.ParseInt(limitStr, 10, 32)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.ParseInt(offsetStr, 10, 32)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+		return
+	}
+
+	post, ok := server.editablePost(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := server.store.ListPostRevisions(c.Request.Context(), db.ListPostRevisionsParams{
+		PostID: post.ID,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list revisions"})
+		return
+	}
+
+	total, err := server.store.CountPostRevisions(c.Request.Context(), post.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count revisions"})
+		return
+	}
+
+	revisionResponses := make([]PostRevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		revisionResponses[i] = toPostRevisionResponse(revision)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisionResponses,
+		"meta": gin.H{
+			"post_id": post.ID,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+			"count":   len(revisionResponses),
+		},
+	})
+}
+
+func (server *Server) getPostRevision(c *gin.Context) {
+	post, ok := server.editablePost(c)
+	if !ok {
+		return
+	}
+
+	revision, ok := server.getRevision(c, post.ID, c.Param("revision"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revision": toPostRevisionResponse(revision),
+	})
+}
+
+// diffPostRevisions compares revision :revision against ?from=, which
+// defaults to the revision before it.
+func (server *Server) diffPostRevisions(c *gin.Context) {
+	post, ok := server.editablePost(c)
+	if !ok {
+		return
+	}
+
+	to, ok := server.getRevision(c, post.ID, c.Param("revision"))
+	if !ok {
+		return
+	}
+
+	fromParam := c.Query("from")
+	if fromParam == "" {
+		if to.Revision == 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "revision 1 has no previous revision, pass ?from="})
+			return
+		}
+		fromParam = strconv.Itoa(int(to.Revision - 1))
+	}
+
+	from, ok := server.getRevision(c, post.ID, fromParam)
+	if !ok {
+		return
+	}
+
+	fields := map[string][]diff.Line{
+		"title":       diff.Lines(from.Title, to.Title),
+		"description": diff.Lines(from.Description, to.Description),
+		"content":     diff.Lines(from.Content, to.Content),
+		"url":         diff.Lines(from.Url, to.Url),
+	}
+	changed := false
+	for _, lines := range fields {
+		changed = changed || diff.Changed(lines)
+	}
+
+	c.JSON(http.StatusOK, PostRevisionDiffResponse{
+		PostID:  post.ID,
+		From:    from.Revision,
+		To:      to.Revision,
+		Changed: changed,
+		Fields:  fields,
+	})
+}
+
+// restorePostRevision copies an older revision back onto the post. The
+// restore is itself recorded as a new revision, so it can be undone too.
+func (server *Server) restorePostRevision(c *gin.Context) {
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	post, ok := server.editablePost(c)
+	if !ok {
+		return
+	}
+
+	revision, ok := server.getRevision(c, post.ID, c.Param("revision"))
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.UpdatePostTx(c.Request.Context(), db.UpdatePostTxParams{
+		UpdatePostParams: db.UpdatePostParams{
+			ID:          post.ID,
+			Title:       revision.Title,
+			Content:     revision.Content,
+			Description: revision.Description,
+			UserID:      post.UserID,
+			Username:    post.Username,
+			Url:         revision.Url,
+
+			ExpectedVersion: expectedVersion,
+		},
+		EditorID:       authPayload.UserID,
+		EditorUsername: authPayload.Username,
+		RestoredFrom:   sql.NullInt32{Int32: revision.Revision, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			preconditionFailed(c)
+			return
+		}
+		if containsString(err.Error(), "duplicate key value") || containsString(err.Error(), "unique constraint") {
+			c.JSON(http.StatusConflict, gin.H{"error": "URL already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore revision"})
+		return
+	}
+
+	setETag(c, result.Post.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"post":     toPostResponse(result.Post),
+		"revision": toPostRevisionResponse(result.Revision),
+	})
+}
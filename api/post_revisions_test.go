@@ -0,0 +1,258 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/diff"
+)
+
+func randomRevision(post db.Post, number int32, editor db.User) db.PostRevision {
+	return db.PostRevision{
+		ID:          int64(number),
+		PostID:      post.ID,
+		Revision:    number,
+		Title:       post.Title,
+		Description: post.Description,
+		Content:     post.Content,
+		Url:         post.Url,
+		UserID:      sql.NullInt64{Int64: editor.ID, Valid: true},
+		Username:    editor.Username,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestPostRevisionsAPI(t *testing.T) {
+	author := randomUserForPosts()
+	stranger := randomUserForPosts()
+	stranger.ID = author.ID + 1
+
+	post := randomPost(author)
+	authors := []db.UserPost{{PostID: post.ID, UserID: author.ID}}
+
+	first := randomRevision(post, 1, author)
+	first.Content = "intro\nbody\noutro"
+	second := randomRevision(post, 2, author)
+	second.Content = "intro\nnew body\noutro"
+
+	expectEditablePost := func(store *mockdb.MockStore, caller db.User) {
+		expectAuthUser(store, caller)
+		store.EXPECT().GetPost(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+		store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(authors, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		caller        db.User
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "ListOK",
+			method: http.MethodGet,
+			path:   "/revisions",
+			caller: author,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectEditablePost(store, author)
+				store.EXPECT().
+					ListPostRevisions(gomock.Any(), gomock.Eq(db.ListPostRevisionsParams{PostID: post.ID, Limit: 10, Offset: 0})).
+					Times(1).
+					Return([]db.PostRevision{second, first}, nil)
+				store.EXPECT().CountPostRevisions(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(int64(2), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Revisions []PostRevisionResponse `json:"revisions"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Revisions, 2)
+				require.Equal(t, int32(2), response.Revisions[0].Revision)
+				require.Equal(t, author.ID, *response.Revisions[0].UserID)
+			},
+		},
+		{
+			name:   "ListByStranger",
+			method: http.MethodGet,
+			path:   "/revisions",
+			caller: stranger,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectEditablePost(store, stranger)
+				store.EXPECT().ListPostRevisions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "GetNotFound",
+			method: http.MethodGet,
+			path:   "/revisions/9",
+			caller: author,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectEditablePost(store, author)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Revision: 9})).
+					Times(1).
+					Return(db.PostRevision{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "GetInvalidRevision",
+			method: http.MethodGet,
+			path:   "/revisions/0",
+			caller: author,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectEditablePost(store, author)
+				store.EXPECT().GetPostRevision(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "DiffAgainstPrevious",
+			method: http.MethodGet,
+			path:   "/revisions/2/diff",
+			caller: author,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectEditablePost(store, author)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Revision: 2})).
+					Times(1).
+					Return(second, nil)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Revision: 1})).
+					Times(1).
+					Return(first, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response PostRevisionDiffResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, int32(1), response.From)
+				require.Equal(t, int32(2), response.To)
+				require.True(t, response.Changed)
+				require.False(t, diff.Changed(response.Fields["title"]))
+				require.Equal(t, []diff.Line{
+					{Op: diff.OpEqual, Text: "intro", OldLine: 1, NewLine: 1},
+					{Op: diff.OpDelete, Text: "body", OldLine: 2},
+					{Op: diff.OpInsert, Text: "new body", NewLine: 2},
+					{Op: diff.OpEqual, Text: "outro", OldLine: 3, NewLine: 3},
+				}, response.Fields["content"])
+			},
+		},
+		{
+			name:   "DiffFirstRevisionNeedsFrom",
+			method: http.MethodGet,
+			path:   "/revisions/1/diff",
+			caller: author,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectEditablePost(store, author)
+				store.EXPECT().GetPostRevision(gomock.Any(), gomock.Any()).Times(1).Return(first, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "RestoreOK",
+			method: http.MethodPost,
+			path:   "/revisions/1/restore",
+			caller: author,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectEditablePost(store, author)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Revision: 1})).
+					Times(1).
+					Return(first, nil)
+
+				arg := db.UpdatePostTxParams{
+					UpdatePostParams: db.UpdatePostParams{
+						ID:          post.ID,
+						Title:       first.Title,
+						Content:     first.Content,
+						Description: first.Description,
+						UserID:      post.UserID,
+						Username:    post.Username,
+						Url:         first.Url,
+					},
+					EditorID:       author.ID,
+					EditorUsername: author.Username,
+					RestoredFrom:   sql.NullInt32{Int32: 1, Valid: true},
+				}
+				restored := post
+				restored.Content = first.Content
+				revision := randomRevision(restored, 3, author)
+				revision.RestoredFrom = arg.RestoredFrom
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UpdatePostTxResult{Post: restored, Revision: revision}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Revision PostRevisionResponse `json:"revision"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, int32(3), response.Revision.Revision)
+				require.Equal(t, int32(1), *response.Revision.RestoredFrom)
+			},
+		},
+		{
+			name:   "RestoreByStranger",
+			method: http.MethodPost,
+			path:   "/revisions/1/restore",
+			caller: stranger,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectEditablePost(store, stranger)
+				store.EXPECT().UpdatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/posts/%d%s", post.ID, tc.path)
+			request, err := http.NewRequest(tc.method, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.caller.ID, tc.caller.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
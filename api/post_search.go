@@ -0,0 +1,322 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/search"
+)
+
+const defaultPostLanguage = "english"
+
+// postLanguages are the text search configurations Postgres ships with. A
+// post's language decides how its words are stemmed in the search index.
+var postLanguages = map[string]bool{
+	"simple":     true,
+	"arabic":     true,
+	"danish":     true,
+	"dutch":      true,
+	"english":    true,
+	"finnish":    true,
+	"french":     true,
+	"german":     true,
+	"hungarian":  true,
+	"indonesian": true,
+	"irish":      true,
+	"italian":    true,
+	"lithuanian": true,
+	"nepali":     true,
+	"norwegian":  true,
+	"portuguese": true,
+	"romanian":   true,
+	"russian":    true,
+	"spanish":    true,
+	"swedish":    true,
+	"tamil":      true,
+	"turkish":    true,
+}
+
+func isPostLanguage(language string) bool {
+	return postLanguages[language]
+}
+
+// PostSearchResult is a post that matched a search. TitleHighlight and
+// Snippet are HTML: the post's text escaped, with the matches in <mark> tags.
+type PostSearchResult struct {
+	PostResponse
+	Rank           float32 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+func toPostSearchResult(row db.SearchPostsRow) PostSearchResult {
+	return PostSearchResult{
+		PostResponse: PostResponse{
+			ID:          row.ID,
+			Title:       row.Title,
+			Content:     row.Content,
+			Description: row.Description,
+			UserID:      row.UserID,
+			Username:    row.Username,
+			Url:         row.Url,
+			Status:      row.Status,
+			Language:    row.Language,
+			PublishedAt: nullTimePtr(row.PublishedAt),
+			PublishAt:   nullTimePtr(row.PublishAt),
+			UnpublishAt: nullTimePtr(row.UnpublishAt),
+			Version:     row.Version,
+			CreatedAt:   row.CreatedAt,
+			ChangedAt:   row.ChangedAt,
+		},
+		Rank:           row.Rank,
+		TitleHighlight: row.TitleHighlight,
+		Snippet:        row.Snippet,
+	}
+}
+
+// searchPosts runs a full-text search over posts in one language. Posts
+// written in any other language are never matched, so a multilingual site
+// runs one search per language. q accepts web search syntax: "quoted
+// phrases", OR, and -excluded words.
+func (server *Server) searchPosts(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "search query is required"})
+		return
+	}
+
+	language := c.DefaultQuery("language", defaultPostLanguage)
+	if !isPostLanguage(language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid language parameter"})
+		return
+	}
+
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, err := strconv.ParseInt(limitStr, 10, 32)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.ParseInt(offsetStr, 10, 32)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+		return
+	}
+
+	taxonomyID, ok := searchIDFilter(c, "taxonomy_id")
+	if !ok {
+		return
+	}
+	authorID, ok := searchIDFilter(c, "author_id")
+	if !ok {
+		return
+	}
+	from, ok := searchDateFilter(c, "from", false)
+	if !ok {
+		return
+	}
+	to, ok := searchDateFilter(c, "to", true)
+	if !ok {
+		return
+	}
+
+	status, ok := postStatusFilter(c, 0)
+	if !ok {
+		return
+	}
+	includes, ok := parsePostIncludes(c)
+	if !ok {
+		return
+	}
+
+	arg := db.SearchPostsParams{
+		Language:      language,
+		Query:         query,
+		Status:        status,
+		TaxonomyID:    taxonomyID,
+		AuthorID:      authorID,
+		CreatedAfter:  from,
+		CreatedBefore: to,
+		Limit:         int32(limit),
+		Offset:        int32(offset),
+	}
+	if server.searchIndex != nil {
+		server.searchPostsInIndex(c, arg, includes)
+		return
+	}
+
+	rows, err := server.store.SearchPosts(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search posts"})
+		return
+	}
+
+	total, err := server.store.CountSearchPosts(c.Request.Context(), db.CountSearchPostsParams{
+		Language:      language,
+		Query:         query,
+		Status:        status,
+		TaxonomyID:    taxonomyID,
+		AuthorID:      authorID,
+		CreatedAfter:  from,
+		CreatedBefore: to,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count search results"})
+		return
+	}
+
+	results := make([]PostSearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = toPostSearchResult(row)
+	}
+	if err := includes.embedResults(c.Request.Context(), server, results); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load included resources"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts": results,
+		"meta": gin.H{
+			"query":    query,
+			"language": language,
+			"total":    total,
+			"limit":    limit,
+			"offset":   offset,
+			"count":    len(results),
+		},
+	})
+}
+
+// postFacets are the fields the search index counts matches for.
+var postFacets = []string{"status", "language", "taxonomy_ids", "author_ids"}
+
+// searchPostsInIndex answers a post search from the SEARCH_DRIVER index,
+// which adds typo tolerance and facet counts, and then loads the matching
+// posts from the database so the response matches the Postgres search.
+func (server *Server) searchPostsInIndex(c *gin.Context, arg db.SearchPostsParams, includes postIncludes) {
+	filters := []search.Filter{{Field: "language", Op: search.OpEqual, Value: arg.Language}}
+	if arg.Status.Valid {
+		filters = append(filters, search.Filter{Field: "status", Op: search.OpEqual, Value: arg.Status.String})
+	}
+	if arg.TaxonomyID.Valid {
+		filters = append(filters, search.Filter{Field: "taxonomy_ids", Op: search.OpEqual, Value: arg.TaxonomyID.Int64})
+	}
+	if arg.AuthorID.Valid {
+		filters = append(filters, search.Filter{Field: "author_ids", Op: search.OpEqual, Value: arg.AuthorID.Int64})
+	}
+	if arg.CreatedAfter.Valid {
+		filters = append(filters, search.Filter{Field: "created_at", Op: search.OpGreaterEqual, Value: arg.CreatedAfter.Time.Unix()})
+	}
+	if arg.CreatedBefore.Valid {
+		filters = append(filters, search.Filter{Field: "created_at", Op: search.OpLess, Value: arg.CreatedBefore.Time.Unix()})
+	}
+
+	result, err := server.searchIndex.Query(c.Request.Context(), search.KindPosts, search.Query{
+		Text:    arg.Query,
+		Filters: filters,
+		Facets:  postFacets,
+		Limit:   int(arg.Limit),
+		Offset:  int(arg.Offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search posts"})
+		return
+	}
+
+	posts, err := server.store.ListPostsByIDs(c.Request.Context(), hitIDs(result.Hits))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get posts"})
+		return
+	}
+	byID := make(map[int64]db.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	// Hits are ranked by the index. A post deleted since it was indexed is
+	// skipped rather than failing the search, and so is one whose status
+	// changed: the index may lag behind, and only the database decides what
+	// the caller is allowed to see.
+	results := make([]PostSearchResult, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		post, ok := byID[hit.ID]
+		if !ok || (arg.Status.Valid && post.Status != arg.Status.String) {
+			continue
+		}
+		results = append(results, PostSearchResult{
+			PostResponse:   toPostResponse(post),
+			Rank:           float32(hit.Score),
+			TitleHighlight: hit.Highlights["title"],
+			Snippet:        hit.Highlights["content"],
+		})
+	}
+	if err := includes.embedResults(c.Request.Context(), server, results); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load included resources"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts": results,
+		"meta": gin.H{
+			"query":    arg.Query,
+			"language": arg.Language,
+			"total":    result.Total,
+			"limit":    arg.Limit,
+			"offset":   arg.Offset,
+			"count":    len(results),
+			"facets":   result.Facets,
+		},
+	})
+}
+
+func hitIDs(hits []search.Hit) []int64 {
+	ids := make([]int64, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func searchIDFilter(c *gin.Context, name string) (sql.NullInt64, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return sql.NullInt64{}, true
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + " parameter"})
+		return sql.NullInt64{}, false
+	}
+	return sql.NullInt64{Int64: id, Valid: true}, true
+}
+
+// searchDateFilter parses an RFC 3339 timestamp or a plain date. A plain date
+// used as an upper bound covers the whole day.
+func searchDateFilter(c *gin.Context, name string, upper bool) (sql.NullTime, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return sql.NullTime{}, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return sql.NullTime{Time: t, Valid: true}, true
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + " parameter"})
+		return sql.NullTime{}, false
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return sql.NullTime{Time: t, Valid: true}, true
+}
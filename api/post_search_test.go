@@ -0,0 +1,477 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/search"
+	"github.com/go-live-cms/go-live-cms/token"
+)
+
+func randomSearchRow(post db.Post) db.SearchPostsRow {
+	return db.SearchPostsRow{
+		ID:             post.ID,
+		Title:          post.Title,
+		Description:    post.Description,
+		Content:        post.Content,
+		UserID:         post.UserID,
+		Username:       post.Username,
+		Url:            post.Url,
+		CreatedAt:      post.CreatedAt,
+		ChangedAt:      post.ChangedAt,
+		Status:         post.Status,
+		PublishedAt:    post.PublishedAt,
+		Language:       defaultPostLanguage,
+		Rank:           0.6,
+		TitleHighlight: "<mark>Go</mark> tips",
+		Snippet:        "write <mark>go</mark> code",
+	}
+}
+
+func TestSearchPostsAPI(t *testing.T) {
+	user := randomUserForPosts()
+	post := randomPost(user)
+	row := randomSearchRow(post)
+
+	moderator := randomUserForPosts()
+	moderator.Role = policy.RoleModerator
+
+	published := sql.NullString{String: policy.PostStatusPublished, Valid: true}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"q": {`"go tips" -java`}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), db.SearchPostsParams{
+						Language: defaultPostLanguage,
+						Query:    `"go tips" -java`,
+						Status:   published,
+						Limit:    10,
+						Offset:   0,
+					}).
+					Times(1).
+					Return([]db.SearchPostsRow{row}, nil)
+				store.EXPECT().
+					CountSearchPosts(gomock.Any(), db.CountSearchPostsParams{
+						Language: defaultPostLanguage,
+						Query:    `"go tips" -java`,
+						Status:   published,
+					}).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostSearchResult `json:"posts"`
+					Meta  struct {
+						Total int64 `json:"total"`
+						Count int   `json:"count"`
+					} `json:"meta"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, int64(1), response.Meta.Total)
+				require.Len(t, response.Posts, 1)
+				require.Equal(t, post.ID, response.Posts[0].ID)
+				require.Equal(t, post.Title, response.Posts[0].Title)
+				require.Equal(t, row.Rank, response.Posts[0].Rank)
+				require.Equal(t, row.TitleHighlight, response.Posts[0].TitleHighlight)
+				require.Equal(t, row.Snippet, response.Posts[0].Snippet)
+			},
+		},
+		{
+			name: "Filters",
+			query: url.Values{
+				"q":           {"go"},
+				"language":    {"german"},
+				"taxonomy_id": {"3"},
+				"author_id":   {"7"},
+				"from":        {"2024-01-01"},
+				"to":          {"2024-01-31"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), db.SearchPostsParams{
+						Language:      "german",
+						Query:         "go",
+						Status:        published,
+						TaxonomyID:    sql.NullInt64{Int64: 3, Valid: true},
+						AuthorID:      sql.NullInt64{Int64: 7, Valid: true},
+						CreatedAfter:  sql.NullTime{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+						CreatedBefore: sql.NullTime{Time: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+						Limit:         10,
+						Offset:        0,
+					}).
+					Times(1).
+					Return([]db.SearchPostsRow{}, nil)
+				store.EXPECT().
+					CountSearchPosts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "ModeratorSearchesAllStatuses",
+			query: url.Values{"q": {"go"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, moderator.ID, moderator.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, moderator)
+				store.EXPECT().
+					SearchPosts(gomock.Any(), db.SearchPostsParams{
+						Language: defaultPostLanguage,
+						Query:    "go",
+						Limit:    10,
+						Offset:   0,
+					}).
+					Times(1).
+					Return([]db.SearchPostsRow{}, nil)
+				store.EXPECT().
+					CountSearchPosts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingQuery",
+			query: url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidLanguage",
+			query: url.Values{"q": {"go"}, "language": {"klingon"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidDate",
+			query: url.Values{"q": {"go"}, "from": {"yesterday"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidTaxonomyID",
+			query: url.Values{"q": {"go"}, "taxonomy_id": {"abc"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "AnonymousCannotSearchDrafts",
+			query: url.Values{"q": {"go"}, "status": {"draft"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"q": {"go"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/posts/search?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+			if tc.setupAuth != nil {
+				tc.setupAuth(t, request, server.tokenMaker)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSearchPostsIndexAPI(t *testing.T) {
+	user := randomUserForPosts()
+	post := randomPost(user)
+	post.Title = "Go tips"
+	post.Content = "Write idiomatic Go code"
+	post.Status = policy.PostStatusPublished
+	post.Language = defaultPostLanguage
+
+	draft := randomPost(user)
+	draft.Title = "Go drafts"
+	draft.Status = policy.PostStatusDraft
+	draft.Language = defaultPostLanguage
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"q": {"go"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsByIDs(gomock.Any(), []int64{post.ID}).
+					Times(1).
+					Return([]db.Post{post}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostSearchResult `json:"posts"`
+					Meta  struct {
+						Total  int64                       `json:"total"`
+						Facets map[string]map[string]int64 `json:"facets"`
+					} `json:"meta"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, int64(1), response.Meta.Total)
+				require.Equal(t, int64(1), response.Meta.Facets["status"][policy.PostStatusPublished])
+				require.Len(t, response.Posts, 1)
+				require.Equal(t, post.ID, response.Posts[0].ID)
+				require.Equal(t, "<mark>Go</mark> tips", response.Posts[0].TitleHighlight)
+				require.Equal(t, "Write idiomatic <mark>Go</mark> code", response.Posts[0].Snippet)
+			},
+		},
+		{
+			name:  "Includes",
+			query: url.Values{"q": {"go"}, "include": {"authors"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsByIDs(gomock.Any(), []int64{post.ID}).
+					Times(1).
+					Return([]db.Post{post}, nil)
+				store.EXPECT().
+					ListPostAuthorsByPostIDs(gomock.Any(), []int64{post.ID}).
+					Times(1).
+					Return([]db.ListPostAuthorsByPostIDsRow{{PostID: post.ID, ID: user.ID, Username: user.Username}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostSearchResult `json:"posts"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Posts, 1)
+				require.Len(t, response.Posts[0].Authors, 1)
+				require.Equal(t, user.Username, response.Posts[0].Authors[0].Username)
+			},
+		},
+		{
+			name:  "NoMatches",
+			query: url.Values{"q": {"rust"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsByIDs(gomock.Any(), []int64{}).
+					Times(1).
+					Return([]db.Post{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "PostDeletedSinceIndexed",
+			query: url.Values{"q": {"go"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsByIDs(gomock.Any(), []int64{post.ID}).
+					Times(1).
+					Return([]db.Post{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostSearchResult `json:"posts"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Empty(t, response.Posts)
+			},
+		},
+		{
+			name:  "UnpublishedSinceIndexed",
+			query: url.Values{"q": {"go"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				unpublished := post
+				unpublished.Status = policy.PostStatusDraft
+				store.EXPECT().
+					ListPostsByIDs(gomock.Any(), []int64{post.ID}).
+					Times(1).
+					Return([]db.Post{unpublished}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response struct {
+					Posts []PostSearchResult `json:"posts"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Empty(t, response.Posts)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"q": {"go"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsByIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			index := search.NewMemoryIndexer()
+			err := index.Index(context.Background(), search.KindPosts,
+				search.PostDocument(post, []int64{user.ID}, nil),
+				search.PostDocument(draft, []int64{user.ID}, nil),
+			)
+			require.NoError(t, err)
+
+			server := newTestServer(t, store)
+			server.searchIndex = index
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/posts/search?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSearchPostsReadsWhatTheStoreIndexed(t *testing.T) {
+	user := randomUserForPosts()
+	post := randomPost(user)
+	post.Title = "Go tips"
+	post.Content = "Write idiomatic Go code"
+	post.Status = policy.PostStatusPublished
+	post.Language = defaultPostLanguage
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockdb.NewMockStore(ctrl)
+	mock.EXPECT().
+		UpdatePostStatus(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(post, nil)
+	mock.EXPECT().GetPost(gomock.Any(), post.ID).Times(1).Return(post, nil)
+	mock.EXPECT().ListPostAuthors(gomock.Any(), post.ID).Times(1).Return([]db.UserPost{}, nil)
+	mock.EXPECT().GetPostTaxonomies(gomock.Any(), post.ID).Times(1).Return([]db.Taxonomy{}, nil)
+	mock.EXPECT().
+		ListPostsByIDs(gomock.Any(), []int64{post.ID}).
+		Times(1).
+		Return([]db.Post{post}, nil)
+
+	index := search.NewMemoryIndexer()
+	store := search.NewIndexingStore(mock, index)
+	server, err := NewServer(newTestServer(t, mock).config, store, index)
+	require.NoError(t, err)
+
+	_, err = store.UpdatePostStatus(context.Background(), db.UpdatePostStatusParams{ID: post.ID, Status: policy.PostStatusPublished})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/posts/search?q=go", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response struct {
+		Posts []PostSearchResult `json:"posts"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Len(t, response.Posts, 1)
+	require.Equal(t, post.ID, response.Posts[0].ID)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
+)
+
+// transitionPost moves a post through the editorial workflow. The status
+// update only applies if the post is still in the status it was read in, so
+// two reviewers acting at once cannot both succeed. Publishing a post whose
+// publish_at lies in the future schedules it instead.
+func (server *Server) transitionPost(transition policy.PostTransition) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idParam := c.Param("id")
+		id, err := strconv.ParseInt(idParam, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+			return
+		}
+
+		post, err := server.store.GetPost(c.Request.Context(), id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post"})
+			return
+		}
+
+		authors, err := server.store.ListPostAuthors(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post authors"})
+			return
+		}
+
+		if err := policy.CanTransitionPost(authActor(c), post, authors, transition); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		status, err := policy.NextPostStatus(transition, post.Status)
+		if err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		status = policy.ScheduledPostStatus(post, status, time.Now())
+
+		updatedPost, err := server.store.UpdatePostStatus(c.Request.Context(), db.UpdatePostStatusParams{
+			ID:         id,
+			Status:     status,
+			FromStatus: post.Status,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusConflict, gin.H{"error": "post status changed, reload and try again"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update post status"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"post": toPostResponse(updatedPost),
+		})
+	}
+}
+
+// SchedulePostRequest replaces a post's schedule; a null field clears it.
+type SchedulePostRequest struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// schedulePost sets when a post goes live and when its embargo ends. The
+// scheduler publishes scheduled posts once publish_at passes and archives
+// published posts once unpublish_at passes. Like transitionPost, the update
+// only applies if the post is still in the status it was checked in, and
+// an If-Match header pins it to the version the client last saw.
+func (server *Server) schedulePost(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		return
+	}
+
+	var req SchedulePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := policy.ValidatePostSchedule(req.PublishAt, req.UnpublishAt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	post, err := server.store.GetPost(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post"})
+		return
+	}
+
+	authors, err := server.store.ListPostAuthors(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post authors"})
+		return
+	}
+
+	if err := policy.CanEditPost(authActor(c), post, authors); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	if post.Status == policy.PostStatusScheduled && req.PublishAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "a scheduled post needs a publish_at, unpublish it first"})
+		return
+	}
+
+	updatedPost, err := server.store.UpdatePostSchedule(c.Request.Context(), db.UpdatePostScheduleParams{
+		ID:          id,
+		PublishAt:   nullTime(req.PublishAt),
+		UnpublishAt: nullTime(req.UnpublishAt),
+		FromStatus:  post.Status,
+
+		ExpectedVersion: expectedVersion,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Every status change bumps the version, so a pinned version
+			// that no longer matches is the more precise answer.
+			if expectedVersion.Valid {
+				preconditionFailed(c)
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{"error": "post status changed, reload and try again"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update post schedule"})
+		return
+	}
+
+	setETag(c, updatedPost.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"post": toPostResponse(updatedPost),
+	})
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,477 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
+)
+
+func TestPostWorkflowAPI(t *testing.T) {
+	author := randomUserForPosts()
+	stranger := randomUserForPosts()
+	stranger.ID = author.ID + 1
+	moderator := randomUserForPosts()
+	moderator.ID = author.ID + 2
+	moderator.Role = policy.RoleModerator
+
+	postID := randomPost(author).ID
+	withStatus := func(status string) db.Post {
+		post := randomPost(author)
+		post.ID = postID
+		post.Status = status
+		post.PublishedAt = sql.NullTime{}
+		return post
+	}
+	authorsOf := func(post db.Post) []db.UserPost {
+		return []db.UserPost{{PostID: post.ID, UserID: author.ID}}
+	}
+
+	testCases := []struct {
+		name          string
+		action        string
+		caller        db.User
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "SubmitOK",
+			action: "submit",
+			caller: author,
+			buildStubs: func(store *mockdb.MockStore) {
+				post := withStatus(policy.PostStatusDraft)
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authorsOf(post), nil)
+
+				updated := post
+				updated.Status = policy.PostStatusPendingReview
+				store.EXPECT().
+					UpdatePostStatus(gomock.Any(), gomock.Eq(db.UpdatePostStatusParams{
+						ID:         post.ID,
+						Status:     policy.PostStatusPendingReview,
+						FromStatus: policy.PostStatusDraft,
+					})).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"status":"pending_review"`)
+			},
+		},
+		{
+			name:   "SubmitByStranger",
+			action: "submit",
+			caller: stranger,
+			buildStubs: func(store *mockdb.MockStore) {
+				post := withStatus(policy.PostStatusDraft)
+				expectAuthUser(store, stranger)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authorsOf(post), nil)
+				store.EXPECT().UpdatePostStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "ApproveByAuthor",
+			action: "approve",
+			caller: author,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "ApproveOK",
+			action: "approve",
+			caller: moderator,
+			buildStubs: func(store *mockdb.MockStore) {
+				post := withStatus(policy.PostStatusPendingReview)
+				expectAuthUser(store, moderator)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authorsOf(post), nil)
+
+				updated := post
+				updated.Status = policy.PostStatusPublished
+				updated.PublishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					UpdatePostStatus(gomock.Any(), gomock.Eq(db.UpdatePostStatusParams{
+						ID:         post.ID,
+						Status:     policy.PostStatusPublished,
+						FromStatus: policy.PostStatusPendingReview,
+					})).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"status":"published"`)
+				require.Contains(t, recorder.Body.String(), `"published_at"`)
+			},
+		},
+		{
+			name:   "RejectDraft",
+			action: "reject",
+			caller: moderator,
+			buildStubs: func(store *mockdb.MockStore) {
+				post := withStatus(policy.PostStatusDraft)
+				expectAuthUser(store, moderator)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authorsOf(post), nil)
+				store.EXPECT().UpdatePostStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "PublishConcurrentChange",
+			action: "publish",
+			caller: moderator,
+			buildStubs: func(store *mockdb.MockStore) {
+				post := withStatus(policy.PostStatusDraft)
+				expectAuthUser(store, moderator)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authorsOf(post), nil)
+				store.EXPECT().UpdatePostStatus(gomock.Any(), gomock.Any()).Times(1).Return(db.Post{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "PublishWithFuturePublishAt",
+			action: "publish",
+			caller: moderator,
+			buildStubs: func(store *mockdb.MockStore) {
+				post := withStatus(policy.PostStatusDraft)
+				post.PublishAt = sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}
+				expectAuthUser(store, moderator)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authorsOf(post), nil)
+
+				updated := post
+				updated.Status = policy.PostStatusScheduled
+				store.EXPECT().
+					UpdatePostStatus(gomock.Any(), gomock.Eq(db.UpdatePostStatusParams{
+						ID:         post.ID,
+						Status:     policy.PostStatusScheduled,
+						FromStatus: policy.PostStatusDraft,
+					})).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"status":"scheduled"`)
+				require.Contains(t, recorder.Body.String(), `"publish_at"`)
+			},
+		},
+		{
+			name:   "UnpublishByAuthor",
+			action: "unpublish",
+			caller: author,
+			buildStubs: func(store *mockdb.MockStore) {
+				post := withStatus(policy.PostStatusPublished)
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authorsOf(post), nil)
+
+				updated := post
+				updated.Status = policy.PostStatusArchived
+				store.EXPECT().
+					UpdatePostStatus(gomock.Any(), gomock.Eq(db.UpdatePostStatusParams{
+						ID:         post.ID,
+						Status:     policy.PostStatusArchived,
+						FromStatus: policy.PostStatusPublished,
+					})).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"status":"archived"`)
+			},
+		},
+		{
+			name:   "NotFound",
+			action: "publish",
+			caller: moderator,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, moderator)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(db.Post{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/posts/%d/%s", postID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.caller.ID, tc.caller.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSchedulePostAPI(t *testing.T) {
+	author := randomUserForPosts()
+	stranger := randomUserForPosts()
+	stranger.ID = author.ID + 1
+
+	post := randomPost(author)
+	post.Status = policy.PostStatusDraft
+	post.PublishedAt = sql.NullTime{}
+	post.Version = 3
+	authors := []db.UserPost{{PostID: post.ID, UserID: author.ID}}
+
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	unpublishAt := publishAt.Add(24 * time.Hour)
+
+	testCases := []struct {
+		name          string
+		caller        db.User
+		body          gin.H
+		ifMatch       string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			caller: author,
+			body:   gin.H{"publish_at": publishAt, "unpublish_at": unpublishAt},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(authors, nil)
+
+				arg := db.UpdatePostScheduleParams{
+					ID:          post.ID,
+					PublishAt:   sql.NullTime{Time: publishAt, Valid: true},
+					UnpublishAt: sql.NullTime{Time: unpublishAt, Valid: true},
+					FromStatus:  post.Status,
+				}
+				updated := post
+				updated.PublishAt = arg.PublishAt
+				updated.UnpublishAt = arg.UnpublishAt
+				store.EXPECT().UpdatePostSchedule(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"publish_at"`)
+				require.Contains(t, recorder.Body.String(), `"unpublish_at"`)
+			},
+		},
+		{
+			name:   "Clear",
+			caller: author,
+			body:   gin.H{"publish_at": nil, "unpublish_at": nil},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authors, nil)
+				store.EXPECT().
+					UpdatePostSchedule(gomock.Any(), gomock.Eq(db.UpdatePostScheduleParams{ID: post.ID, FromStatus: post.Status})).
+					Times(1).
+					Return(post, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "UnpublishBeforePublish",
+			caller: author,
+			body:   gin.H{"publish_at": unpublishAt, "unpublish_at": publishAt},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "Stranger",
+			caller: stranger,
+			body:   gin.H{"publish_at": publishAt},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, stranger)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authors, nil)
+				store.EXPECT().UpdatePostSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "ClearPublishAtWhileScheduled",
+			caller: author,
+			body:   gin.H{"publish_at": nil},
+			buildStubs: func(store *mockdb.MockStore) {
+				scheduled := post
+				scheduled.Status = policy.PostStatusScheduled
+				scheduled.PublishAt = sql.NullTime{Time: publishAt, Valid: true}
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(scheduled, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authors, nil)
+				store.EXPECT().UpdatePostSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "StatusChanged",
+			caller: author,
+			body:   gin.H{"publish_at": publishAt},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authors, nil)
+				store.EXPECT().
+					UpdatePostSchedule(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Post{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:    "IfMatchCurrentVersion",
+			caller:  author,
+			body:    gin.H{"publish_at": publishAt},
+			ifMatch: fmt.Sprintf(`"%d"`, post.Version),
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authors, nil)
+
+				updated := post
+				updated.Version = post.Version + 1
+				store.EXPECT().
+					UpdatePostSchedule(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdatePostScheduleParams) (db.Post, error) {
+						require.Equal(t, sql.NullInt64{Int64: post.Version, Valid: true}, arg.ExpectedVersion)
+						return updated, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, fmt.Sprintf(`"%d"`, post.Version+1), recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name:    "IfMatchStaleVersion",
+			caller:  author,
+			body:    gin.H{"publish_at": publishAt},
+			ifMatch: fmt.Sprintf(`"%d"`, post.Version-1),
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
+				store.EXPECT().ListPostAuthors(gomock.Any(), gomock.Any()).Times(1).Return(authors, nil)
+				store.EXPECT().
+					UpdatePostSchedule(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Post{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name:    "IfMatchWeakTag",
+			caller:  author,
+			body:    gin.H{"publish_at": publishAt},
+			ifMatch: fmt.Sprintf(`W/"%d"`, post.Version),
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdatePostSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			caller: author,
+			body:   gin.H{"publish_at": publishAt},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, author)
+				store.EXPECT().GetPost(gomock.Any(), gomock.Any()).Times(1).Return(db.Post{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/posts/%d/schedule", post.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.caller.ID, tc.caller.Username, time.Minute)
+			if tc.ifMatch != "" {
+				request.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
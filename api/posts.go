@@ -2,12 +2,16 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/listquery"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/token"
 )
 
 type CreatePostRequest struct {
@@ -15,6 +19,7 @@ type CreatePostRequest struct {
 	Content     string  `json:"content" binding:"required,min=10"`
 	Description string  `json:"description" binding:"required,min=10,max=500"`
 	Url         string  `json:"url" binding:"required,url"`
+	Language    string  `json:"language" binding:"omitempty"`
 	AuthorIDs   []int64 `json:"author_ids" binding:"required,min=1"`
 	MediaIDs    []int64 `json:"media_ids" binding:"omitempty"`
 	TaxonomyIDs []int64 `json:"taxonomy_ids" binding:"omitempty"`
@@ -25,20 +30,31 @@ type UpdatePostRequest struct {
 	Content     string  `json:"content" binding:"omitempty,min=10"`
 	Description string  `json:"description" binding:"omitempty,min=10,max=500"`
 	Url         string  `json:"url" binding:"omitempty,url"`
+	Language    string  `json:"language" binding:"omitempty"`
 	MediaIDs    []int64 `json:"media_ids" binding:"omitempty"`
 	TaxonomyIDs []int64 `json:"taxonomy_ids" binding:"omitempty"`
 }
 
 type PostResponse struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Content     string    `json:"content"`
-	Description string    `json:"description"`
-	UserID      int64     `json:"user_id"`
-	Username    string    `json:"username"`
-	Url         string    `json:"url"`
-	CreatedAt   time.Time `json:"created_at"`
-	ChangedAt   time.Time `json:"changed_at"`
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	Description string     `json:"description"`
+	UserID      int64      `json:"user_id"`
+	Username    string     `json:"username"`
+	Url         string     `json:"url"`
+	Status      string     `json:"status"`
+	Language    string     `json:"language"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty"`
+	Version     int64      `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ChangedAt   time.Time  `json:"changed_at"`
+
+	Taxonomies []TaxonomyResponse `json:"taxonomies,omitempty"`
+	Media      []MediaResponse    `json:"media,omitempty"`
+	Authors    []UserResponse     `json:"authors,omitempty"`
 }
 
 func toPostResponse(post db.Post) PostResponse {
@@ -50,46 +66,85 @@ func toPostResponse(post db.Post) PostResponse {
 		UserID:      post.UserID,
 		Username:    post.Username,
 		Url:         post.Url,
+		Status:      post.Status,
+		Language:    post.Language,
+		PublishedAt: nullTimePtr(post.PublishedAt),
+		PublishAt:   nullTimePtr(post.PublishAt),
+		UnpublishAt: nullTimePtr(post.UnpublishAt),
+		Version:     post.Version,
 		CreatedAt:   post.CreatedAt,
 		ChangedAt:   post.ChangedAt,
 	}
 }
 
-func (server *Server) getPosts(c *gin.Context) {
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
 
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
+// postStatusFilter decides which statuses a post list may include. Anonymous
+// callers and regular users only see published posts, except in their own
+// list when ownerID is theirs; reviewers see everything. Both may narrow the
+// list with ?status=. It writes the error response and returns false when
+// the request is not allowed.
+func postStatusFilter(c *gin.Context, ownerID int64) (sql.NullString, bool) {
+	status := c.Query("status")
+	if status != "" && !policy.IsValidPostStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status parameter"})
+		return sql.NullString{}, false
+	}
+
+	actor := optionalActor(c)
+	seesAll := actor != nil && (policy.CanViewAllPosts(*actor) || (ownerID != 0 && actor.UserID == ownerID))
+	if !seesAll {
+		if status != "" && status != policy.PostStatusPublished {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to list posts that are not published"})
+			return sql.NullString{}, false
+		}
+		status = policy.PostStatusPublished
+	}
 
-	limit, err := strconv.ParseInt(limitStr, 10, 32)
-	if err != nil || limit <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+	return sql.NullString{String: status, Valid: status != ""}, true
+}
+
+func (server *Server) getPosts(c *gin.Context) {
+	page, ok := parseListPage(c, postListSchema)
+	if !ok {
 		return
 	}
-	if limit > 100 {
-		limit = 100
+	includes, ok := parsePostIncludes(c)
+	if !ok {
+		return
 	}
-
-	offset, err := strconv.ParseInt(offsetStr, 10, 32)
-	if err != nil || offset < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+	status, ok := postStatusFilter(c, 0)
+	if !ok {
 		return
 	}
+	if status.Valid {
+		page.query.Where("status", listquery.Eq, status.String)
+	}
 
-	posts, err := server.store.ListPosts(c.Request.Context(), db.ListPostsParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
-	})
+	posts, err := server.store.ListPostsFiltered(c.Request.Context(), page.params())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list posts"})
 		return
 	}
+	posts, cursors := pageRows(page, posts, postKey)
 
 	postResponses := make([]PostResponse, len(posts))
 	for i, post := range posts {
 		postResponses[i] = toPostResponse(post)
 	}
+	if err := includes.embed(c.Request.Context(), server, postResponses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load included resources"})
+		return
+	}
 
-	total, err := server.store.CountTotalPosts(c.Request.Context())
+	total, err := server.listTotal(c.Request.Context(), page, "posts", func() (int64, error) {
+		return server.store.CountPostsFiltered(c.Request.Context(), page.query.CountFilter())
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count total posts"})
 		return
@@ -97,12 +152,7 @@ func (server *Server) getPosts(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"posts": postResponses,
-		"meta": gin.H{
-			"total":  total,
-			"limit":  limit,
-			"offset": offset,
-			"count":  len(postResponses),
-		},
+		"meta":  page.meta(len(postResponses), total, cursors),
 	})
 }
 
@@ -113,20 +163,62 @@ func (server *Server) getPostByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
 		return
 	}
+	includes, ok := parsePostIncludes(c)
+	if !ok {
+		return
+	}
+
+	post, ok := server.getViewablePost(c, id)
+	if !ok {
+		return
+	}
 
+	response := []PostResponse{toPostResponse(post)}
+	if err := includes.embed(c.Request.Context(), server, response); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load included resources"})
+		return
+	}
+
+	if len(includes) > 0 {
+		setWeakETag(c, post.Version)
+	} else {
+		setETag(c, post.Version)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"post": response[0],
+	})
+}
+
+// getViewablePost loads a post for a read endpoint. Posts the caller may not
+// view answer 404 like missing ones, so their existence does not leak. It
+// writes the error response itself and returns false on failure.
+func (server *Server) getViewablePost(c *gin.Context, id int64) (db.Post, bool) {
 	post, err := server.store.GetPost(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
-			return
+			return db.Post{}, false
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post"})
-		return
+		return db.Post{}, false
+	}
+
+	if post.Status != policy.PostStatusPublished {
+		actor := optionalActor(c)
+		var authors []db.UserPost
+		if actor != nil {
+			authors, err = server.store.ListPostAuthors(c.Request.Context(), id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post authors"})
+				return db.Post{}, false
+			}
+		}
+		if err := policy.CanViewPost(actor, post, authors); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return db.Post{}, false
+		}
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"post": toPostResponse(post),
-	})
+	return post, true
 }
 
 func (server *Server) createPost(c *gin.Context) {
@@ -136,6 +228,11 @@ func (server *Server) createPost(c *gin.Context) {
 		return
 	}
 
+	if req.Language != "" && !isPostLanguage(req.Language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported language"})
+		return
+	}
+
 	if len(req.AuthorIDs) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one author is required"})
 		return
@@ -158,6 +255,7 @@ func (server *Server) createPost(c *gin.Context) {
 		UserID:      primaryAuthor.ID,
 		Username:    primaryAuthor.Username,
 		Url:         req.Url,
+		Language:    sql.NullString{String: req.Language, Valid: req.Language != ""},
 	}
 
 	if len(req.MediaIDs) > 0 && len(req.TaxonomyIDs) > 0 {
@@ -235,6 +333,16 @@ func (server *Server) updatePost(c *gin.Context) {
 		return
 	}
 
+	if req.Language != "" && !isPostLanguage(req.Language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported language"})
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	existingPost, err := server.store.GetPost(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -245,6 +353,17 @@ func (server *Server) updatePost(c *gin.Context) {
 		return
 	}
 
+	authors, err := server.store.ListPostAuthors(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post authors"})
+		return
+	}
+
+	if err := policy.CanEditPost(authActor(c), existingPost, authors); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
 	updateParams := db.UpdatePostParams{
 		ID:          id,
 		Title:       existingPost.Title,
@@ -253,6 +372,8 @@ func (server *Server) updatePost(c *gin.Context) {
 		UserID:      existingPost.UserID,
 		Username:    existingPost.Username,
 		Url:         existingPost.Url,
+
+		ExpectedVersion: expectedVersion,
 	}
 
 	if req.Title != "" {
@@ -267,9 +388,21 @@ func (server *Server) updatePost(c *gin.Context) {
 	if req.Url != "" {
 		updateParams.Url = req.Url
 	}
+	if req.Language != "" {
+		updateParams.Language = sql.NullString{String: req.Language, Valid: true}
+	}
 
-	updatedPost, err := server.store.UpdatePost(c.Request.Context(), updateParams)
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.UpdatePostTx(c.Request.Context(), db.UpdatePostTxParams{
+		UpdatePostParams: updateParams,
+		EditorID:         authPayload.UserID,
+		EditorUsername:   authPayload.Username,
+	})
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			preconditionFailed(c)
+			return
+		}
 		if containsString(err.Error(), "duplicate key value") || containsString(err.Error(), "unique constraint") {
 			c.JSON(http.StatusConflict, gin.H{"error": "URL already exists"})
 			return
@@ -300,8 +433,10 @@ func (server *Server) updatePost(c *gin.Context) {
 		}
 	}
 
+	setETag(c, result.Post.Version)
 	c.JSON(http.StatusOK, gin.H{
-		"post": toPostResponse(updatedPost),
+		"post":     toPostResponse(result.Post),
+		"revision": result.Revision.Revision,
 	})
 }
 
@@ -313,7 +448,12 @@ func (server *Server) deletePost(c *gin.Context) {
 		return
 	}
 
-	_, err = server.store.GetPost(c.Request.Context(), id)
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	post, err := server.store.GetPost(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
@@ -323,8 +463,20 @@ func (server *Server) deletePost(c *gin.Context) {
 		return
 	}
 
-	err = server.store.DeletePostTx(c.Request.Context(), id)
+	if err := policy.CanDeletePost(authActor(c), post); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = server.store.DeletePostTx(c.Request.Context(), db.DeletePostTxParams{
+		ID:              id,
+		ExpectedVersion: expectedVersion,
+	})
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			preconditionFailed(c)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete post"})
 		return
 	}
@@ -359,6 +511,10 @@ func (server *Server) getPostsByUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
 		return
 	}
+	includes, ok := parsePostIncludes(c)
+	if !ok {
+		return
+	}
 
 	_, err = server.store.GetUser(c.Request.Context(), userID)
 	if err != nil {
@@ -370,8 +526,14 @@ func (server *Server) getPostsByUser(c *gin.Context) {
 		return
 	}
 
+	status, ok := postStatusFilter(c, userID)
+	if !ok {
+		return
+	}
+
 	posts, err := server.store.GetPostsByUserWithMedia(c.Request.Context(), db.GetPostsByUserWithMediaParams{
 		UserID: userID,
+		Status: status,
 		Limit:  int32(limit),
 		Offset: int32(offset),
 	})
@@ -390,10 +552,20 @@ func (server *Server) getPostsByUser(c *gin.Context) {
 			UserID:      post.UserID,
 			Username:    post.Username,
 			Url:         post.Url,
+			Status:      post.Status,
+			Language:    post.Language,
+			PublishedAt: nullTimePtr(post.PublishedAt),
+			PublishAt:   nullTimePtr(post.PublishAt),
+			UnpublishAt: nullTimePtr(post.UnpublishAt),
+			Version:     post.Version,
 			CreatedAt:   post.CreatedAt,
 			ChangedAt:   post.ChangedAt,
 		}
 	}
+	if err := includes.embed(c.Request.Context(), server, postResponses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load included resources"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"posts": postResponses,
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -17,6 +18,7 @@ import (
 
 	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
 	"github.com/go-live-cms/go-live-cms/token"
 )
 
@@ -28,7 +30,7 @@ func randomUserForPosts() db.User {
 		FullName:          gofakeit.Name(),
 		Email:             gofakeit.Email(),
 		HashedPassword:    gofakeit.Password(true, true, true, true, false, 12),
-		PasswordChangedAt: gofakeit.Date(),
+		PasswordChangedAt: gofakeit.PastDate(),
 		CreatedAt:         gofakeit.Date(),
 		Role:              "user",
 	}
@@ -44,6 +46,8 @@ func randomPost(user db.User) db.Post {
 		UserID:      user.ID,
 		Username:    user.Username,
 		Url:         fmt.Sprintf("https://example.com/posts/%s", gofakeit.UUID()),
+		Status:      policy.PostStatusPublished,
+		PublishedAt: sql.NullTime{Time: time.Now(), Valid: true},
 		CreatedAt:   time.Now(),
 		ChangedAt:   time.Now(),
 	}
@@ -73,6 +77,7 @@ func TestCreatePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
 
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.ID)).
@@ -107,6 +112,8 @@ func TestCreatePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(int64(999))).
 					Times(1).
@@ -116,6 +123,30 @@ func TestCreatePostAPI(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "UnsupportedLanguage",
+			body: gin.H{
+				"title":       post.Title,
+				"content":     post.Content,
+				"description": post.Description,
+				"url":         post.Url,
+				"language":    "klingon",
+				"author_ids":  []int64{user.ID},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					CreatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "NoAuthorization",
 			body: gin.H{
@@ -152,6 +183,8 @@ func TestCreatePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -173,6 +206,8 @@ func TestCreatePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -195,6 +230,8 @@ func TestCreatePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
@@ -252,10 +289,16 @@ func TestCreatePostAPI(t *testing.T) {
 func TestGetPostAPI(t *testing.T) {
 	user := randomUserForPosts()
 	post := randomPost(user)
+	draft := randomPost(user)
+	draft.Status = policy.PostStatusDraft
+	draft.PublishedAt = sql.NullTime{}
+	other := randomUserForPosts()
+	other.ID = user.ID + 1
 
 	testCases := []struct {
 		name          string
 		postID        int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
@@ -270,9 +313,84 @@ func TestGetPostAPI(t *testing.T) {
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, etag(post.Version), recorder.Header().Get("ETag"))
 				requireBodyMatchPost(t, recorder.Body.String(), post)
 			},
 		},
+		{
+			name:   "DraftHiddenFromAnonymous",
+			postID: draft.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return(draft, nil)
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "DraftVisibleToAuthor",
+			postID: draft.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return(draft, nil)
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return([]db.UserPost{{PostID: draft.ID, UserID: user.ID}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchPost(t, recorder.Body.String(), draft)
+			},
+		},
+		{
+			name:   "DraftHiddenFromOtherUser",
+			postID: draft.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, other.ID, other.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, other)
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return(draft, nil)
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return([]db.UserPost{{PostID: draft.ID, UserID: user.ID}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidToken",
+			postID: post.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				request.Header.Set(authorizationHeaderKey, "Bearer not-a-token")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			name:   "NotFound",
 			postID: post.ID,
@@ -335,6 +453,9 @@ func TestGetPostAPI(t *testing.T) {
 
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
+			if tc.setupAuth != nil {
+				tc.setupAuth(t, request, server.tokenMaker)
+			}
 
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
@@ -351,9 +472,19 @@ func TestListPostsAPI(t *testing.T) {
 		posts[i].ID = int64(i + 1)
 	}
 
+	moderator := randomUserForPosts()
+	moderator.Role = policy.RoleModerator
+
+	publishedFilter := db.ListFilter{
+		Where:   "status = $1",
+		Args:    []interface{}{policy.PostStatusPublished},
+		OrderBy: "id DESC",
+	}
+
 	testCases := []struct {
 		name          string
 		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
@@ -362,14 +493,15 @@ func TestListPostsAPI(t *testing.T) {
 			query: "?limit=5&offset=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListPosts(gomock.Any(), db.ListPostsParams{
-						Limit:  5,
+					ListPostsFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: publishedFilter,
+						Limit:  6,
 						Offset: 0,
 					}).
 					Times(1).
 					Return(posts, nil)
 				store.EXPECT().
-					CountTotalPosts(gomock.Any()).
+					CountPostsFiltered(gomock.Any(), countFilter(publishedFilter)).
 					Times(1).
 					Return(int64(100), nil)
 			},
@@ -378,12 +510,140 @@ func TestListPostsAPI(t *testing.T) {
 				requireBodyMatchPosts(t, recorder.Body.String(), posts, int64(100))
 			},
 		},
+		{
+			name:  "ModeratorSeesAllStatuses",
+			query: "?limit=5&offset=0",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, moderator.ID, moderator.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, moderator)
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: db.ListFilter{Where: "TRUE", OrderBy: "id DESC"},
+						Limit:  6,
+						Offset: 0,
+					}).
+					Times(1).
+					Return(posts, nil)
+				store.EXPECT().
+					CountPostsFiltered(gomock.Any(), db.ListFilter{Where: "TRUE"}).
+					Times(1).
+					Return(int64(5), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "ModeratorFiltersByStatus",
+			query: "?status=pending_review",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, moderator.ID, moderator.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, moderator)
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: db.ListFilter{
+							Where:   "status = $1",
+							Args:    []interface{}{policy.PostStatusPendingReview},
+							OrderBy: "id DESC",
+						},
+						Limit:  11,
+						Offset: 0,
+					}).
+					Times(1).
+					Return([]db.Post{}, nil)
+				store.EXPECT().
+					CountPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "FilterAndSort",
+			query: "?filter[author_id]=3&filter[created_at][gte]=2025-01-01&sort=-changed_at,title",
+			buildStubs: func(store *mockdb.MockStore) {
+				filter := db.ListFilter{
+					Where:   "EXISTS (SELECT 1 FROM user_posts up WHERE up.post_id = posts.id AND up.user_id = $1) AND created_at >= $2 AND status = $3",
+					Args:    []interface{}{int64(3), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), policy.PostStatusPublished},
+					OrderBy: "changed_at DESC, title ASC, id ASC",
+				}
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: filter,
+						Limit:  11,
+						Offset: 0,
+					}).
+					Times(1).
+					Return(posts, nil)
+				store.EXPECT().
+					CountPostsFiltered(gomock.Any(), countFilter(filter)).
+					Times(1).
+					Return(int64(5), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "StatusIsNotAFilter",
+			query: "?filter[status]=draft",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "UnknownSortField",
+			query: "?sort=content",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "AnonymousCannotListDrafts",
+			query: "?status=draft",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidStatus",
+			query: "?status=deleted",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name:  "InternalError",
 			query: "?limit=5&offset=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListPosts(gomock.Any(), gomock.Any()).
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return([]db.Post{}, sql.ErrConnDone)
 			},
@@ -396,7 +656,7 @@ func TestListPostsAPI(t *testing.T) {
 			query: "?limit=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListPosts(gomock.Any(), gomock.Any()).
+					ListPostsFiltered(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -421,6 +681,9 @@ func TestListPostsAPI(t *testing.T) {
 			url := "/api/v1/posts" + tc.query
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
+			if tc.setupAuth != nil {
+				tc.setupAuth(t, request, server.tokenMaker)
+			}
 
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
@@ -431,6 +694,14 @@ func TestListPostsAPI(t *testing.T) {
 func TestUpdatePostAPI(t *testing.T) {
 	user := randomUserForPosts()
 	post := randomPost(user)
+	coAuthor := randomUserForPosts()
+	coAuthor.ID = user.ID + 1
+	stranger := randomUserForPosts()
+	stranger.ID = user.ID + 2
+	authors := []db.UserPost{
+		{PostID: post.ID, UserID: user.ID, Order: 0},
+		{PostID: post.ID, UserID: coAuthor.ID, Order: 1},
+	}
 	newTitle := gofakeit.Sentence(3)
 	newContent := gofakeit.Paragraph(3, 5, 10, " ")
 
@@ -453,23 +724,43 @@ func TestUpdatePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
 
 				store.EXPECT().
 					GetPost(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
 					Return(post, nil)
 
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(authors, nil)
+
 				updatedPost := post
 				updatedPost.Title = newTitle
 				updatedPost.Content = newContent
 
+				arg := db.UpdatePostTxParams{
+					UpdatePostParams: db.UpdatePostParams{
+						ID:          post.ID,
+						Title:       newTitle,
+						Content:     newContent,
+						Description: post.Description,
+						UserID:      post.UserID,
+						Username:    post.Username,
+						Url:         post.Url,
+					},
+					EditorID:       user.ID,
+					EditorUsername: user.Username,
+				}
 				store.EXPECT().
-					UpdatePost(gomock.Any(), gomock.Any()).
+					UpdatePostTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
-					Return(updatedPost, nil)
+					Return(db.UpdatePostTxResult{Post: updatedPost, Revision: db.PostRevision{PostID: post.ID, Revision: 2}}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"revision":2`)
 			},
 		},
 		{
@@ -486,7 +777,7 @@ func TestUpdatePostAPI(t *testing.T) {
 					GetPost(gomock.Any(), gomock.Any()).
 					Times(0)
 				store.EXPECT().
-					UpdatePost(gomock.Any(), gomock.Any()).
+					UpdatePostTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -503,6 +794,8 @@ func TestUpdatePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetPost(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
@@ -522,20 +815,208 @@ func TestUpdatePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetPost(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
 					Return(post, nil)
 
 				store.EXPECT().
-					UpdatePost(gomock.Any(), gomock.Any()).
+					ListPostAuthors(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(authors, nil)
+
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.Post{}, fmt.Errorf("duplicate key value violates unique constraint"))
+					Return(db.UpdatePostTxResult{}, fmt.Errorf("duplicate key value violates unique constraint"))
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusConflict, recorder.Code)
 			},
 		},
+		{
+			name:   "CoAuthorCanEdit",
+			postID: post.ID,
+			body: gin.H{
+				"title": newTitle,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, coAuthor.ID, coAuthor.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, coAuthor)
+
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(authors, nil)
+
+				updatedPost := post
+				updatedPost.Title = newTitle
+
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdatePostTxParams) (db.UpdatePostTxResult, error) {
+						require.Equal(t, coAuthor.ID, arg.EditorID)
+						return db.UpdatePostTxResult{Post: updatedPost}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "NotAuthorForbidden",
+			postID: post.ID,
+			body: gin.H{
+				"title": newTitle,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, stranger.ID, stranger.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, stranger)
+
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(authors, nil)
+
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "IfMatchCurrentVersion",
+			postID: post.ID,
+			body: gin.H{
+				"title": newTitle,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				request.Header.Set("If-Match", `"3"`)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(authors, nil)
+
+				updatedPost := post
+				updatedPost.Title = newTitle
+				updatedPost.Version = 4
+
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdatePostTxParams) (db.UpdatePostTxResult, error) {
+						require.Equal(t, sql.NullInt64{Int64: 3, Valid: true}, arg.ExpectedVersion)
+						return db.UpdatePostTxResult{Post: updatedPost}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"4"`, recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name:   "IfMatchStaleVersion",
+			postID: post.ID,
+			body: gin.H{
+				"title": newTitle,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				request.Header.Set("If-Match", `"2"`)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(authors, nil)
+
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdatePostTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name:   "IfMatchWeakTag",
+			postID: post.ID,
+			body: gin.H{
+				"title": newTitle,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				request.Header.Set("If-Match", `W/"3"`)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name:   "IfMatchList",
+			postID: post.ID,
+			body: gin.H{
+				"title": newTitle,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				request.Header.Set("If-Match", `"3", "4"`)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -569,6 +1050,11 @@ func TestUpdatePostAPI(t *testing.T) {
 func TestDeletePostAPI(t *testing.T) {
 	user := randomUserForPosts()
 	post := randomPost(user)
+	coAuthor := randomUserForPosts()
+	coAuthor.ID = user.ID + 1
+	moderator := randomUserForPosts()
+	moderator.ID = user.ID + 2
+	moderator.Role = policy.RoleModerator
 
 	testCases := []struct {
 		name          string
@@ -584,13 +1070,15 @@ func TestDeletePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetPost(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
 					Return(post, nil)
 
 				store.EXPECT().
-					DeletePostTx(gomock.Any(), gomock.Eq(post.ID)).
+					DeletePostTx(gomock.Any(), gomock.Eq(db.DeletePostTxParams{ID: post.ID})).
 					Times(1).
 					Return(nil)
 			},
@@ -622,6 +1110,8 @@ func TestDeletePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetPost(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
@@ -638,13 +1128,15 @@ func TestDeletePostAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetPost(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
 					Return(post, nil)
 
 				store.EXPECT().
-					DeletePostTx(gomock.Any(), gomock.Eq(post.ID)).
+					DeletePostTx(gomock.Any(), gomock.Eq(db.DeletePostTxParams{ID: post.ID})).
 					Times(1).
 					Return(sql.ErrConnDone)
 			},
@@ -652,6 +1144,51 @@ func TestDeletePostAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name:   "CoAuthorForbidden",
+			postID: post.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, coAuthor.ID, coAuthor.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, coAuthor)
+
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+
+				store.EXPECT().
+					DeletePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "ModeratorCanDelete",
+			postID: post.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, moderator.ID, moderator.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, moderator)
+
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+
+				store.EXPECT().
+					DeletePostTx(gomock.Any(), gomock.Eq(db.DeletePostTxParams{ID: post.ID})).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -703,6 +1240,7 @@ func TestGetPostsByUserAPI(t *testing.T) {
 		name          string
 		userID        int64
 		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
@@ -719,6 +1257,34 @@ func TestGetPostsByUserAPI(t *testing.T) {
 				store.EXPECT().
 					GetPostsByUserWithMedia(gomock.Any(), db.GetPostsByUserWithMediaParams{
 						UserID: user.ID,
+						Status: sql.NullString{String: policy.PostStatusPublished, Valid: true},
+						Limit:  10,
+						Offset: 0,
+					}).
+					Times(1).
+					Return(posts, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "OwnerSeesDrafts",
+			userID: user.ID,
+			query:  "?status=draft",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(2).
+					Return(user, nil)
+
+				store.EXPECT().
+					GetPostsByUserWithMedia(gomock.Any(), db.GetPostsByUserWithMediaParams{
+						UserID: user.ID,
+						Status: sql.NullString{String: policy.PostStatusDraft, Valid: true},
 						Limit:  10,
 						Offset: 0,
 					}).
@@ -761,6 +1327,9 @@ func TestGetPostsByUserAPI(t *testing.T) {
 			url := fmt.Sprintf("/api/v1/posts/user/%d%s", tc.userID, tc.query)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
+			if tc.setupAuth != nil {
+				tc.setupAuth(t, request, server.tokenMaker)
+			}
 
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
@@ -782,6 +1351,7 @@ func requireBodyMatchPost(t *testing.T, body string, post db.Post) {
 	require.Equal(t, post.UserID, response.Post.UserID)
 	require.Equal(t, post.Username, response.Post.Username)
 	require.Equal(t, post.Url, response.Post.Url)
+	require.Equal(t, post.Status, response.Post.Status)
 }
 
 func requireBodyMatchPosts(t *testing.T, body string, posts []db.Post, expectedTotal int64) {
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/mailer"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+// verificationTokenBytes is the amount of randomness in an email verification
+// token.
+const verificationTokenBytes = 32
+
+type RegisterUserRequest struct {
+	Username string `json:"username" binding:"required,alphanum,min=3,max=50"`
+	Email    string `json:"email" binding:"required,email"`
+	FullName string `json:"full_name" binding:"required,min=2,max=100"`
+	Password string `json:"password" binding:"required,min=6,max=72"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// register creates a user-role account whose email address must be verified
+// before it can log in.
+func (server *Server) register(c *gin.Context) {
+	if !server.config.RegistrationEnabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "registration is disabled"})
+		return
+	}
+
+	var req RegisterUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	token, err := util.RandomToken(verificationTokenBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create verification token"})
+		return
+	}
+
+	result, err := server.store.RegisterUserTx(c.Request.Context(), db.RegisterUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			Email:          req.Email,
+			FullName:       req.FullName,
+			HashedPassword: hashedPassword,
+			Role:           policy.RoleUser,
+		},
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(server.config.EmailVerificationDuration),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "username or email already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		return
+	}
+
+	// The account exists at this point; a failed send is logged and the
+	// user can ask for another email.
+	if err := server.sendVerificationEmail(c.Request.Context(), result.User, token); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", result.User.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"user":    toUserResponse(result.User),
+		"message": "check your email to verify your address",
+	})
+}
+
+func (server *Server) verifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := server.store.VerifyEmailTx(c.Request.Context(), util.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "verification token is invalid or has expired"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": toUserResponse(user),
+	})
+}
+
+// resendVerificationEmail answers the same way whether or not the address
+// belongs to an unverified account, so it cannot be used to probe for users.
+func (server *Server) resendVerificationEmail(c *gin.Context) {
+	var req ResendVerificationEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accepted := gin.H{"message": "if the address belongs to an unverified account, a new email is on its way"}
+
+	user, err := server.store.GetUserByEmail(c.Request.Context(), req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusAccepted, accepted)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
+	token, err := util.RandomToken(verificationTokenBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create verification token"})
+		return
+	}
+
+	_, err = server.store.ReissueEmailVerificationTx(c.Request.Context(), db.CreateEmailVerificationTokenParams{
+		UserID:    user.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(server.config.EmailVerificationDuration),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create verification token"})
+		return
+	}
+
+	if err := server.sendVerificationEmail(c.Request.Context(), user, token); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+func (server *Server) sendVerificationEmail(ctx context.Context, user db.User, token string) error {
+	link := server.webTokenLink("/verify-email", token)
+
+	return server.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm your email address to finish setting up your account:\n\n%s\n\nThe link works once. If you did not sign up, ignore this email.\n",
+			user.FullName, link,
+		),
+	})
+}
+
+// webTokenLink builds a link to a page of the web frontend that carries a
+// single-use token in its query string.
+func (server *Server) webTokenLink(path string, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", strings.TrimSuffix(server.config.WebBaseURL, "/"), path, url.QueryEscape(token))
+}
@@ -0,0 +1,349 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+var verificationLinkPattern = regexp.MustCompile(`/verify-email\?token=([A-Za-z0-9_-]+)`)
+
+// sentVerificationToken returns the token from the last verification link the
+// test server's log mailer wrote.
+func sentVerificationToken(t *testing.T, server *Server) string {
+	return sentLinkToken(t, server, verificationLinkPattern)
+}
+
+// sentLinkToken returns the token captured by pattern from the last matching
+// link in the test server's mail log.
+func sentLinkToken(t *testing.T, server *Server, pattern *regexp.Regexp) string {
+	data, err := os.ReadFile(server.config.MailerLogPath)
+	require.NoError(t, err)
+
+	matches := pattern.FindAllStringSubmatch(string(data), -1)
+	require.NotEmpty(t, matches, "no matching link in mail log")
+	return matches[len(matches)-1][1]
+}
+
+func TestRegisterAPI(t *testing.T) {
+	user := randomUserForSessions()
+	user.EmailVerifiedAt = sql.NullTime{}
+	password := "password123"
+
+	validBody := gin.H{
+		"username":  user.Username,
+		"email":     user.Email,
+		"full_name": user.FullName,
+		"password":  password,
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		disabled      bool
+		buildStubs    func(store *mockdb.MockStore, tokenHash *string)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, tokenHash string)
+	}{
+		{
+			name: "OK",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore, tokenHash *string) {
+				store.EXPECT().
+					RegisterUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RegisterUserTxParams) (db.RegisterUserTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, user.Email, arg.Email)
+						require.Equal(t, policy.RoleUser, arg.Role)
+						require.False(t, arg.EmailVerifiedAt.Valid)
+						require.NoError(t, util.CheckPassword(password, arg.HashedPassword))
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Minute)
+						*tokenHash = arg.TokenHash
+						return db.RegisterUserTxResult{User: user}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, tokenHash string) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "email_verified_at")
+
+				token := sentVerificationToken(t, server)
+				require.Equal(t, tokenHash, util.HashToken(token))
+			},
+		},
+		{
+			name:     "RegistrationDisabled",
+			body:     validBody,
+			disabled: true,
+			buildStubs: func(store *mockdb.MockStore, tokenHash *string) {
+				store.EXPECT().
+					RegisterUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, tokenHash string) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateUser",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore, tokenHash *string) {
+				store.EXPECT().
+					RegisterUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RegisterUserTxResult{}, fmt.Errorf("duplicate key value violates unique constraint"))
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, tokenHash string) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "RoleIsIgnored",
+			body: gin.H{
+				"username":  user.Username,
+				"email":     user.Email,
+				"full_name": user.FullName,
+				"password":  password,
+				"role":      policy.RoleAdmin,
+			},
+			buildStubs: func(store *mockdb.MockStore, tokenHash *string) {
+				store.EXPECT().
+					RegisterUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RegisterUserTxParams) (db.RegisterUserTxResult, error) {
+						require.Equal(t, policy.RoleUser, arg.Role)
+						return db.RegisterUserTxResult{User: user}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, tokenHash string) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"username":  user.Username,
+				"email":     "not-an-email",
+				"full_name": user.FullName,
+				"password":  password,
+			},
+			buildStubs: func(store *mockdb.MockStore, tokenHash *string) {
+				store.EXPECT().
+					RegisterUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, tokenHash string) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var tokenHash string
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store, &tokenHash)
+
+			server := newTestServer(t, store)
+			server.config.RegistrationEnabled = !tc.disabled
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder, tokenHash)
+		})
+	}
+}
+
+func TestVerifyEmailAPI(t *testing.T) {
+	user := randomUserForSessions()
+	token, err := util.RandomToken(verificationTokenBytes)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": token},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(util.HashToken(token))).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "email_verified_at")
+			},
+		},
+		{
+			name: "InvalidOrUsedToken",
+			body: gin.H{"token": token},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(util.HashToken(token))).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MissingToken",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/verify-email", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResendVerificationEmailAPI(t *testing.T) {
+	verified := randomUserForSessions()
+	unverified := verified
+	unverified.EmailVerifiedAt = sql.NullTime{}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Unverified",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(verified.Email)).
+					Times(1).
+					Return(unverified, nil)
+				store.EXPECT().
+					ReissueEmailVerificationTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailVerificationTokenParams) (db.EmailVerificationToken, error) {
+						require.Equal(t, unverified.ID, arg.UserID)
+						return db.EmailVerificationToken{UserID: arg.UserID, TokenHash: arg.TokenHash}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.NotEmpty(t, sentVerificationToken(t, server))
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(verified.Email)).
+					Times(1).
+					Return(verified, nil)
+				store.EXPECT().
+					ReissueEmailVerificationTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(verified.Email)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					ReissueEmailVerificationTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"email": verified.Email})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/verify-email/resend", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
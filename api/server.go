@@ -2,13 +2,23 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/imaging"
+	"github.com/go-live-cms/go-live-cms/lockout"
+	"github.com/go-live-cms/go-live-cms/mailer"
+	"github.com/go-live-cms/go-live-cms/oidc"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/search"
+	"github.com/go-live-cms/go-live-cms/storage"
 	"github.com/go-live-cms/go-live-cms/token"
 	"github.com/go-live-cms/go-live-cms/util"
 )
@@ -18,20 +28,94 @@ type Server struct {
 	router     *gin.Engine
 	config     util.Config
 	tokenMaker token.Maker
+	revoker    token.Revoker
+	loginGuard *lockout.Guard
+	storage    storage.Backend
+	mailer     mailer.Sender
+	// searchIndex answers searches when SEARCH_DRIVER names an external
+	// engine. It is nil for the default Postgres full-text search.
+	searchIndex search.Indexer
+
+	renditionSpecs     []imaging.Spec
+	transformKey       []byte
+	mfaRequiredRoles   []string
+	oidcProviders      map[string]*oidc.Provider
+	authCookieSameSite http.SameSite
 }
 
-func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+// NewServer builds the API server. searchIndex is the indexer store writes
+// through, or nil to search with Postgres; sharing one instance keeps
+// searches reading the index that writes go to.
+func NewServer(config util.Config, store db.Store, searchIndex search.Indexer) (*Server, error) {
+	if config.TokenSymmetricKey == util.InsecureDefaultKey || config.MediaTransformKey == util.InsecureDefaultKey {
+		return nil, fmt.Errorf("refusing to start with the public default key; generate new secrets")
+	}
+	if len(config.MediaTransformKey) < minTransformKeySize {
+		return nil, fmt.Errorf("media transform key must be at least %d characters", minTransformKeySize)
+	}
+
+	tokenMaker, err := token.New(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token maker: %w", err)
 	}
+	revoker, err := token.NewRevoker(config, store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token revoker: %w", err)
+	}
+	loginFailures, err := lockout.NewCounter(config, store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create login lockout counter: %w", err)
+	}
+	storageBackend, err := storage.New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage backend: %w", err)
+	}
+	mailSender, err := mailer.New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mailer: %w", err)
+	}
+	renditionSpecs, err := imaging.ParseSpecs(config.MediaRenditions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid media renditions: %w", err)
+	}
+	mfaRequiredRoles, err := policy.ParseRoles(config.MFARequiredRoles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MFA required roles: %w", err)
+	}
+	oidcProviders, err := newOIDCProviders(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OIDC providers: %w", err)
+	}
+	authCookieSameSite, err := parseSameSite(config.AuthCookieSameSite)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth cookie SameSite mode: %w", err)
+	}
+	if authCookieSameSite == http.SameSiteNoneMode && !config.AuthCookieSecure {
+		return nil, fmt.Errorf("auth cookies with SameSite=None must be Secure")
+	}
 	server := &Server{
-		store:      store,
-		config:     config,
-		tokenMaker: tokenMaker,
+		store:          store,
+		config:         config,
+		tokenMaker:     tokenMaker,
+		revoker:        revoker,
+		loginGuard:     lockout.NewGuardFromConfig(config, loginFailures),
+		storage:        storageBackend,
+		mailer:         mailSender,
+		searchIndex:    searchIndex,
+		renditionSpecs: renditionSpecs,
+		transformKey:   transformSigningKey(config.MediaTransformKey),
+
+		mfaRequiredRoles:   mfaRequiredRoles,
+		oidcProviders:      oidcProviders,
+		authCookieSameSite: authCookieSameSite,
 	}
 
 	server.setupRoutes()
+	// Client IPs feed the login lockout, so X-Forwarded-For is only believed
+	// from the proxies named here; by default it is ignored.
+	if err := server.router.SetTrustedProxies(splitList(config.TrustedProxies)); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
 	if gin.Mode() == gin.DebugMode {
 		server.createDefaultAdminUser()
 	}
@@ -50,14 +134,16 @@ func (server *Server) setupRoutes() {
 				"http://web:4321",
 			},
 			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", csrfHeaderKey},
+			ExposeHeaders:    []string{"Retry-After"},
 			AllowCredentials: true,
 		}))
 	} else {
 		router.Use(cors.New(cors.Config{
 			AllowOrigins:     []string{"https://yourdomain.com"},
 			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", csrfHeaderKey},
+			ExposeHeaders:    []string{"Retry-After"},
 			AllowCredentials: true,
 		}))
 	}
@@ -65,63 +151,113 @@ func (server *Server) setupRoutes() {
 	v1 := router.Group("/api/v1")
 
 	router.GET("/health", server.healthCheck)
+	router.GET("/.well-known/paseto-keys", server.listTokenKeys)
+	if publicPath := server.config.StoragePublicURL; isLocalStorage(server.config) && strings.HasPrefix(publicPath, "/") {
+		router.GET(strings.TrimSuffix(publicPath, "/")+"/*key", server.serveStoredFile) // GET /uploads/:key
+	}
 
 	auth := v1.Group("/auth")
 	auth.POST("/register", server.register)
+	auth.POST("/verify-email", server.verifyEmail)
+	auth.POST("/verify-email/resend", server.resendVerificationEmail)
+	auth.POST("/password/forgot", server.forgotPassword)
+	auth.POST("/password/reset", server.resetPassword)
 	auth.POST("/login", server.loginUser)
 	auth.POST("/refresh", server.renewAccessToken)
-	auth.POST("/logout", authMiddleware(server.tokenMaker), server.logoutUser)
+	auth.POST("/logout", authMiddleware(server.tokenMaker, server.store, server.revoker), requireAccessToken(), server.logoutUser)
+
+	auth.GET("/oidc/:provider/login", server.oidcLogin)       // GET /api/v1/auth/oidc/:provider/login
+	auth.GET("/oidc/:provider/callback", server.oidcCallback) // GET /api/v1/auth/oidc/:provider/callback
+
+	mfa := auth.Group("/mfa")
+	mfa.POST("/verify", server.verifyMFA)                                                                                                              // POST /api/v1/auth/mfa/verify
+	mfa.GET("", authMiddleware(server.tokenMaker, server.store, server.revoker), requireAccessToken(), server.getMFAStatus)                            // GET /api/v1/auth/mfa
+	mfa.POST("/totp/setup", mfaEnrollmentMiddleware(server.tokenMaker, server.store, server.revoker), server.setupTOTP)                                // POST /api/v1/auth/mfa/totp/setup
+	mfa.POST("/totp/confirm", mfaEnrollmentMiddleware(server.tokenMaker, server.store, server.revoker), server.confirmTOTP)                            // POST /api/v1/auth/mfa/totp/confirm
+	mfa.DELETE("/totp", authMiddleware(server.tokenMaker, server.store, server.revoker), requireAccessToken(), server.disableTOTP)                     // DELETE /api/v1/auth/mfa/totp
+	mfa.POST("/recovery-codes", authMiddleware(server.tokenMaker, server.store, server.revoker), requireAccessToken(), server.regenerateRecoveryCodes) // POST /api/v1/auth/mfa/recovery-codes
 
 	sessions := v1.Group("/sessions")
-	sessions.Use(authMiddleware(server.tokenMaker))
+	sessions.Use(authMiddleware(server.tokenMaker, server.store, server.revoker), requireAccessToken())
 	sessions.GET("", server.getUserSessions)    // GET /api/v1/sessions
 	sessions.PUT("/block", server.blockSession) // PUT /api/v1/sessions/block
 
+	lockouts := v1.Group("/lockouts")
+	lockouts.Use(authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionUsersUpdate))
+	lockouts.GET("", server.listLockouts)                    // GET /api/v1/lockouts
+	lockouts.DELETE("/:kind/:subject", server.deleteLockout) // DELETE /api/v1/lockouts/:kind/:subject
+
+	tokens := v1.Group("/tokens")
+	tokens.Use(authMiddleware(server.tokenMaker, server.store, server.revoker), requireAccessToken())
+	tokens.POST("", server.createAPIKey)       // POST /api/v1/tokens
+	tokens.GET("", server.listAPIKeys)         // GET /api/v1/tokens
+	tokens.DELETE("/:id", server.revokeAPIKey) // DELETE /api/v1/tokens/:id
+
 	users := v1.Group("/users")
-	users.POST("", authMiddleware(server.tokenMaker), server.createUser)                 // POST /api/v1/users
-	users.GET("", server.getUsers)                                                       // implement content limiter // GET /api/v1/users
-	users.GET("/:id", server.getUserByID)                                                // GET /api/v1/users/:id
-	users.GET("/username/:username", server.getUserByUsername)                           // GET /api/v1/users/username/:username
-	users.GET("/email/:email", authMiddleware(server.tokenMaker), server.getUserByEmail) // GET /api/v1/users/email/:email
-	users.PUT("/:id", authMiddleware(server.tokenMaker), server.updateUser)              // PUT /api/v1/users/:id
-	users.DELETE("/:id", authMiddleware(server.tokenMaker), server.deleteUser)           // DELETE /api/v1/users/:id
+	users.POST("", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionUsersCreate), server.createUser)               // POST /api/v1/users
+	users.GET("", server.getUsers)                                                                                                                                    // implement content limiter // GET /api/v1/users
+	users.GET("/:id", server.getUserByID)                                                                                                                             // GET /api/v1/users/:id
+	users.GET("/username/:username", server.getUserByUsername)                                                                                                        // GET /api/v1/users/username/:username
+	users.GET("/email/:email", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionUsersRead), server.getUserByEmail) // GET /api/v1/users/email/:email
+	users.PUT("/:id", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionUsersUpdateSelf), server.updateUser)        // PUT /api/v1/users/:id
+	users.DELETE("/:id", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionUsersDelete), server.deleteUser)         // DELETE /api/v1/users/:id
 
 	posts := v1.Group("/posts")
-	posts.POST("", authMiddleware(server.tokenMaker), server.createPost)       // POST /api/v1/posts
-	posts.GET("", server.getPosts)                                             // GET /api/v1/posts
-	posts.GET("/:id", server.getPostByID)                                      // GET /api/v1/posts/:id
-	posts.PUT("/:id", authMiddleware(server.tokenMaker), server.updatePost)    // PUT /api/v1/posts/:id
-	posts.DELETE("/:id", authMiddleware(server.tokenMaker), server.deletePost) // DELETE /api/v1/posts/:id
-	posts.GET("/user/:id", server.getPostsByUser)                              // GET /api/v1/posts/user/:id
-	posts.GET("/:id/taxonomies", server.getPostTaxonomies)                     // GET /api/v1/posts/:id/taxonomies
+	posts.POST("", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsCreate), server.createPost)                                               // POST /api/v1/posts
+	posts.GET("", optionalAuthMiddleware(server.tokenMaker, server.store, server.revoker), requireScope(policy.ScopePostsRead), server.getPosts)                                                      // GET /api/v1/posts
+	posts.GET("/search", optionalAuthMiddleware(server.tokenMaker, server.store, server.revoker), requireScope(policy.ScopePostsRead), server.searchPosts)                                            // GET /api/v1/posts/search
+	posts.GET("/:id", optionalAuthMiddleware(server.tokenMaker, server.store, server.revoker), requireScope(policy.ScopePostsRead), server.getPostByID)                                               // GET /api/v1/posts/:id
+	posts.PUT("/:id", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsUpdate), server.updatePost)                                            // PUT /api/v1/posts/:id
+	posts.DELETE("/:id", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsDelete), server.deletePost)                                         // DELETE /api/v1/posts/:id
+	posts.POST("/:id/submit", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsUpdate), server.transitionPost(policy.TransitionSubmit))       // POST /api/v1/posts/:id/submit
+	posts.POST("/:id/approve", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsPublish), server.transitionPost(policy.TransitionApprove))    // POST /api/v1/posts/:id/approve
+	posts.POST("/:id/reject", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsPublish), server.transitionPost(policy.TransitionReject))      // POST /api/v1/posts/:id/reject
+	posts.POST("/:id/publish", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsPublish), server.transitionPost(policy.TransitionPublish))    // POST /api/v1/posts/:id/publish
+	posts.POST("/:id/unpublish", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsUpdate), server.transitionPost(policy.TransitionUnpublish)) // POST /api/v1/posts/:id/unpublish
+	posts.PUT("/:id/schedule", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsUpdate), server.schedulePost)                                 // PUT /api/v1/posts/:id/schedule
+	posts.GET("/:id/revisions", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsUpdate), server.listPostRevisions)                           // GET /api/v1/posts/:id/revisions
+	posts.GET("/:id/revisions/:revision", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsUpdate), server.getPostRevision)                   // GET /api/v1/posts/:id/revisions/:revision
+	posts.GET("/:id/revisions/:revision/diff", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsUpdate), server.diffPostRevisions)            // GET /api/v1/posts/:id/revisions/:revision/diff
+	posts.POST("/:id/revisions/:revision/restore", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionPostsUpdate), server.restorePostRevision)      // POST /api/v1/posts/:id/revisions/:revision/restore
+	posts.GET("/user/:id", optionalAuthMiddleware(server.tokenMaker, server.store, server.revoker), requireScope(policy.ScopePostsRead), server.getPostsByUser)                                       // GET /api/v1/posts/user/:id
+	posts.GET("/:id/taxonomies", optionalAuthMiddleware(server.tokenMaker, server.store, server.revoker), requireScope(policy.ScopePostsRead), server.getPostTaxonomies)                              // GET /api/v1/posts/:id/taxonomies
 
 	taxonomies := v1.Group("/taxonomies")
-	taxonomies.POST("", authMiddleware(server.tokenMaker), server.createTaxonomy)       // POST /api/v1/taxonomies
-	taxonomies.GET("", server.getTaxonomies)                                            // GET /api/v1/taxonomies
-	taxonomies.GET("/popular", server.getPopularTaxonomies)                             // GET /api/v1/taxonomies/popular
-	taxonomies.GET("/search", server.searchTaxonomies)                                  // GET /api/v1/taxonomies/search
-	taxonomies.GET("/:id", server.getTaxonomyByID)                                      // GET /api/v1/taxonomies/:id
-	taxonomies.GET("/name/:name", server.getTaxonomyByName)                             // GET /api/v1/taxonomies/name/:name
-	taxonomies.PUT("/:id", authMiddleware(server.tokenMaker), server.updateTaxonomy)    // PUT /api/v1/taxonomies/:id
-	taxonomies.DELETE("/:id", authMiddleware(server.tokenMaker), server.deleteTaxonomy) // DELETE /api/v1/taxonomies/:id
-	taxonomies.GET("/:id/posts", server.getTaxonomyPosts)                               // GET /api/v1/taxonomies/:id/posts
+	taxonomies.POST("", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionTaxonomiesCreate), server.createTaxonomy)       // POST /api/v1/taxonomies
+	taxonomies.GET("", server.getTaxonomies)                                                                                                                                // GET /api/v1/taxonomies
+	taxonomies.GET("/popular", server.getPopularTaxonomies)                                                                                                                 // GET /api/v1/taxonomies/popular
+	taxonomies.GET("/search", server.searchTaxonomies)                                                                                                                      // GET /api/v1/taxonomies/search
+	taxonomies.GET("/:id", server.getTaxonomyByID)                                                                                                                          // GET /api/v1/taxonomies/:id
+	taxonomies.GET("/name/:name", server.getTaxonomyByName)                                                                                                                 // GET /api/v1/taxonomies/name/:name
+	taxonomies.PUT("/:id", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionTaxonomiesUpdate), server.updateTaxonomy)    // PUT /api/v1/taxonomies/:id
+	taxonomies.DELETE("/:id", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionTaxonomiesDelete), server.deleteTaxonomy) // DELETE /api/v1/taxonomies/:id
+	taxonomies.GET("/:id/posts", optionalAuthMiddleware(server.tokenMaker, server.store, server.revoker), requireScope(policy.ScopePostsRead), server.getTaxonomyPosts)     // GET /api/v1/taxonomies/:id/posts
 
 	media := v1.Group("/media")
-	media.POST("", authMiddleware(server.tokenMaker), server.createMedia)       // POST /api/v1/media
-	media.GET("", server.getMedia)                                              // GET /api/v1/media
-	media.GET("/popular", server.getPopularMedia)                               // GET /api/v1/media/popular
-	media.GET("/search", server.searchMedia)                                    // GET /api/v1/media/search
-	media.GET("/:id", server.getMediaByID)                                      // GET /api/v1/media/:id
-	media.PUT("/:id", authMiddleware(server.tokenMaker), server.updateMedia)    // PUT /api/v1/media/:id
-	media.DELETE("/:id", authMiddleware(server.tokenMaker), server.deleteMedia) // DELETE /api/v1/media/:id
-	media.GET("/user/:id", server.getMediaByUser)                               // GET /api/v1/media/user/:id
-	media.GET("/post/:id", server.getMediaByPost)                               // GET /api/v1/media/post/:id
+	media.POST("", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionMediaCreate), server.createMedia)                          // POST /api/v1/media
+	media.POST("/upload", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionMediaCreate), server.uploadMedia)                   // POST /api/v1/media/upload
+	media.GET("", server.getMedia)                                                                                                                                                // GET /api/v1/media
+	media.GET("/popular", server.getPopularMedia)                                                                                                                                 // GET /api/v1/media/popular
+	media.GET("/search", server.searchMedia)                                                                                                                                      // GET /api/v1/media/search
+	media.GET("/:id", server.getMediaByID)                                                                                                                                        // GET /api/v1/media/:id
+	media.GET("/:id/transform", server.transformMedia)                                                                                                                            // GET /api/v1/media/:id/transform
+	media.GET("/:id/transform/sign", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionMediaCreate), server.signMediaTransform) // GET /api/v1/media/:id/transform/sign
+	media.PUT("/:id", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionMediaUpdate), server.updateMedia)                       // PUT /api/v1/media/:id
+	media.DELETE("/:id", authMiddleware(server.tokenMaker, server.store, server.revoker), requirePermission(policy.PermissionMediaDelete), server.deleteMedia)                    // DELETE /api/v1/media/:id
+	media.GET("/user/:id", server.getMediaByUser)                                                                                                                                 // GET /api/v1/media/user/:id
+	media.GET("/post/:id", optionalAuthMiddleware(server.tokenMaker, server.store, server.revoker), requireScope(policy.ScopePostsRead), server.getMediaByPost)                   // GET /api/v1/media/post/:id
 
 	//v1.GET("/test-log", server.testLog) // Temporary log endpoint for testing
 
 	server.router = router
 }
 
+// isLocalStorage reports whether uploaded files live on this server's disk and
+// therefore have to be served by it.
+func isLocalStorage(config util.Config) bool {
+	return config.StorageDriver == "" || config.StorageDriver == "local"
+}
+
 func (server *Server) createDefaultAdminUser() {
 	log.Println("🔧 Checking for default admin user...")
 	existingUser, err := server.store.GetUserByUsername(context.TODO(), "admin")
@@ -137,11 +273,12 @@ func (server *Server) createDefaultAdminUser() {
 	}
 
 	adminUser := db.CreateUserParams{
-		Username:       "admin",
-		Email:          "admin@golive-cms.local",
-		FullName:       "Default Administrator",
-		HashedPassword: hashedPassword,
-		Role:           "admin",
+		Username:        "admin",
+		Email:           "admin@golive-cms.local",
+		FullName:        "Default Administrator",
+		HashedPassword:  hashedPassword,
+		Role:            "admin",
+		EmailVerifiedAt: sql.NullTime{Time: time.Now(), Valid: true},
 	}
 	createdUser, err := server.store.CreateUser(context.TODO(), adminUser)
 	if err != nil {
@@ -173,12 +310,54 @@ func (server *Server) healthCheck(c *gin.Context) {
 	})
 } */
 
-func (server *Server) register(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Register endpoint - coming soon",
-	})
+// shutdownTimeout bounds how long Start waits for in-flight requests once its
+// context is cancelled.
+const shutdownTimeout = 10 * time.Second
+
+// Start serves HTTP on address until ctx is cancelled, then shuts down
+// gracefully.
+func (server *Server) Start(ctx context.Context, address string) error {
+	httpServer := &http.Server{
+		Addr:    address,
+		Handler: server.router,
+	}
+
+	if interval := server.config.TokenRevocationCleanupInterval; interval > 0 {
+		go token.RunCleanup(ctx, server.revoker, interval)
+	}
+	if interval := server.config.LoginLockoutCleanupInterval; interval > 0 {
+		go lockout.RunCleanup(ctx, server.loginGuard, interval)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
-func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+// splitList splits a comma-separated config value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
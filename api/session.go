@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/lockout"
 	"github.com/go-live-cms/go-live-cms/token"
 	"github.com/go-live-cms/go-live-cms/util"
 	"github.com/google/uuid"
@@ -17,22 +20,30 @@ type LoginUserRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+// LoginUserResponse carries the tokens in the body, or in cookie mode only
+// the CSRF token, with the tokens themselves set as cookies.
 type LoginUserResponse struct {
 	SessionID             uuid.UUID    `json:"session_id"`
-	AccessToken           string       `json:"access_token"`
+	AccessToken           string       `json:"access_token,omitempty"`
 	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
-	RefreshToken          string       `json:"refresh_token"`
+	RefreshToken          string       `json:"refresh_token,omitempty"`
 	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	CSRFToken             string       `json:"csrf_token,omitempty"`
 	User                  UserResponse `json:"user"`
 }
 
+// RenewAccessTokenRequest may be empty when the refresh token is sent as a
+// cookie instead.
 type RenewAccessTokenRequest struct {
-	RefreshToken string `json:"refresh_token" binding:"required"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type RenewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	AccessToken           string    `json:"access_token,omitempty"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	CSRFToken             string    `json:"csrf_token,omitempty"`
 }
 
 type BlockSessionRequest struct {
@@ -70,10 +81,23 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	// Attempts are counted whether or not the username exists, so lockouts
+	// do not reveal which accounts are real.
+	loginKeys := []lockout.Key{lockout.UsernameKey(req.Username), lockout.IPKey(ctx.ClientIP())}
+	wait, err := server.loginGuard.Check(ctx.Request.Context(), loginKeys...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check login attempts"})
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(ctx, wait)
+		return
+	}
+
 	user, err := server.store.GetUserByUsername(ctx.Request.Context(), req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			server.failLogin(ctx, loginKeys)
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
@@ -82,34 +106,104 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		server.failLogin(ctx, loginKeys)
+		return
+	}
+
+	// Only the username is cleared; an IP's count keeps growing while it
+	// guesses at other accounts.
+	if err := server.loginGuard.Reset(ctx.Request.Context(), loginKeys[0]); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset login attempts"})
 		return
 	}
 
+	// Checked after the password so the response does not reveal which
+	// usernames exist.
+	if !user.EmailVerifiedAt.Valid {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "email address has not been verified"})
+		return
+	}
+
+	server.completeLogin(ctx, user)
+}
+
+// failLogin records a failed password login and rejects it.
+func (server *Server) failLogin(ctx *gin.Context, keys []lockout.Key) {
+	if _, err := server.loginGuard.Fail(ctx.Request.Context(), keys...); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record login attempt"})
+		return
+	}
+	ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+}
+
+// completeLogin finishes a login once the user has proved who they are,
+// either asking for their second factor or starting a session.
+func (server *Server) completeLogin(ctx *gin.Context, user db.User) {
+	pending, rsp, err := server.beginLogin(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if pending != nil {
+		ctx.JSON(http.StatusOK, pending)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// beginLogin decides what a user who has proved who they are gets next: a
+// pending token when a second factor is needed, otherwise a new session.
+// Exactly one of the two results is set when err is nil.
+func (server *Server) beginLogin(ctx *gin.Context, user db.User) (*MFAPendingResponse, *LoginUserResponse, error) {
+	mfa, err := server.store.GetUserMFA(ctx.Request.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, errors.New("failed to get two-factor settings")
+	}
+	mfaEnabled := err == nil && mfa.EnabledAt.Valid
+	if mfaEnabled || server.roleRequiresMFA(user.Role) {
+		pending, err := server.newMFAPending(user, !mfaEnabled)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &pending, nil, nil
+	}
+
+	rsp, err := server.createLoginSession(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &rsp, nil
+}
+
+// createLoginSession issues the access and refresh tokens for a user who has
+// passed every login check and records the session they belong to. In cookie
+// mode the tokens are set as cookies rather than returned.
+func (server *Server) createLoginSession(ctx *gin.Context, user db.User) (LoginUserResponse, error) {
+	sessionID := uuid.New()
 	accessToken, err := server.tokenMaker.CreateToken(
+		sessionID,
 		user.ID,
 		user.Username,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create access token"})
-		return
+		return LoginUserResponse{}, errors.New("failed to create access token")
 	}
 
 	refreshToken, err := server.tokenMaker.CreateRefreshToken(
+		sessionID,
 		user.ID,
 		user.Username,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create refresh token"})
-		return
+		return LoginUserResponse{}, errors.New("failed to create refresh token")
 	}
 
 	userAgent := ctx.GetHeader("User-Agent")
 	clientIP := ctx.ClientIP()
 
-	sessionID := uuid.New()
 	session, err := server.store.CreateSession(ctx.Request.Context(), db.CreateSessionParams{
 		ID:           sessionID,
 		UserID:       user.ID,
@@ -121,8 +215,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		ExpiresAt:    time.Now().Add(server.config.RefreshTokenDuration),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
-		return
+		return LoginUserResponse{}, errors.New("failed to create session")
 	}
 
 	rsp := LoginUserResponse{
@@ -133,14 +226,39 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		RefreshTokenExpiresAt: session.ExpiresAt,
 		User:                  toUserResponse(user),
 	}
-
-	ctx.JSON(http.StatusOK, rsp)
+	if server.config.AuthCookiesEnabled {
+		csrfToken, err := server.setAuthCookies(ctx, rsp.AccessToken, rsp.AccessTokenExpiresAt, rsp.RefreshToken, rsp.RefreshTokenExpiresAt)
+		if err != nil {
+			return LoginUserResponse{}, errors.New("failed to set auth cookies")
+		}
+		rsp.AccessToken, rsp.RefreshToken, rsp.CSRFToken = "", "", csrfToken
+	}
+	return rsp, nil
 }
 
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	var req RenewAccessTokenRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// A refresh token from a cookie is renewed into new cookies, and like
+	// any cookie-authenticated change it needs the CSRF token.
+	fromCookie := false
+	if req.RefreshToken == "" {
+		if req.RefreshToken = cookieCredential(ctx, refreshTokenCookieName); req.RefreshToken != "" {
+			if !validCSRFToken(ctx) {
+				ctx.JSON(http.StatusForbidden, gin.H{"error": "missing or invalid CSRF token"})
+				return
+			}
+			fromCookie = true
+		}
+	}
+	if req.RefreshToken == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "refresh token required"})
 		return
 	}
 
@@ -155,23 +273,17 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	sessions, err := server.store.ListSessionsByUsername(ctx.Request.Context(), refreshPayload.Username)
+	session, err := server.store.GetSession(ctx.Request.Context(), refreshPayload.ID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get sessions"})
-		return
-	}
-
-	var session db.Session
-	var sessionFound bool
-	for _, s := range sessions {
-		if s.RefreshToken == req.RefreshToken {
-			session = s
-			sessionFound = true
-			break
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "session not found"})
+			return
 		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get session"})
+		return
 	}
 
-	if !sessionFound {
+	if session.UserID != refreshPayload.UserID {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "session not found"})
 		return
 	}
@@ -186,16 +298,21 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	currentUserAgent := ctx.GetHeader("User-Agent")
-	ctx.ClientIP()
+	// The token was signed by us for this session but is no longer the
+	// current one, so it was rotated out earlier and someone is replaying it.
+	if session.RefreshToken != req.RefreshToken {
+		server.revokeReusedSession(ctx, session.ID)
+		return
+	}
 
+	currentUserAgent := ctx.GetHeader("User-Agent")
 	if currentUserAgent != session.UserAgent {
-
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "suspicious activity detected"})
 		return
 	}
 
 	accessToken, err := server.tokenMaker.CreateToken(
+		session.ID,
 		refreshPayload.UserID,
 		refreshPayload.Username,
 		server.config.AccessTokenDuration,
@@ -205,14 +322,68 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
+	// The rotated token keeps the session's original expiry so refreshing
+	// cannot extend a login indefinitely.
+	refreshToken, err := server.tokenMaker.CreateRefreshToken(
+		session.ID,
+		refreshPayload.UserID,
+		refreshPayload.Username,
+		time.Until(session.ExpiresAt),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create refresh token"})
+		return
+	}
+
+	session, err = server.store.RotateSessionRefreshToken(ctx.Request.Context(), db.RotateSessionRefreshTokenParams{
+		NewRefreshToken: refreshToken,
+		ID:              session.ID,
+		RefreshToken:    req.RefreshToken,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Another request rotated or blocked the session after we read it.
+			server.revokeReusedSession(ctx, refreshPayload.ID)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate refresh token"})
+		return
+	}
+
 	rsp := RenewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: time.Now().Add(server.config.AccessTokenDuration),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  time.Now().Add(server.config.AccessTokenDuration),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}
+	if fromCookie {
+		csrfToken, err := server.setAuthCookies(ctx, rsp.AccessToken, rsp.AccessTokenExpiresAt, rsp.RefreshToken, rsp.RefreshTokenExpiresAt)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set auth cookies"})
+			return
+		}
+		rsp.AccessToken, rsp.RefreshToken, rsp.CSRFToken = "", "", csrfToken
 	}
 
 	ctx.JSON(http.StatusOK, rsp)
 }
 
+// revokeReusedSession blocks a session whose refresh token was presented after
+// it had been rotated out. Every token descended from that login dies with it,
+// so whichever party holds the current token has to sign in again.
+func (server *Server) revokeReusedSession(ctx *gin.Context, sessionID uuid.UUID) {
+	if err := server.store.BlockSession(ctx.Request.Context(), sessionID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to block session"})
+		return
+	}
+	if err := server.revokeSessionTokens(ctx.Request.Context(), sessionID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke access tokens"})
+		return
+	}
+
+	ctx.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reuse detected"})
+}
+
 func (server *Server) getUserSessions(ctx *gin.Context) {
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
@@ -263,6 +434,10 @@ func (server *Server) blockSession(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to block session"})
 		return
 	}
+	if err := server.revokeSessionTokens(ctx.Request.Context(), req.SessionID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke access tokens"})
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message":    "session blocked successfully",
@@ -278,8 +453,11 @@ func (server *Server) logoutUser(ctx *gin.Context) {
 		RefreshToken string `json:"refresh_token"`
 	}
 
+	refreshCookie := cookieCredential(ctx, refreshTokenCookieName)
 	if err := ctx.ShouldBindJSON(&req); err == nil && req.RefreshToken != "" {
 		refreshToken = req.RefreshToken
+	} else if refreshCookie != "" {
+		refreshToken = refreshCookie
 	} else {
 
 		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
@@ -312,35 +490,71 @@ func (server *Server) logoutUser(ctx *gin.Context) {
 		return
 	}
 
-	sessions, err := server.store.ListSessionsByUsername(ctx.Request.Context(), refreshPayload.Username)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get sessions"})
+	if refreshPayload.TokenType != "refresh" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token type"})
 		return
 	}
 
-	var sessionID uuid.UUID
-	var sessionFound bool
-
-	for _, session := range sessions {
-		if session.RefreshToken == refreshToken {
-			sessionID = session.ID
-			sessionFound = true
-			break
+	session, err := server.store.GetSession(ctx.Request.Context(), refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
 		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get session"})
+		return
 	}
 
-	if !sessionFound {
+	if session.UserID != refreshPayload.UserID {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
 		return
 	}
 
-	err = server.store.BlockSession(ctx.Request.Context(), sessionID)
+	err = server.store.BlockSession(ctx.Request.Context(), session.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
 		return
 	}
+	if err := server.revokeSessionTokens(ctx.Request.Context(), session.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke access tokens"})
+		return
+	}
+
+	if refreshCookie != "" || cookieCredential(ctx, accessTokenCookieName) != "" {
+		server.clearAuthCookies(ctx)
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "logged out successfully",
 	})
 }
+
+// revokeSessionTokens stops the access tokens issued for a session from
+// working before they expire. Blocking the session only stops its refresh
+// token, and access tokens are not checked against the sessions table.
+func (server *Server) revokeSessionTokens(ctx context.Context, sessionID uuid.UUID) error {
+	return server.revoker.Revoke(ctx, sessionID, time.Now().Add(server.config.AccessTokenDuration))
+}
+
+// revokeTokensForSessions revokes the access tokens of every session that could
+// still have one alive.
+func (server *Server) revokeTokensForSessions(ctx context.Context, sessions []db.Session) error {
+	for _, session := range sessions {
+		if time.Since(session.ExpiresAt) > server.config.AccessTokenDuration {
+			continue
+		}
+		if err := server.revokeSessionTokens(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeUserTokens revokes the access tokens of every session a user has.
+func (server *Server) revokeUserTokens(ctx context.Context, userID int64) error {
+	sessions, err := server.store.ListSessionsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return server.revokeTokensForSessions(ctx, sessions)
+}
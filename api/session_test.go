@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -33,9 +34,10 @@ func randomUserForSessions() db.User {
 		FullName:          gofakeit.Name(),
 		Email:             gofakeit.Email(),
 		HashedPassword:    hashedPassword,
-		PasswordChangedAt: gofakeit.Date(),
+		PasswordChangedAt: gofakeit.PastDate(),
 		CreatedAt:         gofakeit.Date(),
 		Role:              "user",
+		EmailVerifiedAt:   sql.NullTime{Time: gofakeit.Date(), Valid: true},
 	}
 }
 
@@ -78,6 +80,11 @@ func TestLoginUserAPI(t *testing.T) {
 					Times(1).
 					Return(user, nil)
 
+				store.EXPECT().
+					GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(db.UserMfa{}, sql.ErrNoRows)
+
 				store.EXPECT().
 					CreateSession(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -120,6 +127,29 @@ func TestLoginUserAPI(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "EmailNotVerified",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				unverified := user
+				unverified.EmailVerifiedAt = sql.NullTime{}
+
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(unverified, nil)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidRequest",
 			body: gin.H{
@@ -147,6 +177,11 @@ func TestLoginUserAPI(t *testing.T) {
 					Times(1).
 					Return(user, nil)
 
+				store.EXPECT().
+					GetUserMFA(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(db.UserMfa{}, sql.ErrNoRows)
+
 				store.EXPECT().
 					CreateSession(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -195,7 +230,7 @@ func TestRenewAccessTokenAPI(t *testing.T) {
 		body          gin.H
 		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore, refreshToken string)
-		checkResponse func(recorder *httptest.ResponseRecorder)
+		checkResponse func(recorder *httptest.ResponseRecorder, refreshToken string)
 	}{
 		{
 			name: "OK",
@@ -204,16 +239,30 @@ func TestRenewAccessTokenAPI(t *testing.T) {
 
 			},
 			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
-
-				session.RefreshToken = refreshToken
+				current := session
+				current.RefreshToken = refreshToken
 				store.EXPECT().
-					ListSessionsByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
 					Times(1).
-					Return([]db.Session{session}, nil)
+					Return(current, nil)
+				store.EXPECT().
+					RotateSessionRefreshToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RotateSessionRefreshTokenParams) (db.Session, error) {
+						require.Equal(t, session.ID, arg.ID)
+						require.Equal(t, refreshToken, arg.RefreshToken)
+						require.NotEqual(t, refreshToken, arg.NewRefreshToken)
+						rotated := current
+						rotated.RefreshToken = arg.NewRefreshToken
+						return rotated, nil
+					})
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
-			checkResponse: func(recorder *httptest.ResponseRecorder) {
+			checkResponse: func(recorder *httptest.ResponseRecorder, refreshToken string) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchRenewResponse(t, recorder.Body.String())
+				requireBodyMatchRenewResponse(t, recorder.Body.String(), refreshToken, session)
 			},
 		},
 		{
@@ -226,10 +275,10 @@ func TestRenewAccessTokenAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
 				store.EXPECT().
-					ListSessionsByUsername(gomock.Any(), gomock.Any()).
+					GetSession(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
-			checkResponse: func(recorder *httptest.ResponseRecorder) {
+			checkResponse: func(recorder *httptest.ResponseRecorder, refreshToken string) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
@@ -241,11 +290,14 @@ func TestRenewAccessTokenAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
 				store.EXPECT().
-					ListSessionsByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
 					Times(1).
-					Return([]db.Session{}, nil)
+					Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().
+					RotateSessionRefreshToken(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
-			checkResponse: func(recorder *httptest.ResponseRecorder) {
+			checkResponse: func(recorder *httptest.ResponseRecorder, refreshToken string) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
@@ -260,11 +312,88 @@ func TestRenewAccessTokenAPI(t *testing.T) {
 				blockedSession.IsBlocked = true
 				blockedSession.RefreshToken = refreshToken
 				store.EXPECT().
-					ListSessionsByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
 					Times(1).
-					Return([]db.Session{blockedSession}, nil)
+					Return(blockedSession, nil)
+				store.EXPECT().
+					RotateSessionRefreshToken(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
-			checkResponse: func(recorder *httptest.ResponseRecorder) {
+			checkResponse: func(recorder *httptest.ResponseRecorder, refreshToken string) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ReusedRefreshToken",
+			body: gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				rotated := session
+				rotated.RefreshToken = "a-newer-refresh-token"
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(rotated, nil)
+				store.EXPECT().
+					RotateSessionRefreshToken(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, refreshToken string) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "reuse detected")
+			},
+		},
+		{
+			name: "ConcurrentRotation",
+			body: gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				current := session
+				current.RefreshToken = refreshToken
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(current, nil)
+				store.EXPECT().
+					RotateSessionRefreshToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, refreshToken string) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "OtherUsersSession",
+			body: gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				other := session
+				other.UserID = user.ID + 1
+				other.RefreshToken = refreshToken
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(other, nil)
+				store.EXPECT().
+					RotateSessionRefreshToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, refreshToken string) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
@@ -285,7 +414,7 @@ func TestRenewAccessTokenAPI(t *testing.T) {
 			var err error
 
 			if tc.name != "InvalidRefreshToken" {
-				refreshToken, err = server.tokenMaker.CreateRefreshToken(user.ID, user.Username, time.Hour)
+				refreshToken, err = server.tokenMaker.CreateRefreshToken(session.ID, user.ID, user.Username, time.Hour)
 				require.NoError(t, err)
 				tc.body["refresh_token"] = refreshToken
 			}
@@ -304,7 +433,7 @@ func TestRenewAccessTokenAPI(t *testing.T) {
 
 			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
-			tc.checkResponse(recorder)
+			tc.checkResponse(recorder, refreshToken)
 		})
 	}
 }
@@ -329,6 +458,8 @@ func TestGetUserSessionsAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					ListSessionsByUser(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
@@ -359,6 +490,8 @@ func TestGetUserSessionsAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					ListSessionsByUser(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
@@ -416,6 +549,8 @@ func TestBlockSessionAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetSession(gomock.Any(), gomock.Eq(session.ID)).
 					Times(1).
@@ -439,6 +574,8 @@ func TestBlockSessionAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetSession(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -457,6 +594,8 @@ func TestBlockSessionAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetSession(gomock.Any(), gomock.Eq(otherSession.ID)).
 					Times(1).
@@ -532,11 +671,13 @@ func TestLogoutUserAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				expectAuthUser(store, user)
+
 				session.RefreshToken = refreshToken
 				store.EXPECT().
-					ListSessionsByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
 					Times(1).
-					Return([]db.Session{session}, nil)
+					Return(session, nil)
 
 				store.EXPECT().
 					BlockSession(gomock.Any(), gomock.Eq(session.ID)).
@@ -557,6 +698,8 @@ func TestLogoutUserAPI(t *testing.T) {
 				request.RemoteAddr = session.ClientIp + ":12345"
 			},
 			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				expectAuthUser(store, user)
+
 				session.RefreshToken = refreshToken
 				store.EXPECT().
 					ListSessionsByUser(gomock.Any(), gomock.Eq(user.ID)).
@@ -564,9 +707,9 @@ func TestLogoutUserAPI(t *testing.T) {
 					Return([]db.Session{session}, nil)
 
 				store.EXPECT().
-					ListSessionsByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
 					Times(1).
-					Return([]db.Session{session}, nil)
+					Return(session, nil)
 
 				store.EXPECT().
 					BlockSession(gomock.Any(), gomock.Eq(session.ID)).
@@ -586,8 +729,10 @@ func TestLogoutUserAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore, refreshToken string) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
-					ListSessionsByUsername(gomock.Any(), gomock.Any()).
+					GetSession(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -611,7 +756,7 @@ func TestLogoutUserAPI(t *testing.T) {
 			var err error
 
 			if tc.name != "InvalidRefreshToken" {
-				refreshToken, err = server.tokenMaker.CreateRefreshToken(user.ID, user.Username, time.Hour)
+				refreshToken, err = server.tokenMaker.CreateRefreshToken(session.ID, user.ID, user.Username, time.Hour)
 				require.NoError(t, err)
 				if tc.name == "OK_WithRefreshToken" {
 					tc.body["refresh_token"] = refreshToken
@@ -645,7 +790,7 @@ func addAuthorization(
 	username string,
 	duration time.Duration,
 ) {
-	token, err := tokenMaker.CreateToken(userID, username, duration)
+	token, err := tokenMaker.CreateToken(uuid.New(), userID, username, duration)
 	require.NoError(t, err)
 
 	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
@@ -667,13 +812,16 @@ func requireBodyMatchLoginResponse(t *testing.T, body string, user db.User) {
 	require.Equal(t, user.FullName, response.User.FullName)
 }
 
-func requireBodyMatchRenewResponse(t *testing.T, body string) {
+func requireBodyMatchRenewResponse(t *testing.T, body string, oldRefreshToken string, session db.Session) {
 	var response RenewAccessTokenResponse
 	err := json.Unmarshal([]byte(body), &response)
 	require.NoError(t, err)
 
 	require.NotEmpty(t, response.AccessToken)
 	require.NotZero(t, response.AccessTokenExpiresAt)
+	require.NotEmpty(t, response.RefreshToken)
+	require.NotEqual(t, oldRefreshToken, response.RefreshToken)
+	require.WithinDuration(t, session.ExpiresAt, response.RefreshTokenExpiresAt, time.Second)
 }
 
 func requireBodyMatchSessionsList(t *testing.T, body string, sessions []db.Session) {
@@ -694,3 +842,45 @@ func requireBodyMatchSessionsList(t *testing.T, body string, sessions []db.Sessi
 		require.Equal(t, session.IsBlocked, response.Sessions[i].IsBlocked)
 	}
 }
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := randomUserForSessions()
+	session := randomSession(user)
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	accessToken, err := server.tokenMaker.CreateToken(session.ID, user.ID, user.Username, time.Minute)
+	require.NoError(t, err)
+	refreshToken, err := server.tokenMaker.CreateRefreshToken(session.ID, user.ID, user.Username, time.Hour)
+	require.NoError(t, err)
+	session.RefreshToken = refreshToken
+
+	expectAuthUser(store, user)
+	store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+	store.EXPECT().BlockSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(nil)
+	store.EXPECT().ListSessionsByUser(gomock.Any(), gomock.Any()).Times(0)
+
+	data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/auth/logout", bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// The access token has not expired yet but belongs to the logged out session.
+	request, err = http.NewRequest(http.MethodGet, "/api/v1/sessions", nil)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "revoked")
+}
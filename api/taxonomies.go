@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/search"
 )
 
 type CreateTaxonomyRequest struct {
@@ -23,6 +26,7 @@ type TaxonomyResponse struct {
 	ID          int64  `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Version     int64  `json:"version"`
 	PostCount   *int64 `json:"post_count,omitempty"`
 }
 
@@ -30,6 +34,7 @@ type PopularTaxonomyResponse struct {
 	ID          int64  `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Version     int64  `json:"version"`
 	PostCount   int64  `json:"post_count"`
 }
 
@@ -38,6 +43,7 @@ func toTaxonomyResponse(taxonomy db.Taxonomy) TaxonomyResponse {
 		ID:          taxonomy.ID,
 		Name:        taxonomy.Name,
 		Description: taxonomy.Description,
+		Version:     taxonomy.Version,
 	}
 }
 
@@ -46,6 +52,7 @@ func toTaxonomyWithCountResponse(row db.ListTaxonomiesWithPostCountRow) Taxonomy
 		ID:          row.ID,
 		Name:        row.Name,
 		Description: row.Description,
+		Version:     row.Version,
 		PostCount:   &row.PostCount,
 	}
 }
@@ -55,6 +62,7 @@ func toPopularTaxonomyResponse(row db.GetPopularTaxonomiesRow) PopularTaxonomyRe
 		ID:          row.ID,
 		Name:        row.Name,
 		Description: row.Description,
+		Version:     row.Version,
 		PostCount:   row.PostCount,
 	}
 }
@@ -114,6 +122,7 @@ func (server *Server) getTaxonomyByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, taxonomy.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"taxonomy": toTaxonomyResponse(taxonomy),
 	})
@@ -136,95 +145,62 @@ func (server *Server) getTaxonomyByName(c *gin.Context) {
 		return
 	}
 
+	setETag(c, taxonomy.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"taxonomy": toTaxonomyResponse(taxonomy),
 	})
 }
 
 func (server *Server) getTaxonomies(c *gin.Context) {
+	withCounts := c.DefaultQuery("with_counts", "false") == "true"
 
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-	withCounts := c.DefaultQuery("with_counts", "false")
-
-	limit, err := strconv.ParseInt(limitStr, 10, 32)
-	if err != nil || limit <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
-		return
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset, err := strconv.ParseInt(offsetStr, 10, 32)
-	if err != nil || offset < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+	page, ok := parseListPage(c, taxonomyListSchema)
+	if !ok {
 		return
 	}
 
-	if withCounts == "true" {
-		taxonomies, err := server.store.ListTaxonomiesWithPostCount(c.Request.Context(), db.ListTaxonomiesWithPostCountParams{
-			Limit:  int32(limit),
-			Offset: int32(offset),
-		})
+	var (
+		taxonomyResponses []TaxonomyResponse
+		cursors           gin.H
+	)
+	if withCounts {
+		taxonomies, err := server.store.ListTaxonomiesWithPostCountFiltered(c.Request.Context(), page.params())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list taxonomies"})
 			return
 		}
+		taxonomies, cursors = pageRows(page, taxonomies, taxonomyWithCountKey)
 
-		taxonomyResponses := make([]TaxonomyResponse, len(taxonomies))
+		taxonomyResponses = make([]TaxonomyResponse, len(taxonomies))
 		for i, taxonomy := range taxonomies {
 			taxonomyResponses[i] = toTaxonomyWithCountResponse(taxonomy)
 		}
-
-		totalCount, err := server.store.CountTotalTaxonomies(c.Request.Context())
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count total taxonomies"})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"taxonomies": taxonomyResponses,
-			"meta": gin.H{
-				"limit":       limit,
-				"offset":      offset,
-				"count":       len(taxonomyResponses),
-				"with_counts": true,
-				"total":       totalCount,
-			},
-		})
 	} else {
-		taxonomies, err := server.store.ListTaxonomies(c.Request.Context(), db.ListTaxonomiesParams{
-			Limit:  int32(limit),
-			Offset: int32(offset),
-		})
+		taxonomies, err := server.store.ListTaxonomiesFiltered(c.Request.Context(), page.params())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list taxonomies"})
 			return
 		}
+		taxonomies, cursors = pageRows(page, taxonomies, taxonomyKey)
 
-		taxonomyResponses := make([]TaxonomyResponse, len(taxonomies))
+		taxonomyResponses = make([]TaxonomyResponse, len(taxonomies))
 		for i, taxonomy := range taxonomies {
 			taxonomyResponses[i] = toTaxonomyResponse(taxonomy)
 		}
+	}
 
-		total, err := server.store.CountTotalTaxonomies(c.Request.Context())
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count total taxonomies"})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"taxonomies": taxonomyResponses,
-			"meta": gin.H{
-				"limit":       limit,
-				"offset":      offset,
-				"count":       len(taxonomyResponses),
-				"with_counts": false,
-				"total":       total,
-			},
-		})
+	total, err := server.listTotal(c.Request.Context(), page, "taxonomies", func() (int64, error) {
+		return server.store.CountTaxonomiesFiltered(c.Request.Context(), page.query.CountFilter())
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count total taxonomies"})
+		return
 	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"taxonomies": taxonomyResponses,
+		"meta":       page.meta(len(taxonomyResponses), total, cursors, gin.H{"with_counts": withCounts}),
+	})
 }
 
 func (server *Server) getPopularTaxonomies(c *gin.Context) {
@@ -284,11 +260,16 @@ func (server *Server) searchTaxonomies(c *gin.Context) {
 		return
 	}
 
-	taxonomies, err := server.store.SearchTaxonomiesByName(c.Request.Context(), db.SearchTaxonomiesByNameParams{
-		Column1: sql.NullString{String: query, Valid: true},
-		Limit:   int32(limit),
-		Offset:  int32(offset),
-	})
+	var taxonomies []db.Taxonomy
+	if server.searchIndex != nil {
+		taxonomies, err = server.searchTaxonomiesInIndex(c.Request.Context(), query, int(limit), int(offset))
+	} else {
+		taxonomies, err = server.store.SearchTaxonomiesByName(c.Request.Context(), db.SearchTaxonomiesByNameParams{
+			Column1: sql.NullString{String: query, Valid: true},
+			Limit:   int32(limit),
+			Offset:  int32(offset),
+		})
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search taxonomies"})
 		return
@@ -324,6 +305,11 @@ func (server *Server) updateTaxonomy(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	existingTaxonomy, err := server.store.GetTaxonomy(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -350,6 +336,8 @@ func (server *Server) updateTaxonomy(c *gin.Context) {
 		ID:          id,
 		Name:        existingTaxonomy.Name,
 		Description: existingTaxonomy.Description,
+
+		ExpectedVersion: expectedVersion,
 	}
 
 	if req.Name != "" {
@@ -361,6 +349,10 @@ func (server *Server) updateTaxonomy(c *gin.Context) {
 
 	updatedTaxonomy, err := server.store.UpdateTaxonomy(c.Request.Context(), updateParams)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			preconditionFailed(c)
+			return
+		}
 		if isUniqueViolation(err) {
 			c.JSON(http.StatusConflict, gin.H{"error": "taxonomy name already exists"})
 			return
@@ -369,6 +361,7 @@ func (server *Server) updateTaxonomy(c *gin.Context) {
 		return
 	}
 
+	setETag(c, updatedTaxonomy.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"taxonomy": toTaxonomyResponse(updatedTaxonomy),
 	})
@@ -382,6 +375,11 @@ func (server *Server) deleteTaxonomy(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	_, err = server.store.GetTaxonomy(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -408,16 +406,28 @@ func (server *Server) deleteTaxonomy(c *gin.Context) {
 		return
 	}
 
+	// Associations are removed in the same transaction as the taxonomy, so a
+	// failed If-Match leaves them in place.
 	if forceDelete && postCount > 0 {
-		err = server.store.DeleteTaxonomyPosts(c.Request.Context(), id)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove taxonomy associations"})
-			return
+		err = server.store.DeleteTaxonomyTx(c.Request.Context(), db.DeleteTaxonomyTxParams{
+			ID:              id,
+			ExpectedVersion: expectedVersion,
+		})
+	} else {
+		var rows int64
+		rows, err = server.store.DeleteTaxonomy(c.Request.Context(), db.DeleteTaxonomyParams{
+			ID:              id,
+			ExpectedVersion: expectedVersion,
+		})
+		if err == nil && rows == 0 {
+			err = sql.ErrNoRows
 		}
 	}
-
-	err = server.store.DeleteTaxonomy(c.Request.Context(), id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			preconditionFailed(c)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete taxonomy"})
 		return
 	}
@@ -452,6 +462,10 @@ func (server *Server) getTaxonomyPosts(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
 		return
 	}
+	includes, ok := parsePostIncludes(c)
+	if !ok {
+		return
+	}
 
 	taxonomy, err := server.store.GetTaxonomy(c.Request.Context(), id)
 	if err != nil {
@@ -463,8 +477,14 @@ func (server *Server) getTaxonomyPosts(c *gin.Context) {
 		return
 	}
 
+	status, ok := postStatusFilter(c, 0)
+	if !ok {
+		return
+	}
+
 	posts, err := server.store.GetTaxonomyPosts(c.Request.Context(), db.GetTaxonomyPostsParams{
 		TaxonomyID: id,
+		Status:     status,
 		Limit:      int32(limit),
 		Offset:     int32(offset),
 	})
@@ -477,6 +497,10 @@ func (server *Server) getTaxonomyPosts(c *gin.Context) {
 	for i, post := range posts {
 		postResponses[i] = toPostResponse(post)
 	}
+	if err := includes.embed(c.Request.Context(), server, postResponses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load included resources"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"taxonomy": toTaxonomyResponse(taxonomy),
@@ -498,13 +522,8 @@ func (server *Server) getPostTaxonomies(c *gin.Context) {
 		return
 	}
 
-	post, err := server.store.GetPost(c.Request.Context(), id)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get post"})
+	post, ok := server.getViewablePost(c, id)
+	if !ok {
 		return
 	}
 
@@ -528,3 +547,27 @@ func (server *Server) getPostTaxonomies(c *gin.Context) {
 		},
 	})
 }
+
+// searchTaxonomiesInIndex returns the taxonomies matching query in the
+// SEARCH_DRIVER index, in ranked order.
+func (server *Server) searchTaxonomiesInIndex(ctx context.Context, query string, limit, offset int) ([]db.Taxonomy, error) {
+	result, err := server.searchIndex.Query(ctx, search.KindTaxonomies, search.Query{Text: query, Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := server.store.ListTaxonomiesByIDs(ctx, hitIDs(result.Hits))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]db.Taxonomy, len(rows))
+	for _, taxonomy := range rows {
+		byID[taxonomy.ID] = taxonomy
+	}
+	taxonomies := make([]db.Taxonomy, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		if taxonomy, ok := byID[hit.ID]; ok {
+			taxonomies = append(taxonomies, taxonomy)
+		}
+	}
+	return taxonomies, nil
+}
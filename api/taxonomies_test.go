@@ -17,6 +17,7 @@ import (
 
 	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
 	"github.com/go-live-cms/go-live-cms/token"
 )
 
@@ -50,6 +51,7 @@ func TestCreateTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
 
 				store.EXPECT().
 					GetTaxonomyByName(gomock.Any(), gomock.Eq(taxonomy.Name)).
@@ -98,6 +100,7 @@ func TestCreateTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
 
 				store.EXPECT().
 					GetTaxonomyByName(gomock.Any(), gomock.Eq(taxonomy.Name)).
@@ -118,6 +121,8 @@ func TestCreateTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetTaxonomyByName(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -136,6 +141,8 @@ func TestCreateTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetTaxonomyByName(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -284,6 +291,8 @@ func TestListTaxonomiesAPI(t *testing.T) {
 		}
 	}
 
+	taxonomyFilter := db.ListFilter{Where: "TRUE", OrderBy: "name ASC, id ASC"}
+
 	testCases := []struct {
 		name          string
 		query         string
@@ -295,14 +304,15 @@ func TestListTaxonomiesAPI(t *testing.T) {
 			query: "?limit=5&offset=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListTaxonomies(gomock.Any(), db.ListTaxonomiesParams{
-						Limit:  5,
+					ListTaxonomiesFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: taxonomyFilter,
+						Limit:  6,
 						Offset: 0,
 					}).
 					Times(1).
 					Return(taxonomies, nil)
 				store.EXPECT().
-					CountTotalTaxonomies(gomock.Any()).
+					CountTaxonomiesFiltered(gomock.Any(), countFilter(taxonomyFilter)).
 					Times(1).
 					Return(int64(100), nil)
 			},
@@ -316,14 +326,15 @@ func TestListTaxonomiesAPI(t *testing.T) {
 			query: "?limit=5&offset=0&with_counts=true",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListTaxonomiesWithPostCount(gomock.Any(), db.ListTaxonomiesWithPostCountParams{
-						Limit:  5,
+					ListTaxonomiesWithPostCountFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: taxonomyFilter,
+						Limit:  6,
 						Offset: 0,
 					}).
 					Times(1).
 					Return(taxonomiesWithCount, nil)
 				store.EXPECT().
-					CountTotalTaxonomies(gomock.Any()).
+					CountTaxonomiesFiltered(gomock.Any(), countFilter(taxonomyFilter)).
 					Times(1).
 					Return(int64(150), nil)
 			},
@@ -337,7 +348,7 @@ func TestListTaxonomiesAPI(t *testing.T) {
 			query: "?limit=5&offset=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListTaxonomies(gomock.Any(), gomock.Any()).
+					ListTaxonomiesFiltered(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return([]db.Taxonomy{}, sql.ErrConnDone)
 			},
@@ -345,12 +356,50 @@ func TestListTaxonomiesAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name:  "FilterAndSort",
+			query: "?filter[name][contains]=50%25_off&sort=-id",
+			buildStubs: func(store *mockdb.MockStore) {
+				filter := db.ListFilter{
+					Where:   "name ILIKE $1",
+					Args:    []interface{}{`%50\%\_off%`},
+					OrderBy: "id DESC",
+				}
+				store.EXPECT().
+					ListTaxonomiesFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: filter,
+						Limit:  11,
+						Offset: 0,
+					}).
+					Times(1).
+					Return(taxonomies, nil)
+				store.EXPECT().
+					CountTaxonomiesFiltered(gomock.Any(), countFilter(filter)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidFilterValue",
+			query: "?filter[id]=abc",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTaxonomiesFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name:  "InvalidLimit",
 			query: "?limit=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListTaxonomies(gomock.Any(), gomock.Any()).
+					ListTaxonomiesFiltered(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -534,6 +583,7 @@ func TestSearchTaxonomiesAPI(t *testing.T) {
 func TestUpdateTaxonomyAPI(t *testing.T) {
 	taxonomy := randomTaxonomy()
 	user := randomUserNew()
+	user.Role = policy.RoleModerator
 	newName := gofakeit.BuzzWord()
 	newDescription := gofakeit.Sentence(10)
 
@@ -556,6 +606,7 @@ func TestUpdateTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
 
 				store.EXPECT().
 					GetTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
@@ -608,6 +659,8 @@ func TestUpdateTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
 					Times(1).
@@ -627,6 +680,8 @@ func TestUpdateTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
 					Times(1).
@@ -674,6 +729,7 @@ func TestUpdateTaxonomyAPI(t *testing.T) {
 func TestDeleteTaxonomyAPI(t *testing.T) {
 	taxonomy := randomTaxonomy()
 	user := randomUserNew()
+	user.Role = policy.RoleModerator
 
 	testCases := []struct {
 		name          string
@@ -691,6 +747,8 @@ func TestDeleteTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
 					Times(1).
@@ -702,14 +760,48 @@ func TestDeleteTaxonomyAPI(t *testing.T) {
 					Return(int64(0), nil)
 
 				store.EXPECT().
-					DeleteTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
+					DeleteTaxonomy(gomock.Any(), gomock.Eq(db.DeleteTaxonomyParams{ID: taxonomy.ID})).
 					Times(1).
-					Return(nil)
+					Return(int64(1), nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name:       "StaleIfMatch",
+			taxonomyID: taxonomy.ID,
+			query:      "",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				request.Header.Set("If-Match", `"1"`)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					GetTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
+					Times(1).
+					Return(taxonomy, nil)
+
+				store.EXPECT().
+					GetTaxonomyPostCount(gomock.Any(), gomock.Eq(taxonomy.ID)).
+					Times(1).
+					Return(int64(0), nil)
+
+				arg := db.DeleteTaxonomyParams{
+					ID:              taxonomy.ID,
+					ExpectedVersion: sql.NullInt64{Int64: 1, Valid: true},
+				}
+				store.EXPECT().
+					DeleteTaxonomy(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
 		{
 			name:       "NoAuthorization",
 			taxonomyID: taxonomy.ID,
@@ -734,6 +826,8 @@ func TestDeleteTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
 					Times(1).
@@ -756,6 +850,8 @@ func TestDeleteTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
 					Times(1).
@@ -767,12 +863,7 @@ func TestDeleteTaxonomyAPI(t *testing.T) {
 					Return(int64(5), nil)
 
 				store.EXPECT().
-					DeleteTaxonomyPosts(gomock.Any(), gomock.Eq(taxonomy.ID)).
-					Times(1).
-					Return(nil)
-
-				store.EXPECT().
-					DeleteTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
+					DeleteTaxonomyTx(gomock.Any(), gomock.Eq(db.DeleteTaxonomyTxParams{ID: taxonomy.ID})).
 					Times(1).
 					Return(nil)
 			},
@@ -788,6 +879,8 @@ func TestDeleteTaxonomyAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetTaxonomy(gomock.Any(), gomock.Eq(taxonomy.ID)).
 					Times(1).
@@ -849,6 +942,7 @@ func TestGetTaxonomyPostsAPI(t *testing.T) {
 				store.EXPECT().
 					GetTaxonomyPosts(gomock.Any(), db.GetTaxonomyPostsParams{
 						TaxonomyID: taxonomy.ID,
+						Status:     sql.NullString{String: policy.PostStatusPublished, Valid: true},
 						Limit:      10,
 						Offset:     0,
 					}).
@@ -901,12 +995,16 @@ func TestGetTaxonomyPostsAPI(t *testing.T) {
 func TestGetPostTaxonomiesAPI(t *testing.T) {
 	user := randomUserForPosts()
 	post := randomPost(user)
+	draft := randomPost(user)
+	draft.Status = policy.PostStatusDraft
+	draft.PublishedAt = sql.NullTime{}
 	taxonomy := randomTaxonomy()
 	taxonomies := []db.Taxonomy{taxonomy}
 
 	testCases := []struct {
 		name          string
 		postID        int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
@@ -928,6 +1026,50 @@ func TestGetPostTaxonomiesAPI(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name:   "DraftHiddenFromAnonymous",
+			postID: draft.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return(draft, nil)
+
+				store.EXPECT().
+					GetPostTaxonomies(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "DraftVisibleToAuthor",
+			postID: draft.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+				store.EXPECT().
+					GetPost(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return(draft, nil)
+
+				store.EXPECT().
+					ListPostAuthors(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return([]db.UserPost{{PostID: draft.ID, UserID: user.ID}}, nil)
+
+				store.EXPECT().
+					GetPostTaxonomies(gomock.Any(), gomock.Eq(draft.ID)).
+					Times(1).
+					Return(taxonomies, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
 		{
 			name:   "PostNotFound",
 			postID: post.ID,
@@ -960,6 +1102,9 @@ func TestGetPostTaxonomiesAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
+			if tc.setupAuth != nil {
+				tc.setupAuth(t, request, server.tokenMaker)
+			}
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
 		})
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -10,12 +11,51 @@ import (
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenSymmetricKey:    "12345678901234567890123456789012",
+		TokenSymmetricKey:    "test-token-symmetric-key-0123456",
 		AccessTokenDuration:  time.Minute * 15,
 		RefreshTokenDuration: time.Hour * 24,
+		StorageDriver:        "local",
+		StorageLocalPath:     t.TempDir(),
+		StoragePublicURL:     "/uploads",
+		MediaMaxUploadSize:   1 << 20,
+		MediaRenditions:      "thumbnail=150x150,medium=640x640",
+		MediaRenditionsWebP:  true,
+		MediaTransformKey:    "test-media-transform-key-0123456",
+		WebBaseURL:           "http://localhost:4321",
+
+		RegistrationEnabled:       true,
+		EmailVerificationDuration: time.Hour,
+		PasswordResetDuration:     time.Hour,
+
+		MFAIssuer:        "GoLive CMS",
+		MFATokenDuration: 5 * time.Minute,
+
+		MFAMaxFailuresPerUser:  5,
+		MFAMaxFailuresPerToken: 3,
+
+		AuthCookieSecure:   true,
+		AuthCookieSameSite: "strict",
+
+		TokenRevocationDriver: "memory",
+
+		LoginLockoutDriver:          "memory",
+		LoginMaxFailuresPerUsername: 5,
+		LoginMaxFailuresPerIP:       20,
+		LoginBackoffBase:            time.Second,
+		LoginLockoutDuration:        15 * time.Minute,
+		LoginFailureWindow:          time.Hour,
+
+		APIKeyMaxDuration: 365 * 24 * time.Hour,
+
+		OIDCRedirectBaseURL: "http://localhost:8080/api/v1/auth/oidc",
+		OIDCStateDuration:   10 * time.Minute,
+
+		MailerDriver:  "log",
+		MailerFrom:    "GoLive CMS <no-reply@golive-cms.local>",
+		MailerLogPath: filepath.Join(t.TempDir(), "mail.log"),
 	}
 
-	server, err := NewServer(config, store)
+	server, err := NewServer(config, store, nil)
 	if err != nil {
 		t.Fatal("Failed to create test server:", err)
 	}
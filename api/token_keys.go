@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-live-cms/go-live-cms/token"
+)
+
+// tokenKeysMaxAge is how long verifiers may cache the published keys. A new
+// key should be published at least this long before it starts signing.
+const tokenKeysMaxAge = "public, max-age=300"
+
+type tokenKeyResponse struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Use       string `json:"use"`
+	PASERK    string `json:"paserk"`
+	Algorithm string `json:"alg"`
+}
+
+// listTokenKeys publishes the keys access tokens can be verified with, in
+// JWK form with the PASERK encoding alongside, so other services can accept
+// CMS tokens without calling back into the API.
+func (server *Server) listTokenKeys(ctx *gin.Context) {
+	publisher, ok := server.tokenMaker.(token.KeyPublisher)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "tokens are not signed with public keys"})
+		return
+	}
+
+	keys := []tokenKeyResponse{}
+	for _, key := range publisher.PublicKeys() {
+		keys = append(keys, tokenKeyResponse{
+			KeyID:     key.ID,
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.Key),
+			Use:       "sig",
+			PASERK:    key.PASERK(),
+			Algorithm: "v4.public",
+		})
+	}
+
+	ctx.Header("Cache-Control", tokenKeysMaxAge)
+	ctx.JSON(http.StatusOK, gin.H{"keys": keys})
+}
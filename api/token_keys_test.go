@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	"github.com/go-live-cms/go-live-cms/token"
+)
+
+func TestListTokenKeysAPI(t *testing.T) {
+	_, oldPublic, err := token.GenerateSigningKey()
+	require.NoError(t, err)
+	secret, public, err := token.GenerateSigningKey()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		setupServer   func(t *testing.T, server *Server)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupServer: func(t *testing.T, server *Server) {
+				keys, err := token.NewKeyRing(secret, []string{oldPublic.PASERK()})
+				require.NoError(t, err)
+				server.tokenMaker = token.NewPublicPasetoMaker(keys)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotEmpty(t, recorder.Header().Get("Cache-Control"))
+
+				var response struct {
+					Keys []tokenKeyResponse `json:"keys"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response.Keys, 2)
+				require.Equal(t, public.ID, response.Keys[0].KeyID)
+				require.Equal(t, public.PASERK(), response.Keys[0].PASERK)
+				require.Equal(t, "OKP", response.Keys[0].KeyType)
+				require.Equal(t, "Ed25519", response.Keys[0].Curve)
+				require.Equal(t, oldPublic.ID, response.Keys[1].KeyID)
+			},
+		},
+		{
+			name:        "SymmetricTokens",
+			setupServer: func(t *testing.T, server *Server) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.setupServer(t, server)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/.well-known/paseto-keys", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -17,6 +18,8 @@ import (
 
 	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
+	"github.com/go-live-cms/go-live-cms/storage"
 	"github.com/go-live-cms/go-live-cms/token"
 	"github.com/go-live-cms/go-live-cms/util"
 )
@@ -29,7 +32,7 @@ func randomUserNew() db.User {
 		FullName:          gofakeit.Name(),
 		Email:             gofakeit.Email(),
 		HashedPassword:    gofakeit.Password(true, true, true, true, false, 12),
-		PasswordChangedAt: gofakeit.Date(),
+		PasswordChangedAt: gofakeit.PastDate(),
 		CreatedAt:         gofakeit.Date(),
 		Role:              "user",
 	}
@@ -37,6 +40,9 @@ func randomUserNew() db.User {
 
 func TestCreateUserAPI(t *testing.T) {
 	user := randomUserNew()
+	admin := randomUserNew()
+	admin.ID = user.ID + 1
+	admin.Role = policy.RoleAdmin
 	password := "password123"
 
 	testCases := []struct {
@@ -56,14 +62,17 @@ func TestCreateUserAPI(t *testing.T) {
 				"role":      user.Role,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, admin)
+
 				arg := db.CreateUserParams{
-					Username: user.Username,
-					Email:    user.Email,
-					FullName: user.FullName,
-					Role:     user.Role,
+					Username:        user.Username,
+					Email:           user.Email,
+					FullName:        user.FullName,
+					Role:            user.Role,
+					EmailVerifiedAt: sql.NullTime{Valid: true},
 				}
 				store.EXPECT().
 					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
@@ -106,9 +115,11 @@ func TestCreateUserAPI(t *testing.T) {
 				"role":      user.Role,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, admin)
+
 				store.EXPECT().
 					CreateUser(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -128,9 +139,11 @@ func TestCreateUserAPI(t *testing.T) {
 				"role":      user.Role,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, admin)
+
 				store.EXPECT().
 					CreateUser(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -149,9 +162,11 @@ func TestCreateUserAPI(t *testing.T) {
 				"role":      user.Role,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, admin)
+
 				store.EXPECT().
 					CreateUser(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -170,9 +185,11 @@ func TestCreateUserAPI(t *testing.T) {
 				"role":      "invalid_role",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, admin)
+
 				store.EXPECT().
 					CreateUser(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -322,14 +339,15 @@ func TestListUsersAPI(t *testing.T) {
 			query: "?limit=5&offset=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListUsers(gomock.Any(), db.ListUsersParams{
-						Limit:  5,
+					ListUsersFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: db.ListFilter{Where: "TRUE", OrderBy: "id ASC"},
+						Limit:  6,
 						Offset: 0,
 					}).
 					Times(1).
 					Return(users, nil)
 				store.EXPECT().
-					CountTotalUsers(gomock.Any()).
+					CountUsersFiltered(gomock.Any(), db.ListFilter{Where: "TRUE"}).
 					Times(1).
 					Return(int64(100), nil)
 			},
@@ -343,7 +361,7 @@ func TestListUsersAPI(t *testing.T) {
 			query: "?limit=5&offset=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListUsers(gomock.Any(), gomock.Any()).
+					ListUsersFiltered(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return([]db.User{}, sql.ErrConnDone)
 			},
@@ -351,12 +369,50 @@ func TestListUsersAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name:  "FilterAndSort",
+			query: "?filter[role]=admin&sort=-created_at",
+			buildStubs: func(store *mockdb.MockStore) {
+				filter := db.ListFilter{
+					Where:   "role = $1",
+					Args:    []interface{}{"admin"},
+					OrderBy: "created_at DESC, id DESC",
+				}
+				store.EXPECT().
+					ListUsersFiltered(gomock.Any(), db.ListFilteredParams{
+						Filter: filter,
+						Limit:  11,
+						Offset: 0,
+					}).
+					Times(1).
+					Return(users, nil)
+				store.EXPECT().
+					CountUsersFiltered(gomock.Any(), countFilter(filter)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "CannotFilterOnPassword",
+			query: "?filter[hashed_password]=x",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsersFiltered(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name:  "InvalidLimit",
 			query: "?limit=0",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListUsers(gomock.Any(), gomock.Any()).
+					ListUsersFiltered(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -390,6 +446,11 @@ func TestListUsersAPI(t *testing.T) {
 
 func TestUpdateUserAPI(t *testing.T) {
 	user := randomUserNew()
+	otherUser := randomUserNew()
+	otherUser.ID = user.ID + 1
+	admin := randomUserNew()
+	admin.ID = user.ID + 2
+	admin.Role = policy.RoleAdmin
 	newUsername := gofakeit.Username()
 	newEmail := gofakeit.Email()
 
@@ -412,6 +473,7 @@ func TestUpdateUserAPI(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
 
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.ID)).
@@ -455,6 +517,8 @@ func TestUpdateUserAPI(t *testing.T) {
 				"username": newUsername,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
@@ -474,6 +538,8 @@ func TestUpdateUserAPI(t *testing.T) {
 				"username": newUsername,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
@@ -491,6 +557,109 @@ func TestUpdateUserAPI(t *testing.T) {
 				require.Equal(t, http.StatusConflict, recorder.Code)
 			},
 		},
+		{
+			name:   "UpdateOtherUserForbidden",
+			userID: otherUser.ID,
+			body: gin.H{
+				"username": newUsername,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(otherUser.ID)).
+					Times(0)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "PromoteSelfForbidden",
+			userID: user.ID,
+			body: gin.H{
+				"role": policy.RoleAdmin,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, user)
+
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "SendOwnRoleInLowercase",
+			userID: user.ID,
+			body: gin.H{
+				"role": "user",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				storedUser := user
+				storedUser.Role = "User"
+				expectAuthUser(store, storedUser)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(storedUser, nil)
+
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, "User", arg.Role)
+						return db.UpdateUserTxResult{User: storedUser}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "AdminChangesRole",
+			userID: otherUser.ID,
+			body: gin.H{
+				"role": policy.RoleModerator,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, admin)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(otherUser.ID)).
+					Times(1).
+					Return(otherUser, nil)
+
+				updatedUser := otherUser
+				updatedUser.Role = policy.RoleModerator
+
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{User: updatedUser}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -525,6 +694,7 @@ func TestDeleteUserAPI(t *testing.T) {
 	user := randomUserNew()
 	adminUser := randomUserNew()
 	adminUser.ID = user.ID + 1
+	adminUser.Role = policy.RoleAdmin
 
 	testCases := []struct {
 		name          string
@@ -539,15 +709,25 @@ func TestDeleteUserAPI(t *testing.T) {
 			userID: user.ID,
 			body:   gin.H{},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminUser.ID, adminUser.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, adminUser)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
 					Return(user, nil)
 				store.EXPECT().
-					DeleteUserTx(gomock.Any(), gomock.Eq(user.ID)).
+					ListSessionsByUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return([]db.Session{randomSession(user)}, nil)
+				store.EXPECT().
+					ListMediaIDsByUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return([]int64{}, nil)
+				store.EXPECT().
+					DeleteUserTx(gomock.Any(), gomock.Eq(db.DeleteUserTxParams{ID: user.ID})).
 					Times(1).
 					Return(nil)
 			},
@@ -562,13 +742,19 @@ func TestDeleteUserAPI(t *testing.T) {
 				"transfer_to_id": adminUser.ID,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminUser.ID, adminUser.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, adminUser)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					ListSessionsByUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return([]db.Session{randomSession(user)}, nil)
 				store.EXPECT().
 					DeleteUserWithTransferTx(gomock.Any(), db.DeleteUserWithTransferTxParams{
 						UserID:       user.ID,
@@ -586,9 +772,11 @@ func TestDeleteUserAPI(t *testing.T) {
 			userID: user.ID,
 			body:   gin.H{},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminUser.ID, adminUser.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectAuthUser(store, adminUser)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
@@ -644,6 +832,51 @@ func TestDeleteUserAPI(t *testing.T) {
 	}
 }
 
+func TestDeleteUserRemovesMediaFiles(t *testing.T) {
+	user := randomUserNew()
+	adminUser := randomUserNew()
+	adminUser.ID = user.ID + 1
+	adminUser.Role = policy.RoleAdmin
+
+	image := randomMedia()
+	image.UserID = user.ID
+	image.StorageKey = "2026/01/cover.png"
+	image.MimeType = "image/png"
+	rendition := db.MediaRendition{MediaID: image.ID, Name: "thumbnail", StorageKey: "2026/01/cover_thumbnail.png"}
+	transformKey := transformPrefix(image.ID) + "/0123456789abcdef.jpg"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	expectAuthUser(store, adminUser)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+	store.EXPECT().ListSessionsByUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return([]db.Session{}, nil)
+	store.EXPECT().ListMediaIDsByUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return([]int64{image.ID}, nil)
+	store.EXPECT().ListMediaByIDs(gomock.Any(), []int64{image.ID}).Times(1).Return([]db.Medium{image}, nil)
+	store.EXPECT().ListMediaRenditions(gomock.Any(), []int64{image.ID}).Times(1).Return([]db.MediaRendition{rendition}, nil)
+	store.EXPECT().DeleteUserTx(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+	server := newTestServer(t, store)
+	keys := []string{image.StorageKey, rendition.StorageKey, transformKey}
+	for _, key := range keys {
+		_, err := server.storage.Put(context.Background(), key, bytes.NewReader([]byte("x")), 1, "image/png")
+		require.NoError(t, err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", user.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminUser.ID, adminUser.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	for _, key := range keys {
+		_, err := server.storage.Stat(context.Background(), key)
+		require.ErrorIs(t, err, storage.ErrNotFound, key)
+	}
+}
+
 func requireBodyMatchUser(t *testing.T, body string, user db.User) {
 	var response struct {
 		User UserResponse `json:"user"`
@@ -702,7 +935,8 @@ func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
 	return arg.Username == e.expected.Username &&
 		arg.Email == e.expected.Email &&
 		arg.FullName == e.expected.FullName &&
-		arg.Role == e.expected.Role
+		arg.Role == e.expected.Role &&
+		arg.EmailVerifiedAt.Valid == e.expected.EmailVerifiedAt.Valid
 }
 
 func (e eqCreateUserParamsMatcher) String() string {
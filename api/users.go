@@ -2,12 +2,14 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/policy"
 	"github.com/go-live-cms/go-live-cms/util"
 )
 
@@ -33,7 +35,10 @@ type UserResponse struct {
 	Email     string    `json:"email"`
 	FullName  string    `json:"full_name"`
 	Role      string    `json:"role"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 }
 
 type DeleteUserRequest struct {
@@ -47,7 +52,10 @@ func toUserResponse(user db.User) UserResponse {
 		Email:     user.Email,
 		FullName:  user.FullName,
 		Role:      user.Role,
+		Version:   user.Version,
 		CreatedAt: user.CreatedAt,
+
+		EmailVerifiedAt: nullTimePtr(user.EmailVerifiedAt),
 	}
 }
 
@@ -70,6 +78,8 @@ func (server *Server) createUser(c *gin.Context) {
 		FullName:       req.FullName,
 		HashedPassword: hashedPassword,
 		Role:           req.Role,
+		// Accounts created by an administrator skip email verification.
+		EmailVerifiedAt: sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
 	user, err := server.store.CreateUser(c.Request.Context(), arg)
@@ -105,6 +115,7 @@ func (server *Server) getUserByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"user": toUserResponse(user),
 	})
@@ -127,6 +138,7 @@ func (server *Server) getUserByUsername(c *gin.Context) {
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"user": toUserResponse(user),
 	})
@@ -149,46 +161,33 @@ func (server *Server) getUserByEmail(c *gin.Context) {
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"user": toUserResponse(user),
 	})
 }
 
 func (server *Server) getUsers(c *gin.Context) {
-
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, err := strconv.ParseInt(limitStr, 10, 32)
-	if err != nil || limit <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+	page, ok := parseListPage(c, userListSchema)
+	if !ok {
 		return
 	}
-	if limit > 100 {
-		limit = 100
-	}
 
-	offset, err := strconv.ParseInt(offsetStr, 10, 32)
-	if err != nil || offset < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
-		return
-	}
-
-	users, err := server.store.ListUsers(c.Request.Context(), db.ListUsersParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
-	})
+	users, err := server.store.ListUsersFiltered(c.Request.Context(), page.params())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users"})
 		return
 	}
+	users, cursors := pageRows(page, users, userKey)
 
 	userResponses := make([]UserResponse, len(users))
 	for i, user := range users {
 		userResponses[i] = toUserResponse(user)
 	}
 
-	totalCount, err := server.store.CountTotalUsers(c.Request.Context())
+	total, err := server.listTotal(c.Request.Context(), page, "users", func() (int64, error) {
+		return server.store.CountUsersFiltered(c.Request.Context(), page.query.CountFilter())
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count total users"})
 		return
@@ -196,12 +195,7 @@ func (server *Server) getUsers(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"users": userResponses,
-		"meta": gin.H{
-			"limit":  limit,
-			"offset": offset,
-			"count":  len(userResponses),
-			"total":  totalCount,
-		},
+		"meta":  page.meta(len(userResponses), total, cursors),
 	})
 }
 
@@ -219,6 +213,23 @@ func (server *Server) updateUser(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	authUser := c.MustGet(authorizationUserKey).(db.User)
+	if authUser.ID != id && !policy.HasPermission(authUser.Role, policy.PermissionUsersUpdate) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized to update this user"})
+		return
+	}
+	// Stored roles are capitalized ('User'), while requests use lowercase.
+	roleChanged := req.Role != "" && policy.NormalizeRole(req.Role) != policy.NormalizeRole(authUser.Role)
+	if roleChanged && !policy.HasPermission(authUser.Role, policy.PermissionUsersManageRole) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized to change user roles"})
+		return
+	}
+
 	existingUser, err := server.store.GetUser(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -237,6 +248,7 @@ func (server *Server) updateUser(c *gin.Context) {
 		HashedPassword:    existingUser.HashedPassword,
 		Role:              existingUser.Role,
 		PasswordChangedAt: existingUser.PasswordChangedAt,
+		ExpectedVersion:   expectedVersion,
 	}
 
 	if req.Username != "" {
@@ -248,7 +260,7 @@ func (server *Server) updateUser(c *gin.Context) {
 	if req.Email != "" {
 		updateParams.Email = req.Email
 	}
-	if req.Role != "" {
+	if req.Role != "" && policy.NormalizeRole(req.Role) != policy.NormalizeRole(existingUser.Role) {
 		updateParams.Role = req.Role
 	}
 	if req.Password != "" {
@@ -266,6 +278,10 @@ func (server *Server) updateUser(c *gin.Context) {
 		CheckUniqueness:  true,
 	})
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			preconditionFailed(c)
+			return
+		}
 		if isUniqueViolation(err) || containsString(err.Error(), "already exists") {
 			c.JSON(http.StatusConflict, gin.H{"error": "username or email already exists"})
 			return
@@ -274,6 +290,14 @@ func (server *Server) updateUser(c *gin.Context) {
 		return
 	}
 
+	if req.Password != "" {
+		if err := server.revokeUserTokens(c.Request.Context(), id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke access tokens"})
+			return
+		}
+	}
+
+	setETag(c, result.User.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"user": toUserResponse(result.User),
 	})
@@ -293,6 +317,11 @@ func (server *Server) deleteUser(c *gin.Context) {
 		req = DeleteUserRequest{}
 	}
 
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	_, err = server.store.GetUser(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -303,25 +332,68 @@ func (server *Server) deleteUser(c *gin.Context) {
 		return
 	}
 
+	// The sessions are deleted along with the user, so collect them first to
+	// revoke their access tokens afterwards.
+	sessions, err := server.store.ListSessionsByUser(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get sessions"})
+		return
+	}
+
+	// Without a transfer the user's media are deleted too, and their files
+	// are removed from storage once that has committed.
+	var mediaKeys, mediaPrefixes []string
+	if req.TransferToID == nil {
+		mediaKeys, mediaPrefixes, err = server.userMediaFiles(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user media"})
+			return
+		}
+	}
+
 	if req.TransferToID != nil {
 
 		err = server.store.DeleteUserWithTransferTx(c.Request.Context(), db.DeleteUserWithTransferTxParams{
-			UserID:       id,
-			TransferToID: *req.TransferToID,
+			UserID:          id,
+			TransferToID:    *req.TransferToID,
+			ExpectedVersion: expectedVersion,
 		})
 		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				preconditionFailed(c)
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user with transfer"})
 			return
 		}
 	} else {
 
-		err = server.store.DeleteUserTx(c.Request.Context(), id)
+		err = server.store.DeleteUserTx(c.Request.Context(), db.DeleteUserTxParams{
+			ID:              id,
+			ExpectedVersion: expectedVersion,
+		})
 		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				preconditionFailed(c)
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
 			return
 		}
 	}
 
+	for _, key := range mediaKeys {
+		server.removeStoredFile(c, key)
+	}
+	for _, prefix := range mediaPrefixes {
+		server.removeStoredPrefix(c, prefix)
+	}
+
+	if err := server.revokeTokensForSessions(c.Request.Context(), sessions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke access tokens"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "user deleted successfully",
 	})
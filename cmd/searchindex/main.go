@@ -0,0 +1,53 @@
+// Command searchindex rebuilds the SEARCH_DRIVER index from the database. Run
+// it after switching to an external engine, or whenever the index has drifted
+// from the database.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/search"
+	"github.com/go-live-cms/go-live-cms/util"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	config, err := util.LoadConfig(".")
+	if err != nil {
+		log.Fatal("❌ Cannot load config:", err)
+	}
+
+	indexer, err := search.New(config)
+	if err != nil {
+		log.Fatal("❌ Cannot set up search:", err)
+	}
+	if indexer == nil {
+		log.Println("SEARCH_DRIVER is postgres, which indexes posts itself; nothing to rebuild")
+		return
+	}
+
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	if err != nil {
+		log.Fatal("❌ Cannot connect to db:", err)
+	}
+	defer conn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Println("🔎 Rebuilding", config.SearchDriver, "search index...")
+	counts, err := search.Rebuild(ctx, db.NewStore(conn), indexer)
+	if err != nil {
+		log.Fatal("❌ Cannot rebuild search index:", err)
+	}
+	for _, kind := range search.Kinds {
+		log.Printf("✅ Indexed %d %s", counts[kind], kind)
+	}
+}
@@ -0,0 +1,22 @@
+// Command tokenkey generates an Ed25519 key pair for TOKEN_DRIVER=v4.public.
+// The secret key goes in TOKEN_SIGNING_KEY; the public key is what to add to
+// TOKEN_VERIFICATION_KEYS on other instances while rotating.
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/go-live-cms/go-live-cms/token"
+)
+
+func main() {
+	secret, public, err := token.GenerateSigningKey()
+	if err != nil {
+		log.Fatal("❌ Cannot generate key:", err)
+	}
+
+	fmt.Printf("TOKEN_SIGNING_KEY=%s\n", secret)
+	fmt.Printf("# public key: %s\n", public.PASERK())
+	fmt.Printf("# key id:     %s\n", public.ID)
+}
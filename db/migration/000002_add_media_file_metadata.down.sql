@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS "media_checksum_idx";
+
+ALTER TABLE "media" DROP COLUMN IF EXISTS "height";
+ALTER TABLE "media" DROP COLUMN IF EXISTS "width";
+ALTER TABLE "media" DROP COLUMN IF EXISTS "checksum";
+ALTER TABLE "media" DROP COLUMN IF EXISTS "mime_type";
+ALTER TABLE "media" DROP COLUMN IF EXISTS "size";
+ALTER TABLE "media" DROP COLUMN IF EXISTS "storage_key";
@@ -0,0 +1,8 @@
+ALTER TABLE "media" ADD COLUMN "storage_key" varchar NOT NULL DEFAULT '';
+ALTER TABLE "media" ADD COLUMN "size" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "media" ADD COLUMN "mime_type" varchar NOT NULL DEFAULT '';
+ALTER TABLE "media" ADD COLUMN "checksum" varchar NOT NULL DEFAULT '';
+ALTER TABLE "media" ADD COLUMN "width" int;
+ALTER TABLE "media" ADD COLUMN "height" int;
+
+CREATE INDEX "media_checksum_idx" ON "media" ("checksum");
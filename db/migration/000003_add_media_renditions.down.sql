@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "unique_media_rendition";
+
+DROP TABLE IF EXISTS "media_renditions";
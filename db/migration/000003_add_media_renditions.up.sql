@@ -0,0 +1,16 @@
+CREATE TABLE "media_renditions" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "media_id" bigint NOT NULL,
+  "name" varchar NOT NULL,
+  "format" varchar NOT NULL,
+  "storage_key" varchar NOT NULL,
+  "mime_type" varchar NOT NULL,
+  "width" int NOT NULL,
+  "height" int NOT NULL,
+  "size" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX "unique_media_rendition" ON "media_renditions" ("media_id", "name", "format");
+
+ALTER TABLE "media_renditions" ADD FOREIGN KEY ("media_id") REFERENCES "media" ("id") ON DELETE CASCADE;
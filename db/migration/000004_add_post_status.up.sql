@@ -0,0 +1,10 @@
+ALTER TABLE "posts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'draft';
+ALTER TABLE "posts" ADD COLUMN "published_at" timestamptz NULL;
+
+ALTER TABLE "posts" ADD CONSTRAINT "posts_status_check"
+  CHECK ("status" IN ('draft', 'pending_review', 'scheduled', 'published', 'archived'));
+
+-- Every existing post was public, so keep it that way.
+UPDATE "posts" SET "status" = 'published', "published_at" = "created_at";
+
+CREATE INDEX "posts_status_idx" ON "posts" ("status");
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "posts_unpublish_at_idx";
+DROP INDEX IF EXISTS "posts_publish_at_idx";
+
+ALTER TABLE "posts" DROP CONSTRAINT IF EXISTS "posts_schedule_check";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "unpublish_at";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "publish_at";
@@ -0,0 +1,9 @@
+ALTER TABLE "posts" ADD COLUMN "publish_at" timestamptz NULL;
+ALTER TABLE "posts" ADD COLUMN "unpublish_at" timestamptz NULL;
+
+ALTER TABLE "posts" ADD CONSTRAINT "posts_schedule_check"
+  CHECK ("publish_at" IS NULL OR "unpublish_at" IS NULL OR "unpublish_at" > "publish_at");
+
+-- The scheduler only ever looks for due rows in these two states.
+CREATE INDEX "posts_publish_at_idx" ON "posts" ("publish_at") WHERE "status" = 'scheduled';
+CREATE INDEX "posts_unpublish_at_idx" ON "posts" ("unpublish_at") WHERE "status" = 'published' AND "unpublish_at" IS NOT NULL;
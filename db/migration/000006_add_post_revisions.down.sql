@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "unique_post_revision";
+
+DROP TABLE IF EXISTS "post_revisions";
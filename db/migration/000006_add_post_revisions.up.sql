@@ -0,0 +1,20 @@
+CREATE TABLE "post_revisions" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "post_id" bigint NOT NULL,
+  "revision" int NOT NULL,
+  "title" varchar NOT NULL,
+  "description" varchar NOT NULL,
+  "content" text NOT NULL,
+  "url" varchar NOT NULL,
+  "user_id" bigint NULL,
+  "username" varchar NOT NULL,
+  "restored_from" int NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX "unique_post_revision" ON "post_revisions" ("post_id", "revision");
+
+ALTER TABLE "post_revisions" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE;
+
+-- Keep the history when an editor's account is removed.
+ALTER TABLE "post_revisions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE "media" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "taxonomies" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "version";
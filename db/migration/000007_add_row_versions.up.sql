@@ -0,0 +1,6 @@
+-- version is bumped by every update and checked against If-Match, so
+-- concurrent edits fail instead of silently overwriting each other.
+ALTER TABLE "users" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "posts" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "taxonomies" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "media" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,20 @@
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamptz NULL;
+
+-- Accounts that existed before self-service registration were created by an
+-- administrator, so they are trusted as verified.
+UPDATE "users" SET "email_verified_at" = "created_at";
+
+CREATE TABLE "email_verification_tokens" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "token_hash" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX "unique_email_verification_token_hash" ON "email_verification_tokens" ("token_hash");
+
+CREATE INDEX ON "email_verification_tokens" ("user_id");
+
+ALTER TABLE "email_verification_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,14 @@
+CREATE TABLE "password_reset_tokens" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "token_hash" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX "unique_password_reset_token_hash" ON "password_reset_tokens" ("token_hash");
+
+CREATE INDEX ON "password_reset_tokens" ("user_id");
+
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
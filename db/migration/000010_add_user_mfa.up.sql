@@ -0,0 +1,21 @@
+CREATE TABLE "user_mfa" (
+  "user_id" bigint PRIMARY KEY,
+  "totp_secret" varchar NOT NULL,
+  "enabled_at" timestamptz NULL,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_mfa" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE TABLE "mfa_recovery_codes" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "used_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX "unique_mfa_recovery_code" ON "mfa_recovery_codes" ("user_id", "code_hash");
+
+ALTER TABLE "mfa_recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
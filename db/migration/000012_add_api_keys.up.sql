@@ -0,0 +1,18 @@
+CREATE TABLE "api_keys" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "name" varchar NOT NULL,
+  "prefix" varchar NOT NULL,
+  "key_hash" varchar NOT NULL,
+  "scopes" text[] NOT NULL DEFAULT '{}',
+  "expires_at" timestamptz NOT NULL,
+  "last_used_at" timestamptz NULL,
+  "revoked_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX "unique_api_key_hash" ON "api_keys" ("key_hash");
+
+CREATE INDEX ON "api_keys" ("user_id");
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,9 @@
+CREATE TABLE "login_failures" (
+  "kind" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "failures" integer NOT NULL DEFAULT 0,
+  "last_failure_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("kind", "subject")
+);
+
+CREATE INDEX ON "login_failures" ("last_failure_at");
@@ -0,0 +1,13 @@
+-- language picks the text search configuration (stemming and stop words) a
+-- post is indexed with. It is a regconfig rather than a name so the
+-- generated column below stays immutable.
+ALTER TABLE "posts" ADD COLUMN "language" regconfig NOT NULL DEFAULT 'english';
+
+-- Title matches outrank description matches, which outrank content matches.
+ALTER TABLE "posts" ADD COLUMN "search_vector" tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector("language", "title"), 'A') ||
+  setweight(to_tsvector("language", "description"), 'B') ||
+  setweight(to_tsvector("language", "content"), 'C')
+) STORED;
+
+CREATE INDEX "posts_search_vector_idx" ON "posts" USING GIN ("search_vector");
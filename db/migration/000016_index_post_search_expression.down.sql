@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS "posts_search_idx";
+DROP FUNCTION IF EXISTS post_search_vector(regconfig, varchar, varchar, text);
+
+ALTER TABLE "posts" ADD COLUMN "search_vector" tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector("language", "title"), 'A') ||
+  setweight(to_tsvector("language", "description"), 'B') ||
+  setweight(to_tsvector("language", "content"), 'C')
+) STORED;
+
+CREATE INDEX "posts_search_vector_idx" ON "posts" USING GIN ("search_vector");
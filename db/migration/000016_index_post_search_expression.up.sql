@@ -0,0 +1,18 @@
+-- The stored search_vector column was read along with every post although
+-- only searches use it, roughly doubling the size of each row fetched. The
+-- vector is now computed by a function the search queries call, and the
+-- index is built on that expression instead.
+DROP INDEX IF EXISTS "posts_search_vector_idx";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "search_vector";
+
+-- Title matches outrank description matches, which outrank content matches.
+CREATE FUNCTION post_search_vector(language regconfig, title varchar, description varchar, content text)
+RETURNS tsvector
+LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$
+  SELECT setweight(to_tsvector(language, title), 'A') ||
+         setweight(to_tsvector(language, description), 'B') ||
+         setweight(to_tsvector(language, content), 'C')
+$$;
+
+CREATE INDEX "posts_search_idx" ON "posts" USING GIN (post_search_vector("language", "title", "description", "content"));
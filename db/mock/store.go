@@ -6,7 +6,9 @@ package mockdb
 
 import (
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
 	gomock "github.com/golang/mock/gomock"
@@ -36,6 +38,21 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// ArchiveExpiredPosts mocks base method.
+func (m *MockStore) ArchiveExpiredPosts(arg0 context.Context, arg1 int32) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveExpiredPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveExpiredPosts indicates an expected call of ArchiveExpiredPosts.
+func (mr *MockStoreMockRecorder) ArchiveExpiredPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveExpiredPosts", reflect.TypeOf((*MockStore)(nil).ArchiveExpiredPosts), arg0, arg1)
+}
+
 // BlockSession mocks base method.
 func (m *MockStore) BlockSession(arg0 context.Context, arg1 uuid.UUID) error {
 	m.ctrl.T.Helper()
@@ -50,6 +67,170 @@ func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
 }
 
+// BlockUserSessions mocks base method.
+func (m *MockStore) BlockUserSessions(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockUserSessions indicates an expected call of BlockUserSessions.
+func (mr *MockStoreMockRecorder) BlockUserSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessions", reflect.TypeOf((*MockStore)(nil).BlockUserSessions), arg0, arg1)
+}
+
+// ConfirmMFAEnrollmentTx mocks base method.
+func (m *MockStore) ConfirmMFAEnrollmentTx(arg0 context.Context, arg1 db.ConfirmMFAEnrollmentTxParams) (db.UserMfa, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmMFAEnrollmentTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UserMfa)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmMFAEnrollmentTx indicates an expected call of ConfirmMFAEnrollmentTx.
+func (mr *MockStoreMockRecorder) ConfirmMFAEnrollmentTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmMFAEnrollmentTx", reflect.TypeOf((*MockStore)(nil).ConfirmMFAEnrollmentTx), arg0, arg1)
+}
+
+// ConsumeEmailVerificationToken mocks base method.
+func (m *MockStore) ConsumeEmailVerificationToken(arg0 context.Context, arg1 string) (db.EmailVerificationToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeEmailVerificationToken", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailVerificationToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeEmailVerificationToken indicates an expected call of ConsumeEmailVerificationToken.
+func (mr *MockStoreMockRecorder) ConsumeEmailVerificationToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeEmailVerificationToken", reflect.TypeOf((*MockStore)(nil).ConsumeEmailVerificationToken), arg0, arg1)
+}
+
+// ConsumeMFARecoveryCode mocks base method.
+func (m *MockStore) ConsumeMFARecoveryCode(arg0 context.Context, arg1 db.ConsumeMFARecoveryCodeParams) (db.MfaRecoveryCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeMFARecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(db.MfaRecoveryCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeMFARecoveryCode indicates an expected call of ConsumeMFARecoveryCode.
+func (mr *MockStoreMockRecorder) ConsumeMFARecoveryCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeMFARecoveryCode", reflect.TypeOf((*MockStore)(nil).ConsumeMFARecoveryCode), arg0, arg1)
+}
+
+// ConsumeOIDCLoginState mocks base method.
+func (m *MockStore) ConsumeOIDCLoginState(arg0 context.Context, arg1 string) (db.OidcLoginState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeOIDCLoginState", arg0, arg1)
+	ret0, _ := ret[0].(db.OidcLoginState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeOIDCLoginState indicates an expected call of ConsumeOIDCLoginState.
+func (mr *MockStoreMockRecorder) ConsumeOIDCLoginState(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeOIDCLoginState", reflect.TypeOf((*MockStore)(nil).ConsumeOIDCLoginState), arg0, arg1)
+}
+
+// ConsumePasswordResetToken mocks base method.
+func (m *MockStore) ConsumePasswordResetToken(arg0 context.Context, arg1 string) (db.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePasswordResetToken", arg0, arg1)
+	ret0, _ := ret[0].(db.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumePasswordResetToken indicates an expected call of ConsumePasswordResetToken.
+func (mr *MockStoreMockRecorder) ConsumePasswordResetToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePasswordResetToken", reflect.TypeOf((*MockStore)(nil).ConsumePasswordResetToken), arg0, arg1)
+}
+
+// CountMediaFiltered mocks base method.
+func (m *MockStore) CountMediaFiltered(arg0 context.Context, arg1 db.ListFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountMediaFiltered", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountMediaFiltered indicates an expected call of CountMediaFiltered.
+func (mr *MockStoreMockRecorder) CountMediaFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountMediaFiltered", reflect.TypeOf((*MockStore)(nil).CountMediaFiltered), arg0, arg1)
+}
+
+// CountPostRevisions mocks base method.
+func (m *MockStore) CountPostRevisions(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPostRevisions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPostRevisions indicates an expected call of CountPostRevisions.
+func (mr *MockStoreMockRecorder) CountPostRevisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPostRevisions", reflect.TypeOf((*MockStore)(nil).CountPostRevisions), arg0, arg1)
+}
+
+// CountPostsFiltered mocks base method.
+func (m *MockStore) CountPostsFiltered(arg0 context.Context, arg1 db.ListFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPostsFiltered", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPostsFiltered indicates an expected call of CountPostsFiltered.
+func (mr *MockStoreMockRecorder) CountPostsFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPostsFiltered", reflect.TypeOf((*MockStore)(nil).CountPostsFiltered), arg0, arg1)
+}
+
+// CountSearchPosts mocks base method.
+func (m *MockStore) CountSearchPosts(arg0 context.Context, arg1 db.CountSearchPostsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountSearchPosts", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountSearchPosts indicates an expected call of CountSearchPosts.
+func (mr *MockStoreMockRecorder) CountSearchPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountSearchPosts", reflect.TypeOf((*MockStore)(nil).CountSearchPosts), arg0, arg1)
+}
+
+// CountTaxonomiesFiltered mocks base method.
+func (m *MockStore) CountTaxonomiesFiltered(arg0 context.Context, arg1 db.ListFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTaxonomiesFiltered", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTaxonomiesFiltered indicates an expected call of CountTaxonomiesFiltered.
+func (mr *MockStoreMockRecorder) CountTaxonomiesFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTaxonomiesFiltered", reflect.TypeOf((*MockStore)(nil).CountTaxonomiesFiltered), arg0, arg1)
+}
+
 // CountTotalMedia mocks base method.
 func (m *MockStore) CountTotalMedia(arg0 context.Context) (int64, error) {
 	m.ctrl.T.Helper()
@@ -66,18 +247,18 @@ func (mr *MockStoreMockRecorder) CountTotalMedia(arg0 interface{}) *gomock.Call
 }
 
 // CountTotalPosts mocks base method.
-func (m *MockStore) CountTotalPosts(arg0 context.Context) (int64, error) {
+func (m *MockStore) CountTotalPosts(arg0 context.Context, arg1 sql.NullString) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CountTotalPosts", arg0)
+	ret := m.ctrl.Call(m, "CountTotalPosts", arg0, arg1)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CountTotalPosts indicates an expected call of CountTotalPosts.
-func (mr *MockStoreMockRecorder) CountTotalPosts(arg0 interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) CountTotalPosts(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTotalPosts", reflect.TypeOf((*MockStore)(nil).CountTotalPosts), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTotalPosts", reflect.TypeOf((*MockStore)(nil).CountTotalPosts), arg0, arg1)
 }
 
 // CountTotalSessions mocks base method.
@@ -125,6 +306,95 @@ func (mr *MockStoreMockRecorder) CountTotalUsers(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTotalUsers", reflect.TypeOf((*MockStore)(nil).CountTotalUsers), arg0)
 }
 
+// CountUnusedMFARecoveryCodes mocks base method.
+func (m *MockStore) CountUnusedMFARecoveryCodes(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnusedMFARecoveryCodes", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnusedMFARecoveryCodes indicates an expected call of CountUnusedMFARecoveryCodes.
+func (mr *MockStoreMockRecorder) CountUnusedMFARecoveryCodes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnusedMFARecoveryCodes", reflect.TypeOf((*MockStore)(nil).CountUnusedMFARecoveryCodes), arg0, arg1)
+}
+
+// CountUsersFiltered mocks base method.
+func (m *MockStore) CountUsersFiltered(arg0 context.Context, arg1 db.ListFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsersFiltered", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUsersFiltered indicates an expected call of CountUsersFiltered.
+func (mr *MockStoreMockRecorder) CountUsersFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsersFiltered", reflect.TypeOf((*MockStore)(nil).CountUsersFiltered), arg0, arg1)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockStore) CreateAPIKey(arg0 context.Context, arg1 db.CreateAPIKeyParams) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockStoreMockRecorder) CreateAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockStore)(nil).CreateAPIKey), arg0, arg1)
+}
+
+// CreateEmailVerificationToken mocks base method.
+func (m *MockStore) CreateEmailVerificationToken(arg0 context.Context, arg1 db.CreateEmailVerificationTokenParams) (db.EmailVerificationToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerificationToken", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailVerificationToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEmailVerificationToken indicates an expected call of CreateEmailVerificationToken.
+func (mr *MockStoreMockRecorder) CreateEmailVerificationToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerificationToken", reflect.TypeOf((*MockStore)(nil).CreateEmailVerificationToken), arg0, arg1)
+}
+
+// CreateInitialPostRevision mocks base method.
+func (m *MockStore) CreateInitialPostRevision(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInitialPostRevision", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInitialPostRevision indicates an expected call of CreateInitialPostRevision.
+func (mr *MockStoreMockRecorder) CreateInitialPostRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInitialPostRevision", reflect.TypeOf((*MockStore)(nil).CreateInitialPostRevision), arg0, arg1)
+}
+
+// CreateMFARecoveryCode mocks base method.
+func (m *MockStore) CreateMFARecoveryCode(arg0 context.Context, arg1 db.CreateMFARecoveryCodeParams) (db.MfaRecoveryCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFARecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(db.MfaRecoveryCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMFARecoveryCode indicates an expected call of CreateMFARecoveryCode.
+func (mr *MockStoreMockRecorder) CreateMFARecoveryCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFARecoveryCode", reflect.TypeOf((*MockStore)(nil).CreateMFARecoveryCode), arg0, arg1)
+}
+
 // CreateMedia mocks base method.
 func (m *MockStore) CreateMedia(arg0 context.Context, arg1 db.CreateMediaParams) (db.Medium, error) {
 	m.ctrl.T.Helper()
@@ -155,6 +425,80 @@ func (mr *MockStoreMockRecorder) CreateMediaAndLinkTx(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMediaAndLinkTx", reflect.TypeOf((*MockStore)(nil).CreateMediaAndLinkTx), arg0, arg1)
 }
 
+// CreateMediaRendition mocks base method.
+func (m *MockStore) CreateMediaRendition(arg0 context.Context, arg1 db.CreateMediaRenditionParams) (db.MediaRendition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMediaRendition", arg0, arg1)
+	ret0, _ := ret[0].(db.MediaRendition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMediaRendition indicates an expected call of CreateMediaRendition.
+func (mr *MockStoreMockRecorder) CreateMediaRendition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMediaRendition", reflect.TypeOf((*MockStore)(nil).CreateMediaRendition), arg0, arg1)
+}
+
+// CreateMediaWithRenditionsTx mocks base method.
+func (m *MockStore) CreateMediaWithRenditionsTx(arg0 context.Context, arg1 db.CreateMediaWithRenditionsTxParams) (db.CreateMediaWithRenditionsTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMediaWithRenditionsTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateMediaWithRenditionsTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMediaWithRenditionsTx indicates an expected call of CreateMediaWithRenditionsTx.
+func (mr *MockStoreMockRecorder) CreateMediaWithRenditionsTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMediaWithRenditionsTx", reflect.TypeOf((*MockStore)(nil).CreateMediaWithRenditionsTx), arg0, arg1)
+}
+
+// CreateOIDCLoginState mocks base method.
+func (m *MockStore) CreateOIDCLoginState(arg0 context.Context, arg1 db.CreateOIDCLoginStateParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCLoginState", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOIDCLoginState indicates an expected call of CreateOIDCLoginState.
+func (mr *MockStoreMockRecorder) CreateOIDCLoginState(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCLoginState", reflect.TypeOf((*MockStore)(nil).CreateOIDCLoginState), arg0, arg1)
+}
+
+// CreateOIDCUserTx mocks base method.
+func (m *MockStore) CreateOIDCUserTx(arg0 context.Context, arg1 db.CreateOIDCUserTxParams) (db.CreateOIDCUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateOIDCUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOIDCUserTx indicates an expected call of CreateOIDCUserTx.
+func (mr *MockStoreMockRecorder) CreateOIDCUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCUserTx", reflect.TypeOf((*MockStore)(nil).CreateOIDCUserTx), arg0, arg1)
+}
+
+// CreatePasswordResetToken mocks base method.
+func (m *MockStore) CreatePasswordResetToken(arg0 context.Context, arg1 db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", arg0, arg1)
+	ret0, _ := ret[0].(db.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockStoreMockRecorder) CreatePasswordResetToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockStore)(nil).CreatePasswordResetToken), arg0, arg1)
+}
+
 // CreatePostMedia mocks base method.
 func (m *MockStore) CreatePostMedia(arg0 context.Context, arg1 db.CreatePostMediaParams) (db.PostMedium, error) {
 	m.ctrl.T.Helper()
@@ -170,6 +514,21 @@ func (mr *MockStoreMockRecorder) CreatePostMedia(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePostMedia", reflect.TypeOf((*MockStore)(nil).CreatePostMedia), arg0, arg1)
 }
 
+// CreatePostRevision mocks base method.
+func (m *MockStore) CreatePostRevision(arg0 context.Context, arg1 db.CreatePostRevisionParams) (db.PostRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePostRevision", arg0, arg1)
+	ret0, _ := ret[0].(db.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePostRevision indicates an expected call of CreatePostRevision.
+func (mr *MockStoreMockRecorder) CreatePostRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePostRevision", reflect.TypeOf((*MockStore)(nil).CreatePostRevision), arg0, arg1)
+}
+
 // CreatePostTaxonomy mocks base method.
 func (m *MockStore) CreatePostTaxonomy(arg0 context.Context, arg1 db.CreatePostTaxonomyParams) (db.PostsTaxonomy, error) {
 	m.ctrl.T.Helper()
@@ -305,6 +664,21 @@ func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
 }
 
+// CreateUserIdentity mocks base method.
+func (m *MockStore) CreateUserIdentity(arg0 context.Context, arg1 db.CreateUserIdentityParams) (db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserIdentity indicates an expected call of CreateUserIdentity.
+func (mr *MockStoreMockRecorder) CreateUserIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserIdentity", reflect.TypeOf((*MockStore)(nil).CreateUserIdentity), arg0, arg1)
+}
+
 // CreateUserPost mocks base method.
 func (m *MockStore) CreateUserPost(arg0 context.Context, arg1 db.CreateUserPostParams) (db.UserPost, error) {
 	m.ctrl.T.Helper()
@@ -320,82 +694,170 @@ func (mr *MockStoreMockRecorder) CreateUserPost(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserPost", reflect.TypeOf((*MockStore)(nil).CreateUserPost), arg0, arg1)
 }
 
-// DeleteMedia mocks base method.
-func (m *MockStore) DeleteMedia(arg0 context.Context, arg1 int64) error {
+// DeleteExpiredLoginFailures mocks base method.
+func (m *MockStore) DeleteExpiredLoginFailures(arg0 context.Context, arg1 time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteMedia", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteExpiredLoginFailures", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteMedia indicates an expected call of DeleteMedia.
-func (mr *MockStoreMockRecorder) DeleteMedia(arg0, arg1 interface{}) *gomock.Call {
+// DeleteExpiredLoginFailures indicates an expected call of DeleteExpiredLoginFailures.
+func (mr *MockStoreMockRecorder) DeleteExpiredLoginFailures(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMedia", reflect.TypeOf((*MockStore)(nil).DeleteMedia), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredLoginFailures", reflect.TypeOf((*MockStore)(nil).DeleteExpiredLoginFailures), arg0, arg1)
 }
 
-// DeleteMediaByUserID mocks base method.
-func (m *MockStore) DeleteMediaByUserID(arg0 context.Context, arg1 int64) error {
+// DeleteExpiredOIDCLoginStates mocks base method.
+func (m *MockStore) DeleteExpiredOIDCLoginStates(arg0 context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteMediaByUserID", arg0, arg1)
+	ret := m.ctrl.Call(m, "DeleteExpiredOIDCLoginStates", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteMediaByUserID indicates an expected call of DeleteMediaByUserID.
-func (mr *MockStoreMockRecorder) DeleteMediaByUserID(arg0, arg1 interface{}) *gomock.Call {
+// DeleteExpiredOIDCLoginStates indicates an expected call of DeleteExpiredOIDCLoginStates.
+func (mr *MockStoreMockRecorder) DeleteExpiredOIDCLoginStates(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMediaByUserID", reflect.TypeOf((*MockStore)(nil).DeleteMediaByUserID), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredOIDCLoginStates", reflect.TypeOf((*MockStore)(nil).DeleteExpiredOIDCLoginStates), arg0)
 }
 
-// DeleteMediaPosts mocks base method.
-func (m *MockStore) DeleteMediaPosts(arg0 context.Context, arg1 int64) error {
+// DeleteExpiredRevokedTokens mocks base method.
+func (m *MockStore) DeleteExpiredRevokedTokens(arg0 context.Context) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteMediaPosts", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteExpiredRevokedTokens", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteMediaPosts indicates an expected call of DeleteMediaPosts.
-func (mr *MockStoreMockRecorder) DeleteMediaPosts(arg0, arg1 interface{}) *gomock.Call {
+// DeleteExpiredRevokedTokens indicates an expected call of DeleteExpiredRevokedTokens.
+func (mr *MockStoreMockRecorder) DeleteExpiredRevokedTokens(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMediaPosts", reflect.TypeOf((*MockStore)(nil).DeleteMediaPosts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredRevokedTokens", reflect.TypeOf((*MockStore)(nil).DeleteExpiredRevokedTokens), arg0)
 }
 
-// DeleteMediaTx mocks base method.
-func (m *MockStore) DeleteMediaTx(arg0 context.Context, arg1 db.DeleteMediaTxParams) error {
+// DeleteLoginFailure mocks base method.
+func (m *MockStore) DeleteLoginFailure(arg0 context.Context, arg1 db.DeleteLoginFailureParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteMediaTx", arg0, arg1)
+	ret := m.ctrl.Call(m, "DeleteLoginFailure", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteMediaTx indicates an expected call of DeleteMediaTx.
-func (mr *MockStoreMockRecorder) DeleteMediaTx(arg0, arg1 interface{}) *gomock.Call {
+// DeleteLoginFailure indicates an expected call of DeleteLoginFailure.
+func (mr *MockStoreMockRecorder) DeleteLoginFailure(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMediaTx", reflect.TypeOf((*MockStore)(nil).DeleteMediaTx), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginFailure", reflect.TypeOf((*MockStore)(nil).DeleteLoginFailure), arg0, arg1)
 }
 
-// DeletePost mocks base method.
-func (m *MockStore) DeletePost(arg0 context.Context, arg1 int64) error {
+// DeleteMFARecoveryCodes mocks base method.
+func (m *MockStore) DeleteMFARecoveryCodes(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePost", arg0, arg1)
+	ret := m.ctrl.Call(m, "DeleteMFARecoveryCodes", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeletePost indicates an expected call of DeletePost.
-func (mr *MockStoreMockRecorder) DeletePost(arg0, arg1 interface{}) *gomock.Call {
+// DeleteMFARecoveryCodes indicates an expected call of DeleteMFARecoveryCodes.
+func (mr *MockStoreMockRecorder) DeleteMFARecoveryCodes(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePost", reflect.TypeOf((*MockStore)(nil).DeletePost), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMFARecoveryCodes", reflect.TypeOf((*MockStore)(nil).DeleteMFARecoveryCodes), arg0, arg1)
 }
 
-// DeletePostMedia mocks base method.
-func (m *MockStore) DeletePostMedia(arg0 context.Context, arg1 db.DeletePostMediaParams) error {
+// DeleteMedia mocks base method.
+func (m *MockStore) DeleteMedia(arg0 context.Context, arg1 db.DeleteMediaParams) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePostMedia", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteMedia", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMedia indicates an expected call of DeleteMedia.
+func (mr *MockStoreMockRecorder) DeleteMedia(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMedia", reflect.TypeOf((*MockStore)(nil).DeleteMedia), arg0, arg1)
+}
+
+// DeleteMediaByUserID mocks base method.
+func (m *MockStore) DeleteMediaByUserID(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMediaByUserID", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMediaByUserID indicates an expected call of DeleteMediaByUserID.
+func (mr *MockStoreMockRecorder) DeleteMediaByUserID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMediaByUserID", reflect.TypeOf((*MockStore)(nil).DeleteMediaByUserID), arg0, arg1)
+}
+
+// DeleteMediaPosts mocks base method.
+func (m *MockStore) DeleteMediaPosts(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMediaPosts", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMediaPosts indicates an expected call of DeleteMediaPosts.
+func (mr *MockStoreMockRecorder) DeleteMediaPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMediaPosts", reflect.TypeOf((*MockStore)(nil).DeleteMediaPosts), arg0, arg1)
+}
+
+// DeleteMediaRenditions mocks base method.
+func (m *MockStore) DeleteMediaRenditions(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMediaRenditions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMediaRenditions indicates an expected call of DeleteMediaRenditions.
+func (mr *MockStoreMockRecorder) DeleteMediaRenditions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMediaRenditions", reflect.TypeOf((*MockStore)(nil).DeleteMediaRenditions), arg0, arg1)
+}
+
+// DeleteMediaTx mocks base method.
+func (m *MockStore) DeleteMediaTx(arg0 context.Context, arg1 db.DeleteMediaTxParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMediaTx", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMediaTx indicates an expected call of DeleteMediaTx.
+func (mr *MockStoreMockRecorder) DeleteMediaTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMediaTx", reflect.TypeOf((*MockStore)(nil).DeleteMediaTx), arg0, arg1)
+}
+
+// DeletePost mocks base method.
+func (m *MockStore) DeletePost(arg0 context.Context, arg1 db.DeletePostParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePost", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePost indicates an expected call of DeletePost.
+func (mr *MockStoreMockRecorder) DeletePost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePost", reflect.TypeOf((*MockStore)(nil).DeletePost), arg0, arg1)
+}
+
+// DeletePostMedia mocks base method.
+func (m *MockStore) DeletePostMedia(arg0 context.Context, arg1 db.DeletePostMediaParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePostMedia", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // DeletePostMedia indicates an expected call of DeletePostMedia.
@@ -447,7 +909,7 @@ func (mr *MockStoreMockRecorder) DeletePostTaxonomy(arg0, arg1 interface{}) *gom
 }
 
 // DeletePostTx mocks base method.
-func (m *MockStore) DeletePostTx(arg0 context.Context, arg1 int64) error {
+func (m *MockStore) DeletePostTx(arg0 context.Context, arg1 db.DeletePostTxParams) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeletePostTx", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -475,11 +937,12 @@ func (mr *MockStoreMockRecorder) DeletePostsByUserID(arg0, arg1 interface{}) *go
 }
 
 // DeleteTaxonomy mocks base method.
-func (m *MockStore) DeleteTaxonomy(arg0 context.Context, arg1 int64) error {
+func (m *MockStore) DeleteTaxonomy(arg0 context.Context, arg1 db.DeleteTaxonomyParams) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteTaxonomy", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteTaxonomy indicates an expected call of DeleteTaxonomy.
@@ -503,7 +966,7 @@ func (mr *MockStoreMockRecorder) DeleteTaxonomyPosts(arg0, arg1 interface{}) *go
 }
 
 // DeleteTaxonomyTx mocks base method.
-func (m *MockStore) DeleteTaxonomyTx(arg0 context.Context, arg1 int64) error {
+func (m *MockStore) DeleteTaxonomyTx(arg0 context.Context, arg1 db.DeleteTaxonomyTxParams) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteTaxonomyTx", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -516,20 +979,63 @@ func (mr *MockStoreMockRecorder) DeleteTaxonomyTx(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaxonomyTx", reflect.TypeOf((*MockStore)(nil).DeleteTaxonomyTx), arg0, arg1)
 }
 
-// DeleteUser mocks base method.
-func (m *MockStore) DeleteUser(arg0 context.Context, arg1 int64) error {
+// DeleteUnusedEmailVerificationTokens mocks base method.
+func (m *MockStore) DeleteUnusedEmailVerificationTokens(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", arg0, arg1)
+	ret := m.ctrl.Call(m, "DeleteUnusedEmailVerificationTokens", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUnusedEmailVerificationTokens indicates an expected call of DeleteUnusedEmailVerificationTokens.
+func (mr *MockStoreMockRecorder) DeleteUnusedEmailVerificationTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnusedEmailVerificationTokens", reflect.TypeOf((*MockStore)(nil).DeleteUnusedEmailVerificationTokens), arg0, arg1)
+}
+
+// DeleteUnusedPasswordResetTokens mocks base method.
+func (m *MockStore) DeleteUnusedPasswordResetTokens(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnusedPasswordResetTokens", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// DeleteUnusedPasswordResetTokens indicates an expected call of DeleteUnusedPasswordResetTokens.
+func (mr *MockStoreMockRecorder) DeleteUnusedPasswordResetTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnusedPasswordResetTokens", reflect.TypeOf((*MockStore)(nil).DeleteUnusedPasswordResetTokens), arg0, arg1)
+}
+
+// DeleteUser mocks base method.
+func (m *MockStore) DeleteUser(arg0 context.Context, arg1 db.DeleteUserParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // DeleteUser indicates an expected call of DeleteUser.
 func (mr *MockStoreMockRecorder) DeleteUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStore)(nil).DeleteUser), arg0, arg1)
 }
 
+// DeleteUserMFA mocks base method.
+func (m *MockStore) DeleteUserMFA(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserMFA", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserMFA indicates an expected call of DeleteUserMFA.
+func (mr *MockStoreMockRecorder) DeleteUserMFA(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserMFA", reflect.TypeOf((*MockStore)(nil).DeleteUserMFA), arg0, arg1)
+}
+
 // DeleteUserPost mocks base method.
 func (m *MockStore) DeleteUserPost(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
@@ -573,7 +1079,7 @@ func (mr *MockStoreMockRecorder) DeleteUserSessions(arg0, arg1 interface{}) *gom
 }
 
 // DeleteUserTx mocks base method.
-func (m *MockStore) DeleteUserTx(arg0 context.Context, arg1 int64) error {
+func (m *MockStore) DeleteUserTx(arg0 context.Context, arg1 db.DeleteUserTxParams) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteUserTx", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -600,6 +1106,50 @@ func (mr *MockStoreMockRecorder) DeleteUserWithTransferTx(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserWithTransferTx", reflect.TypeOf((*MockStore)(nil).DeleteUserWithTransferTx), arg0, arg1)
 }
 
+// DisableMFATx mocks base method.
+func (m *MockStore) DisableMFATx(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableMFATx", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableMFATx indicates an expected call of DisableMFATx.
+func (mr *MockStoreMockRecorder) DisableMFATx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableMFATx", reflect.TypeOf((*MockStore)(nil).DisableMFATx), arg0, arg1)
+}
+
+// EnableUserMFA mocks base method.
+func (m *MockStore) EnableUserMFA(arg0 context.Context, arg1 db.EnableUserMFAParams) (db.UserMfa, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserMFA", arg0, arg1)
+	ret0, _ := ret[0].(db.UserMfa)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableUserMFA indicates an expected call of EnableUserMFA.
+func (mr *MockStoreMockRecorder) EnableUserMFA(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserMFA", reflect.TypeOf((*MockStore)(nil).EnableUserMFA), arg0, arg1)
+}
+
+// EstimateTableRows mocks base method.
+func (m *MockStore) EstimateTableRows(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EstimateTableRows", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EstimateTableRows indicates an expected call of EstimateTableRows.
+func (mr *MockStoreMockRecorder) EstimateTableRows(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimateTableRows", reflect.TypeOf((*MockStore)(nil).EstimateTableRows), arg0, arg1)
+}
+
 // ExecTx mocks base method.
 func (m *MockStore) ExecTx(arg0 context.Context, arg1 func(*db.Queries) error) error {
 	m.ctrl.T.Helper()
@@ -614,6 +1164,36 @@ func (mr *MockStoreMockRecorder) ExecTx(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecTx", reflect.TypeOf((*MockStore)(nil).ExecTx), arg0, arg1)
 }
 
+// GetAPIKeyByHash mocks base method.
+func (m *MockStore) GetAPIKeyByHash(arg0 context.Context, arg1 string) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", arg0, arg1)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockStoreMockRecorder) GetAPIKeyByHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockStore)(nil).GetAPIKeyByHash), arg0, arg1)
+}
+
+// GetLoginFailure mocks base method.
+func (m *MockStore) GetLoginFailure(arg0 context.Context, arg1 db.GetLoginFailureParams) (db.LoginFailure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginFailure", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginFailure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginFailure indicates an expected call of GetLoginFailure.
+func (mr *MockStoreMockRecorder) GetLoginFailure(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginFailure", reflect.TypeOf((*MockStore)(nil).GetLoginFailure), arg0, arg1)
+}
+
 // GetMedia mocks base method.
 func (m *MockStore) GetMedia(arg0 context.Context, arg1 int64) (db.Medium, error) {
 	m.ctrl.T.Helper()
@@ -644,6 +1224,21 @@ func (mr *MockStoreMockRecorder) GetMediaByPost(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMediaByPost", reflect.TypeOf((*MockStore)(nil).GetMediaByPost), arg0, arg1)
 }
 
+// GetMediaByPostIDs mocks base method.
+func (m *MockStore) GetMediaByPostIDs(arg0 context.Context, arg1 []int64) ([]db.GetMediaByPostIDsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMediaByPostIDs", arg0, arg1)
+	ret0, _ := ret[0].([]db.GetMediaByPostIDsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMediaByPostIDs indicates an expected call of GetMediaByPostIDs.
+func (mr *MockStoreMockRecorder) GetMediaByPostIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMediaByPostIDs", reflect.TypeOf((*MockStore)(nil).GetMediaByPostIDs), arg0, arg1)
+}
+
 // GetMediaByUser mocks base method.
 func (m *MockStore) GetMediaByUser(arg0 context.Context, arg1 db.GetMediaByUserParams) ([]db.Medium, error) {
 	m.ctrl.T.Helper()
@@ -734,6 +1329,21 @@ func (mr *MockStoreMockRecorder) GetPostMediaCount(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostMediaCount", reflect.TypeOf((*MockStore)(nil).GetPostMediaCount), arg0, arg1)
 }
 
+// GetPostRevision mocks base method.
+func (m *MockStore) GetPostRevision(arg0 context.Context, arg1 db.GetPostRevisionParams) (db.PostRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostRevision", arg0, arg1)
+	ret0, _ := ret[0].(db.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostRevision indicates an expected call of GetPostRevision.
+func (mr *MockStoreMockRecorder) GetPostRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostRevision", reflect.TypeOf((*MockStore)(nil).GetPostRevision), arg0, arg1)
+}
+
 // GetPostTaxonomies mocks base method.
 func (m *MockStore) GetPostTaxonomies(arg0 context.Context, arg1 int64) ([]db.Taxonomy, error) {
 	m.ctrl.T.Helper()
@@ -782,286 +1392,779 @@ func (mr *MockStoreMockRecorder) GetPostWithMedia(arg0, arg1 interface{}) *gomoc
 // GetPostsByUserWithMedia mocks base method.
 func (m *MockStore) GetPostsByUserWithMedia(arg0 context.Context, arg1 db.GetPostsByUserWithMediaParams) ([]db.GetPostsByUserWithMediaRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostsByUserWithMedia", arg0, arg1)
-	ret0, _ := ret[0].([]db.GetPostsByUserWithMediaRow)
+	ret := m.ctrl.Call(m, "GetPostsByUserWithMedia", arg0, arg1)
+	ret0, _ := ret[0].([]db.GetPostsByUserWithMediaRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostsByUserWithMedia indicates an expected call of GetPostsByUserWithMedia.
+func (mr *MockStoreMockRecorder) GetPostsByUserWithMedia(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByUserWithMedia", reflect.TypeOf((*MockStore)(nil).GetPostsByUserWithMedia), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
+// GetTaxonomiesByPostIDs mocks base method.
+func (m *MockStore) GetTaxonomiesByPostIDs(arg0 context.Context, arg1 []int64) ([]db.GetTaxonomiesByPostIDsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxonomiesByPostIDs", arg0, arg1)
+	ret0, _ := ret[0].([]db.GetTaxonomiesByPostIDsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxonomiesByPostIDs indicates an expected call of GetTaxonomiesByPostIDs.
+func (mr *MockStoreMockRecorder) GetTaxonomiesByPostIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxonomiesByPostIDs", reflect.TypeOf((*MockStore)(nil).GetTaxonomiesByPostIDs), arg0, arg1)
+}
+
+// GetTaxonomy mocks base method.
+func (m *MockStore) GetTaxonomy(arg0 context.Context, arg1 int64) (db.Taxonomy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxonomy", arg0, arg1)
+	ret0, _ := ret[0].(db.Taxonomy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxonomy indicates an expected call of GetTaxonomy.
+func (mr *MockStoreMockRecorder) GetTaxonomy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxonomy", reflect.TypeOf((*MockStore)(nil).GetTaxonomy), arg0, arg1)
+}
+
+// GetTaxonomyByName mocks base method.
+func (m *MockStore) GetTaxonomyByName(arg0 context.Context, arg1 string) (db.Taxonomy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxonomyByName", arg0, arg1)
+	ret0, _ := ret[0].(db.Taxonomy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxonomyByName indicates an expected call of GetTaxonomyByName.
+func (mr *MockStoreMockRecorder) GetTaxonomyByName(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxonomyByName", reflect.TypeOf((*MockStore)(nil).GetTaxonomyByName), arg0, arg1)
+}
+
+// GetTaxonomyPostCount mocks base method.
+func (m *MockStore) GetTaxonomyPostCount(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxonomyPostCount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxonomyPostCount indicates an expected call of GetTaxonomyPostCount.
+func (mr *MockStoreMockRecorder) GetTaxonomyPostCount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxonomyPostCount", reflect.TypeOf((*MockStore)(nil).GetTaxonomyPostCount), arg0, arg1)
+}
+
+// GetTaxonomyPosts mocks base method.
+func (m *MockStore) GetTaxonomyPosts(arg0 context.Context, arg1 db.GetTaxonomyPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxonomyPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxonomyPosts indicates an expected call of GetTaxonomyPosts.
+func (mr *MockStoreMockRecorder) GetTaxonomyPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxonomyPosts", reflect.TypeOf((*MockStore)(nil).GetTaxonomyPosts), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 int64) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStore) GetUserByEmail(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStoreMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), arg0, arg1)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockStore) GetUserByUsername(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockStoreMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0, arg1)
+}
+
+// GetUserIdentity mocks base method.
+func (m *MockStore) GetUserIdentity(arg0 context.Context, arg1 db.GetUserIdentityParams) (db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIdentity indicates an expected call of GetUserIdentity.
+func (mr *MockStoreMockRecorder) GetUserIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdentity", reflect.TypeOf((*MockStore)(nil).GetUserIdentity), arg0, arg1)
+}
+
+// GetUserMFA mocks base method.
+func (m *MockStore) GetUserMFA(arg0 context.Context, arg1 int64) (db.UserMfa, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserMFA", arg0, arg1)
+	ret0, _ := ret[0].(db.UserMfa)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserMFA indicates an expected call of GetUserMFA.
+func (mr *MockStoreMockRecorder) GetUserMFA(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMFA", reflect.TypeOf((*MockStore)(nil).GetUserMFA), arg0, arg1)
+}
+
+// GetUserMediaCount mocks base method.
+func (m *MockStore) GetUserMediaCount(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserMediaCount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserMediaCount indicates an expected call of GetUserMediaCount.
+func (mr *MockStoreMockRecorder) GetUserMediaCount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMediaCount", reflect.TypeOf((*MockStore)(nil).GetUserMediaCount), arg0, arg1)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockStore) IsTokenRevoked(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockStoreMockRecorder) IsTokenRevoked(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockStore)(nil).IsTokenRevoked), arg0, arg1)
+}
+
+// ListAPIKeysByUser mocks base method.
+func (m *MockStore) ListAPIKeysByUser(arg0 context.Context, arg1 int64) ([]db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeysByUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeysByUser indicates an expected call of ListAPIKeysByUser.
+func (mr *MockStoreMockRecorder) ListAPIKeysByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeysByUser", reflect.TypeOf((*MockStore)(nil).ListAPIKeysByUser), arg0, arg1)
+}
+
+// ListLoginLockouts mocks base method.
+func (m *MockStore) ListLoginLockouts(arg0 context.Context, arg1 db.ListLoginLockoutsParams) ([]db.LoginFailure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginLockouts", arg0, arg1)
+	ret0, _ := ret[0].([]db.LoginFailure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginLockouts indicates an expected call of ListLoginLockouts.
+func (mr *MockStoreMockRecorder) ListLoginLockouts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginLockouts", reflect.TypeOf((*MockStore)(nil).ListLoginLockouts), arg0, arg1)
+}
+
+// ListMedia mocks base method.
+func (m *MockStore) ListMedia(arg0 context.Context, arg1 db.ListMediaParams) ([]db.Medium, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMedia", arg0, arg1)
+	ret0, _ := ret[0].([]db.Medium)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMedia indicates an expected call of ListMedia.
+func (mr *MockStoreMockRecorder) ListMedia(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMedia", reflect.TypeOf((*MockStore)(nil).ListMedia), arg0, arg1)
+}
+
+// ListMediaByIDs mocks base method.
+func (m *MockStore) ListMediaByIDs(arg0 context.Context, arg1 []int64) ([]db.Medium, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMediaByIDs", arg0, arg1)
+	ret0, _ := ret[0].([]db.Medium)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMediaByIDs indicates an expected call of ListMediaByIDs.
+func (mr *MockStoreMockRecorder) ListMediaByIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaByIDs", reflect.TypeOf((*MockStore)(nil).ListMediaByIDs), arg0, arg1)
+}
+
+// ListMediaFiltered mocks base method.
+func (m *MockStore) ListMediaFiltered(arg0 context.Context, arg1 db.ListFilteredParams) ([]db.Medium, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMediaFiltered", arg0, arg1)
+	ret0, _ := ret[0].([]db.Medium)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMediaFiltered indicates an expected call of ListMediaFiltered.
+func (mr *MockStoreMockRecorder) ListMediaFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaFiltered", reflect.TypeOf((*MockStore)(nil).ListMediaFiltered), arg0, arg1)
+}
+
+// ListMediaIDsByUser mocks base method.
+func (m *MockStore) ListMediaIDsByUser(arg0 context.Context, arg1 int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMediaIDsByUser", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMediaIDsByUser indicates an expected call of ListMediaIDsByUser.
+func (mr *MockStoreMockRecorder) ListMediaIDsByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaIDsByUser", reflect.TypeOf((*MockStore)(nil).ListMediaIDsByUser), arg0, arg1)
+}
+
+// ListMediaRenditions mocks base method.
+func (m *MockStore) ListMediaRenditions(arg0 context.Context, arg1 []int64) ([]db.MediaRendition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMediaRenditions", arg0, arg1)
+	ret0, _ := ret[0].([]db.MediaRendition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMediaRenditions indicates an expected call of ListMediaRenditions.
+func (mr *MockStoreMockRecorder) ListMediaRenditions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaRenditions", reflect.TypeOf((*MockStore)(nil).ListMediaRenditions), arg0, arg1)
+}
+
+// ListMediaWithPostCount mocks base method.
+func (m *MockStore) ListMediaWithPostCount(arg0 context.Context, arg1 db.ListMediaWithPostCountParams) ([]db.ListMediaWithPostCountRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMediaWithPostCount", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListMediaWithPostCountRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMediaWithPostCount indicates an expected call of ListMediaWithPostCount.
+func (mr *MockStoreMockRecorder) ListMediaWithPostCount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaWithPostCount", reflect.TypeOf((*MockStore)(nil).ListMediaWithPostCount), arg0, arg1)
+}
+
+// ListMediaWithPostCountFiltered mocks base method.
+func (m *MockStore) ListMediaWithPostCountFiltered(arg0 context.Context, arg1 db.ListFilteredParams) ([]db.ListMediaWithPostCountRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMediaWithPostCountFiltered", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListMediaWithPostCountRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMediaWithPostCountFiltered indicates an expected call of ListMediaWithPostCountFiltered.
+func (mr *MockStoreMockRecorder) ListMediaWithPostCountFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaWithPostCountFiltered", reflect.TypeOf((*MockStore)(nil).ListMediaWithPostCountFiltered), arg0, arg1)
+}
+
+// ListPostAuthors mocks base method.
+func (m *MockStore) ListPostAuthors(arg0 context.Context, arg1 int64) ([]db.UserPost, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostAuthors", arg0, arg1)
+	ret0, _ := ret[0].([]db.UserPost)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostAuthors indicates an expected call of ListPostAuthors.
+func (mr *MockStoreMockRecorder) ListPostAuthors(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostAuthors", reflect.TypeOf((*MockStore)(nil).ListPostAuthors), arg0, arg1)
+}
+
+// ListPostAuthorsByPostIDs mocks base method.
+func (m *MockStore) ListPostAuthorsByPostIDs(arg0 context.Context, arg1 []int64) ([]db.ListPostAuthorsByPostIDsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostAuthorsByPostIDs", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListPostAuthorsByPostIDsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostAuthorsByPostIDs indicates an expected call of ListPostAuthorsByPostIDs.
+func (mr *MockStoreMockRecorder) ListPostAuthorsByPostIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostAuthorsByPostIDs", reflect.TypeOf((*MockStore)(nil).ListPostAuthorsByPostIDs), arg0, arg1)
+}
+
+// ListPostIDsByTaxonomy mocks base method.
+func (m *MockStore) ListPostIDsByTaxonomy(arg0 context.Context, arg1 int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostIDsByTaxonomy", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostIDsByTaxonomy indicates an expected call of ListPostIDsByTaxonomy.
+func (mr *MockStoreMockRecorder) ListPostIDsByTaxonomy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostIDsByTaxonomy", reflect.TypeOf((*MockStore)(nil).ListPostIDsByTaxonomy), arg0, arg1)
+}
+
+// ListPostIDsByUser mocks base method.
+func (m *MockStore) ListPostIDsByUser(arg0 context.Context, arg1 int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostIDsByUser", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostIDsByUser indicates an expected call of ListPostIDsByUser.
+func (mr *MockStoreMockRecorder) ListPostIDsByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostIDsByUser", reflect.TypeOf((*MockStore)(nil).ListPostIDsByUser), arg0, arg1)
+}
+
+// ListPostRevisions mocks base method.
+func (m *MockStore) ListPostRevisions(arg0 context.Context, arg1 db.ListPostRevisionsParams) ([]db.PostRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostRevisions", arg0, arg1)
+	ret0, _ := ret[0].([]db.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostRevisions indicates an expected call of ListPostRevisions.
+func (mr *MockStoreMockRecorder) ListPostRevisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostRevisions", reflect.TypeOf((*MockStore)(nil).ListPostRevisions), arg0, arg1)
+}
+
+// ListPosts mocks base method.
+func (m *MockStore) ListPosts(arg0 context.Context, arg1 db.ListPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPosts indicates an expected call of ListPosts.
+func (mr *MockStoreMockRecorder) ListPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPosts", reflect.TypeOf((*MockStore)(nil).ListPosts), arg0, arg1)
+}
+
+// ListPostsByIDs mocks base method.
+func (m *MockStore) ListPostsByIDs(arg0 context.Context, arg1 []int64) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostsByIDs", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostsByIDs indicates an expected call of ListPostsByIDs.
+func (mr *MockStoreMockRecorder) ListPostsByIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostsByIDs", reflect.TypeOf((*MockStore)(nil).ListPostsByIDs), arg0, arg1)
+}
+
+// ListPostsFiltered mocks base method.
+func (m *MockStore) ListPostsFiltered(arg0 context.Context, arg1 db.ListFilteredParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostsFiltered", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostsFiltered indicates an expected call of ListPostsFiltered.
+func (mr *MockStoreMockRecorder) ListPostsFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostsFiltered", reflect.TypeOf((*MockStore)(nil).ListPostsFiltered), arg0, arg1)
+}
+
+// ListPostsWithMedia mocks base method.
+func (m *MockStore) ListPostsWithMedia(arg0 context.Context, arg1 db.ListPostsWithMediaParams) ([]db.ListPostsWithMediaRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostsWithMedia", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListPostsWithMediaRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostsWithMedia indicates an expected call of ListPostsWithMedia.
+func (mr *MockStoreMockRecorder) ListPostsWithMedia(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostsWithMedia", reflect.TypeOf((*MockStore)(nil).ListPostsWithMedia), arg0, arg1)
+}
+
+// ListSessionsByUser mocks base method.
+func (m *MockStore) ListSessionsByUser(arg0 context.Context, arg1 int64) ([]db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessionsByUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessionsByUser indicates an expected call of ListSessionsByUser.
+func (mr *MockStoreMockRecorder) ListSessionsByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessionsByUser", reflect.TypeOf((*MockStore)(nil).ListSessionsByUser), arg0, arg1)
+}
+
+// ListSessionsByUsername mocks base method.
+func (m *MockStore) ListSessionsByUsername(arg0 context.Context, arg1 string) ([]db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessionsByUsername", arg0, arg1)
+	ret0, _ := ret[0].([]db.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPostsByUserWithMedia indicates an expected call of GetPostsByUserWithMedia.
-func (mr *MockStoreMockRecorder) GetPostsByUserWithMedia(arg0, arg1 interface{}) *gomock.Call {
+// ListSessionsByUsername indicates an expected call of ListSessionsByUsername.
+func (mr *MockStoreMockRecorder) ListSessionsByUsername(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByUserWithMedia", reflect.TypeOf((*MockStore)(nil).GetPostsByUserWithMedia), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessionsByUsername", reflect.TypeOf((*MockStore)(nil).ListSessionsByUsername), arg0, arg1)
 }
 
-// GetSession mocks base method.
-func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+// ListTaxonomies mocks base method.
+func (m *MockStore) ListTaxonomies(arg0 context.Context, arg1 db.ListTaxonomiesParams) ([]db.Taxonomy, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "ListTaxonomies", arg0, arg1)
+	ret0, _ := ret[0].([]db.Taxonomy)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetSession indicates an expected call of GetSession.
-func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+// ListTaxonomies indicates an expected call of ListTaxonomies.
+func (mr *MockStoreMockRecorder) ListTaxonomies(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaxonomies", reflect.TypeOf((*MockStore)(nil).ListTaxonomies), arg0, arg1)
 }
 
-// GetTaxonomy mocks base method.
-func (m *MockStore) GetTaxonomy(arg0 context.Context, arg1 int64) (db.Taxonomy, error) {
+// ListTaxonomiesByIDs mocks base method.
+func (m *MockStore) ListTaxonomiesByIDs(arg0 context.Context, arg1 []int64) ([]db.Taxonomy, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTaxonomy", arg0, arg1)
-	ret0, _ := ret[0].(db.Taxonomy)
+	ret := m.ctrl.Call(m, "ListTaxonomiesByIDs", arg0, arg1)
+	ret0, _ := ret[0].([]db.Taxonomy)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTaxonomy indicates an expected call of GetTaxonomy.
-func (mr *MockStoreMockRecorder) GetTaxonomy(arg0, arg1 interface{}) *gomock.Call {
+// ListTaxonomiesByIDs indicates an expected call of ListTaxonomiesByIDs.
+func (mr *MockStoreMockRecorder) ListTaxonomiesByIDs(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxonomy", reflect.TypeOf((*MockStore)(nil).GetTaxonomy), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaxonomiesByIDs", reflect.TypeOf((*MockStore)(nil).ListTaxonomiesByIDs), arg0, arg1)
 }
 
-// GetTaxonomyByName mocks base method.
-func (m *MockStore) GetTaxonomyByName(arg0 context.Context, arg1 string) (db.Taxonomy, error) {
+// ListTaxonomiesFiltered mocks base method.
+func (m *MockStore) ListTaxonomiesFiltered(arg0 context.Context, arg1 db.ListFilteredParams) ([]db.Taxonomy, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTaxonomyByName", arg0, arg1)
-	ret0, _ := ret[0].(db.Taxonomy)
+	ret := m.ctrl.Call(m, "ListTaxonomiesFiltered", arg0, arg1)
+	ret0, _ := ret[0].([]db.Taxonomy)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTaxonomyByName indicates an expected call of GetTaxonomyByName.
-func (mr *MockStoreMockRecorder) GetTaxonomyByName(arg0, arg1 interface{}) *gomock.Call {
+// ListTaxonomiesFiltered indicates an expected call of ListTaxonomiesFiltered.
+func (mr *MockStoreMockRecorder) ListTaxonomiesFiltered(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxonomyByName", reflect.TypeOf((*MockStore)(nil).GetTaxonomyByName), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaxonomiesFiltered", reflect.TypeOf((*MockStore)(nil).ListTaxonomiesFiltered), arg0, arg1)
 }
 
-// GetTaxonomyPostCount mocks base method.
-func (m *MockStore) GetTaxonomyPostCount(arg0 context.Context, arg1 int64) (int64, error) {
+// ListTaxonomiesWithPostCount mocks base method.
+func (m *MockStore) ListTaxonomiesWithPostCount(arg0 context.Context, arg1 db.ListTaxonomiesWithPostCountParams) ([]db.ListTaxonomiesWithPostCountRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTaxonomyPostCount", arg0, arg1)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "ListTaxonomiesWithPostCount", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListTaxonomiesWithPostCountRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTaxonomyPostCount indicates an expected call of GetTaxonomyPostCount.
-func (mr *MockStoreMockRecorder) GetTaxonomyPostCount(arg0, arg1 interface{}) *gomock.Call {
+// ListTaxonomiesWithPostCount indicates an expected call of ListTaxonomiesWithPostCount.
+func (mr *MockStoreMockRecorder) ListTaxonomiesWithPostCount(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxonomyPostCount", reflect.TypeOf((*MockStore)(nil).GetTaxonomyPostCount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaxonomiesWithPostCount", reflect.TypeOf((*MockStore)(nil).ListTaxonomiesWithPostCount), arg0, arg1)
 }
 
-// GetTaxonomyPosts mocks base method.
-func (m *MockStore) GetTaxonomyPosts(arg0 context.Context, arg1 db.GetTaxonomyPostsParams) ([]db.Post, error) {
+// ListTaxonomiesWithPostCountFiltered mocks base method.
+func (m *MockStore) ListTaxonomiesWithPostCountFiltered(arg0 context.Context, arg1 db.ListFilteredParams) ([]db.ListTaxonomiesWithPostCountRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTaxonomyPosts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Post)
+	ret := m.ctrl.Call(m, "ListTaxonomiesWithPostCountFiltered", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListTaxonomiesWithPostCountRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTaxonomyPosts indicates an expected call of GetTaxonomyPosts.
-func (mr *MockStoreMockRecorder) GetTaxonomyPosts(arg0, arg1 interface{}) *gomock.Call {
+// ListTaxonomiesWithPostCountFiltered indicates an expected call of ListTaxonomiesWithPostCountFiltered.
+func (mr *MockStoreMockRecorder) ListTaxonomiesWithPostCountFiltered(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxonomyPosts", reflect.TypeOf((*MockStore)(nil).GetTaxonomyPosts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaxonomiesWithPostCountFiltered", reflect.TypeOf((*MockStore)(nil).ListTaxonomiesWithPostCountFiltered), arg0, arg1)
 }
 
-// GetUser mocks base method.
-func (m *MockStore) GetUser(arg0 context.Context, arg1 int64) (db.User, error) {
+// ListUsers mocks base method.
+func (m *MockStore) ListUsers(arg0 context.Context, arg1 db.ListUsersParams) ([]db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStoreMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers), arg0, arg1)
 }
 
-// GetUserByEmail mocks base method.
-func (m *MockStore) GetUserByEmail(arg0 context.Context, arg1 string) (db.User, error) {
+// ListUsersFiltered mocks base method.
+func (m *MockStore) ListUsersFiltered(arg0 context.Context, arg1 db.ListFilteredParams) ([]db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ListUsersFiltered", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByEmail indicates an expected call of GetUserByEmail.
-func (mr *MockStoreMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+// ListUsersFiltered indicates an expected call of ListUsersFiltered.
+func (mr *MockStoreMockRecorder) ListUsersFiltered(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersFiltered", reflect.TypeOf((*MockStore)(nil).ListUsersFiltered), arg0, arg1)
 }
 
-// GetUserByUsername mocks base method.
-func (m *MockStore) GetUserByUsername(arg0 context.Context, arg1 string) (db.User, error) {
+// MarkUserEmailVerified mocks base method.
+func (m *MockStore) MarkUserEmailVerified(arg0 context.Context, arg1 int64) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
+	ret := m.ctrl.Call(m, "MarkUserEmailVerified", arg0, arg1)
 	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByUsername indicates an expected call of GetUserByUsername.
-func (mr *MockStoreMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+// MarkUserEmailVerified indicates an expected call of MarkUserEmailVerified.
+func (mr *MockStoreMockRecorder) MarkUserEmailVerified(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUserEmailVerified", reflect.TypeOf((*MockStore)(nil).MarkUserEmailVerified), arg0, arg1)
 }
 
-// GetUserMediaCount mocks base method.
-func (m *MockStore) GetUserMediaCount(arg0 context.Context, arg1 int64) (int64, error) {
+// PublishDuePosts mocks base method.
+func (m *MockStore) PublishDuePosts(arg0 context.Context, arg1 int32) ([]db.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserMediaCount", arg0, arg1)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "PublishDuePosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserMediaCount indicates an expected call of GetUserMediaCount.
-func (mr *MockStoreMockRecorder) GetUserMediaCount(arg0, arg1 interface{}) *gomock.Call {
+// PublishDuePosts indicates an expected call of PublishDuePosts.
+func (mr *MockStoreMockRecorder) PublishDuePosts(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMediaCount", reflect.TypeOf((*MockStore)(nil).GetUserMediaCount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDuePosts", reflect.TypeOf((*MockStore)(nil).PublishDuePosts), arg0, arg1)
 }
 
-// ListMedia mocks base method.
-func (m *MockStore) ListMedia(arg0 context.Context, arg1 db.ListMediaParams) ([]db.Medium, error) {
+// RecordLoginFailure mocks base method.
+func (m *MockStore) RecordLoginFailure(arg0 context.Context, arg1 db.RecordLoginFailureParams) (db.LoginFailure, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListMedia", arg0, arg1)
-	ret0, _ := ret[0].([]db.Medium)
+	ret := m.ctrl.Call(m, "RecordLoginFailure", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginFailure)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListMedia indicates an expected call of ListMedia.
-func (mr *MockStoreMockRecorder) ListMedia(arg0, arg1 interface{}) *gomock.Call {
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockStoreMockRecorder) RecordLoginFailure(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMedia", reflect.TypeOf((*MockStore)(nil).ListMedia), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockStore)(nil).RecordLoginFailure), arg0, arg1)
 }
 
-// ListMediaWithPostCount mocks base method.
-func (m *MockStore) ListMediaWithPostCount(arg0 context.Context, arg1 db.ListMediaWithPostCountParams) ([]db.ListMediaWithPostCountRow, error) {
+// RegisterUserTx mocks base method.
+func (m *MockStore) RegisterUserTx(arg0 context.Context, arg1 db.RegisterUserTxParams) (db.RegisterUserTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListMediaWithPostCount", arg0, arg1)
-	ret0, _ := ret[0].([]db.ListMediaWithPostCountRow)
+	ret := m.ctrl.Call(m, "RegisterUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.RegisterUserTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListMediaWithPostCount indicates an expected call of ListMediaWithPostCount.
-func (mr *MockStoreMockRecorder) ListMediaWithPostCount(arg0, arg1 interface{}) *gomock.Call {
+// RegisterUserTx indicates an expected call of RegisterUserTx.
+func (mr *MockStoreMockRecorder) RegisterUserTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaWithPostCount", reflect.TypeOf((*MockStore)(nil).ListMediaWithPostCount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUserTx", reflect.TypeOf((*MockStore)(nil).RegisterUserTx), arg0, arg1)
 }
 
-// ListPosts mocks base method.
-func (m *MockStore) ListPosts(arg0 context.Context, arg1 db.ListPostsParams) ([]db.Post, error) {
+// ReissueEmailVerificationTx mocks base method.
+func (m *MockStore) ReissueEmailVerificationTx(arg0 context.Context, arg1 db.CreateEmailVerificationTokenParams) (db.EmailVerificationToken, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListPosts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Post)
+	ret := m.ctrl.Call(m, "ReissueEmailVerificationTx", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailVerificationToken)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListPosts indicates an expected call of ListPosts.
-func (mr *MockStoreMockRecorder) ListPosts(arg0, arg1 interface{}) *gomock.Call {
+// ReissueEmailVerificationTx indicates an expected call of ReissueEmailVerificationTx.
+func (mr *MockStoreMockRecorder) ReissueEmailVerificationTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPosts", reflect.TypeOf((*MockStore)(nil).ListPosts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReissueEmailVerificationTx", reflect.TypeOf((*MockStore)(nil).ReissueEmailVerificationTx), arg0, arg1)
 }
 
-// ListPostsWithMedia mocks base method.
-func (m *MockStore) ListPostsWithMedia(arg0 context.Context, arg1 db.ListPostsWithMediaParams) ([]db.ListPostsWithMediaRow, error) {
+// ReissuePasswordResetTx mocks base method.
+func (m *MockStore) ReissuePasswordResetTx(arg0 context.Context, arg1 db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListPostsWithMedia", arg0, arg1)
-	ret0, _ := ret[0].([]db.ListPostsWithMediaRow)
+	ret := m.ctrl.Call(m, "ReissuePasswordResetTx", arg0, arg1)
+	ret0, _ := ret[0].(db.PasswordResetToken)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListPostsWithMedia indicates an expected call of ListPostsWithMedia.
-func (mr *MockStoreMockRecorder) ListPostsWithMedia(arg0, arg1 interface{}) *gomock.Call {
+// ReissuePasswordResetTx indicates an expected call of ReissuePasswordResetTx.
+func (mr *MockStoreMockRecorder) ReissuePasswordResetTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostsWithMedia", reflect.TypeOf((*MockStore)(nil).ListPostsWithMedia), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReissuePasswordResetTx", reflect.TypeOf((*MockStore)(nil).ReissuePasswordResetTx), arg0, arg1)
 }
 
-// ListSessionsByUser mocks base method.
-func (m *MockStore) ListSessionsByUser(arg0 context.Context, arg1 int64) ([]db.Session, error) {
+// ReplaceMFARecoveryCodesTx mocks base method.
+func (m *MockStore) ReplaceMFARecoveryCodesTx(arg0 context.Context, arg1 db.ReplaceMFARecoveryCodesTxParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListSessionsByUser", arg0, arg1)
-	ret0, _ := ret[0].([]db.Session)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "ReplaceMFARecoveryCodesTx", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// ListSessionsByUser indicates an expected call of ListSessionsByUser.
-func (mr *MockStoreMockRecorder) ListSessionsByUser(arg0, arg1 interface{}) *gomock.Call {
+// ReplaceMFARecoveryCodesTx indicates an expected call of ReplaceMFARecoveryCodesTx.
+func (mr *MockStoreMockRecorder) ReplaceMFARecoveryCodesTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessionsByUser", reflect.TypeOf((*MockStore)(nil).ListSessionsByUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceMFARecoveryCodesTx", reflect.TypeOf((*MockStore)(nil).ReplaceMFARecoveryCodesTx), arg0, arg1)
 }
 
-// ListSessionsByUsername mocks base method.
-func (m *MockStore) ListSessionsByUsername(arg0 context.Context, arg1 string) ([]db.Session, error) {
+// ResetPasswordTx mocks base method.
+func (m *MockStore) ResetPasswordTx(arg0 context.Context, arg1 db.ResetPasswordTxParams) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListSessionsByUsername", arg0, arg1)
-	ret0, _ := ret[0].([]db.Session)
+	ret := m.ctrl.Call(m, "ResetPasswordTx", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListSessionsByUsername indicates an expected call of ListSessionsByUsername.
-func (mr *MockStoreMockRecorder) ListSessionsByUsername(arg0, arg1 interface{}) *gomock.Call {
+// ResetPasswordTx indicates an expected call of ResetPasswordTx.
+func (mr *MockStoreMockRecorder) ResetPasswordTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessionsByUsername", reflect.TypeOf((*MockStore)(nil).ListSessionsByUsername), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPasswordTx", reflect.TypeOf((*MockStore)(nil).ResetPasswordTx), arg0, arg1)
 }
 
-// ListTaxonomies mocks base method.
-func (m *MockStore) ListTaxonomies(arg0 context.Context, arg1 db.ListTaxonomiesParams) ([]db.Taxonomy, error) {
+// RevokeAPIKey mocks base method.
+func (m *MockStore) RevokeAPIKey(arg0 context.Context, arg1 db.RevokeAPIKeyParams) (db.ApiKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTaxonomies", arg0, arg1)
-	ret0, _ := ret[0].([]db.Taxonomy)
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(db.ApiKey)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListTaxonomies indicates an expected call of ListTaxonomies.
-func (mr *MockStoreMockRecorder) ListTaxonomies(arg0, arg1 interface{}) *gomock.Call {
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockStoreMockRecorder) RevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaxonomies", reflect.TypeOf((*MockStore)(nil).ListTaxonomies), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockStore)(nil).RevokeAPIKey), arg0, arg1)
 }
 
-// ListTaxonomiesWithPostCount mocks base method.
-func (m *MockStore) ListTaxonomiesWithPostCount(arg0 context.Context, arg1 db.ListTaxonomiesWithPostCountParams) ([]db.ListTaxonomiesWithPostCountRow, error) {
+// RevokeToken mocks base method.
+func (m *MockStore) RevokeToken(arg0 context.Context, arg1 db.RevokeTokenParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTaxonomiesWithPostCount", arg0, arg1)
-	ret0, _ := ret[0].([]db.ListTaxonomiesWithPostCountRow)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "RevokeToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// ListTaxonomiesWithPostCount indicates an expected call of ListTaxonomiesWithPostCount.
-func (mr *MockStoreMockRecorder) ListTaxonomiesWithPostCount(arg0, arg1 interface{}) *gomock.Call {
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockStoreMockRecorder) RevokeToken(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaxonomiesWithPostCount", reflect.TypeOf((*MockStore)(nil).ListTaxonomiesWithPostCount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockStore)(nil).RevokeToken), arg0, arg1)
 }
 
-// ListUsers mocks base method.
-func (m *MockStore) ListUsers(arg0 context.Context, arg1 db.ListUsersParams) ([]db.User, error) {
+// RotateSessionRefreshToken mocks base method.
+func (m *MockStore) RotateSessionRefreshToken(arg0 context.Context, arg1 db.RotateSessionRefreshTokenParams) (db.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
-	ret0, _ := ret[0].([]db.User)
+	ret := m.ctrl.Call(m, "RotateSessionRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListUsers indicates an expected call of ListUsers.
-func (mr *MockStoreMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call {
+// RotateSessionRefreshToken indicates an expected call of RotateSessionRefreshToken.
+func (mr *MockStoreMockRecorder) RotateSessionRefreshToken(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSessionRefreshToken", reflect.TypeOf((*MockStore)(nil).RotateSessionRefreshToken), arg0, arg1)
 }
 
 // SearchMediaByName mocks base method.
@@ -1079,6 +2182,21 @@ func (mr *MockStoreMockRecorder) SearchMediaByName(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMediaByName", reflect.TypeOf((*MockStore)(nil).SearchMediaByName), arg0, arg1)
 }
 
+// SearchPosts mocks base method.
+func (m *MockStore) SearchPosts(arg0 context.Context, arg1 db.SearchPostsParams) ([]db.SearchPostsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.SearchPostsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchPosts indicates an expected call of SearchPosts.
+func (mr *MockStoreMockRecorder) SearchPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPosts", reflect.TypeOf((*MockStore)(nil).SearchPosts), arg0, arg1)
+}
+
 // SearchTaxonomiesByName mocks base method.
 func (m *MockStore) SearchTaxonomiesByName(arg0 context.Context, arg1 db.SearchTaxonomiesByNameParams) ([]db.Taxonomy, error) {
 	m.ctrl.T.Helper()
@@ -1094,6 +2212,34 @@ func (mr *MockStoreMockRecorder) SearchTaxonomiesByName(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTaxonomiesByName", reflect.TypeOf((*MockStore)(nil).SearchTaxonomiesByName), arg0, arg1)
 }
 
+// TouchAPIKey mocks base method.
+func (m *MockStore) TouchAPIKey(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockStoreMockRecorder) TouchAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockStore)(nil).TouchAPIKey), arg0, arg1)
+}
+
+// TouchUserIdentity mocks base method.
+func (m *MockStore) TouchUserIdentity(arg0 context.Context, arg1 db.TouchUserIdentityParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchUserIdentity indicates an expected call of TouchUserIdentity.
+func (mr *MockStoreMockRecorder) TouchUserIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchUserIdentity", reflect.TypeOf((*MockStore)(nil).TouchUserIdentity), arg0, arg1)
+}
+
 // TransferMediaToUser mocks base method.
 func (m *MockStore) TransferMediaToUser(arg0 context.Context, arg1 db.TransferMediaToUserParams) error {
 	m.ctrl.T.Helper()
@@ -1166,6 +2312,36 @@ func (mr *MockStoreMockRecorder) UpdatePostMediaTx(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostMediaTx", reflect.TypeOf((*MockStore)(nil).UpdatePostMediaTx), arg0, arg1)
 }
 
+// UpdatePostSchedule mocks base method.
+func (m *MockStore) UpdatePostSchedule(arg0 context.Context, arg1 db.UpdatePostScheduleParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePostSchedule", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePostSchedule indicates an expected call of UpdatePostSchedule.
+func (mr *MockStoreMockRecorder) UpdatePostSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostSchedule", reflect.TypeOf((*MockStore)(nil).UpdatePostSchedule), arg0, arg1)
+}
+
+// UpdatePostStatus mocks base method.
+func (m *MockStore) UpdatePostStatus(arg0 context.Context, arg1 db.UpdatePostStatusParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePostStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePostStatus indicates an expected call of UpdatePostStatus.
+func (mr *MockStoreMockRecorder) UpdatePostStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostStatus", reflect.TypeOf((*MockStore)(nil).UpdatePostStatus), arg0, arg1)
+}
+
 // UpdatePostTaxonomiesTx mocks base method.
 func (m *MockStore) UpdatePostTaxonomiesTx(arg0 context.Context, arg1 db.UpdatePostTaxonomiesTxParams) error {
 	m.ctrl.T.Helper()
@@ -1180,6 +2356,21 @@ func (mr *MockStoreMockRecorder) UpdatePostTaxonomiesTx(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostTaxonomiesTx", reflect.TypeOf((*MockStore)(nil).UpdatePostTaxonomiesTx), arg0, arg1)
 }
 
+// UpdatePostTx mocks base method.
+func (m *MockStore) UpdatePostTx(arg0 context.Context, arg1 db.UpdatePostTxParams) (db.UpdatePostTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePostTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdatePostTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePostTx indicates an expected call of UpdatePostTx.
+func (mr *MockStoreMockRecorder) UpdatePostTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostTx", reflect.TypeOf((*MockStore)(nil).UpdatePostTx), arg0, arg1)
+}
+
 // UpdatePostsUsername mocks base method.
 func (m *MockStore) UpdatePostsUsername(arg0 context.Context, arg1 db.UpdatePostsUsernameParams) error {
 	m.ctrl.T.Helper()
@@ -1254,6 +2445,21 @@ func (mr *MockStoreMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), arg0, arg1)
 }
 
+// UpdateUserPassword mocks base method.
+func (m *MockStore) UpdateUserPassword(arg0 context.Context, arg1 db.UpdateUserPasswordParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockStoreMockRecorder) UpdateUserPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockStore)(nil).UpdateUserPassword), arg0, arg1)
+}
+
 // UpdateUserPostsOwnership mocks base method.
 func (m *MockStore) UpdateUserPostsOwnership(arg0 context.Context, arg1 db.UpdateUserPostsOwnershipParams) error {
 	m.ctrl.T.Helper()
@@ -1282,3 +2488,48 @@ func (mr *MockStoreMockRecorder) UpdateUserTx(arg0, arg1 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTx", reflect.TypeOf((*MockStore)(nil).UpdateUserTx), arg0, arg1)
 }
+
+// UpsertUserMFASecret mocks base method.
+func (m *MockStore) UpsertUserMFASecret(arg0 context.Context, arg1 db.UpsertUserMFASecretParams) (db.UserMfa, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertUserMFASecret", arg0, arg1)
+	ret0, _ := ret[0].(db.UserMfa)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertUserMFASecret indicates an expected call of UpsertUserMFASecret.
+func (mr *MockStoreMockRecorder) UpsertUserMFASecret(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUserMFASecret", reflect.TypeOf((*MockStore)(nil).UpsertUserMFASecret), arg0, arg1)
+}
+
+// UseUserMFAStep mocks base method.
+func (m *MockStore) UseUserMFAStep(arg0 context.Context, arg1 db.UseUserMFAStepParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseUserMFAStep", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseUserMFAStep indicates an expected call of UseUserMFAStep.
+func (mr *MockStoreMockRecorder) UseUserMFAStep(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseUserMFAStep", reflect.TypeOf((*MockStore)(nil).UseUserMFAStep), arg0, arg1)
+}
+
+// VerifyEmailTx mocks base method.
+func (m *MockStore) VerifyEmailTx(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmailTx", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmailTx indicates an expected call of VerifyEmailTx.
+func (mr *MockStoreMockRecorder) VerifyEmailTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmailTx", reflect.TypeOf((*MockStore)(nil).VerifyEmailTx), arg0, arg1)
+}
@@ -0,0 +1,23 @@
+-- name: CreateEmailVerificationToken :one
+INSERT INTO email_verification_tokens (
+    user_id,
+    token_hash,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: ConsumeEmailVerificationToken :one
+-- Marks a token used in the same statement that checks it, so it can only
+-- succeed once.
+UPDATE email_verification_tokens
+SET used_at = now()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > now()
+RETURNING *;
+
+-- name: DeleteUnusedEmailVerificationTokens :exec
+DELETE FROM email_verification_tokens
+WHERE user_id = $1
+  AND used_at IS NULL;
@@ -0,0 +1,36 @@
+-- name: RecordLoginFailure :one
+-- The count starts over when the previous failure happened before reset_before.
+INSERT INTO login_failures (
+    kind,
+    subject,
+    failures,
+    last_failure_at
+) VALUES (
+    $1, $2, 1, now()
+)
+ON CONFLICT (kind, subject) DO UPDATE
+SET failures = CASE
+        WHEN login_failures.last_failure_at < sqlc.arg(reset_before) THEN 1
+        ELSE login_failures.failures + 1
+    END,
+    last_failure_at = now()
+RETURNING *;
+
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures
+WHERE kind = $1 AND subject = $2;
+
+-- name: DeleteLoginFailure :exec
+DELETE FROM login_failures
+WHERE kind = $1 AND subject = $2;
+
+-- name: ListLoginLockouts :many
+SELECT * FROM login_failures
+WHERE kind = $1
+  AND failures >= sqlc.arg(min_failures)
+  AND last_failure_at > sqlc.arg(since)
+ORDER BY last_failure_at DESC;
+
+-- name: DeleteExpiredLoginFailures :execrows
+DELETE FROM login_failures
+WHERE last_failure_at < $1;
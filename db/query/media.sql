@@ -4,9 +4,15 @@ INSERT INTO media (
     description,
     alt,
     media_path,
-    user_id
+    user_id,
+    storage_key,
+    size,
+    mime_type,
+    checksum,
+    width,
+    height
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
 ) RETURNING *;
 
 -- name: GetMedia :one
@@ -26,25 +32,36 @@ ORDER BY created_at DESC
 LIMIT $1
 OFFSET $2;
 
+-- name: ListMediaByIDs :many
+SELECT * FROM media
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
 -- name: UpdateMedia :one
 UPDATE media
 SET
-    name = COALESCE($2, name),
-    description = COALESCE($3, description),
-    alt = COALESCE($4, alt),
-    media_path = COALESCE($5, media_path),
-    changed_at = now()
-WHERE id = $1
+    name = COALESCE(sqlc.arg(name), name),
+    description = COALESCE(sqlc.arg(description), description),
+    alt = COALESCE(sqlc.arg(alt), alt),
+    media_path = COALESCE(sqlc.arg(media_path), media_path),
+    changed_at = now(),
+    version = version + 1
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(expected_version)::bigint IS NULL OR version = sqlc.narg(expected_version))
 RETURNING *;
 
--- name: DeleteMedia :exec
+-- name: DeleteMedia :execrows
 DELETE FROM media
-WHERE id = $1;
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(expected_version)::bigint IS NULL OR version = sqlc.narg(expected_version));
 
 -- name: DeleteMediaByUserID :exec
 DELETE FROM media
 WHERE user_id = $1;
 
+-- name: ListMediaIDsByUser :many
+SELECT id FROM media
+WHERE user_id = $1;
+
 -- name: SearchMediaByName :many
 SELECT * FROM media
 WHERE name ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%'
@@ -58,6 +75,12 @@ JOIN post_media pm ON m.id = pm.media_id
 WHERE pm.post_id = $1
 ORDER BY pm."order", m.created_at;
 
+-- name: GetMediaByPostIDs :many
+SELECT pm.post_id, m.* FROM media m
+JOIN post_media pm ON m.id = pm.media_id
+WHERE pm.post_id = ANY(sqlc.arg(post_ids)::bigint[])
+ORDER BY pm.post_id, pm."order", m.created_at;
+
 -- name: CreatePostMedia :one
 INSERT INTO post_media (
     post_id,
@@ -115,7 +138,7 @@ WHERE user_id = $1;
 
 -- name: TransferMediaToUser :exec
 UPDATE media
-SET user_id = $2
+SET user_id = $2, version = version + 1
 WHERE user_id = $1;
 
 -- name: GetPostWithMedia :one
@@ -192,11 +215,12 @@ SELECT
 FROM posts p
 LEFT JOIN post_media pm ON p.id = pm.post_id
 LEFT JOIN media m ON pm.media_id = m.id
-WHERE p.user_id = $1
+WHERE p.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(status)::varchar IS NULL OR p.status = sqlc.narg(status))
 GROUP BY p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at
 ORDER BY p.created_at DESC
-LIMIT $2
-OFFSET $3;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
 -- name: CountTotalMedia :one
 SELECT COUNT(*) AS total FROM media;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- name: CreateMediaRendition :one
+INSERT INTO media_renditions (
+    media_id,
+    name,
+    format,
+    storage_key,
+    mime_type,
+    width,
+    height,
+    size
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: ListMediaRenditions :many
+SELECT * FROM media_renditions
+WHERE media_id = ANY(sqlc.arg(media_ids)::bigint[])
+ORDER BY media_id, width, format;
+
+-- name: DeleteMediaRenditions :exec
+DELETE FROM media_renditions
+WHERE media_id = $1;
@@ -0,0 +1,43 @@
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (
+    state_hash,
+    provider,
+    nonce,
+    code_verifier,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+);
+
+-- name: ConsumeOIDCLoginState :one
+-- Deletes the state as it is read, so a callback can only be replayed once.
+DELETE FROM oidc_login_states
+WHERE state_hash = $1
+  AND expires_at > now()
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= now();
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (
+    user_id,
+    provider,
+    subject,
+    email
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE provider = $1
+  AND subject = $2
+LIMIT 1;
+
+-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET last_login_at = now(),
+    email = $2
+WHERE id = $1;
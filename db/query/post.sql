@@ -5,9 +5,10 @@ INSERT INTO posts (
     user_id,
     username,
     content,
-    url
+    url,
+    language
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, COALESCE(sqlc.narg(language)::varchar, 'english')::regconfig
 ) RETURNING *;
 
 -- name: CreateUserPost :one
@@ -19,35 +20,162 @@ INSERT INTO user_posts (
     $1, $2, $3
 ) RETURNING *;
 
+-- name: ListPostAuthors :many
+SELECT * FROM user_posts
+WHERE post_id = $1
+ORDER BY "order";
+
+-- name: ListPostAuthorsByPostIDs :many
+SELECT up.post_id, u.* FROM user_posts up
+JOIN users u ON u.id = up.user_id
+WHERE up.post_id = ANY(sqlc.arg(post_ids)::bigint[])
+ORDER BY up.post_id, up."order";
+
 -- name: GetPost :one
 SELECT * FROM posts 
 WHERE id = $1 LIMIT 1;
 
 -- name: ListPosts :many
 SELECT * FROM posts 
+WHERE sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)
 ORDER BY id DESC
-LIMIT $1
-OFFSET $2;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListPostsByIDs :many
+SELECT * FROM posts
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
 
 -- name: UpdatePost :one
 UPDATE posts
-SET title = COALESCE($1, title),
-    description = COALESCE($2, description),
-    user_id = COALESCE($3, user_id),
-    username = COALESCE($4, username),
-    content = COALESCE($5, content),
-    url = COALESCE($6, url),
-    changed_at = now()
-WHERE id = $7
+SET title = COALESCE(sqlc.arg(title), title),
+    description = COALESCE(sqlc.arg(description), description),
+    user_id = COALESCE(sqlc.arg(user_id), user_id),
+    username = COALESCE(sqlc.arg(username), username),
+    content = COALESCE(sqlc.arg(content), content),
+    url = COALESCE(sqlc.arg(url), url),
+    language = COALESCE(sqlc.narg(language)::varchar::regconfig, language),
+    changed_at = now(),
+    version = version + 1
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(expected_version)::bigint IS NULL OR version = sqlc.narg(expected_version))
 RETURNING *;
 
--- name: DeletePost :exec
+-- name: UpdatePostStatus :one
+UPDATE posts
+SET status = sqlc.arg(status),
+    published_at = CASE
+        WHEN sqlc.arg(status)::varchar = 'published' THEN COALESCE(published_at, now())
+        ELSE published_at
+    END,
+    changed_at = now(),
+    version = version + 1
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
+RETURNING *;
+
+-- name: UpdatePostSchedule :one
+UPDATE posts
+SET publish_at = sqlc.arg(publish_at),
+    unpublish_at = sqlc.arg(unpublish_at),
+    changed_at = now(),
+    version = version + 1
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
+  AND (sqlc.narg(expected_version)::bigint IS NULL OR version = sqlc.narg(expected_version))
+RETURNING *;
+
+-- name: PublishDuePosts :many
+WITH due AS (
+    SELECT id FROM posts
+    WHERE status = 'scheduled' AND publish_at <= now()
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE posts p
+SET status = 'published',
+    published_at = COALESCE(p.published_at, p.publish_at),
+    changed_at = now(),
+    version = p.version + 1
+FROM due
+WHERE p.id = due.id
+RETURNING p.*;
+
+-- name: ArchiveExpiredPosts :many
+WITH expired AS (
+    SELECT id FROM posts
+    WHERE status = 'published' AND unpublish_at <= now()
+    ORDER BY unpublish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE posts p
+SET status = 'archived',
+    unpublish_at = NULL,
+    changed_at = now(),
+    version = p.version + 1
+FROM expired
+WHERE p.id = expired.id
+RETURNING p.*;
+
+-- name: DeletePost :execrows
 DELETE FROM posts
-WHERE id = $1;
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(expected_version)::bigint IS NULL OR version = sqlc.narg(expected_version));
 
 -- name: DeleteUserPost :exec
 DELETE FROM user_posts
 WHERE post_id = $1;
 
 -- name: CountTotalPosts :one
-SELECT COUNT(*) AS total FROM posts;
+SELECT COUNT(*) AS total FROM posts
+WHERE sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status);
+
+-- name: SearchPosts :many
+-- Ranks and highlights only the page of matches being returned, since
+-- ts_headline has to re-parse each document. The text is HTML-escaped before
+-- it is highlighted, so the only markup in the result is the <mark> tags.
+WITH matches AS (
+    SELECT id, ts_rank(post_search_vector(language, title, description, content), websearch_to_tsquery(sqlc.arg(language)::varchar::regconfig, sqlc.arg(query))) AS rank
+    FROM posts
+    WHERE post_search_vector(language, title, description, content) @@ websearch_to_tsquery(sqlc.arg(language)::varchar::regconfig, sqlc.arg(query))
+      AND language = sqlc.arg(language)::varchar::regconfig
+      AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+      AND (sqlc.narg(taxonomy_id)::bigint IS NULL OR EXISTS (
+          SELECT 1 FROM posts_taxonomies pt
+          WHERE pt.post_id = posts.id AND pt.taxonomy_id = sqlc.narg(taxonomy_id)
+      ))
+      AND (sqlc.narg(author_id)::bigint IS NULL OR EXISTS (
+          SELECT 1 FROM user_posts up
+          WHERE up.post_id = posts.id AND up.user_id = sqlc.narg(author_id)
+      ))
+      AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after))
+      AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before))
+    ORDER BY rank DESC, id DESC
+    LIMIT sqlc.arg('limit')
+    OFFSET sqlc.arg('offset')
+)
+SELECT p.*,
+    m.rank::real AS rank,
+    ts_headline(p.language, replace(replace(replace(p.title, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), websearch_to_tsquery(sqlc.arg(language)::varchar::regconfig, sqlc.arg(query)),
+        'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS title_highlight,
+    ts_headline(p.language, replace(replace(replace(p.content, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), websearch_to_tsquery(sqlc.arg(language)::varchar::regconfig, sqlc.arg(query)),
+        'MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … ", StartSel=<mark>, StopSel=</mark>')::text AS snippet
+FROM matches m
+JOIN posts p ON p.id = m.id
+ORDER BY m.rank DESC, p.id DESC;
+
+-- name: CountSearchPosts :one
+SELECT COUNT(*) AS total FROM posts
+WHERE post_search_vector(language, title, description, content) @@ websearch_to_tsquery(sqlc.arg(language)::varchar::regconfig, sqlc.arg(query))
+  AND language = sqlc.arg(language)::varchar::regconfig
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(taxonomy_id)::bigint IS NULL OR EXISTS (
+      SELECT 1 FROM posts_taxonomies pt
+      WHERE pt.post_id = posts.id AND pt.taxonomy_id = sqlc.narg(taxonomy_id)
+  ))
+  AND (sqlc.narg(author_id)::bigint IS NULL OR EXISTS (
+      SELECT 1 FROM user_posts up
+      WHERE up.post_id = posts.id AND up.user_id = sqlc.narg(author_id)
+  ))
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before));
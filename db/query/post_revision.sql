@@ -0,0 +1,59 @@
+-- name: CreateInitialPostRevision :exec
+-- Records a post's current state as revision 1 unless it already has one.
+INSERT INTO post_revisions (
+    post_id,
+    revision,
+    title,
+    description,
+    content,
+    url,
+    user_id,
+    username,
+    created_at
+)
+SELECT id, 1, title, description, content, url, user_id, username, changed_at
+FROM posts
+WHERE id = $1
+ON CONFLICT (post_id, revision) DO NOTHING;
+
+-- name: CreatePostRevision :one
+INSERT INTO post_revisions (
+    post_id,
+    revision,
+    title,
+    description,
+    content,
+    url,
+    user_id,
+    username,
+    restored_from
+)
+SELECT
+    sqlc.arg(post_id),
+    COALESCE(MAX(revision), 0) + 1,
+    sqlc.arg(title)::varchar,
+    sqlc.arg(description)::varchar,
+    sqlc.arg(content)::text,
+    sqlc.arg(url)::varchar,
+    sqlc.narg(user_id)::bigint,
+    sqlc.arg(username)::varchar,
+    sqlc.narg(restored_from)::int
+FROM post_revisions
+WHERE post_id = sqlc.arg(post_id)
+RETURNING *;
+
+-- name: GetPostRevision :one
+SELECT * FROM post_revisions
+WHERE post_id = $1 AND revision = $2
+LIMIT 1;
+
+-- name: ListPostRevisions :many
+SELECT * FROM post_revisions
+WHERE post_id = $1
+ORDER BY revision DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CountPostRevisions :one
+SELECT COUNT(*) FROM post_revisions
+WHERE post_id = $1;
@@ -42,5 +42,18 @@ UPDATE sessions
 SET is_blocked = true
 WHERE id = $1;
 
+-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE user_id = $1;
+
 -- name: CountTotalSessions :one
-SELECT COUNT(*) AS total FROM sessions;
\ No newline at end of file
+SELECT COUNT(*) AS total FROM sessions;
+
+-- name: RotateSessionRefreshToken :one
+UPDATE sessions
+SET refresh_token = sqlc.arg(new_refresh_token)
+WHERE id = sqlc.arg(id)
+  AND refresh_token = sqlc.arg(refresh_token)
+  AND is_blocked = false
+RETURNING *;
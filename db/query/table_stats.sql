@@ -0,0 +1,6 @@
+-- name: EstimateTableRows :one
+-- reltuples is kept up to date by VACUUM and ANALYZE, so reading it is cheap
+-- but approximate. It is -1 until a table has been analyzed.
+SELECT GREATEST(reltuples, 0)::bigint AS estimate
+FROM pg_class
+WHERE oid = to_regclass(sqlc.arg(table_name)::text);
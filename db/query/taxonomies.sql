@@ -20,17 +20,24 @@ ORDER BY name
 LIMIT $1
 OFFSET $2;
 
+-- name: ListTaxonomiesByIDs :many
+SELECT * FROM taxonomies
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
 -- name: UpdateTaxonomy :one
 UPDATE taxonomies 
 SET 
-    name = COALESCE($2, name),
-    description = COALESCE($3, description)
-WHERE id = $1
+    name = COALESCE(sqlc.arg(name), name),
+    description = COALESCE(sqlc.arg(description), description),
+    version = version + 1
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(expected_version)::bigint IS NULL OR version = sqlc.narg(expected_version))
 RETURNING *;
 
--- name: DeleteTaxonomy :exec
+-- name: DeleteTaxonomy :execrows
 DELETE FROM taxonomies
-WHERE id = $1;
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(expected_version)::bigint IS NULL OR version = sqlc.narg(expected_version));
 
 -- name: CreatePostTaxonomy :one
 INSERT INTO posts_taxonomies (
@@ -46,13 +53,20 @@ JOIN posts_taxonomies pt ON t.id = pt.taxonomy_id
 WHERE pt.post_id = $1
 ORDER BY t.name;
 
+-- name: GetTaxonomiesByPostIDs :many
+SELECT pt.post_id, t.* FROM taxonomies t
+JOIN posts_taxonomies pt ON t.id = pt.taxonomy_id
+WHERE pt.post_id = ANY(sqlc.arg(post_ids)::bigint[])
+ORDER BY pt.post_id, t.name;
+
 -- name: GetTaxonomyPosts :many
 SELECT p.* FROM posts p
 JOIN posts_taxonomies pt ON p.id = pt.post_id
-WHERE pt.taxonomy_id = $1
+WHERE pt.taxonomy_id = sqlc.arg(taxonomy_id)
+  AND (sqlc.narg(status)::varchar IS NULL OR p.status = sqlc.narg(status))
 ORDER BY p.created_at DESC
-LIMIT $2
-OFFSET $3;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
 -- name: DeletePostTaxonomy :exec
 DELETE FROM posts_taxonomies
@@ -74,6 +88,10 @@ WHERE post_id = $1;
 SELECT COUNT(*) FROM posts_taxonomies
 WHERE taxonomy_id = $1;
 
+-- name: ListPostIDsByTaxonomy :many
+SELECT post_id FROM posts_taxonomies
+WHERE taxonomy_id = $1;
+
 -- name: ListTaxonomiesWithPostCount :many
 SELECT 
     t.*,
@@ -0,0 +1,66 @@
+-- name: UpsertUserMFASecret :one
+-- Starts or restarts enrolment. Once 2FA is enabled the row is left alone and
+-- no row is returned, so a pending login cannot replace a confirmed secret.
+INSERT INTO user_mfa (
+    user_id,
+    totp_secret
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET
+    totp_secret = EXCLUDED.totp_secret,
+    last_used_step = 0,
+    created_at = now()
+WHERE user_mfa.enabled_at IS NULL
+RETURNING *;
+
+-- name: GetUserMFA :one
+SELECT * FROM user_mfa
+WHERE user_id = $1 LIMIT 1;
+
+-- name: EnableUserMFA :one
+UPDATE user_mfa
+SET
+    enabled_at = now(),
+    last_used_step = $2
+WHERE user_id = $1
+  AND enabled_at IS NULL
+RETURNING *;
+
+-- name: UseUserMFAStep :execrows
+-- Records the time step of an accepted code. It matches no row when the step
+-- was already used, which is how replayed codes are refused.
+UPDATE user_mfa
+SET last_used_step = $2
+WHERE user_id = $1
+  AND last_used_step < $2;
+
+-- name: DeleteUserMFA :exec
+DELETE FROM user_mfa
+WHERE user_id = $1;
+
+-- name: CreateMFARecoveryCode :one
+INSERT INTO mfa_recovery_codes (
+    user_id,
+    code_hash
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: ConsumeMFARecoveryCode :one
+UPDATE mfa_recovery_codes
+SET used_at = now()
+WHERE user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL
+RETURNING *;
+
+-- name: CountUnusedMFARecoveryCodes :one
+SELECT COUNT(*) FROM mfa_recovery_codes
+WHERE user_id = $1
+  AND used_at IS NULL;
+
+-- name: DeleteMFARecoveryCodes :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1;
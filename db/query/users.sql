@@ -4,9 +4,10 @@ INSERT INTO users (
     full_name,
     email,
     hashed_password,
-    role
+    role,
+    email_verified_at
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetUser :one
@@ -32,18 +33,38 @@ OFFSET $2;
 -- name: UpdateUser :one
 UPDATE users 
 SET 
-    username = COALESCE($2, username),
-    full_name = COALESCE($3, full_name),
-    email = COALESCE($4, email),
-    hashed_password = COALESCE($5, hashed_password),
-    password_changed_at = COALESCE($6, password_changed_at),
-    role = COALESCE($7, role)
+    username = COALESCE(sqlc.arg(username), username),
+    full_name = COALESCE(sqlc.arg(full_name), full_name),
+    email = COALESCE(sqlc.arg(email), email),
+    hashed_password = COALESCE(sqlc.arg(hashed_password), hashed_password),
+    password_changed_at = COALESCE(sqlc.arg(password_changed_at), password_changed_at),
+    role = COALESCE(sqlc.arg(role), role),
+    version = version + 1
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(expected_version)::bigint IS NULL OR version = sqlc.narg(expected_version))
+RETURNING *;
+
+-- name: MarkUserEmailVerified :one
+UPDATE users
+SET
+    email_verified_at = COALESCE(email_verified_at, now()),
+    version = version + 1
 WHERE id = $1
 RETURNING *;
 
--- name: DeleteUser :exec
+-- name: UpdateUserPassword :one
+UPDATE users
+SET
+    hashed_password = $2,
+    password_changed_at = now(),
+    version = version + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteUser :execrows
 DELETE FROM users
-WHERE id = $1;
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(expected_version)::bigint IS NULL OR version = sqlc.narg(expected_version));
 
 -- name: DeleteUserSessions :exec
 DELETE FROM sessions
@@ -57,14 +78,21 @@ WHERE user_id = $1;
 DELETE FROM posts
 WHERE user_id = $1;
 
+-- name: ListPostIDsByUser :many
+SELECT id FROM posts
+WHERE user_id = $1
+UNION
+SELECT post_id FROM user_posts
+WHERE user_id = $1;
+
 -- name: UpdatePostsUsername :exec
 UPDATE posts
-SET username = $2
+SET username = $2, version = version + 1
 WHERE user_id = $1;
 
 -- name: TransferPostsToAdmin :exec
 UPDATE posts 
-SET user_id = $2, username = (SELECT username FROM users WHERE id = $2)
+SET user_id = $2, username = (SELECT username FROM users WHERE id = $2), version = version + 1
 WHERE user_id = $1;
 
 -- name: UpdateUserPostsOwnership :exec
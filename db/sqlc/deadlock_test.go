@@ -135,7 +135,7 @@ func TestConcurrentPostOperations_Deadlock(t *testing.T) {
 		},
 
 		func() {
-			err := testStore.DeletePostTx(context.Background(), post2.Post.ID)
+			err := testStore.DeletePostTx(context.Background(), DeletePostTxParams{ID: post2.Post.ID})
 			if err != nil {
 				errChan <- fmt.Errorf("delete post2: %w", err)
 			}
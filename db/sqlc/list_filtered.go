@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+)
+
+// ListFilter is a WHERE and ORDER BY clause for the *Filtered list queries,
+// which sqlc's static queries cannot express. It is built by the listquery
+// package from whitelisted column names only; every client-supplied value is
+// in Args, referenced from the clause as $1, $2, ...
+type ListFilter struct {
+	Where   string
+	Args    []interface{}
+	OrderBy string
+}
+
+type ListFilteredParams struct {
+	Filter ListFilter
+	Limit  int32
+	Offset int32
+}
+
+const (
+	postColumns     = "id, title, description, content, user_id, username, url, created_at, changed_at, status, published_at, publish_at, unpublish_at, version, language"
+	userColumns     = "id, username, full_name, email, hashed_password, password_changed_at, created_at, role, version, email_verified_at"
+	mediaColumns    = "id, name, description, alt, media_path, user_id, created_at, changed_at, storage_key, size, mime_type, checksum, width, height, version"
+	taxonomyColumns = "id, name, description, version"
+)
+
+// selectFiltered builds a paged SELECT, numbering LIMIT and OFFSET after the
+// filter's own arguments.
+func selectFiltered(columns, from string, arg ListFilteredParams) (string, []interface{}) {
+	n := len(arg.Filter.Args)
+	query := "SELECT " + columns + " FROM " + from +
+		" WHERE " + arg.Filter.Where +
+		" ORDER BY " + arg.Filter.OrderBy +
+		" LIMIT $" + strconv.Itoa(n+1) +
+		" OFFSET $" + strconv.Itoa(n+2)
+	args := append(append([]interface{}{}, arg.Filter.Args...), arg.Limit, arg.Offset)
+	return query, args
+}
+
+// queryFiltered runs query and scans every row with scan.
+func queryFiltered[T any](ctx context.Context, db DBTX, query string, args []interface{}, scan func(*sql.Rows, *T) error) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []T{}
+	for rows.Next() {
+		var i T
+		if err := scan(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (q *Queries) countFiltered(ctx context.Context, from string, filter ListFilter) (int64, error) {
+	row := q.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+from+" WHERE "+filter.Where, filter.Args...)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+func (q *Queries) ListPostsFiltered(ctx context.Context, arg ListFilteredParams) ([]Post, error) {
+	query, args := selectFiltered(postColumns, "posts", arg)
+	return queryFiltered(ctx, q.db, query, args, func(rows *sql.Rows, i *Post) error {
+		return rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Content,
+			&i.UserID,
+			&i.Username,
+			&i.Url,
+			&i.CreatedAt,
+			&i.ChangedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.Version,
+			&i.Language,
+		)
+	})
+}
+
+func (q *Queries) CountPostsFiltered(ctx context.Context, filter ListFilter) (int64, error) {
+	return q.countFiltered(ctx, "posts", filter)
+}
+
+func (q *Queries) ListUsersFiltered(ctx context.Context, arg ListFilteredParams) ([]User, error) {
+	query, args := selectFiltered(userColumns, "users", arg)
+	return queryFiltered(ctx, q.db, query, args, func(rows *sql.Rows, i *User) error {
+		return rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FullName,
+			&i.Email,
+			&i.HashedPassword,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.Role,
+			&i.Version,
+			&i.EmailVerifiedAt,
+		)
+	})
+}
+
+func (q *Queries) CountUsersFiltered(ctx context.Context, filter ListFilter) (int64, error) {
+	return q.countFiltered(ctx, "users", filter)
+}
+
+func (q *Queries) ListMediaFiltered(ctx context.Context, arg ListFilteredParams) ([]Medium, error) {
+	query, args := selectFiltered(mediaColumns, "media", arg)
+	return queryFiltered(ctx, q.db, query, args, func(rows *sql.Rows, i *Medium) error {
+		return rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Alt,
+			&i.MediaPath,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
+		)
+	})
+}
+
+// ListMediaWithPostCountFiltered counts links in a subquery rather than with
+// GROUP BY, so the filter can refer to the media columns unqualified.
+func (q *Queries) ListMediaWithPostCountFiltered(ctx context.Context, arg ListFilteredParams) ([]ListMediaWithPostCountRow, error) {
+	columns := mediaColumns + ", (SELECT COUNT(*) FROM post_media pm WHERE pm.media_id = media.id) AS post_count"
+	query, args := selectFiltered(columns, "media", arg)
+	return queryFiltered(ctx, q.db, query, args, func(rows *sql.Rows, i *ListMediaWithPostCountRow) error {
+		return rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Alt,
+			&i.MediaPath,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
+			&i.PostCount,
+		)
+	})
+}
+
+func (q *Queries) CountMediaFiltered(ctx context.Context, filter ListFilter) (int64, error) {
+	return q.countFiltered(ctx, "media", filter)
+}
+
+func (q *Queries) ListTaxonomiesFiltered(ctx context.Context, arg ListFilteredParams) ([]Taxonomy, error) {
+	query, args := selectFiltered(taxonomyColumns, "taxonomies", arg)
+	return queryFiltered(ctx, q.db, query, args, func(rows *sql.Rows, i *Taxonomy) error {
+		return rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Version,
+		)
+	})
+}
+
+func (q *Queries) ListTaxonomiesWithPostCountFiltered(ctx context.Context, arg ListFilteredParams) ([]ListTaxonomiesWithPostCountRow, error) {
+	columns := taxonomyColumns + ", (SELECT COUNT(*) FROM posts_taxonomies pt WHERE pt.taxonomy_id = taxonomies.id) AS post_count"
+	query, args := selectFiltered(columns, "taxonomies", arg)
+	return queryFiltered(ctx, q.db, query, args, func(rows *sql.Rows, i *ListTaxonomiesWithPostCountRow) error {
+		return rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Version,
+			&i.PostCount,
+		)
+	})
+}
+
+func (q *Queries) CountTaxonomiesFiltered(ctx context.Context, filter ListFilter) (int64, error) {
+	return q.countFiltered(ctx, "taxonomies", filter)
+}
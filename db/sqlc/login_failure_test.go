@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	subject := gofakeit.Username()
+
+	failure, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Kind:        "username",
+		Subject:     subject,
+		ResetBefore: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.Failures)
+	require.WithinDuration(t, time.Now(), failure.LastFailureAt, time.Second)
+
+	failure, err = testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Kind:        "username",
+		Subject:     subject,
+		ResetBefore: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), failure.Failures)
+
+	// A previous failure older than reset_before starts the count over.
+	failure, err = testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Kind:        "username",
+		Subject:     subject,
+		ResetBefore: time.Now().Add(time.Second),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.Failures)
+
+	stored, err := testQueries.GetLoginFailure(context.Background(), GetLoginFailureParams{Kind: "username", Subject: subject})
+	require.NoError(t, err)
+	require.Equal(t, failure, stored)
+
+	_, err = testQueries.GetLoginFailure(context.Background(), GetLoginFailureParams{Kind: "ip", Subject: subject})
+	require.Error(t, err)
+}
+
+func TestListLoginLockouts(t *testing.T) {
+	locked := gofakeit.Username()
+	for i := 0; i < 3; i++ {
+		_, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+			Kind:        "username",
+			Subject:     locked,
+			ResetBefore: time.Now().Add(-time.Hour),
+		})
+		require.NoError(t, err)
+	}
+
+	lockouts, err := testQueries.ListLoginLockouts(context.Background(), ListLoginLockoutsParams{
+		Kind:        "username",
+		MinFailures: 3,
+		Since:       time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	found := false
+	for _, lockout := range lockouts {
+		require.GreaterOrEqual(t, lockout.Failures, int32(3))
+		if lockout.Subject == locked {
+			found = true
+		}
+	}
+	require.True(t, found)
+
+	err = testQueries.DeleteLoginFailure(context.Background(), DeleteLoginFailureParams{Kind: "username", Subject: locked})
+	require.NoError(t, err)
+
+	_, err = testQueries.GetLoginFailure(context.Background(), GetLoginFailureParams{Kind: "username", Subject: locked})
+	require.Error(t, err)
+
+	purged, err := testQueries.DeleteExpiredLoginFailures(context.Background(), time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, purged, int64(0))
+}
@@ -9,6 +9,8 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 const countTotalMedia = `-- name: CountTotalMedia :one
@@ -28,18 +30,30 @@ INSERT INTO media (
     description,
     alt,
     media_path,
-    user_id
+    user_id,
+    storage_key,
+    size,
+    mime_type,
+    checksum,
+    width,
+    height
 ) VALUES (
-    $1, $2, $3, $4, $5
-) RETURNING id, name, description, alt, media_path, user_id, created_at, changed_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING id, name, description, alt, media_path, user_id, created_at, changed_at, storage_key, size, mime_type, checksum, width, height, version
 `
 
 type CreateMediaParams struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Alt         string `json:"alt"`
-	MediaPath   string `json:"media_path"`
-	UserID      int64  `json:"user_id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Alt         string        `json:"alt"`
+	MediaPath   string        `json:"media_path"`
+	UserID      int64         `json:"user_id"`
+	StorageKey  string        `json:"storage_key"`
+	Size        int64         `json:"size"`
+	MimeType    string        `json:"mime_type"`
+	Checksum    string        `json:"checksum"`
+	Width       sql.NullInt32 `json:"width"`
+	Height      sql.NullInt32 `json:"height"`
 }
 
 func (q *Queries) CreateMedia(ctx context.Context, arg CreateMediaParams) (Medium, error) {
@@ -49,6 +63,12 @@ func (q *Queries) CreateMedia(ctx context.Context, arg CreateMediaParams) (Mediu
 		arg.Alt,
 		arg.MediaPath,
 		arg.UserID,
+		arg.StorageKey,
+		arg.Size,
+		arg.MimeType,
+		arg.Checksum,
+		arg.Width,
+		arg.Height,
 	)
 	var i Medium
 	err := row.Scan(
@@ -60,6 +80,13 @@ func (q *Queries) CreateMedia(ctx context.Context, arg CreateMediaParams) (Mediu
 		&i.UserID,
 		&i.CreatedAt,
 		&i.ChangedAt,
+		&i.StorageKey,
+		&i.Size,
+		&i.MimeType,
+		&i.Checksum,
+		&i.Width,
+		&i.Height,
+		&i.Version,
 	)
 	return i, err
 }
@@ -87,14 +114,23 @@ func (q *Queries) CreatePostMedia(ctx context.Context, arg CreatePostMediaParams
 	return i, err
 }
 
-const deleteMedia = `-- name: DeleteMedia :exec
+const deleteMedia = `-- name: DeleteMedia :execrows
 DELETE FROM media
 WHERE id = $1
+  AND ($2::bigint IS NULL OR version = $2)
 `
 
-func (q *Queries) DeleteMedia(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteMedia, id)
-	return err
+type DeleteMediaParams struct {
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
+}
+
+func (q *Queries) DeleteMedia(ctx context.Context, arg DeleteMediaParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteMedia, arg.ID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const deleteMediaByUserID = `-- name: DeleteMediaByUserID :exec
@@ -143,7 +179,7 @@ func (q *Queries) DeletePostMedias(ctx context.Context, postID int64) error {
 }
 
 const getMedia = `-- name: GetMedia :one
-SELECT id, name, description, alt, media_path, user_id, created_at, changed_at FROM media
+SELECT id, name, description, alt, media_path, user_id, created_at, changed_at, storage_key, size, mime_type, checksum, width, height, version FROM media
 WHERE id = $1 LIMIT 1
 `
 
@@ -159,12 +195,19 @@ func (q *Queries) GetMedia(ctx context.Context, id int64) (Medium, error) {
 		&i.UserID,
 		&i.CreatedAt,
 		&i.ChangedAt,
+		&i.StorageKey,
+		&i.Size,
+		&i.MimeType,
+		&i.Checksum,
+		&i.Width,
+		&i.Height,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getMediaByPost = `-- name: GetMediaByPost :many
-SELECT m.id, m.name, m.description, m.alt, m.media_path, m.user_id, m.created_at, m.changed_at FROM media m
+SELECT m.id, m.name, m.description, m.alt, m.media_path, m.user_id, m.created_at, m.changed_at, m.storage_key, m.size, m.mime_type, m.checksum, m.width, m.height, m.version FROM media m
 JOIN post_media pm ON m.id = pm.media_id
 WHERE pm.post_id = $1
 ORDER BY pm."order", m.created_at
@@ -188,6 +231,79 @@ func (q *Queries) GetMediaByPost(ctx context.Context, postID int64) ([]Medium, e
 			&i.UserID,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMediaByPostIDs = `-- name: GetMediaByPostIDs :many
+SELECT pm.post_id, m.id, m.name, m.description, m.alt, m.media_path, m.user_id, m.created_at, m.changed_at, m.storage_key, m.size, m.mime_type, m.checksum, m.width, m.height, m.version FROM media m
+JOIN post_media pm ON m.id = pm.media_id
+WHERE pm.post_id = ANY($1::bigint[])
+ORDER BY pm.post_id, pm."order", m.created_at
+`
+
+type GetMediaByPostIDsRow struct {
+	PostID      int64         `json:"post_id"`
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Alt         string        `json:"alt"`
+	MediaPath   string        `json:"media_path"`
+	UserID      int64         `json:"user_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ChangedAt   time.Time     `json:"changed_at"`
+	StorageKey  string        `json:"storage_key"`
+	Size        int64         `json:"size"`
+	MimeType    string        `json:"mime_type"`
+	Checksum    string        `json:"checksum"`
+	Width       sql.NullInt32 `json:"width"`
+	Height      sql.NullInt32 `json:"height"`
+	Version     int64         `json:"version"`
+}
+
+func (q *Queries) GetMediaByPostIDs(ctx context.Context, postIds []int64) ([]GetMediaByPostIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMediaByPostIDs, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetMediaByPostIDsRow{}
+	for rows.Next() {
+		var i GetMediaByPostIDsRow
+		if err := rows.Scan(
+			&i.PostID,
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Alt,
+			&i.MediaPath,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -203,7 +319,7 @@ func (q *Queries) GetMediaByPost(ctx context.Context, postID int64) ([]Medium, e
 }
 
 const getMediaByUser = `-- name: GetMediaByUser :many
-SELECT id, name, description, alt, media_path, user_id, created_at, changed_at FROM media
+SELECT id, name, description, alt, media_path, user_id, created_at, changed_at, storage_key, size, mime_type, checksum, width, height, version FROM media
 WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -234,6 +350,13 @@ func (q *Queries) GetMediaByUser(ctx context.Context, arg GetMediaByUserParams)
 			&i.UserID,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -262,7 +385,7 @@ func (q *Queries) GetMediaPostCount(ctx context.Context, mediaID int64) (int64,
 
 const getPopularMedia = `-- name: GetPopularMedia :many
 SELECT 
-    m.id, m.name, m.description, m.alt, m.media_path, m.user_id, m.created_at, m.changed_at,
+    m.id, m.name, m.description, m.alt, m.media_path, m.user_id, m.created_at, m.changed_at, m.storage_key, m.size, m.mime_type, m.checksum, m.width, m.height, m.version,
     COUNT(pm.post_id) as post_count
 FROM media m
 JOIN post_media pm ON m.id = pm.media_id
@@ -273,15 +396,22 @@ LIMIT $1
 `
 
 type GetPopularMediaRow struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Alt         string    `json:"alt"`
-	MediaPath   string    `json:"media_path"`
-	UserID      int64     `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	ChangedAt   time.Time `json:"changed_at"`
-	PostCount   int64     `json:"post_count"`
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Alt         string        `json:"alt"`
+	MediaPath   string        `json:"media_path"`
+	UserID      int64         `json:"user_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ChangedAt   time.Time     `json:"changed_at"`
+	StorageKey  string        `json:"storage_key"`
+	Size        int64         `json:"size"`
+	MimeType    string        `json:"mime_type"`
+	Checksum    string        `json:"checksum"`
+	Width       sql.NullInt32 `json:"width"`
+	Height      sql.NullInt32 `json:"height"`
+	Version     int64         `json:"version"`
+	PostCount   int64         `json:"post_count"`
 }
 
 func (q *Queries) GetPopularMedia(ctx context.Context, limit int32) ([]GetPopularMediaRow, error) {
@@ -302,6 +432,13 @@ func (q *Queries) GetPopularMedia(ctx context.Context, limit int32) ([]GetPopula
 			&i.UserID,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
 			&i.PostCount,
 		); err != nil {
 			return nil, err
@@ -331,7 +468,7 @@ func (q *Queries) GetPostMediaCount(ctx context.Context, postID int64) (int64, e
 
 const getPostWithMedia = `-- name: GetPostWithMedia :one
 SELECT 
-    p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at,
+    p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at, p.status, p.published_at, p.publish_at, p.unpublish_at, p.version, p.language,
     COALESCE(
         json_agg(
             json_build_object(
@@ -356,16 +493,22 @@ GROUP BY p.id, p.title, p.description, p.content, p.user_id, p.username, p.url,
 `
 
 type GetPostWithMediaRow struct {
-	ID          int64       `json:"id"`
-	Title       string      `json:"title"`
-	Description string      `json:"description"`
-	Content     string      `json:"content"`
-	UserID      int64       `json:"user_id"`
-	Username    string      `json:"username"`
-	Url         string      `json:"url"`
-	CreatedAt   time.Time   `json:"created_at"`
-	ChangedAt   time.Time   `json:"changed_at"`
-	Media       interface{} `json:"media"`
+	ID          int64        `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Content     string       `json:"content"`
+	UserID      int64        `json:"user_id"`
+	Username    string       `json:"username"`
+	Url         string       `json:"url"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ChangedAt   time.Time    `json:"changed_at"`
+	Status      string       `json:"status"`
+	PublishedAt sql.NullTime `json:"published_at"`
+	PublishAt   sql.NullTime `json:"publish_at"`
+	UnpublishAt sql.NullTime `json:"unpublish_at"`
+	Version     int64        `json:"version"`
+	Language    string       `json:"language"`
+	Media       interface{}  `json:"media"`
 }
 
 func (q *Queries) GetPostWithMedia(ctx context.Context, id int64) (GetPostWithMediaRow, error) {
@@ -381,6 +524,12 @@ func (q *Queries) GetPostWithMedia(ctx context.Context, id int64) (GetPostWithMe
 		&i.Url,
 		&i.CreatedAt,
 		&i.ChangedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.Version,
+		&i.Language,
 		&i.Media,
 	)
 	return i, err
@@ -388,7 +537,7 @@ func (q *Queries) GetPostWithMedia(ctx context.Context, id int64) (GetPostWithMe
 
 const getPostsByUserWithMedia = `-- name: GetPostsByUserWithMedia :many
 SELECT 
-    p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at,
+    p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at, p.status, p.published_at, p.publish_at, p.unpublish_at, p.version, p.language,
     COALESCE(
         json_agg(
             json_build_object(
@@ -409,33 +558,46 @@ FROM posts p
 LEFT JOIN post_media pm ON p.id = pm.post_id
 LEFT JOIN media m ON pm.media_id = m.id
 WHERE p.user_id = $1
+  AND ($2::varchar IS NULL OR p.status = $2)
 GROUP BY p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at
 ORDER BY p.created_at DESC
-LIMIT $2
-OFFSET $3
+LIMIT $3
+OFFSET $4
 `
 
 type GetPostsByUserWithMediaParams struct {
-	UserID int64 `json:"user_id"`
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	UserID int64          `json:"user_id"`
+	Status sql.NullString `json:"status"`
+	Limit  int32          `json:"limit"`
+	Offset int32          `json:"offset"`
 }
 
 type GetPostsByUserWithMediaRow struct {
-	ID          int64       `json:"id"`
-	Title       string      `json:"title"`
-	Description string      `json:"description"`
-	Content     string      `json:"content"`
-	UserID      int64       `json:"user_id"`
-	Username    string      `json:"username"`
-	Url         string      `json:"url"`
-	CreatedAt   time.Time   `json:"created_at"`
-	ChangedAt   time.Time   `json:"changed_at"`
-	Media       interface{} `json:"media"`
+	ID          int64        `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Content     string       `json:"content"`
+	UserID      int64        `json:"user_id"`
+	Username    string       `json:"username"`
+	Url         string       `json:"url"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ChangedAt   time.Time    `json:"changed_at"`
+	Status      string       `json:"status"`
+	PublishedAt sql.NullTime `json:"published_at"`
+	PublishAt   sql.NullTime `json:"publish_at"`
+	UnpublishAt sql.NullTime `json:"unpublish_at"`
+	Version     int64        `json:"version"`
+	Language    string       `json:"language"`
+	Media       interface{}  `json:"media"`
 }
 
 func (q *Queries) GetPostsByUserWithMedia(ctx context.Context, arg GetPostsByUserWithMediaParams) ([]GetPostsByUserWithMediaRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsByUserWithMedia, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getPostsByUserWithMedia,
+		arg.UserID,
+		arg.Status,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -453,6 +615,12 @@ func (q *Queries) GetPostsByUserWithMedia(ctx context.Context, arg GetPostsByUse
 			&i.Url,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.Version,
+			&i.Language,
 			&i.Media,
 		); err != nil {
 			return nil, err
@@ -481,7 +649,7 @@ func (q *Queries) GetUserMediaCount(ctx context.Context, userID int64) (int64, e
 }
 
 const listMedia = `-- name: ListMedia :many
-SELECT id, name, description, alt, media_path, user_id, created_at, changed_at FROM media
+SELECT id, name, description, alt, media_path, user_id, created_at, changed_at, storage_key, size, mime_type, checksum, width, height, version FROM media
 ORDER BY created_at DESC
 LIMIT $1
 OFFSET $2
@@ -510,6 +678,13 @@ func (q *Queries) ListMedia(ctx context.Context, arg ListMediaParams) ([]Medium,
 			&i.UserID,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -524,9 +699,81 @@ func (q *Queries) ListMedia(ctx context.Context, arg ListMediaParams) ([]Medium,
 	return items, nil
 }
 
+const listMediaByIDs = `-- name: ListMediaByIDs :many
+SELECT id, name, description, alt, media_path, user_id, created_at, changed_at, storage_key, size, mime_type, checksum, width, height, version FROM media
+WHERE id = ANY($1::bigint[])
+`
+
+func (q *Queries) ListMediaByIDs(ctx context.Context, ids []int64) ([]Medium, error) {
+	rows, err := q.db.QueryContext(ctx, listMediaByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Medium{}
+	for rows.Next() {
+		var i Medium
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Alt,
+			&i.MediaPath,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMediaIDsByUser = `-- name: ListMediaIDsByUser :many
+SELECT id FROM media
+WHERE user_id = $1
+`
+
+func (q *Queries) ListMediaIDsByUser(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listMediaIDsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listMediaWithPostCount = `-- name: ListMediaWithPostCount :many
 SELECT 
-    m.id, m.name, m.description, m.alt, m.media_path, m.user_id, m.created_at, m.changed_at,
+    m.id, m.name, m.description, m.alt, m.media_path, m.user_id, m.created_at, m.changed_at, m.storage_key, m.size, m.mime_type, m.checksum, m.width, m.height, m.version,
     COUNT(pm.post_id) as post_count
 FROM media m
 LEFT JOIN post_media pm ON m.id = pm.media_id
@@ -542,15 +789,22 @@ type ListMediaWithPostCountParams struct {
 }
 
 type ListMediaWithPostCountRow struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Alt         string    `json:"alt"`
-	MediaPath   string    `json:"media_path"`
-	UserID      int64     `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	ChangedAt   time.Time `json:"changed_at"`
-	PostCount   int64     `json:"post_count"`
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Alt         string        `json:"alt"`
+	MediaPath   string        `json:"media_path"`
+	UserID      int64         `json:"user_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ChangedAt   time.Time     `json:"changed_at"`
+	StorageKey  string        `json:"storage_key"`
+	Size        int64         `json:"size"`
+	MimeType    string        `json:"mime_type"`
+	Checksum    string        `json:"checksum"`
+	Width       sql.NullInt32 `json:"width"`
+	Height      sql.NullInt32 `json:"height"`
+	Version     int64         `json:"version"`
+	PostCount   int64         `json:"post_count"`
 }
 
 func (q *Queries) ListMediaWithPostCount(ctx context.Context, arg ListMediaWithPostCountParams) ([]ListMediaWithPostCountRow, error) {
@@ -571,6 +825,13 @@ func (q *Queries) ListMediaWithPostCount(ctx context.Context, arg ListMediaWithP
 			&i.UserID,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
 			&i.PostCount,
 		); err != nil {
 			return nil, err
@@ -588,7 +849,7 @@ func (q *Queries) ListMediaWithPostCount(ctx context.Context, arg ListMediaWithP
 
 const listPostsWithMedia = `-- name: ListPostsWithMedia :many
 SELECT 
-    p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at,
+    p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at, p.status, p.published_at, p.publish_at, p.unpublish_at, p.version, p.language,
     COALESCE(
         json_agg(
             json_build_object(
@@ -620,16 +881,22 @@ type ListPostsWithMediaParams struct {
 }
 
 type ListPostsWithMediaRow struct {
-	ID          int64       `json:"id"`
-	Title       string      `json:"title"`
-	Description string      `json:"description"`
-	Content     string      `json:"content"`
-	UserID      int64       `json:"user_id"`
-	Username    string      `json:"username"`
-	Url         string      `json:"url"`
-	CreatedAt   time.Time   `json:"created_at"`
-	ChangedAt   time.Time   `json:"changed_at"`
-	Media       interface{} `json:"media"`
+	ID          int64        `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Content     string       `json:"content"`
+	UserID      int64        `json:"user_id"`
+	Username    string       `json:"username"`
+	Url         string       `json:"url"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ChangedAt   time.Time    `json:"changed_at"`
+	Status      string       `json:"status"`
+	PublishedAt sql.NullTime `json:"published_at"`
+	PublishAt   sql.NullTime `json:"publish_at"`
+	UnpublishAt sql.NullTime `json:"unpublish_at"`
+	Version     int64        `json:"version"`
+	Language    string       `json:"language"`
+	Media       interface{}  `json:"media"`
 }
 
 func (q *Queries) ListPostsWithMedia(ctx context.Context, arg ListPostsWithMediaParams) ([]ListPostsWithMediaRow, error) {
@@ -651,6 +918,12 @@ func (q *Queries) ListPostsWithMedia(ctx context.Context, arg ListPostsWithMedia
 			&i.Url,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.Version,
+			&i.Language,
 			&i.Media,
 		); err != nil {
 			return nil, err
@@ -667,7 +940,7 @@ func (q *Queries) ListPostsWithMedia(ctx context.Context, arg ListPostsWithMedia
 }
 
 const searchMediaByName = `-- name: SearchMediaByName :many
-SELECT id, name, description, alt, media_path, user_id, created_at, changed_at FROM media
+SELECT id, name, description, alt, media_path, user_id, created_at, changed_at, storage_key, size, mime_type, checksum, width, height, version FROM media
 WHERE name ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%'
 ORDER BY created_at DESC
 LIMIT $2
@@ -698,6 +971,13 @@ func (q *Queries) SearchMediaByName(ctx context.Context, arg SearchMediaByNamePa
 			&i.UserID,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.StorageKey,
+			&i.Size,
+			&i.MimeType,
+			&i.Checksum,
+			&i.Width,
+			&i.Height,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -714,7 +994,7 @@ func (q *Queries) SearchMediaByName(ctx context.Context, arg SearchMediaByNamePa
 
 const transferMediaToUser = `-- name: TransferMediaToUser :exec
 UPDATE media
-SET user_id = $2
+SET user_id = $2, version = version + 1
 WHERE user_id = $1
 `
 
@@ -731,30 +1011,34 @@ func (q *Queries) TransferMediaToUser(ctx context.Context, arg TransferMediaToUs
 const updateMedia = `-- name: UpdateMedia :one
 UPDATE media
 SET
-    name = COALESCE($2, name),
-    description = COALESCE($3, description),
-    alt = COALESCE($4, alt),
-    media_path = COALESCE($5, media_path),
-    changed_at = now()
-WHERE id = $1
-RETURNING id, name, description, alt, media_path, user_id, created_at, changed_at
+    name = COALESCE($1, name),
+    description = COALESCE($2, description),
+    alt = COALESCE($3, alt),
+    media_path = COALESCE($4, media_path),
+    changed_at = now(),
+    version = version + 1
+WHERE id = $5
+  AND ($6::bigint IS NULL OR version = $6)
+RETURNING id, name, description, alt, media_path, user_id, created_at, changed_at, storage_key, size, mime_type, checksum, width, height, version
 `
 
 type UpdateMediaParams struct {
-	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Alt         string `json:"alt"`
-	MediaPath   string `json:"media_path"`
+	Name            string        `json:"name"`
+	Description     string        `json:"description"`
+	Alt             string        `json:"alt"`
+	MediaPath       string        `json:"media_path"`
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
 }
 
 func (q *Queries) UpdateMedia(ctx context.Context, arg UpdateMediaParams) (Medium, error) {
 	row := q.db.QueryRowContext(ctx, updateMedia,
-		arg.ID,
 		arg.Name,
 		arg.Description,
 		arg.Alt,
 		arg.MediaPath,
+		arg.ID,
+		arg.ExpectedVersion,
 	)
 	var i Medium
 	err := row.Scan(
@@ -766,6 +1050,13 @@ func (q *Queries) UpdateMedia(ctx context.Context, arg UpdateMediaParams) (Mediu
 		&i.UserID,
 		&i.CreatedAt,
 		&i.ChangedAt,
+		&i.StorageKey,
+		&i.Size,
+		&i.MimeType,
+		&i.Checksum,
+		&i.Width,
+		&i.Height,
+		&i.Version,
 	)
 	return i, err
 }
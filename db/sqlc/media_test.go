@@ -41,6 +41,37 @@ func TestCreateMedia(t *testing.T) {
 	require.NotEmpty(t, media)
 }
 
+func TestCreateMediaWithFileMetadata(t *testing.T) {
+	user := createTestUser(t)
+
+	arg := CreateMediaParams{
+		Name:        gofakeit.Word(),
+		Description: gofakeit.Sentence(10),
+		Alt:         gofakeit.Sentence(5),
+		MediaPath:   "/uploads/2026/01/cover.png",
+		UserID:      user.ID,
+		StorageKey:  "2026/01/cover.png",
+		Size:        2048,
+		MimeType:    "image/png",
+		Checksum:    gofakeit.LetterN(64),
+		Width:       sql.NullInt32{Int32: 640, Valid: true},
+		Height:      sql.NullInt32{Int32: 480, Valid: true},
+	}
+
+	media, err := testQueries.CreateMedia(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.StorageKey, media.StorageKey)
+	require.Equal(t, arg.Size, media.Size)
+	require.Equal(t, arg.MimeType, media.MimeType)
+	require.Equal(t, arg.Checksum, media.Checksum)
+	require.Equal(t, arg.Width, media.Width)
+	require.Equal(t, arg.Height, media.Height)
+
+	fetched, err := testQueries.GetMedia(context.Background(), media.ID)
+	require.NoError(t, err)
+	require.Equal(t, media, fetched)
+}
+
 func TestGetMedia(t *testing.T) {
 	_, media1 := createTestMedia(t)
 	media2, err := testQueries.GetMedia(context.Background(), media1.ID)
@@ -119,6 +150,70 @@ func TestDeleteMediaTx(t *testing.T) {
 	require.Len(t, postMedia, 0)
 }
 
+func TestCreateMediaWithRenditionsTx(t *testing.T) {
+	user := createTestUser(t)
+	_, post := createTestUserWithPosts(t)
+
+	arg := CreateMediaWithRenditionsTxParams{
+		CreateMediaParams: CreateMediaParams{
+			Name:       gofakeit.Word(),
+			Alt:        gofakeit.Sentence(5),
+			MediaPath:  "/uploads/2026/01/photo.jpg",
+			UserID:     user.ID,
+			StorageKey: "2026/01/photo.jpg",
+			Size:       4096,
+			MimeType:   "image/jpeg",
+			Width:      sql.NullInt32{Int32: 1600, Valid: true},
+			Height:     sql.NullInt32{Int32: 1200, Valid: true},
+		},
+		PostID: &post.Post.ID,
+		Order:  1,
+		Renditions: []CreateMediaRenditionParams{
+			{Name: "thumbnail", Format: "jpeg", StorageKey: "2026/01/photo_thumbnail.jpg", MimeType: "image/jpeg", Width: 150, Height: 112, Size: 512},
+			{Name: "thumbnail", Format: "webp", StorageKey: "2026/01/photo_thumbnail.webp", MimeType: "image/webp", Width: 150, Height: 112, Size: 400},
+		},
+	}
+
+	result, err := testStore.CreateMediaWithRenditionsTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, result.Media.ID)
+	require.NotNil(t, result.PostMedia)
+	require.Equal(t, post.Post.ID, result.PostMedia.PostID)
+	require.Len(t, result.Renditions, 2)
+
+	renditions, err := testQueries.ListMediaRenditions(context.Background(), []int64{result.Media.ID})
+	require.NoError(t, err)
+	require.Len(t, renditions, 2)
+	for _, rendition := range renditions {
+		require.Equal(t, result.Media.ID, rendition.MediaID)
+	}
+
+	err = testStore.DeleteMediaTx(context.Background(), DeleteMediaTxParams{
+		MediaID: result.Media.ID,
+		UserID:  user.ID,
+	})
+	require.NoError(t, err)
+
+	renditions, err = testQueries.ListMediaRenditions(context.Background(), []int64{result.Media.ID})
+	require.NoError(t, err)
+	require.Empty(t, renditions)
+}
+
+func TestCreateMediaWithRenditionsTxMissingPost(t *testing.T) {
+	user := createTestUser(t)
+	postID := int64(-1)
+
+	_, err := testStore.CreateMediaWithRenditionsTx(context.Background(), CreateMediaWithRenditionsTxParams{
+		CreateMediaParams: CreateMediaParams{
+			Name:      gofakeit.Word(),
+			MediaPath: "/uploads/2026/01/orphan.png",
+			UserID:    user.ID,
+		},
+		PostID: &postID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func TestCreatePostWithMediaTx(t *testing.T) {
 	user := createTestUser(t)
 	_, media1 := createTestMedia(t)
@@ -461,8 +556,9 @@ func TestGetUserMediaCount(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, int64(3), count)
 
-	err = testQueries.DeleteMedia(context.Background(), media3.ID)
+	rows, err := testQueries.DeleteMedia(context.Background(), DeleteMediaParams{ID: media3.ID})
 	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
 	count, err = testQueries.GetUserMediaCount(context.Background(), user.ID)
 	require.NoError(t, err)
 	require.Equal(t, int64(2), count)
@@ -752,3 +848,11 @@ func TestSearchMediaByNameEmptyTerm(t *testing.T) {
 
 	require.Len(t, results2, 0)
 }
+
+func TestListMediaIDsByUser(t *testing.T) {
+	user, media := createTestMedia(t)
+
+	ids, err := testQueries.ListMediaIDsByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []int64{media.ID}, ids)
+}
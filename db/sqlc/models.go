@@ -5,32 +5,114 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         int64        `json:"id"`
+	UserID     int64        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"key_hash"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type EmailVerificationToken struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type LoginFailure struct {
+	Kind          string    `json:"kind"`
+	Subject       string    `json:"subject"`
+	Failures      int32     `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
 type Medium struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Alt         string    `json:"alt"`
-	MediaPath   string    `json:"media_path"`
-	UserID      int64     `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	ChangedAt   time.Time `json:"changed_at"`
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Alt         string        `json:"alt"`
+	MediaPath   string        `json:"media_path"`
+	UserID      int64         `json:"user_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ChangedAt   time.Time     `json:"changed_at"`
+	StorageKey  string        `json:"storage_key"`
+	Size        int64         `json:"size"`
+	MimeType    string        `json:"mime_type"`
+	Checksum    string        `json:"checksum"`
+	Width       sql.NullInt32 `json:"width"`
+	Height      sql.NullInt32 `json:"height"`
+	Version     int64         `json:"version"`
+}
+
+type MediaRendition struct {
+	ID         int64     `json:"id"`
+	MediaID    int64     `json:"media_id"`
+	Name       string    `json:"name"`
+	Format     string    `json:"format"`
+	StorageKey string    `json:"storage_key"`
+	MimeType   string    `json:"mime_type"`
+	Width      int32     `json:"width"`
+	Height     int32     `json:"height"`
+	Size       int64     `json:"size"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type MfaRecoveryCode struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type OidcLoginState struct {
+	StateHash    string    `json:"state_hash"`
+	Provider     string    `json:"provider"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
 }
 
 type Post struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Content     string    `json:"content"`
-	UserID      int64     `json:"user_id"`
-	Username    string    `json:"username"`
-	Url         string    `json:"url"`
-	CreatedAt   time.Time `json:"created_at"`
-	ChangedAt   time.Time `json:"changed_at"`
+	ID          int64        `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Content     string       `json:"content"`
+	UserID      int64        `json:"user_id"`
+	Username    string       `json:"username"`
+	Url         string       `json:"url"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ChangedAt   time.Time    `json:"changed_at"`
+	Status      string       `json:"status"`
+	PublishedAt sql.NullTime `json:"published_at"`
+	PublishAt   sql.NullTime `json:"publish_at"`
+	UnpublishAt sql.NullTime `json:"unpublish_at"`
+	Version     int64        `json:"version"`
+	Language    string       `json:"language"`
 }
 
 type PostMedium struct {
@@ -39,11 +121,31 @@ type PostMedium struct {
 	Order   int32 `json:"order"`
 }
 
+type PostRevision struct {
+	ID           int64         `json:"id"`
+	PostID       int64         `json:"post_id"`
+	Revision     int32         `json:"revision"`
+	Title        string        `json:"title"`
+	Description  string        `json:"description"`
+	Content      string        `json:"content"`
+	Url          string        `json:"url"`
+	UserID       sql.NullInt64 `json:"user_id"`
+	Username     string        `json:"username"`
+	RestoredFrom sql.NullInt32 `json:"restored_from"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
 type PostsTaxonomy struct {
 	PostID     int64 `json:"post_id"`
 	TaxonomyID int64 `json:"taxonomy_id"`
 }
 
+type RevokedToken struct {
+	ID        uuid.UUID `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Session struct {
 	ID           uuid.UUID `json:"id"`
 	UserID       int64     `json:"user_id"`
@@ -60,17 +162,38 @@ type Taxonomy struct {
 	ID          int64  `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Version     int64  `json:"version"`
 }
 
 type User struct {
-	ID                int64     `json:"id"`
-	Username          string    `json:"username"`
-	FullName          string    `json:"full_name"`
-	Email             string    `json:"email"`
-	HashedPassword    string    `json:"hashed_password"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
-	CreatedAt         time.Time `json:"created_at"`
-	Role              string    `json:"role"`
+	ID                int64        `json:"id"`
+	Username          string       `json:"username"`
+	FullName          string       `json:"full_name"`
+	Email             string       `json:"email"`
+	HashedPassword    string       `json:"hashed_password"`
+	PasswordChangedAt time.Time    `json:"password_changed_at"`
+	CreatedAt         time.Time    `json:"created_at"`
+	Role              string       `json:"role"`
+	Version           int64        `json:"version"`
+	EmailVerifiedAt   sql.NullTime `json:"email_verified_at"`
+}
+
+type UserIdentity struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Provider    string    `json:"provider"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email"`
+	LastLoginAt time.Time `json:"last_login_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type UserMfa struct {
+	UserID       int64        `json:"user_id"`
+	TotpSecret   string       `json:"totp_secret"`
+	EnabledAt    sql.NullTime `json:"enabled_at"`
+	LastUsedStep int64        `json:"last_used_step"`
+	CreatedAt    time.Time    `json:"created_at"`
 }
 
 type UserPost struct {
@@ -7,14 +7,118 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const archiveExpiredPosts = `-- name: ArchiveExpiredPosts :many
+WITH expired AS (
+    SELECT id FROM posts
+    WHERE status = 'published' AND unpublish_at <= now()
+    ORDER BY unpublish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
 )
+UPDATE posts p
+SET status = 'archived',
+    unpublish_at = NULL,
+    changed_at = now(),
+    version = p.version + 1
+FROM expired
+WHERE p.id = expired.id
+RETURNING p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at, p.status, p.published_at, p.publish_at, p.unpublish_at, p.version, p.language
+`
+
+func (q *Queries) ArchiveExpiredPosts(ctx context.Context, limit int32) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, archiveExpiredPosts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Content,
+			&i.UserID,
+			&i.Username,
+			&i.Url,
+			&i.CreatedAt,
+			&i.ChangedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.Version,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countSearchPosts = `-- name: CountSearchPosts :one
+SELECT COUNT(*) AS total FROM posts
+WHERE post_search_vector(language, title, description, content) @@ websearch_to_tsquery($1::varchar::regconfig, $2)
+  AND language = $1::varchar::regconfig
+  AND ($3::varchar IS NULL OR status = $3)
+  AND ($4::bigint IS NULL OR EXISTS (
+      SELECT 1 FROM posts_taxonomies pt
+      WHERE pt.post_id = posts.id AND pt.taxonomy_id = $4
+  ))
+  AND ($5::bigint IS NULL OR EXISTS (
+      SELECT 1 FROM user_posts up
+      WHERE up.post_id = posts.id AND up.user_id = $5
+  ))
+  AND ($6::timestamptz IS NULL OR created_at >= $6)
+  AND ($7::timestamptz IS NULL OR created_at < $7)
+`
+
+type CountSearchPostsParams struct {
+	Language      string         `json:"language"`
+	Query         string         `json:"query"`
+	Status        sql.NullString `json:"status"`
+	TaxonomyID    sql.NullInt64  `json:"taxonomy_id"`
+	AuthorID      sql.NullInt64  `json:"author_id"`
+	CreatedAfter  sql.NullTime   `json:"created_after"`
+	CreatedBefore sql.NullTime   `json:"created_before"`
+}
+
+func (q *Queries) CountSearchPosts(ctx context.Context, arg CountSearchPostsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSearchPosts,
+		arg.Language,
+		arg.Query,
+		arg.Status,
+		arg.TaxonomyID,
+		arg.AuthorID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+	)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
 
 const countTotalPosts = `-- name: CountTotalPosts :one
 SELECT COUNT(*) AS total FROM posts
+WHERE $1::varchar IS NULL OR status = $1
 `
 
-func (q *Queries) CountTotalPosts(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countTotalPosts)
+func (q *Queries) CountTotalPosts(ctx context.Context, status sql.NullString) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTotalPosts, status)
 	var total int64
 	err := row.Scan(&total)
 	return total, err
@@ -27,19 +131,21 @@ INSERT INTO posts (
     user_id,
     username,
     content,
-    url
+    url,
+    language
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
-) RETURNING id, title, description, content, user_id, username, url, created_at, changed_at
+    $1, $2, $3, $4, $5, $6, COALESCE($7::varchar, 'english')::regconfig
+) RETURNING id, title, description, content, user_id, username, url, created_at, changed_at, status, published_at, publish_at, unpublish_at, version, language
 `
 
 type CreatePostsParams struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	UserID      int64  `json:"user_id"`
-	Username    string `json:"username"`
-	Content     string `json:"content"`
-	Url         string `json:"url"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	UserID      int64          `json:"user_id"`
+	Username    string         `json:"username"`
+	Content     string         `json:"content"`
+	Url         string         `json:"url"`
+	Language    sql.NullString `json:"language"`
 }
 
 func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) (Post, error) {
@@ -50,6 +156,7 @@ func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) (Post,
 		arg.Username,
 		arg.Content,
 		arg.Url,
+		arg.Language,
 	)
 	var i Post
 	err := row.Scan(
@@ -62,6 +169,12 @@ func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) (Post,
 		&i.Url,
 		&i.CreatedAt,
 		&i.ChangedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.Version,
+		&i.Language,
 	)
 	return i, err
 }
@@ -89,14 +202,23 @@ func (q *Queries) CreateUserPost(ctx context.Context, arg CreateUserPostParams)
 	return i, err
 }
 
-const deletePost = `-- name: DeletePost :exec
+const deletePost = `-- name: DeletePost :execrows
 DELETE FROM posts
 WHERE id = $1
+  AND ($2::bigint IS NULL OR version = $2)
 `
 
-func (q *Queries) DeletePost(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deletePost, id)
-	return err
+type DeletePostParams struct {
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
+}
+
+func (q *Queries) DeletePost(ctx context.Context, arg DeletePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePost, arg.ID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const deleteUserPost = `-- name: DeleteUserPost :exec
@@ -110,7 +232,7 @@ func (q *Queries) DeleteUserPost(ctx context.Context, postID int64) error {
 }
 
 const getPost = `-- name: GetPost :one
-SELECT id, title, description, content, user_id, username, url, created_at, changed_at FROM posts 
+SELECT id, title, description, content, user_id, username, url, created_at, changed_at, status, published_at, publish_at, unpublish_at, version, language FROM posts 
 WHERE id = $1 LIMIT 1
 `
 
@@ -127,24 +249,117 @@ func (q *Queries) GetPost(ctx context.Context, id int64) (Post, error) {
 		&i.Url,
 		&i.CreatedAt,
 		&i.ChangedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.Version,
+		&i.Language,
 	)
 	return i, err
 }
 
+const listPostAuthors = `-- name: ListPostAuthors :many
+SELECT post_id, user_id, "order" FROM user_posts
+WHERE post_id = $1
+ORDER BY "order"
+`
+
+func (q *Queries) ListPostAuthors(ctx context.Context, postID int64) ([]UserPost, error) {
+	rows, err := q.db.QueryContext(ctx, listPostAuthors, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserPost{}
+	for rows.Next() {
+		var i UserPost
+		if err := rows.Scan(&i.PostID, &i.UserID, &i.Order); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostAuthorsByPostIDs = `-- name: ListPostAuthorsByPostIDs :many
+SELECT up.post_id, u.id, u.username, u.full_name, u.email, u.hashed_password, u.password_changed_at, u.created_at, u.role, u.version, u.email_verified_at FROM user_posts up
+JOIN users u ON u.id = up.user_id
+WHERE up.post_id = ANY($1::bigint[])
+ORDER BY up.post_id, up."order"
+`
+
+type ListPostAuthorsByPostIDsRow struct {
+	PostID            int64        `json:"post_id"`
+	ID                int64        `json:"id"`
+	Username          string       `json:"username"`
+	FullName          string       `json:"full_name"`
+	Email             string       `json:"email"`
+	HashedPassword    string       `json:"hashed_password"`
+	PasswordChangedAt time.Time    `json:"password_changed_at"`
+	CreatedAt         time.Time    `json:"created_at"`
+	Role              string       `json:"role"`
+	Version           int64        `json:"version"`
+	EmailVerifiedAt   sql.NullTime `json:"email_verified_at"`
+}
+
+func (q *Queries) ListPostAuthorsByPostIDs(ctx context.Context, postIds []int64) ([]ListPostAuthorsByPostIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPostAuthorsByPostIDs, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPostAuthorsByPostIDsRow{}
+	for rows.Next() {
+		var i ListPostAuthorsByPostIDsRow
+		if err := rows.Scan(
+			&i.PostID,
+			&i.ID,
+			&i.Username,
+			&i.FullName,
+			&i.Email,
+			&i.HashedPassword,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.Role,
+			&i.Version,
+			&i.EmailVerifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listPosts = `-- name: ListPosts :many
-SELECT id, title, description, content, user_id, username, url, created_at, changed_at FROM posts 
+SELECT id, title, description, content, user_id, username, url, created_at, changed_at, status, published_at, publish_at, unpublish_at, version, language FROM posts 
+WHERE $1::varchar IS NULL OR status = $1
 ORDER BY id DESC
-LIMIT $1
-OFFSET $2
+LIMIT $2
+OFFSET $3
 `
 
 type ListPostsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Status sql.NullString `json:"status"`
+	Limit  int32          `json:"limit"`
+	Offset int32          `json:"offset"`
 }
 
 func (q *Queries) ListPosts(ctx context.Context, arg ListPostsParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, listPosts, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listPosts, arg.Status, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -162,6 +377,233 @@ func (q *Queries) ListPosts(ctx context.Context, arg ListPostsParams) ([]Post, e
 			&i.Url,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.Version,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsByIDs = `-- name: ListPostsByIDs :many
+SELECT id, title, description, content, user_id, username, url, created_at, changed_at, status, published_at, publish_at, unpublish_at, version, language FROM posts
+WHERE id = ANY($1::bigint[])
+`
+
+func (q *Queries) ListPostsByIDs(ctx context.Context, ids []int64) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listPostsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Content,
+			&i.UserID,
+			&i.Username,
+			&i.Url,
+			&i.CreatedAt,
+			&i.ChangedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.Version,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishDuePosts = `-- name: PublishDuePosts :many
+WITH due AS (
+    SELECT id FROM posts
+    WHERE status = 'scheduled' AND publish_at <= now()
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE posts p
+SET status = 'published',
+    published_at = COALESCE(p.published_at, p.publish_at),
+    changed_at = now(),
+    version = p.version + 1
+FROM due
+WHERE p.id = due.id
+RETURNING p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at, p.status, p.published_at, p.publish_at, p.unpublish_at, p.version, p.language
+`
+
+func (q *Queries) PublishDuePosts(ctx context.Context, limit int32) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, publishDuePosts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Content,
+			&i.UserID,
+			&i.Username,
+			&i.Url,
+			&i.CreatedAt,
+			&i.ChangedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.Version,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPosts = `-- name: SearchPosts :many
+WITH matches AS (
+    SELECT id, ts_rank(post_search_vector(language, title, description, content), websearch_to_tsquery($1::varchar::regconfig, $2)) AS rank
+    FROM posts
+    WHERE post_search_vector(language, title, description, content) @@ websearch_to_tsquery($1::varchar::regconfig, $2)
+      AND language = $1::varchar::regconfig
+      AND ($3::varchar IS NULL OR status = $3)
+      AND ($4::bigint IS NULL OR EXISTS (
+          SELECT 1 FROM posts_taxonomies pt
+          WHERE pt.post_id = posts.id AND pt.taxonomy_id = $4
+      ))
+      AND ($5::bigint IS NULL OR EXISTS (
+          SELECT 1 FROM user_posts up
+          WHERE up.post_id = posts.id AND up.user_id = $5
+      ))
+      AND ($6::timestamptz IS NULL OR created_at >= $6)
+      AND ($7::timestamptz IS NULL OR created_at < $7)
+    ORDER BY rank DESC, id DESC
+    LIMIT $8
+    OFFSET $9
+)
+SELECT p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at, p.status, p.published_at, p.publish_at, p.unpublish_at, p.version, p.language,
+    m.rank::real AS rank,
+    ts_headline(p.language, replace(replace(replace(p.title, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), websearch_to_tsquery($1::varchar::regconfig, $2),
+        'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS title_highlight,
+    ts_headline(p.language, replace(replace(replace(p.content, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), websearch_to_tsquery($1::varchar::regconfig, $2),
+        'MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … ", StartSel=<mark>, StopSel=</mark>')::text AS snippet
+FROM matches m
+JOIN posts p ON p.id = m.id
+ORDER BY m.rank DESC, p.id DESC
+`
+
+type SearchPostsParams struct {
+	Language      string         `json:"language"`
+	Query         string         `json:"query"`
+	Status        sql.NullString `json:"status"`
+	TaxonomyID    sql.NullInt64  `json:"taxonomy_id"`
+	AuthorID      sql.NullInt64  `json:"author_id"`
+	CreatedAfter  sql.NullTime   `json:"created_after"`
+	CreatedBefore sql.NullTime   `json:"created_before"`
+	Limit         int32          `json:"limit"`
+	Offset        int32          `json:"offset"`
+}
+
+type SearchPostsRow struct {
+	ID             int64        `json:"id"`
+	Title          string       `json:"title"`
+	Description    string       `json:"description"`
+	Content        string       `json:"content"`
+	UserID         int64        `json:"user_id"`
+	Username       string       `json:"username"`
+	Url            string       `json:"url"`
+	CreatedAt      time.Time    `json:"created_at"`
+	ChangedAt      time.Time    `json:"changed_at"`
+	Status         string       `json:"status"`
+	PublishedAt    sql.NullTime `json:"published_at"`
+	PublishAt      sql.NullTime `json:"publish_at"`
+	UnpublishAt    sql.NullTime `json:"unpublish_at"`
+	Version        int64        `json:"version"`
+	Language       string       `json:"language"`
+	Rank           float32      `json:"rank"`
+	TitleHighlight string       `json:"title_highlight"`
+	Snippet        string       `json:"snippet"`
+}
+
+// Ranks and highlights only the page of matches being returned, since
+// ts_headline has to re-parse each document. The text is HTML-escaped before
+// it is highlighted, so the only markup in the result is the <mark> tags.
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Language,
+		arg.Query,
+		arg.Status,
+		arg.TaxonomyID,
+		arg.AuthorID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchPostsRow{}
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Content,
+			&i.UserID,
+			&i.Username,
+			&i.Url,
+			&i.CreatedAt,
+			&i.ChangedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.Version,
+			&i.Language,
+			&i.Rank,
+			&i.TitleHighlight,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -184,19 +626,24 @@ SET title = COALESCE($1, title),
     username = COALESCE($4, username),
     content = COALESCE($5, content),
     url = COALESCE($6, url),
-    changed_at = now()
-WHERE id = $7
-RETURNING id, title, description, content, user_id, username, url, created_at, changed_at
+    language = COALESCE($7::varchar::regconfig, language),
+    changed_at = now(),
+    version = version + 1
+WHERE id = $8
+  AND ($9::bigint IS NULL OR version = $9)
+RETURNING id, title, description, content, user_id, username, url, created_at, changed_at, status, published_at, publish_at, unpublish_at, version, language
 `
 
 type UpdatePostParams struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	UserID      int64  `json:"user_id"`
-	Username    string `json:"username"`
-	Content     string `json:"content"`
-	Url         string `json:"url"`
-	ID          int64  `json:"id"`
+	Title           string         `json:"title"`
+	Description     string         `json:"description"`
+	UserID          int64          `json:"user_id"`
+	Username        string         `json:"username"`
+	Content         string         `json:"content"`
+	Url             string         `json:"url"`
+	Language        sql.NullString `json:"language"`
+	ID              int64          `json:"id"`
+	ExpectedVersion sql.NullInt64  `json:"expected_version"`
 }
 
 func (q *Queries) UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, error) {
@@ -207,8 +654,100 @@ func (q *Queries) UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, e
 		arg.Username,
 		arg.Content,
 		arg.Url,
+		arg.Language,
 		arg.ID,
+		arg.ExpectedVersion,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.Content,
+		&i.UserID,
+		&i.Username,
+		&i.Url,
+		&i.CreatedAt,
+		&i.ChangedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.Version,
+		&i.Language,
 	)
+	return i, err
+}
+
+const updatePostSchedule = `-- name: UpdatePostSchedule :one
+UPDATE posts
+SET publish_at = $1,
+    unpublish_at = $2,
+    changed_at = now(),
+    version = version + 1
+WHERE id = $3 AND status = $4
+  AND ($5::bigint IS NULL OR version = $5)
+RETURNING id, title, description, content, user_id, username, url, created_at, changed_at, status, published_at, publish_at, unpublish_at, version, language
+`
+
+type UpdatePostScheduleParams struct {
+	PublishAt       sql.NullTime  `json:"publish_at"`
+	UnpublishAt     sql.NullTime  `json:"unpublish_at"`
+	ID              int64         `json:"id"`
+	FromStatus      string        `json:"from_status"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
+}
+
+func (q *Queries) UpdatePostSchedule(ctx context.Context, arg UpdatePostScheduleParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, updatePostSchedule,
+		arg.PublishAt,
+		arg.UnpublishAt,
+		arg.ID,
+		arg.FromStatus,
+		arg.ExpectedVersion,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.Content,
+		&i.UserID,
+		&i.Username,
+		&i.Url,
+		&i.CreatedAt,
+		&i.ChangedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.Version,
+		&i.Language,
+	)
+	return i, err
+}
+
+const updatePostStatus = `-- name: UpdatePostStatus :one
+UPDATE posts
+SET status = $1,
+    published_at = CASE
+        WHEN $1::varchar = 'published' THEN COALESCE(published_at, now())
+        ELSE published_at
+    END,
+    changed_at = now(),
+    version = version + 1
+WHERE id = $2 AND status = $3
+RETURNING id, title, description, content, user_id, username, url, created_at, changed_at, status, published_at, publish_at, unpublish_at, version, language
+`
+
+type UpdatePostStatusParams struct {
+	Status     string `json:"status"`
+	ID         int64  `json:"id"`
+	FromStatus string `json:"from_status"`
+}
+
+func (q *Queries) UpdatePostStatus(ctx context.Context, arg UpdatePostStatusParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, updatePostStatus, arg.Status, arg.ID, arg.FromStatus)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -220,6 +759,12 @@ func (q *Queries) UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, e
 		&i.Url,
 		&i.CreatedAt,
 		&i.ChangedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.Version,
+		&i.Language,
 	)
 	return i, err
 }
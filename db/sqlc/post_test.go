@@ -2,9 +2,11 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/stretchr/testify/require"
@@ -43,6 +45,8 @@ func createPostWithTransaction(t *testing.T) CreatePostTxResult {
 
 	require.NotZero(t, result.Post.ID)
 	require.NotZero(t, result.Post.CreatedAt)
+	require.Equal(t, "draft", result.Post.Status)
+	require.False(t, result.Post.PublishedAt.Valid)
 
 	require.Len(t, result.UserPosts, 1)
 	require.Equal(t, result.Post.ID, result.UserPosts[0].PostID)
@@ -93,7 +97,7 @@ func TestCreatePostTxWithMultipleAuthors(t *testing.T) {
 func TestDeletePostTx(t *testing.T) {
 	result := createPostWithTransaction(t)
 
-	err := testStore.DeletePostTx(context.Background(), result.Post.ID)
+	err := testStore.DeletePostTx(context.Background(), DeletePostTxParams{ID: result.Post.ID})
 	require.NoError(t, err)
 
 	post, err := testQueries.GetPost(context.Background(), result.Post.ID)
@@ -102,6 +106,55 @@ func TestDeletePostTx(t *testing.T) {
 	require.Empty(t, post)
 }
 
+func TestDeletePostTxStaleVersion(t *testing.T) {
+	result := createPostWithTransaction(t)
+
+	err := testStore.DeletePostTx(context.Background(), DeletePostTxParams{
+		ID:              result.Post.ID,
+		ExpectedVersion: sql.NullInt64{Int64: result.Post.Version + 1, Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The rollback keeps the authors that were deleted before the version check.
+	authors, err := testQueries.ListPostAuthors(context.Background(), result.Post.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, authors)
+
+	err = testStore.DeletePostTx(context.Background(), DeletePostTxParams{
+		ID:              result.Post.ID,
+		ExpectedVersion: sql.NullInt64{Int64: result.Post.Version, Valid: true},
+	})
+	require.NoError(t, err)
+}
+
+func TestUpdatePostExpectedVersion(t *testing.T) {
+	result := createPostWithTransaction(t)
+	require.Equal(t, int64(1), result.Post.Version)
+
+	arg := UpdatePostParams{
+		ID:              result.Post.ID,
+		Title:           result.Post.Title,
+		Description:     result.Post.Description,
+		Content:         result.Post.Content,
+		Url:             result.Post.Url,
+		UserID:          result.Post.UserID,
+		Username:        result.Post.Username,
+		ExpectedVersion: sql.NullInt64{Int64: 1, Valid: true},
+	}
+	updated, err := testQueries.UpdatePost(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), updated.Version)
+
+	// A second writer still holding version 1 loses.
+	_, err = testQueries.UpdatePost(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	arg.ExpectedVersion = sql.NullInt64{}
+	updated, err = testQueries.UpdatePost(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), updated.Version)
+}
+
 func TestListPosts(t *testing.T) {
 	gofakeit.Seed(0)
 
@@ -160,6 +213,174 @@ func TestUpdatePost(t *testing.T) {
 	require.Equal(t, "", updatedPost2.Description)
 }
 
+func TestUpdatePostTxRecordsRevisions(t *testing.T) {
+	gofakeit.Seed(0)
+	result := createPostWithTransaction(t)
+	editor := createRandomUser(t)
+	original := result.Post
+
+	arg := UpdatePostTxParams{
+		UpdatePostParams: UpdatePostParams{
+			ID:          original.ID,
+			Title:       gofakeit.Sentence(3),
+			Description: original.Description,
+			Content:     gofakeit.Paragraph(3, 5, 10, " "),
+			Url:         original.Url,
+			UserID:      original.UserID,
+			Username:    original.Username,
+		},
+		EditorID:       editor.ID,
+		EditorUsername: editor.Username,
+	}
+
+	updated, err := testStore.UpdatePostTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Title, updated.Post.Title)
+
+	// The first edit also saves the state the post was created in.
+	require.Equal(t, int32(2), updated.Revision.Revision)
+	require.Equal(t, arg.Title, updated.Revision.Title)
+	require.Equal(t, editor.ID, updated.Revision.UserID.Int64)
+	require.Equal(t, editor.Username, updated.Revision.Username)
+
+	initial, err := testQueries.GetPostRevision(context.Background(), GetPostRevisionParams{
+		PostID:   original.ID,
+		Revision: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, original.Title, initial.Title)
+	require.Equal(t, original.Content, initial.Content)
+	require.Equal(t, original.UserID, initial.UserID.Int64)
+
+	arg.Title = initial.Title
+	arg.Content = initial.Content
+	arg.RestoredFrom = sql.NullInt32{Int32: initial.Revision, Valid: true}
+	restored, err := testStore.UpdatePostTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), restored.Revision.Revision)
+	require.Equal(t, int32(1), restored.Revision.RestoredFrom.Int32)
+	require.Equal(t, original.Content, restored.Post.Content)
+
+	revisions, err := testQueries.ListPostRevisions(context.Background(), ListPostRevisionsParams{
+		PostID: original.ID,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	require.Equal(t, int32(3), revisions[0].Revision)
+
+	total, err := testQueries.CountPostRevisions(context.Background(), original.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), total)
+}
+
+func TestUpdatePostStatus(t *testing.T) {
+	result := createPostWithTransaction(t)
+
+	published, err := testQueries.UpdatePostStatus(context.Background(), UpdatePostStatusParams{
+		ID:         result.Post.ID,
+		Status:     "published",
+		FromStatus: "draft",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "published", published.Status)
+	require.True(t, published.PublishedAt.Valid)
+
+	// The update only applies while the post is still in FromStatus.
+	_, err = testQueries.UpdatePostStatus(context.Background(), UpdatePostStatusParams{
+		ID:         result.Post.ID,
+		Status:     "archived",
+		FromStatus: "draft",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	archived, err := testQueries.UpdatePostStatus(context.Background(), UpdatePostStatusParams{
+		ID:         result.Post.ID,
+		Status:     "archived",
+		FromStatus: "published",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "archived", archived.Status)
+	require.WithinDuration(t, published.PublishedAt.Time, archived.PublishedAt.Time, 0)
+
+	_, err = testQueries.UpdatePostStatus(context.Background(), UpdatePostStatusParams{
+		ID:         result.Post.ID,
+		Status:     "deleted",
+		FromStatus: "archived",
+	})
+	require.Error(t, err)
+}
+
+func TestPublishDueAndArchiveExpiredPosts(t *testing.T) {
+	result := createPostWithTransaction(t)
+	publishAt := time.Now().Add(-time.Minute)
+
+	_, err := testQueries.UpdatePostSchedule(context.Background(), UpdatePostScheduleParams{
+		ID:          result.Post.ID,
+		PublishAt:   sql.NullTime{Time: publishAt, Valid: true},
+		UnpublishAt: sql.NullTime{Time: publishAt.Add(time.Second), Valid: true},
+		FromStatus:  "draft",
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.UpdatePostStatus(context.Background(), UpdatePostStatusParams{
+		ID:         result.Post.ID,
+		Status:     "scheduled",
+		FromStatus: "draft",
+	})
+	require.NoError(t, err)
+
+	published, err := testQueries.PublishDuePosts(context.Background(), 1000)
+	require.NoError(t, err)
+	post := findPost(t, published, result.Post.ID)
+	require.Equal(t, "published", post.Status)
+	require.WithinDuration(t, publishAt, post.PublishedAt.Time, time.Second)
+
+	archived, err := testQueries.ArchiveExpiredPosts(context.Background(), 1000)
+	require.NoError(t, err)
+	post = findPost(t, archived, result.Post.ID)
+	require.Equal(t, "archived", post.Status)
+	require.False(t, post.UnpublishAt.Valid)
+}
+
+func findPost(t *testing.T, posts []Post, id int64) Post {
+	for _, post := range posts {
+		if post.ID == id {
+			return post
+		}
+	}
+	t.Fatalf("post %d not in result", id)
+	return Post{}
+}
+
+func TestListPostsByStatus(t *testing.T) {
+	result := createPostWithTransaction(t)
+	status := sql.NullString{String: "pending_review", Valid: true}
+
+	before, err := testQueries.CountTotalPosts(context.Background(), status)
+	require.NoError(t, err)
+
+	_, err = testQueries.UpdatePostStatus(context.Background(), UpdatePostStatusParams{
+		ID:         result.Post.ID,
+		Status:     "pending_review",
+		FromStatus: "draft",
+	})
+	require.NoError(t, err)
+
+	after, err := testQueries.CountTotalPosts(context.Background(), status)
+	require.NoError(t, err)
+	require.Equal(t, before+1, after)
+
+	posts, err := testQueries.ListPosts(context.Background(), ListPostsParams{
+		Status: status,
+		Limit:  100,
+	})
+	require.NoError(t, err)
+	for _, post := range posts {
+		require.Equal(t, "pending_review", post.Status)
+	}
+}
+
 func TestCreatePostWithMedia(t *testing.T) {
 	user := createTestUser(t)
 
@@ -198,3 +419,120 @@ func TestCreatePostWithMedia(t *testing.T) {
 	}
 	require.ElementsMatch(t, []int64{media1.ID, media2.ID}, mediaIDs)
 }
+
+func TestSearchPosts(t *testing.T) {
+	user := createTestUser(t)
+	marker := strings.ToLower(gofakeit.LetterN(12))
+
+	post, err := testQueries.CreatePosts(context.Background(), CreatePostsParams{
+		Title:       "Running " + marker,
+		Description: "Notes on training for a first marathon",
+		Content:     "Long runs build endurance. <img src=x onerror=alert(1)> Rest days matter as much as the runs themselves.",
+		UserID:      user.ID,
+		Username:    user.Username,
+		Url:         fmt.Sprintf("https://example.com/posts/%s", gofakeit.UUID()),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "english", post.Language)
+
+	arg := SearchPostsParams{
+		Language: "english",
+		Query:    marker + " runs",
+		AuthorID: sql.NullInt64{Int64: user.ID, Valid: true},
+		Limit:    10,
+	}
+	rows, err := testQueries.SearchPosts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, post.ID, rows[0].ID)
+	require.Positive(t, rows[0].Rank)
+	require.Contains(t, rows[0].TitleHighlight, "<mark>"+marker+"</mark>")
+	require.Contains(t, rows[0].Snippet, "<mark>")
+	require.NotContains(t, rows[0].Snippet, "<img")
+
+	total, err := testQueries.CountSearchPosts(context.Background(), CountSearchPostsParams{
+		Language: arg.Language,
+		Query:    arg.Query,
+		AuthorID: arg.AuthorID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+
+	// Excluded words and other languages drop the post.
+	rows, err = testQueries.SearchPosts(context.Background(), SearchPostsParams{
+		Language: "english",
+		Query:    marker + " -marathon",
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, rows)
+
+	rows, err = testQueries.SearchPosts(context.Background(), SearchPostsParams{
+		Language: "german",
+		Query:    marker,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, rows)
+}
+
+func TestListPostsFiltered(t *testing.T) {
+	user := createTestUser(t)
+	var ids []int64
+	for _, title := range []string{"Bravo", "Alpha"} {
+		post, err := testQueries.CreatePosts(context.Background(), CreatePostsParams{
+			Title:       title,
+			Description: "Filtered list test post",
+			Content:     "Content",
+			UserID:      user.ID,
+			Username:    user.Username,
+			Url:         fmt.Sprintf("https://example.com/posts/%s", gofakeit.UUID()),
+		})
+		require.NoError(t, err)
+		ids = append(ids, post.ID)
+	}
+
+	filter := ListFilter{
+		Where:   "user_id = $1",
+		Args:    []interface{}{user.ID},
+		OrderBy: "title ASC, id ASC",
+	}
+	posts, err := testQueries.ListPostsFiltered(context.Background(), ListFilteredParams{Filter: filter, Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	require.Equal(t, ids[0], posts[0].ID)
+
+	total, err := testQueries.CountPostsFiltered(context.Background(), filter)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), total)
+}
+
+func TestEstimateTableRows(t *testing.T) {
+	estimate, err := testQueries.EstimateTableRows(context.Background(), "posts")
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, estimate, int64(0))
+
+	estimate, err = testQueries.EstimateTableRows(context.Background(), "no_such_table")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.Zero(t, estimate)
+}
+
+func TestListPostAuthorsByPostIDs(t *testing.T) {
+	first := createPostWithTransaction(t)
+	second := createPostWithTransaction(t)
+
+	rows, err := testQueries.ListPostAuthorsByPostIDs(context.Background(), []int64{first.Post.ID, second.Post.ID})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	for _, row := range rows {
+		switch row.PostID {
+		case first.Post.ID:
+			require.Equal(t, first.UserPosts[0].UserID, row.ID)
+		case second.Post.ID:
+			require.Equal(t, second.UserPosts[0].UserID, row.ID)
+		default:
+			t.Fatalf("unexpected post %d", row.PostID)
+		}
+	}
+}
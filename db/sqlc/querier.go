@@ -6,53 +6,96 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	ArchiveExpiredPosts(ctx context.Context, limit int32) ([]Post, error)
 	BlockSession(ctx context.Context, id uuid.UUID) error
+	BlockUserSessions(ctx context.Context, userID int64) error
+	// Marks a token used in the same statement that checks it, so it can only
+	// succeed once.
+	ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (EmailVerificationToken, error)
+	ConsumeMFARecoveryCode(ctx context.Context, arg ConsumeMFARecoveryCodeParams) (MfaRecoveryCode, error)
+	// Deletes the state as it is read, so a callback can only be replayed once.
+	ConsumeOIDCLoginState(ctx context.Context, stateHash string) (OidcLoginState, error)
+	// Marks a token used in the same statement that checks it, so it can only
+	// succeed once.
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	CountPostRevisions(ctx context.Context, postID int64) (int64, error)
+	CountSearchPosts(ctx context.Context, arg CountSearchPostsParams) (int64, error)
 	CountTotalMedia(ctx context.Context) (int64, error)
-	CountTotalPosts(ctx context.Context) (int64, error)
+	CountTotalPosts(ctx context.Context, status sql.NullString) (int64, error)
 	CountTotalSessions(ctx context.Context) (int64, error)
 	CountTotalTaxonomies(ctx context.Context) (int64, error)
 	CountTotalUsers(ctx context.Context) (int64, error)
+	CountUnusedMFARecoveryCodes(ctx context.Context, userID int64) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) (EmailVerificationToken, error)
+	CreateInitialPostRevision(ctx context.Context, id int64) error
+	CreateMFARecoveryCode(ctx context.Context, arg CreateMFARecoveryCodeParams) (MfaRecoveryCode, error)
 	CreateMedia(ctx context.Context, arg CreateMediaParams) (Medium, error)
+	CreateMediaRendition(ctx context.Context, arg CreateMediaRenditionParams) (MediaRendition, error)
+	CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) error
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
 	CreatePostMedia(ctx context.Context, arg CreatePostMediaParams) (PostMedium, error)
+	CreatePostRevision(ctx context.Context, arg CreatePostRevisionParams) (PostRevision, error)
 	CreatePostTaxonomy(ctx context.Context, arg CreatePostTaxonomyParams) (PostsTaxonomy, error)
 	CreatePosts(ctx context.Context, arg CreatePostsParams) (Post, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTaxonomy(ctx context.Context, arg CreateTaxonomyParams) (Taxonomy, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
 	CreateUserPost(ctx context.Context, arg CreateUserPostParams) (UserPost, error)
-	DeleteMedia(ctx context.Context, id int64) error
+	DeleteExpiredLoginFailures(ctx context.Context, lastFailureAt time.Time) (int64, error)
+	DeleteExpiredOIDCLoginStates(ctx context.Context) error
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+	DeleteLoginFailure(ctx context.Context, arg DeleteLoginFailureParams) error
+	DeleteMFARecoveryCodes(ctx context.Context, userID int64) error
+	DeleteMedia(ctx context.Context, arg DeleteMediaParams) (int64, error)
 	DeleteMediaByUserID(ctx context.Context, userID int64) error
 	DeleteMediaPosts(ctx context.Context, mediaID int64) error
-	DeletePost(ctx context.Context, id int64) error
+	DeleteMediaRenditions(ctx context.Context, mediaID int64) error
+	DeletePost(ctx context.Context, arg DeletePostParams) (int64, error)
 	DeletePostMedia(ctx context.Context, arg DeletePostMediaParams) error
 	DeletePostMedias(ctx context.Context, postID int64) error
 	DeletePostTaxonomies(ctx context.Context, postID int64) error
 	DeletePostTaxonomy(ctx context.Context, arg DeletePostTaxonomyParams) error
 	DeletePostsByUserID(ctx context.Context, userID int64) error
-	DeleteTaxonomy(ctx context.Context, id int64) error
+	DeleteTaxonomy(ctx context.Context, arg DeleteTaxonomyParams) (int64, error)
 	DeleteTaxonomyPosts(ctx context.Context, taxonomyID int64) error
-	DeleteUser(ctx context.Context, id int64) error
+	DeleteUnusedEmailVerificationTokens(ctx context.Context, userID int64) error
+	DeleteUnusedPasswordResetTokens(ctx context.Context, userID int64) error
+	DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error)
+	DeleteUserMFA(ctx context.Context, userID int64) error
 	DeleteUserPost(ctx context.Context, postID int64) error
 	DeleteUserPostsByUserID(ctx context.Context, userID int64) error
 	DeleteUserSessions(ctx context.Context, id int64) error
+	EnableUserMFA(ctx context.Context, arg EnableUserMFAParams) (UserMfa, error)
+	// reltuples is kept up to date by VACUUM and ANALYZE, so reading it is cheap
+	// but approximate. It is -1 until a table has been analyzed.
+	EstimateTableRows(ctx context.Context, tableName string) (int64, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
+	GetLoginFailure(ctx context.Context, arg GetLoginFailureParams) (LoginFailure, error)
 	GetMedia(ctx context.Context, id int64) (Medium, error)
 	GetMediaByPost(ctx context.Context, postID int64) ([]Medium, error)
+	GetMediaByPostIDs(ctx context.Context, postIds []int64) ([]GetMediaByPostIDsRow, error)
 	GetMediaByUser(ctx context.Context, arg GetMediaByUserParams) ([]Medium, error)
 	GetMediaPostCount(ctx context.Context, mediaID int64) (int64, error)
 	GetPopularMedia(ctx context.Context, limit int32) ([]GetPopularMediaRow, error)
 	GetPopularTaxonomies(ctx context.Context, limit int32) ([]GetPopularTaxonomiesRow, error)
 	GetPost(ctx context.Context, id int64) (Post, error)
 	GetPostMediaCount(ctx context.Context, postID int64) (int64, error)
+	GetPostRevision(ctx context.Context, arg GetPostRevisionParams) (PostRevision, error)
 	GetPostTaxonomies(ctx context.Context, postID int64) ([]Taxonomy, error)
 	GetPostTaxonomyCount(ctx context.Context, postID int64) (int64, error)
 	GetPostWithMedia(ctx context.Context, id int64) (GetPostWithMediaRow, error)
 	GetPostsByUserWithMedia(ctx context.Context, arg GetPostsByUserWithMediaParams) ([]GetPostsByUserWithMediaRow, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetTaxonomiesByPostIDs(ctx context.Context, postIds []int64) ([]GetTaxonomiesByPostIDsRow, error)
 	GetTaxonomy(ctx context.Context, id int64) (Taxonomy, error)
 	GetTaxonomyByName(ctx context.Context, name string) (Taxonomy, error)
 	GetTaxonomyPostCount(ctx context.Context, taxonomyID int64) (int64, error)
@@ -60,28 +103,67 @@ type Querier interface {
 	GetUser(ctx context.Context, id int64) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	GetUserMFA(ctx context.Context, userID int64) (UserMfa, error)
 	GetUserMediaCount(ctx context.Context, userID int64) (int64, error)
+	IsTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error)
+	ListAPIKeysByUser(ctx context.Context, userID int64) ([]ApiKey, error)
+	ListLoginLockouts(ctx context.Context, arg ListLoginLockoutsParams) ([]LoginFailure, error)
 	ListMedia(ctx context.Context, arg ListMediaParams) ([]Medium, error)
+	ListMediaByIDs(ctx context.Context, ids []int64) ([]Medium, error)
+	ListMediaIDsByUser(ctx context.Context, userID int64) ([]int64, error)
+	ListMediaRenditions(ctx context.Context, mediaIds []int64) ([]MediaRendition, error)
 	ListMediaWithPostCount(ctx context.Context, arg ListMediaWithPostCountParams) ([]ListMediaWithPostCountRow, error)
+	ListPostAuthors(ctx context.Context, postID int64) ([]UserPost, error)
+	ListPostAuthorsByPostIDs(ctx context.Context, postIds []int64) ([]ListPostAuthorsByPostIDsRow, error)
+	ListPostIDsByTaxonomy(ctx context.Context, taxonomyID int64) ([]int64, error)
+	ListPostIDsByUser(ctx context.Context, userID int64) ([]int64, error)
+	ListPostRevisions(ctx context.Context, arg ListPostRevisionsParams) ([]PostRevision, error)
 	ListPosts(ctx context.Context, arg ListPostsParams) ([]Post, error)
+	ListPostsByIDs(ctx context.Context, ids []int64) ([]Post, error)
 	ListPostsWithMedia(ctx context.Context, arg ListPostsWithMediaParams) ([]ListPostsWithMediaRow, error)
 	ListSessionsByUser(ctx context.Context, userID int64) ([]Session, error)
 	ListSessionsByUsername(ctx context.Context, username string) ([]Session, error)
 	ListTaxonomies(ctx context.Context, arg ListTaxonomiesParams) ([]Taxonomy, error)
+	ListTaxonomiesByIDs(ctx context.Context, ids []int64) ([]Taxonomy, error)
 	ListTaxonomiesWithPostCount(ctx context.Context, arg ListTaxonomiesWithPostCountParams) ([]ListTaxonomiesWithPostCountRow, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	MarkUserEmailVerified(ctx context.Context, id int64) (User, error)
+	PublishDuePosts(ctx context.Context, limit int32) ([]Post, error)
+	// The count starts over when the previous failure happened before reset_before.
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginFailure, error)
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (ApiKey, error)
+	// Revoking an ID twice keeps the later expiry.
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RotateSessionRefreshToken(ctx context.Context, arg RotateSessionRefreshTokenParams) (Session, error)
 	SearchMediaByName(ctx context.Context, arg SearchMediaByNameParams) ([]Medium, error)
+	// Ranks and highlights only the page of matches being returned, since
+	// ts_headline has to re-parse each document.
+	SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error)
 	SearchTaxonomiesByName(ctx context.Context, arg SearchTaxonomiesByNameParams) ([]Taxonomy, error)
+	// Records use at most once a minute so busy integrations do not write on
+	// every request.
+	TouchAPIKey(ctx context.Context, id int64) error
+	TouchUserIdentity(ctx context.Context, arg TouchUserIdentityParams) error
 	TransferMediaToUser(ctx context.Context, arg TransferMediaToUserParams) error
 	TransferPostsToAdmin(ctx context.Context, arg TransferPostsToAdminParams) error
 	UpdateMedia(ctx context.Context, arg UpdateMediaParams) (Medium, error)
 	UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, error)
+	UpdatePostSchedule(ctx context.Context, arg UpdatePostScheduleParams) (Post, error)
+	UpdatePostStatus(ctx context.Context, arg UpdatePostStatusParams) (Post, error)
 	UpdatePostsUsername(ctx context.Context, arg UpdatePostsUsernameParams) error
 	UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error)
 	UpdateSessionsUsername(ctx context.Context, arg UpdateSessionsUsernameParams) ([]Session, error)
 	UpdateTaxonomy(ctx context.Context, arg UpdateTaxonomyParams) (Taxonomy, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
 	UpdateUserPostsOwnership(ctx context.Context, arg UpdateUserPostsOwnershipParams) error
+	// Starts or restarts enrolment. Once 2FA is enabled the row is left alone and
+	// no row is returned, so a pending login cannot replace a confirmed secret.
+	UpsertUserMFASecret(ctx context.Context, arg UpsertUserMFASecretParams) (UserMfa, error)
+	// Records the time step of an accepted code. It matches no row when the step
+	// was already used, which is how replayed codes are refused.
+	UseUserMFAStep(ctx context.Context, arg UseUserMFAStepParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
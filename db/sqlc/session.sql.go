@@ -23,6 +23,17 @@ func (q *Queries) BlockSession(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const blockUserSessions = `-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE user_id = $1
+`
+
+func (q *Queries) BlockUserSessions(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, blockUserSessions, userID)
+	return err
+}
+
 const countTotalSessions = `-- name: CountTotalSessions :one
 SELECT COUNT(*) AS total FROM sessions
 `
@@ -184,6 +195,38 @@ func (q *Queries) ListSessionsByUsername(ctx context.Context, username string) (
 	return items, nil
 }
 
+const rotateSessionRefreshToken = `-- name: RotateSessionRefreshToken :one
+UPDATE sessions
+SET refresh_token = $1
+WHERE id = $2
+  AND refresh_token = $3
+  AND is_blocked = false
+RETURNING id, user_id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+`
+
+type RotateSessionRefreshTokenParams struct {
+	NewRefreshToken string    `json:"new_refresh_token"`
+	ID              uuid.UUID `json:"id"`
+	RefreshToken    string    `json:"refresh_token"`
+}
+
+func (q *Queries) RotateSessionRefreshToken(ctx context.Context, arg RotateSessionRefreshTokenParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, rotateSessionRefreshToken, arg.NewRefreshToken, arg.ID, arg.RefreshToken)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const updateSession = `-- name: UpdateSession :one
 UPDATE sessions 
 SET 
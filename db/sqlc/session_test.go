@@ -175,6 +175,39 @@ func TestBlockSession(t *testing.T) {
 	require.True(t, blockedSession.IsBlocked)
 }
 
+func TestRotateSessionRefreshToken(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+	newRefreshToken := gofakeit.UUID()
+
+	rotated, err := testQueries.RotateSessionRefreshToken(context.Background(), RotateSessionRefreshTokenParams{
+		NewRefreshToken: newRefreshToken,
+		ID:              session.ID,
+		RefreshToken:    session.RefreshToken,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newRefreshToken, rotated.RefreshToken)
+	require.WithinDuration(t, session.ExpiresAt, rotated.ExpiresAt, time.Second)
+
+	// The old token no longer matches, so a second rotation with it fails.
+	_, err = testQueries.RotateSessionRefreshToken(context.Background(), RotateSessionRefreshTokenParams{
+		NewRefreshToken: gofakeit.UUID(),
+		ID:              session.ID,
+		RefreshToken:    session.RefreshToken,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = testQueries.BlockSession(context.Background(), session.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.RotateSessionRefreshToken(context.Background(), RotateSessionRefreshTokenParams{
+		NewRefreshToken: gofakeit.UUID(),
+		ID:              session.ID,
+		RefreshToken:    newRefreshToken,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func TestUpdateSessionsUsername(t *testing.T) {
 	user := createRandomUser(t)
 
@@ -248,3 +281,47 @@ func TestCreateSessionWithExpiredTime(t *testing.T) {
 	require.NotEmpty(t, session)
 	require.True(t, session.ExpiresAt.Before(time.Now()))
 }
+
+func TestRevokeToken(t *testing.T) {
+	id := uuid.New()
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), id)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = testQueries.RevokeToken(context.Background(), RevokeTokenParams{
+		ID:        id,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	// Revoking again with an earlier expiry keeps the later one.
+	err = testQueries.RevokeToken(context.Background(), RevokeTokenParams{
+		ID:        id,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), id)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	expired := uuid.New()
+	err = testQueries.RevokeToken(context.Background(), RevokeTokenParams{
+		ID:        expired,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), expired)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	purged, err := testQueries.DeleteExpiredRevokedTokens(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, purged, int64(1))
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), id)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
@@ -4,19 +4,30 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 )
 
 type Store interface {
 	Querier
 	CreatePostTx(ctx context.Context, arg CreatePostTxParams) (CreatePostTxResult, error)
-	DeletePostTx(ctx context.Context, id int64) error
+	DeletePostTx(ctx context.Context, arg DeletePostTxParams) error
+	UpdatePostTx(ctx context.Context, arg UpdatePostTxParams) (UpdatePostTxResult, error)
 
-	DeleteUserTx(ctx context.Context, id int64) error
+	DeleteUserTx(ctx context.Context, arg DeleteUserTxParams) error
 	DeleteUserWithTransferTx(ctx context.Context, arg DeleteUserWithTransferTxParams) error
 	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	RegisterUserTx(ctx context.Context, arg RegisterUserTxParams) (RegisterUserTxResult, error)
+	ReissueEmailVerificationTx(ctx context.Context, arg CreateEmailVerificationTokenParams) (EmailVerificationToken, error)
+	VerifyEmailTx(ctx context.Context, tokenHash string) (User, error)
+	ReissuePasswordResetTx(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error)
+	ConfirmMFAEnrollmentTx(ctx context.Context, arg ConfirmMFAEnrollmentTxParams) (UserMfa, error)
+	ReplaceMFARecoveryCodesTx(ctx context.Context, arg ReplaceMFARecoveryCodesTxParams) error
+	DisableMFATx(ctx context.Context, userID int64) error
+	CreateOIDCUserTx(ctx context.Context, arg CreateOIDCUserTxParams) (CreateOIDCUserTxResult, error)
 
 	CreatePostWithTaxonomiesTx(ctx context.Context, arg CreatePostWithTaxonomiesTxParams) (CreatePostWithTaxonomiesTxResult, error)
-	DeleteTaxonomyTx(ctx context.Context, id int64) error
+	DeleteTaxonomyTx(ctx context.Context, arg DeleteTaxonomyTxParams) error
 	UpdatePostTaxonomiesTx(ctx context.Context, arg UpdatePostTaxonomiesTxParams) error
 	CreateTaxonomyAndLinkTx(ctx context.Context, arg CreateTaxonomyAndLinkTxParams) (CreateTaxonomyAndLinkTxResult, error)
 
@@ -24,6 +35,18 @@ type Store interface {
 	DeleteMediaTx(ctx context.Context, arg DeleteMediaTxParams) error
 	UpdatePostMediaTx(ctx context.Context, arg UpdatePostMediaTxParams) error
 	CreateMediaAndLinkTx(ctx context.Context, arg CreateMediaAndLinkTxParams) (CreateMediaAndLinkTxResult, error)
+	CreateMediaWithRenditionsTx(ctx context.Context, arg CreateMediaWithRenditionsTxParams) (CreateMediaWithRenditionsTxResult, error)
+
+	ListPostsFiltered(ctx context.Context, arg ListFilteredParams) ([]Post, error)
+	CountPostsFiltered(ctx context.Context, filter ListFilter) (int64, error)
+	ListUsersFiltered(ctx context.Context, arg ListFilteredParams) ([]User, error)
+	CountUsersFiltered(ctx context.Context, filter ListFilter) (int64, error)
+	ListMediaFiltered(ctx context.Context, arg ListFilteredParams) ([]Medium, error)
+	ListMediaWithPostCountFiltered(ctx context.Context, arg ListFilteredParams) ([]ListMediaWithPostCountRow, error)
+	CountMediaFiltered(ctx context.Context, filter ListFilter) (int64, error)
+	ListTaxonomiesFiltered(ctx context.Context, arg ListFilteredParams) ([]Taxonomy, error)
+	ListTaxonomiesWithPostCountFiltered(ctx context.Context, arg ListFilteredParams) ([]ListTaxonomiesWithPostCountRow, error)
+	CountTaxonomiesFiltered(ctx context.Context, filter ListFilter) (int64, error)
 
 	ExecTx(ctx context.Context, fn func(*Queries) error) error
 }
@@ -59,6 +82,18 @@ func NewStore(db *sql.DB) Store {
 	}
 }
 
+// checkDeleted turns a delete that matched no rows into sql.ErrNoRows, so a
+// stale expected version rolls the whole transaction back.
+func checkDeleted(rows int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
 	tx, err := store.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -128,14 +163,24 @@ func (store *SQLStore) CreatePostTx(ctx context.Context, arg CreatePostTxParams)
 	return result, err
 }
 
-func (store *SQLStore) DeletePostTx(ctx context.Context, id int64) error {
+// DeletePostTxParams identifies the post to delete. When ExpectedVersion is
+// set the post is only deleted if it has not changed since that version.
+type DeletePostTxParams struct {
+	ID              int64
+	ExpectedVersion sql.NullInt64
+}
+
+func (store *SQLStore) DeletePostTx(ctx context.Context, arg DeletePostTxParams) error {
 	err := store.execTx(ctx, func(q *Queries) error {
-		err := q.DeleteUserPost(ctx, id)
+		err := q.DeleteUserPost(ctx, arg.ID)
 		if err != nil {
 			return err
 		}
 
-		err = q.DeletePost(ctx, id)
+		err = checkDeleted(q.DeletePost(ctx, DeletePostParams{
+			ID:              arg.ID,
+			ExpectedVersion: arg.ExpectedVersion,
+		}))
 		if err != nil {
 			return err
 		}
@@ -146,30 +191,85 @@ func (store *SQLStore) DeletePostTx(ctx context.Context, id int64) error {
 	return err
 }
 
-func (store *SQLStore) DeleteUserTx(ctx context.Context, id int64) error {
+type UpdatePostTxParams struct {
+	UpdatePostParams
+	// EditorID and EditorUsername identify who made the change.
+	EditorID       int64
+	EditorUsername string
+	// RestoredFrom is set when the update restores an older revision.
+	RestoredFrom sql.NullInt32
+}
+
+type UpdatePostTxResult struct {
+	Post     Post         `json:"post"`
+	Revision PostRevision `json:"revision"`
+}
+
+// UpdatePostTx updates a post and records the new state as a revision. Posts
+// that have never been revised first get their current state saved as
+// revision 1, so the first edit can always be undone.
+func (store *SQLStore) UpdatePostTx(ctx context.Context, arg UpdatePostTxParams) (UpdatePostTxResult, error) {
+	var result UpdatePostTxResult
+
 	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.CreateInitialPostRevision(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
 
-		err := q.DeleteUserSessions(ctx, id)
+		result.Post, err = q.UpdatePost(ctx, arg.UpdatePostParams)
 		if err != nil {
 			return err
 		}
 
-		err = q.DeleteUserPostsByUserID(ctx, id)
+		result.Revision, err = q.CreatePostRevision(ctx, CreatePostRevisionParams{
+			PostID:       result.Post.ID,
+			Title:        result.Post.Title,
+			Description:  result.Post.Description,
+			Content:      result.Post.Content,
+			Url:          result.Post.Url,
+			UserID:       sql.NullInt64{Int64: arg.EditorID, Valid: true},
+			Username:     arg.EditorUsername,
+			RestoredFrom: arg.RestoredFrom,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+type DeleteUserTxParams struct {
+	ID              int64
+	ExpectedVersion sql.NullInt64
+}
+
+func (store *SQLStore) DeleteUserTx(ctx context.Context, arg DeleteUserTxParams) error {
+	err := store.execTx(ctx, func(q *Queries) error {
+
+		err := q.DeleteUserSessions(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteUserPostsByUserID(ctx, arg.ID)
 		if err != nil {
 			return err
 		}
 
-		err = q.DeleteMediaByUserID(ctx, id)
+		err = q.DeleteMediaByUserID(ctx, arg.ID)
 		if err != nil {
 			return err
 		}
 
-		err = q.DeletePostsByUserID(ctx, id)
+		err = q.DeletePostsByUserID(ctx, arg.ID)
 		if err != nil {
 			return err
 		}
 
-		err = q.DeleteUser(ctx, id)
+		err = checkDeleted(q.DeleteUser(ctx, DeleteUserParams{
+			ID:              arg.ID,
+			ExpectedVersion: arg.ExpectedVersion,
+		}))
 		if err != nil {
 			return err
 		}
@@ -228,9 +328,240 @@ func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams)
 	return result, err
 }
 
+type RegisterUserTxParams struct {
+	CreateUserParams
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+type RegisterUserTxResult struct {
+	User  User                   `json:"user"`
+	Token EmailVerificationToken `json:"token"`
+}
+
+// RegisterUserTx creates a self-registered account together with the token
+// that verifies its email address.
+func (store *SQLStore) RegisterUserTx(ctx context.Context, arg RegisterUserTxParams) (RegisterUserTxResult, error) {
+	var result RegisterUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.Token, err = q.CreateEmailVerificationToken(ctx, CreateEmailVerificationTokenParams{
+			UserID:    result.User.ID,
+			TokenHash: arg.TokenHash,
+			ExpiresAt: arg.ExpiresAt,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ReissueEmailVerificationTx replaces a user's outstanding verification
+// tokens with a new one, so only the most recent email works.
+func (store *SQLStore) ReissueEmailVerificationTx(ctx context.Context, arg CreateEmailVerificationTokenParams) (EmailVerificationToken, error) {
+	var token EmailVerificationToken
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.DeleteUnusedEmailVerificationTokens(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		token, err = q.CreateEmailVerificationToken(ctx, arg)
+		return err
+	})
+
+	return token, err
+}
+
+// VerifyEmailTx consumes a verification token and marks its user's email
+// address verified. It returns sql.ErrNoRows when the token is unknown,
+// expired or already used.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, tokenHash string) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		token, err := q.ConsumeEmailVerificationToken(ctx, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.MarkUserEmailVerified(ctx, token.UserID)
+		return err
+	})
+
+	return user, err
+}
+
+// ReissuePasswordResetTx replaces a user's outstanding password reset tokens
+// with a new one, so only the most recent email works.
+func (store *SQLStore) ReissuePasswordResetTx(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	var token PasswordResetToken
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.DeleteUnusedPasswordResetTokens(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		token, err = q.CreatePasswordResetToken(ctx, arg)
+		return err
+	})
+
+	return token, err
+}
+
+type ResetPasswordTxParams struct {
+	TokenHash      string
+	HashedPassword string
+}
+
+// ResetPasswordTx consumes a password reset token, stores the new password
+// and blocks every session of its user. It returns sql.ErrNoRows when the
+// token is unknown, expired or already used.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		token, err := q.ConsumePasswordResetToken(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			ID:             token.UserID,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, user.ID)
+	})
+
+	return user, err
+}
+
+type ConfirmMFAEnrollmentTxParams struct {
+	UserID             int64
+	Step               int64
+	RecoveryCodeHashes []string
+}
+
+// ConfirmMFAEnrollmentTx turns on two-factor authentication for a user whose
+// first code checked out, and stores a fresh set of recovery codes. It returns
+// sql.ErrNoRows when there is no pending enrolment.
+func (store *SQLStore) ConfirmMFAEnrollmentTx(ctx context.Context, arg ConfirmMFAEnrollmentTxParams) (UserMfa, error) {
+	var mfa UserMfa
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		mfa, err = q.EnableUserMFA(ctx, EnableUserMFAParams{
+			UserID:       arg.UserID,
+			LastUsedStep: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		return replaceMFARecoveryCodes(ctx, q, arg.UserID, arg.RecoveryCodeHashes)
+	})
+
+	return mfa, err
+}
+
+type ReplaceMFARecoveryCodesTxParams struct {
+	UserID     int64
+	CodeHashes []string
+}
+
+// ReplaceMFARecoveryCodesTx discards a user's recovery codes, used or not, in
+// favour of a new set.
+func (store *SQLStore) ReplaceMFARecoveryCodesTx(ctx context.Context, arg ReplaceMFARecoveryCodesTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		return replaceMFARecoveryCodes(ctx, q, arg.UserID, arg.CodeHashes)
+	})
+}
+
+func replaceMFARecoveryCodes(ctx context.Context, q *Queries, userID int64, codeHashes []string) error {
+	err := q.DeleteMFARecoveryCodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		_, err = q.CreateMFARecoveryCode(ctx, CreateMFARecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: codeHash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisableMFATx removes a user's TOTP secret together with their recovery
+// codes.
+func (store *SQLStore) DisableMFATx(ctx context.Context, userID int64) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		err := q.DeleteMFARecoveryCodes(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteUserMFA(ctx, userID)
+	})
+}
+
+type CreateOIDCUserTxParams struct {
+	CreateUserParams
+	Provider string
+	Subject  string
+}
+
+type CreateOIDCUserTxResult struct {
+	User     User         `json:"user"`
+	Identity UserIdentity `json:"identity"`
+}
+
+// CreateOIDCUserTx creates the account for someone signing in through an
+// identity provider for the first time, linked to their identity there.
+func (store *SQLStore) CreateOIDCUserTx(ctx context.Context, arg CreateOIDCUserTxParams) (CreateOIDCUserTxResult, error) {
+	var result CreateOIDCUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.Identity, err = q.CreateUserIdentity(ctx, CreateUserIdentityParams{
+			UserID:   result.User.ID,
+			Provider: arg.Provider,
+			Subject:  arg.Subject,
+			Email:    arg.Email,
+		})
+		return err
+	})
+
+	return result, err
+}
+
 type DeleteUserWithTransferTxParams struct {
-	UserID       int64
-	TransferToID int64
+	UserID          int64
+	TransferToID    int64
+	ExpectedVersion sql.NullInt64
 }
 
 func (store *SQLStore) DeleteUserWithTransferTx(ctx context.Context, arg DeleteUserWithTransferTxParams) error {
@@ -265,7 +596,10 @@ func (store *SQLStore) DeleteUserWithTransferTx(ctx context.Context, arg DeleteU
 			return err
 		}
 
-		err = q.DeleteUser(ctx, arg.UserID)
+		err = checkDeleted(q.DeleteUser(ctx, DeleteUserParams{
+			ID:              arg.UserID,
+			ExpectedVersion: arg.ExpectedVersion,
+		}))
 		if err != nil {
 			return err
 		}
@@ -346,15 +680,23 @@ func (store *SQLStore) CreatePostWithTaxonomiesTx(ctx context.Context, arg Creat
 	return result, err
 }
 
-func (store *SQLStore) DeleteTaxonomyTx(ctx context.Context, id int64) error {
+type DeleteTaxonomyTxParams struct {
+	ID              int64
+	ExpectedVersion sql.NullInt64
+}
+
+func (store *SQLStore) DeleteTaxonomyTx(ctx context.Context, arg DeleteTaxonomyTxParams) error {
 	err := store.ExecTx(ctx, func(q *Queries) error {
 
-		err := q.DeleteTaxonomyPosts(ctx, id)
+		err := q.DeleteTaxonomyPosts(ctx, arg.ID)
 		if err != nil {
 			return err
 		}
 
-		err = q.DeleteTaxonomy(ctx, id)
+		err = checkDeleted(q.DeleteTaxonomy(ctx, DeleteTaxonomyParams{
+			ID:              arg.ID,
+			ExpectedVersion: arg.ExpectedVersion,
+		}))
 		if err != nil {
 			return err
 		}
@@ -473,8 +815,9 @@ type CreatePostWithMediaTxResult struct {
 }
 
 type DeleteMediaTxParams struct {
-	MediaID int64
-	UserID  int64
+	MediaID         int64
+	UserID          int64
+	ExpectedVersion sql.NullInt64
 }
 
 type UpdatePostMediaTxParams struct {
@@ -483,13 +826,9 @@ type UpdatePostMediaTxParams struct {
 }
 
 type CreateMediaAndLinkTxParams struct {
-	Name        string
-	Description string
-	Alt         string
-	MediaPath   string
-	UserID      int64
-	PostID      int64
-	Order       int32
+	CreateMediaParams
+	PostID int64
+	Order  int32
 }
 
 type CreateMediaAndLinkTxResult struct {
@@ -573,7 +912,10 @@ func (store *SQLStore) DeleteMediaTx(ctx context.Context, arg DeleteMediaTxParam
 			return err
 		}
 
-		err = q.DeleteMedia(ctx, arg.MediaID)
+		err = checkDeleted(q.DeleteMedia(ctx, DeleteMediaParams{
+			ID:              arg.MediaID,
+			ExpectedVersion: arg.ExpectedVersion,
+		}))
 		if err != nil {
 			return err
 		}
@@ -626,13 +968,7 @@ func (store *SQLStore) CreateMediaAndLinkTx(ctx context.Context, arg CreateMedia
 	err := store.ExecTx(ctx, func(q *Queries) error {
 		var err error
 
-		result.Media, err = q.CreateMedia(ctx, CreateMediaParams{
-			Name:        arg.Name,
-			Description: arg.Description,
-			Alt:         arg.Alt,
-			MediaPath:   arg.MediaPath,
-			UserID:      arg.UserID,
-		})
+		result.Media, err = q.CreateMedia(ctx, arg.CreateMediaParams)
 		if err != nil {
 			return err
 		}
@@ -656,3 +992,62 @@ func (store *SQLStore) CreateMediaAndLinkTx(ctx context.Context, arg CreateMedia
 
 	return result, err
 }
+
+type CreateMediaWithRenditionsTxParams struct {
+	CreateMediaParams
+	PostID     *int64
+	Order      int32
+	Renditions []CreateMediaRenditionParams
+}
+
+type CreateMediaWithRenditionsTxResult struct {
+	Media      Medium           `json:"media"`
+	PostMedia  *PostMedium      `json:"post_media,omitempty"`
+	Renditions []MediaRendition `json:"renditions"`
+}
+
+// CreateMediaWithRenditionsTx records an uploaded file, its renditions and an
+// optional post link together, so a failed upload leaves no partial rows.
+func (store *SQLStore) CreateMediaWithRenditionsTx(ctx context.Context, arg CreateMediaWithRenditionsTxParams) (CreateMediaWithRenditionsTxResult, error) {
+	var result CreateMediaWithRenditionsTxResult
+
+	err := store.ExecTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Media, err = q.CreateMedia(ctx, arg.CreateMediaParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.PostID != nil {
+			_, err = q.GetPost(ctx, *arg.PostID)
+			if err != nil {
+				return fmt.Errorf("post %d not found: %w", *arg.PostID, err)
+			}
+
+			postMedia, err := q.CreatePostMedia(ctx, CreatePostMediaParams{
+				PostID:  *arg.PostID,
+				MediaID: result.Media.ID,
+				Order:   arg.Order,
+			})
+			if err != nil {
+				return err
+			}
+			result.PostMedia = &postMedia
+		}
+
+		result.Renditions = make([]MediaRendition, 0, len(arg.Renditions))
+		for _, renditionArg := range arg.Renditions {
+			renditionArg.MediaID = result.Media.ID
+			rendition, err := q.CreateMediaRendition(ctx, renditionArg)
+			if err != nil {
+				return err
+			}
+			result.Renditions = append(result.Renditions, rendition)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -8,6 +8,8 @@ package db
 import (
 	"context"
 	"database/sql"
+
+	"github.com/lib/pq"
 )
 
 const countTotalTaxonomies = `-- name: CountTotalTaxonomies :one
@@ -48,7 +50,7 @@ INSERT INTO taxonomies (
     description
 ) VALUES (
     $1, $2
-) RETURNING id, name, description
+) RETURNING id, name, description, version
 `
 
 type CreateTaxonomyParams struct {
@@ -59,7 +61,12 @@ type CreateTaxonomyParams struct {
 func (q *Queries) CreateTaxonomy(ctx context.Context, arg CreateTaxonomyParams) (Taxonomy, error) {
 	row := q.db.QueryRowContext(ctx, createTaxonomy, arg.Name, arg.Description)
 	var i Taxonomy
-	err := row.Scan(&i.ID, &i.Name, &i.Description)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Version,
+	)
 	return i, err
 }
 
@@ -88,14 +95,23 @@ func (q *Queries) DeletePostTaxonomy(ctx context.Context, arg DeletePostTaxonomy
 	return err
 }
 
-const deleteTaxonomy = `-- name: DeleteTaxonomy :exec
+const deleteTaxonomy = `-- name: DeleteTaxonomy :execrows
 DELETE FROM taxonomies
 WHERE id = $1
+  AND ($2::bigint IS NULL OR version = $2)
 `
 
-func (q *Queries) DeleteTaxonomy(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteTaxonomy, id)
-	return err
+type DeleteTaxonomyParams struct {
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
+}
+
+func (q *Queries) DeleteTaxonomy(ctx context.Context, arg DeleteTaxonomyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTaxonomy, arg.ID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const deleteTaxonomyPosts = `-- name: DeleteTaxonomyPosts :exec
@@ -110,7 +126,7 @@ func (q *Queries) DeleteTaxonomyPosts(ctx context.Context, taxonomyID int64) err
 
 const getPopularTaxonomies = `-- name: GetPopularTaxonomies :many
 SELECT 
-    t.id, t.name, t.description,
+    t.id, t.name, t.description, t.version,
     COUNT(pt.post_id) as post_count
 FROM taxonomies t
 JOIN posts_taxonomies pt ON t.id = pt.taxonomy_id
@@ -124,6 +140,7 @@ type GetPopularTaxonomiesRow struct {
 	ID          int64  `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Version     int64  `json:"version"`
 	PostCount   int64  `json:"post_count"`
 }
 
@@ -140,6 +157,7 @@ func (q *Queries) GetPopularTaxonomies(ctx context.Context, limit int32) ([]GetP
 			&i.ID,
 			&i.Name,
 			&i.Description,
+			&i.Version,
 			&i.PostCount,
 		); err != nil {
 			return nil, err
@@ -156,7 +174,7 @@ func (q *Queries) GetPopularTaxonomies(ctx context.Context, limit int32) ([]GetP
 }
 
 const getPostTaxonomies = `-- name: GetPostTaxonomies :many
-SELECT t.id, t.name, t.description FROM taxonomies t
+SELECT t.id, t.name, t.description, t.version FROM taxonomies t
 JOIN posts_taxonomies pt ON t.id = pt.taxonomy_id
 WHERE pt.post_id = $1
 ORDER BY t.name
@@ -171,7 +189,12 @@ func (q *Queries) GetPostTaxonomies(ctx context.Context, postID int64) ([]Taxono
 	items := []Taxonomy{}
 	for rows.Next() {
 		var i Taxonomy
-		if err := rows.Scan(&i.ID, &i.Name, &i.Description); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Version,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -197,27 +220,81 @@ func (q *Queries) GetPostTaxonomyCount(ctx context.Context, postID int64) (int64
 	return count, err
 }
 
+const getTaxonomiesByPostIDs = `-- name: GetTaxonomiesByPostIDs :many
+SELECT pt.post_id, t.id, t.name, t.description, t.version FROM taxonomies t
+JOIN posts_taxonomies pt ON t.id = pt.taxonomy_id
+WHERE pt.post_id = ANY($1::bigint[])
+ORDER BY pt.post_id, t.name
+`
+
+type GetTaxonomiesByPostIDsRow struct {
+	PostID      int64  `json:"post_id"`
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     int64  `json:"version"`
+}
+
+func (q *Queries) GetTaxonomiesByPostIDs(ctx context.Context, postIds []int64) ([]GetTaxonomiesByPostIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTaxonomiesByPostIDs, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTaxonomiesByPostIDsRow{}
+	for rows.Next() {
+		var i GetTaxonomiesByPostIDsRow
+		if err := rows.Scan(
+			&i.PostID,
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTaxonomy = `-- name: GetTaxonomy :one
-SELECT id, name, description FROM taxonomies
+SELECT id, name, description, version FROM taxonomies
 WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetTaxonomy(ctx context.Context, id int64) (Taxonomy, error) {
 	row := q.db.QueryRowContext(ctx, getTaxonomy, id)
 	var i Taxonomy
-	err := row.Scan(&i.ID, &i.Name, &i.Description)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Version,
+	)
 	return i, err
 }
 
 const getTaxonomyByName = `-- name: GetTaxonomyByName :one
-SELECT id, name, description FROM taxonomies
+SELECT id, name, description, version FROM taxonomies
 WHERE name = $1 LIMIT 1
 `
 
 func (q *Queries) GetTaxonomyByName(ctx context.Context, name string) (Taxonomy, error) {
 	row := q.db.QueryRowContext(ctx, getTaxonomyByName, name)
 	var i Taxonomy
-	err := row.Scan(&i.ID, &i.Name, &i.Description)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Version,
+	)
 	return i, err
 }
 
@@ -234,22 +311,29 @@ func (q *Queries) GetTaxonomyPostCount(ctx context.Context, taxonomyID int64) (i
 }
 
 const getTaxonomyPosts = `-- name: GetTaxonomyPosts :many
-SELECT p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at FROM posts p
+SELECT p.id, p.title, p.description, p.content, p.user_id, p.username, p.url, p.created_at, p.changed_at, p.status, p.published_at, p.publish_at, p.unpublish_at, p.version, p.language FROM posts p
 JOIN posts_taxonomies pt ON p.id = pt.post_id
 WHERE pt.taxonomy_id = $1
+  AND ($2::varchar IS NULL OR p.status = $2)
 ORDER BY p.created_at DESC
-LIMIT $2
-OFFSET $3
+LIMIT $3
+OFFSET $4
 `
 
 type GetTaxonomyPostsParams struct {
-	TaxonomyID int64 `json:"taxonomy_id"`
-	Limit      int32 `json:"limit"`
-	Offset     int32 `json:"offset"`
+	TaxonomyID int64          `json:"taxonomy_id"`
+	Status     sql.NullString `json:"status"`
+	Limit      int32          `json:"limit"`
+	Offset     int32          `json:"offset"`
 }
 
 func (q *Queries) GetTaxonomyPosts(ctx context.Context, arg GetTaxonomyPostsParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getTaxonomyPosts, arg.TaxonomyID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getTaxonomyPosts,
+		arg.TaxonomyID,
+		arg.Status,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -267,6 +351,12 @@ func (q *Queries) GetTaxonomyPosts(ctx context.Context, arg GetTaxonomyPostsPara
 			&i.Url,
 			&i.CreatedAt,
 			&i.ChangedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.Version,
+			&i.Language,
 		); err != nil {
 			return nil, err
 		}
@@ -281,8 +371,36 @@ func (q *Queries) GetTaxonomyPosts(ctx context.Context, arg GetTaxonomyPostsPara
 	return items, nil
 }
 
+const listPostIDsByTaxonomy = `-- name: ListPostIDsByTaxonomy :many
+SELECT post_id FROM posts_taxonomies
+WHERE taxonomy_id = $1
+`
+
+func (q *Queries) ListPostIDsByTaxonomy(ctx context.Context, taxonomyID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listPostIDsByTaxonomy, taxonomyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var post_id int64
+		if err := rows.Scan(&post_id); err != nil {
+			return nil, err
+		}
+		items = append(items, post_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTaxonomies = `-- name: ListTaxonomies :many
-SELECT id, name, description FROM taxonomies
+SELECT id, name, description, version FROM taxonomies
 ORDER BY name
 LIMIT $1
 OFFSET $2
@@ -302,7 +420,45 @@ func (q *Queries) ListTaxonomies(ctx context.Context, arg ListTaxonomiesParams)
 	items := []Taxonomy{}
 	for rows.Next() {
 		var i Taxonomy
-		if err := rows.Scan(&i.ID, &i.Name, &i.Description); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaxonomiesByIDs = `-- name: ListTaxonomiesByIDs :many
+SELECT id, name, description, version FROM taxonomies
+WHERE id = ANY($1::bigint[])
+`
+
+func (q *Queries) ListTaxonomiesByIDs(ctx context.Context, ids []int64) ([]Taxonomy, error) {
+	rows, err := q.db.QueryContext(ctx, listTaxonomiesByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Taxonomy{}
+	for rows.Next() {
+		var i Taxonomy
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Version,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -318,7 +474,7 @@ func (q *Queries) ListTaxonomies(ctx context.Context, arg ListTaxonomiesParams)
 
 const listTaxonomiesWithPostCount = `-- name: ListTaxonomiesWithPostCount :many
 SELECT 
-    t.id, t.name, t.description,
+    t.id, t.name, t.description, t.version,
     COUNT(pt.post_id) as post_count
 FROM taxonomies t
 LEFT JOIN posts_taxonomies pt ON t.id = pt.taxonomy_id
@@ -337,6 +493,7 @@ type ListTaxonomiesWithPostCountRow struct {
 	ID          int64  `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Version     int64  `json:"version"`
 	PostCount   int64  `json:"post_count"`
 }
 
@@ -353,6 +510,7 @@ func (q *Queries) ListTaxonomiesWithPostCount(ctx context.Context, arg ListTaxon
 			&i.ID,
 			&i.Name,
 			&i.Description,
+			&i.Version,
 			&i.PostCount,
 		); err != nil {
 			return nil, err
@@ -369,7 +527,7 @@ func (q *Queries) ListTaxonomiesWithPostCount(ctx context.Context, arg ListTaxon
 }
 
 const searchTaxonomiesByName = `-- name: SearchTaxonomiesByName :many
-SELECT id, name, description FROM taxonomies
+SELECT id, name, description, version FROM taxonomies
 WHERE name ILIKE '%' || $1 || '%'
 ORDER BY name
 LIMIT $2
@@ -391,7 +549,12 @@ func (q *Queries) SearchTaxonomiesByName(ctx context.Context, arg SearchTaxonomi
 	items := []Taxonomy{}
 	for rows.Next() {
 		var i Taxonomy
-		if err := rows.Scan(&i.ID, &i.Name, &i.Description); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Version,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -408,21 +571,34 @@ func (q *Queries) SearchTaxonomiesByName(ctx context.Context, arg SearchTaxonomi
 const updateTaxonomy = `-- name: UpdateTaxonomy :one
 UPDATE taxonomies 
 SET 
-    name = COALESCE($2, name),
-    description = COALESCE($3, description)
-WHERE id = $1
-RETURNING id, name, description
+    name = COALESCE($1, name),
+    description = COALESCE($2, description),
+    version = version + 1
+WHERE id = $3
+  AND ($4::bigint IS NULL OR version = $4)
+RETURNING id, name, description, version
 `
 
 type UpdateTaxonomyParams struct {
-	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name            string        `json:"name"`
+	Description     string        `json:"description"`
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
 }
 
 func (q *Queries) UpdateTaxonomy(ctx context.Context, arg UpdateTaxonomyParams) (Taxonomy, error) {
-	row := q.db.QueryRowContext(ctx, updateTaxonomy, arg.ID, arg.Name, arg.Description)
+	row := q.db.QueryRowContext(ctx, updateTaxonomy,
+		arg.Name,
+		arg.Description,
+		arg.ID,
+		arg.ExpectedVersion,
+	)
 	var i Taxonomy
-	err := row.Scan(&i.ID, &i.Name, &i.Description)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Version,
+	)
 	return i, err
 }
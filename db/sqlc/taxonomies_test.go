@@ -151,7 +151,7 @@ func TestDeleteTaxonomyTx(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	err = testStore.DeleteTaxonomyTx(context.Background(), taxonomy.ID)
+	err = testStore.DeleteTaxonomyTx(context.Background(), DeleteTaxonomyTxParams{ID: taxonomy.ID})
 	require.NoError(t, err)
 
 	deletedTaxonomy, err := testQueries.GetTaxonomy(context.Background(), taxonomy.ID)
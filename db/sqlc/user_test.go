@@ -2,13 +2,17 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+
+	"github.com/go-live-cms/go-live-cms/util"
 )
 
 var userCounter int64 = 0
@@ -206,7 +210,7 @@ func TestDeleteUserWithTransferTx(t *testing.T) {
 func TestDeleteUserTx_NuclearOption(t *testing.T) {
 	user, post := createTestUserWithPosts(t)
 
-	err := testStore.DeleteUserTx(context.Background(), user.ID)
+	err := testStore.DeleteUserTx(context.Background(), DeleteUserTxParams{ID: user.ID})
 	require.NoError(t, err)
 
 	deletedUser, err := testQueries.GetUser(context.Background(), user.ID)
@@ -221,7 +225,7 @@ func TestDeleteUserTx_NuclearOption(t *testing.T) {
 func TestDeleteUser_WithoutTransaction_ShouldFail(t *testing.T) {
 	user, _ := createTestUserWithPosts(t)
 
-	err := testQueries.DeleteUser(context.Background(), user.ID)
+	_, err := testQueries.DeleteUser(context.Background(), DeleteUserParams{ID: user.ID})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "foreign key constraint")
 }
@@ -269,3 +273,302 @@ func TestUpdateUserTx_UniqueConstraintViolation(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "already exists")
 }
+
+func TestRegisterUserTxAndVerifyEmailTx(t *testing.T) {
+	timestamp := time.Now().UnixNano()
+	tokenHash := fmt.Sprintf("hash_%d", timestamp)
+
+	result, err := testStore.RegisterUserTx(context.Background(), RegisterUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       fmt.Sprintf("registered_%d", timestamp),
+			Email:          fmt.Sprintf("registered_%d@example.com", timestamp),
+			FullName:       gofakeit.Name(),
+			HashedPassword: gofakeit.Password(true, true, true, true, false, 32),
+			Role:           "user",
+		},
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.False(t, result.User.EmailVerifiedAt.Valid)
+	require.Equal(t, result.User.ID, result.Token.UserID)
+
+	user, err := testStore.VerifyEmailTx(context.Background(), tokenHash)
+	require.NoError(t, err)
+	require.Equal(t, result.User.ID, user.ID)
+	require.True(t, user.EmailVerifiedAt.Valid)
+
+	// Tokens are single use.
+	_, err = testStore.VerifyEmailTx(context.Background(), tokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestVerifyEmailTxExpiredToken(t *testing.T) {
+	user := createTestUser(t)
+	tokenHash := fmt.Sprintf("expired_%d", time.Now().UnixNano())
+
+	_, err := testStore.ReissueEmailVerificationTx(context.Background(), CreateEmailVerificationTokenParams{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.VerifyEmailTx(context.Background(), tokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err = testQueries.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.False(t, user.EmailVerifiedAt.Valid)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	user := createTestUser(t)
+	session := createRandomSession(t, user)
+	tokenHash := fmt.Sprintf("reset_%d", time.Now().UnixNano())
+
+	_, err := testStore.ReissuePasswordResetTx(context.Background(), CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	newHashedPassword := gofakeit.Password(true, true, true, true, false, 32)
+	updated, err := testStore.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      tokenHash,
+		HashedPassword: newHashedPassword,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.ID, updated.ID)
+	require.Equal(t, newHashedPassword, updated.HashedPassword)
+	require.True(t, updated.PasswordChangedAt.After(user.PasswordChangedAt))
+	require.Equal(t, user.Version+1, updated.Version)
+
+	session, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	// Tokens are single use.
+	_, err = testStore.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      tokenHash,
+		HashedPassword: newHashedPassword,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestReissuePasswordResetTxInvalidatesOldToken(t *testing.T) {
+	user := createTestUser(t)
+	oldHash := fmt.Sprintf("reset_old_%d", time.Now().UnixNano())
+	newHash := fmt.Sprintf("reset_new_%d", time.Now().UnixNano())
+
+	for _, tokenHash := range []string{oldHash, newHash} {
+		_, err := testStore.ReissuePasswordResetTx(context.Background(), CreatePasswordResetTokenParams{
+			UserID:    user.ID,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+	}
+
+	_, err := testStore.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      oldHash,
+		HashedPassword: "irrelevant",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err = testQueries.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, "irrelevant", user.HashedPassword)
+}
+
+func TestMFAEnrollmentTxs(t *testing.T) {
+	user := createTestUser(t)
+	suffix := time.Now().UnixNano()
+
+	_, err := testQueries.UpsertUserMFASecret(context.Background(), UpsertUserMFASecretParams{
+		UserID:     user.ID,
+		TotpSecret: "JBSWY3DPEHPK3PXP",
+	})
+	require.NoError(t, err)
+
+	mfa, err := testStore.ConfirmMFAEnrollmentTx(context.Background(), ConfirmMFAEnrollmentTxParams{
+		UserID:             user.ID,
+		Step:               100,
+		RecoveryCodeHashes: []string{fmt.Sprintf("code_a_%d", suffix), fmt.Sprintf("code_b_%d", suffix)},
+	})
+	require.NoError(t, err)
+	require.True(t, mfa.EnabledAt.Valid)
+	require.Equal(t, int64(100), mfa.LastUsedStep)
+
+	// An enabled secret cannot be overwritten by a new enrolment.
+	_, err = testQueries.UpsertUserMFASecret(context.Background(), UpsertUserMFASecretParams{
+		UserID:     user.ID,
+		TotpSecret: "OTHERSECRET",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err := testQueries.UseUserMFAStep(context.Background(), UseUserMFAStepParams{UserID: user.ID, LastUsedStep: 100})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	_, err = testQueries.ConsumeMFARecoveryCode(context.Background(), ConsumeMFARecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: fmt.Sprintf("code_a_%d", suffix),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.ConsumeMFARecoveryCode(context.Background(), ConsumeMFARecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: fmt.Sprintf("code_a_%d", suffix),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	count, err := testQueries.CountUnusedMFARecoveryCodes(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	err = testStore.DisableMFATx(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetUserMFA(context.Background(), user.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	count, err = testQueries.CountUnusedMFARecoveryCodes(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func TestAPIKeys(t *testing.T) {
+	user := createTestUser(t)
+	hash := util.HashToken(uuid.NewString())
+
+	apiKey, err := testQueries.CreateAPIKey(context.Background(), CreateAPIKeyParams{
+		UserID:    user.ID,
+		Name:      "ci",
+		Prefix:    "glc_abcdefgh",
+		KeyHash:   hash,
+		Scopes:    []string{"posts:read", "posts:write"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"posts:read", "posts:write"}, apiKey.Scopes)
+	require.False(t, apiKey.LastUsedAt.Valid)
+	require.False(t, apiKey.RevokedAt.Valid)
+
+	found, err := testQueries.GetAPIKeyByHash(context.Background(), hash)
+	require.NoError(t, err)
+	require.Equal(t, apiKey.ID, found.ID)
+
+	err = testQueries.TouchAPIKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+
+	apiKeys, err := testQueries.ListAPIKeysByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, apiKeys, 1)
+	require.True(t, apiKeys[0].LastUsedAt.Valid)
+
+	// Keys can only be revoked by their owner, and only once.
+	_, err = testQueries.RevokeAPIKey(context.Background(), RevokeAPIKeyParams{ID: apiKey.ID, UserID: user.ID + 1})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	revoked, err := testQueries.RevokeAPIKey(context.Background(), RevokeAPIKeyParams{ID: apiKey.ID, UserID: user.ID})
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	_, err = testQueries.RevokeAPIKey(context.Background(), RevokeAPIKeyParams{ID: apiKey.ID, UserID: user.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateOIDCUserTx(t *testing.T) {
+	subject := uuid.NewString()
+	username := "oidc" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+
+	result, err := testStore.CreateOIDCUserTx(context.Background(), CreateOIDCUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:        username,
+			FullName:        "OIDC User",
+			Email:           username + "@example.com",
+			HashedPassword:  "unusable",
+			Role:            "user",
+			EmailVerifiedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		},
+		Provider: "corp",
+		Subject:  subject,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.User.ID, result.Identity.UserID)
+	require.Equal(t, result.User.Email, result.Identity.Email)
+
+	identity, err := testQueries.GetUserIdentity(context.Background(), GetUserIdentityParams{
+		Provider: "corp",
+		Subject:  subject,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.Identity.ID, identity.ID)
+
+	err = testQueries.TouchUserIdentity(context.Background(), TouchUserIdentityParams{
+		ID:    identity.ID,
+		Email: "changed@example.com",
+	})
+	require.NoError(t, err)
+
+	// The same subject cannot be linked twice.
+	_, err = testQueries.CreateUserIdentity(context.Background(), CreateUserIdentityParams{
+		UserID:   createTestUser(t).ID,
+		Provider: "corp",
+		Subject:  subject,
+	})
+	require.Error(t, err)
+}
+
+func TestConsumeOIDCLoginState(t *testing.T) {
+	stateHash := util.HashToken(uuid.NewString())
+	err := testQueries.CreateOIDCLoginState(context.Background(), CreateOIDCLoginStateParams{
+		StateHash:    stateHash,
+		Provider:     "corp",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	state, err := testQueries.ConsumeOIDCLoginState(context.Background(), stateHash)
+	require.NoError(t, err)
+	require.Equal(t, "verifier", state.CodeVerifier)
+
+	_, err = testQueries.ConsumeOIDCLoginState(context.Background(), stateHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	expiredHash := util.HashToken(uuid.NewString())
+	err = testQueries.CreateOIDCLoginState(context.Background(), CreateOIDCLoginStateParams{
+		StateHash:    expiredHash,
+		Provider:     "corp",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.ConsumeOIDCLoginState(context.Background(), expiredHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, testQueries.DeleteExpiredOIDCLoginStates(context.Background()))
+}
+
+func TestListPostIDsByUser(t *testing.T) {
+	owner, owned := createTestUserWithPosts(t)
+	coAuthor, coAuthored := createTestUserWithPosts(t)
+
+	_, err := testQueries.CreateUserPost(context.Background(), CreateUserPostParams{
+		UserID: owner.ID,
+		PostID: coAuthored.Post.ID,
+	})
+	require.NoError(t, err)
+
+	ids, err := testQueries.ListPostIDsByUser(context.Background(), owner.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int64{owned.Post.ID, coAuthored.Post.ID}, ids)
+
+	ids, err = testQueries.ListPostIDsByUser(context.Background(), coAuthor.ID)
+	require.NoError(t, err)
+	require.Equal(t, []int64{coAuthored.Post.ID}, ids)
+}
@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
@@ -27,18 +28,20 @@ INSERT INTO users (
     full_name,
     email,
     hashed_password,
-    role
+    role,
+    email_verified_at
 ) VALUES (
-    $1, $2, $3, $4, $5
-) RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, role
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, role, version, email_verified_at
 `
 
 type CreateUserParams struct {
-	Username       string `json:"username"`
-	FullName       string `json:"full_name"`
-	Email          string `json:"email"`
-	HashedPassword string `json:"hashed_password"`
-	Role           string `json:"role"`
+	Username        string       `json:"username"`
+	FullName        string       `json:"full_name"`
+	Email           string       `json:"email"`
+	HashedPassword  string       `json:"hashed_password"`
+	Role            string       `json:"role"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -48,6 +51,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.Email,
 		arg.HashedPassword,
 		arg.Role,
+		arg.EmailVerifiedAt,
 	)
 	var i User
 	err := row.Scan(
@@ -59,6 +63,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.Role,
+		&i.Version,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -73,14 +79,23 @@ func (q *Queries) DeletePostsByUserID(ctx context.Context, userID int64) error {
 	return err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
+const deleteUser = `-- name: DeleteUser :execrows
 DELETE FROM users
 WHERE id = $1
+  AND ($2::bigint IS NULL OR version = $2)
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteUser, id)
-	return err
+type DeleteUserParams struct {
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
+}
+
+func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, arg.ID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const deleteUserPostsByUserID = `-- name: DeleteUserPostsByUserID :exec
@@ -104,7 +119,7 @@ func (q *Queries) DeleteUserSessions(ctx context.Context, id int64) error {
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, role FROM users
+SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, role, version, email_verified_at FROM users
 WHERE id = $1 LIMIT 1
 `
 
@@ -120,12 +135,14 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.Role,
+		&i.Version,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, role FROM users
+SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, role, version, email_verified_at FROM users
 WHERE email = $1
 LIMIT 1
 `
@@ -142,12 +159,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.Role,
+		&i.Version,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, role FROM users
+SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, role, version, email_verified_at FROM users
 WHERE username = $1
 LIMIT 1
 `
@@ -164,12 +183,45 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.Role,
+		&i.Version,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
+const listPostIDsByUser = `-- name: ListPostIDsByUser :many
+SELECT id FROM posts
+WHERE user_id = $1
+UNION
+SELECT post_id FROM user_posts
+WHERE user_id = $1
+`
+
+func (q *Queries) ListPostIDsByUser(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listPostIDsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
-SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, role FROM users
+SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, role, version, email_verified_at FROM users
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -198,6 +250,8 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.PasswordChangedAt,
 			&i.CreatedAt,
 			&i.Role,
+			&i.Version,
+			&i.EmailVerifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -212,9 +266,36 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :one
+UPDATE users
+SET
+    email_verified_at = COALESCE(email_verified_at, now()),
+    version = version + 1
+WHERE id = $1
+RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, role, version, email_verified_at
+`
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, markUserEmailVerified, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FullName,
+		&i.Email,
+		&i.HashedPassword,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.Version,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
 const transferPostsToAdmin = `-- name: TransferPostsToAdmin :exec
 UPDATE posts 
-SET user_id = $2, username = (SELECT username FROM users WHERE id = $2)
+SET user_id = $2, username = (SELECT username FROM users WHERE id = $2), version = version + 1
 WHERE user_id = $1
 `
 
@@ -230,7 +311,7 @@ func (q *Queries) TransferPostsToAdmin(ctx context.Context, arg TransferPostsToA
 
 const updatePostsUsername = `-- name: UpdatePostsUsername :exec
 UPDATE posts
-SET username = $2
+SET username = $2, version = version + 1
 WHERE user_id = $1
 `
 
@@ -247,35 +328,39 @@ func (q *Queries) UpdatePostsUsername(ctx context.Context, arg UpdatePostsUserna
 const updateUser = `-- name: UpdateUser :one
 UPDATE users 
 SET 
-    username = COALESCE($2, username),
-    full_name = COALESCE($3, full_name),
-    email = COALESCE($4, email),
-    hashed_password = COALESCE($5, hashed_password),
-    password_changed_at = COALESCE($6, password_changed_at),
-    role = COALESCE($7, role)
-WHERE id = $1
-RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, role
+    username = COALESCE($1, username),
+    full_name = COALESCE($2, full_name),
+    email = COALESCE($3, email),
+    hashed_password = COALESCE($4, hashed_password),
+    password_changed_at = COALESCE($5, password_changed_at),
+    role = COALESCE($6, role),
+    version = version + 1
+WHERE id = $7
+  AND ($8::bigint IS NULL OR version = $8)
+RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, role, version, email_verified_at
 `
 
 type UpdateUserParams struct {
-	ID                int64     `json:"id"`
-	Username          string    `json:"username"`
-	FullName          string    `json:"full_name"`
-	Email             string    `json:"email"`
-	HashedPassword    string    `json:"hashed_password"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
-	Role              string    `json:"role"`
+	Username          string        `json:"username"`
+	FullName          string        `json:"full_name"`
+	Email             string        `json:"email"`
+	HashedPassword    string        `json:"hashed_password"`
+	PasswordChangedAt time.Time     `json:"password_changed_at"`
+	Role              string        `json:"role"`
+	ID                int64         `json:"id"`
+	ExpectedVersion   sql.NullInt64 `json:"expected_version"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, updateUser,
-		arg.ID,
 		arg.Username,
 		arg.FullName,
 		arg.Email,
 		arg.HashedPassword,
 		arg.PasswordChangedAt,
 		arg.Role,
+		arg.ID,
+		arg.ExpectedVersion,
 	)
 	var i User
 	err := row.Scan(
@@ -287,6 +372,41 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.Role,
+		&i.Version,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET
+    hashed_password = $2,
+    password_changed_at = now(),
+    version = version + 1
+WHERE id = $1
+RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, role, version, email_verified_at
+`
+
+type UpdateUserPasswordParams struct {
+	ID             int64  `json:"id"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.ID, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FullName,
+		&i.Email,
+		&i.HashedPassword,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.Version,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
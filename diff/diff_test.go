@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// apply rebuilds both sides of a diff so tests can check it is consistent.
+func apply(lines []Line) (string, string) {
+	var oldLines, newLines []string
+	for _, line := range lines {
+		if line.Op != OpInsert {
+			oldLines = append(oldLines, line.Text)
+		}
+		if line.Op != OpDelete {
+			newLines = append(newLines, line.Text)
+		}
+	}
+	return strings.Join(oldLines, "\n"), strings.Join(newLines, "\n")
+}
+
+func TestLines(t *testing.T) {
+	a := "one\ntwo\nthree\nfour"
+	b := "one\n2\nthree\nfour\nfive"
+
+	lines := Lines(a, b)
+	require.Equal(t, []Line{
+		{Op: OpEqual, Text: "one", OldLine: 1, NewLine: 1},
+		{Op: OpDelete, Text: "two", OldLine: 2},
+		{Op: OpInsert, Text: "2", NewLine: 2},
+		{Op: OpEqual, Text: "three", OldLine: 3, NewLine: 3},
+		{Op: OpEqual, Text: "four", OldLine: 4, NewLine: 4},
+		{Op: OpInsert, Text: "five", NewLine: 5},
+	}, lines)
+	require.True(t, Changed(lines))
+}
+
+func TestLinesEqual(t *testing.T) {
+	lines := Lines("same\ntext\n", "same\r\ntext")
+	require.Len(t, lines, 2)
+	require.False(t, Changed(lines))
+
+	require.Empty(t, Lines("", ""))
+}
+
+func TestLinesRoundTrip(t *testing.T) {
+	testCases := []struct{ a, b string }{
+		{"", "a\nb"},
+		{"a\nb", ""},
+		{"a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc"},
+		{"x\ny\nz", "z\ny\nx"},
+		{"header\nbody\nfooter", "header\nnew body\nmore\nfooter"},
+	}
+	for _, tc := range testCases {
+		lines := Lines(tc.a, tc.b)
+		gotA, gotB := apply(lines)
+		require.Equal(t, tc.a, gotA)
+		require.Equal(t, tc.b, gotB)
+	}
+}
+
+func TestLinesIsMinimal(t *testing.T) {
+	// The classic example from Myers' paper has an edit distance of 5.
+	a := strings.Join(strings.Split("ABCABBA", ""), "\n")
+	b := strings.Join(strings.Split("CBABAC", ""), "\n")
+
+	edits := 0
+	for _, line := range Lines(a, b) {
+		if line.Op != OpEqual {
+			edits++
+		}
+	}
+	require.Equal(t, 5, edits)
+}
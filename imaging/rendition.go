@@ -0,0 +1,195 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+
+	jpegQuality = 85
+)
+
+// Spec describes a rendition that fits inside MaxWidth x MaxHeight while
+// keeping the aspect ratio of the original.
+type Spec struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+}
+
+var DefaultSpecs = []Spec{
+	{Name: "thumbnail", MaxWidth: 150, MaxHeight: 150},
+	{Name: "medium", MaxWidth: 640, MaxHeight: 640},
+	{Name: "large", MaxWidth: 1280, MaxHeight: 1280},
+}
+
+// Rendition is an encoded, resized copy of an image.
+type Rendition struct {
+	Name     string
+	Format   string
+	MimeType string
+	Width    int
+	Height   int
+	Data     []byte
+}
+
+// ParseSpecs parses a comma-separated list such as
+// "thumbnail=150x150,medium=640x640". An empty string yields DefaultSpecs.
+func ParseSpecs(value string) ([]Spec, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return DefaultSpecs, nil
+	}
+
+	var specs []Spec
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		name, size, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid rendition %q: expected name=WIDTHxHEIGHT", item)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate rendition %q", name)
+		}
+		widthStr, heightStr, ok := strings.Cut(size, "x")
+		if !ok {
+			return nil, fmt.Errorf("invalid rendition size %q", size)
+		}
+		width, err := strconv.Atoi(widthStr)
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("invalid rendition width %q", widthStr)
+		}
+		height, err := strconv.Atoi(heightStr)
+		if err != nil || height <= 0 {
+			return nil, fmt.Errorf("invalid rendition height %q", heightStr)
+		}
+		seen[name] = true
+		specs = append(specs, Spec{Name: name, MaxWidth: width, MaxHeight: height})
+	}
+	return specs, nil
+}
+
+// Fit scales img down so it fits inside maxWidth x maxHeight. Images that
+// already fit are returned unchanged; nothing is ever scaled up.
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	width, height := fitSize(img.Bounds().Dx(), img.Bounds().Dy(), maxWidth, maxHeight)
+	if width == img.Bounds().Dx() && height == img.Bounds().Dy() {
+		return img
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+func fitSize(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+	if width*maxHeight > height*maxWidth {
+		return maxWidth, max(1, height*maxWidth/width)
+	}
+	return max(1, width*maxHeight/height), maxHeight
+}
+
+// Generate renders every spec that is smaller than src. Each size is encoded
+// in the primary format for the source format and, with webp set, also as WebP.
+// The WebP encoder is lossless, so a WebP copy that comes out no smaller than
+// the primary one is dropped rather than offered as the lighter alternative.
+func Generate(src image.Image, sourceFormat string, specs []Spec, webp bool) ([]Rendition, error) {
+	format := PrimaryFormat(sourceFormat)
+	bounds := src.Bounds()
+
+	var renditions []Rendition
+	for _, spec := range specs {
+		if bounds.Dx() <= spec.MaxWidth && bounds.Dy() <= spec.MaxHeight {
+			continue
+		}
+		resized := Fit(src, spec.MaxWidth, spec.MaxHeight)
+
+		primary, err := encodeRendition(spec.Name, resized, format)
+		if err != nil {
+			return nil, err
+		}
+		renditions = append(renditions, primary)
+
+		if !webp {
+			continue
+		}
+		alternative, err := encodeRendition(spec.Name, resized, FormatWebP)
+		if err != nil {
+			return nil, err
+		}
+		if len(alternative.Data) < len(primary.Data) {
+			renditions = append(renditions, alternative)
+		}
+	}
+	return renditions, nil
+}
+
+func encodeRendition(name string, img image.Image, format string) (Rendition, error) {
+	data, err := Encode(img, format)
+	if err != nil {
+		return Rendition{}, fmt.Errorf("failed to encode %s rendition as %s: %w", name, format, err)
+	}
+	return Rendition{
+		Name:     name,
+		Format:   format,
+		MimeType: MimeType(format),
+		Width:    img.Bounds().Dx(),
+		Height:   img.Bounds().Dy(),
+		Data:     data,
+	}, nil
+}
+
+// PrimaryFormat keeps JPEG sources as JPEG and stores everything else as PNG
+// so transparency survives.
+func PrimaryFormat(sourceFormat string) string {
+	if sourceFormat == FormatJPEG {
+		return FormatJPEG
+	}
+	return FormatPNG
+}
+
+func Encode(img image.Image, format string) ([]byte, error) {
+	return EncodeQuality(img, format, jpegQuality)
+}
+
+// EncodeQuality is Encode with an explicit JPEG quality. PNG and the WebP
+// encoder are lossless and ignore it.
+func EncodeQuality(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+	case FormatWebP:
+		err = EncodeWebP(&buf, img)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	return buf.Bytes(), err
+}
+
+func MimeType(format string) string {
+	return "image/" + format
+}
+
+func Extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
@@ -0,0 +1,141 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+func newTestImage(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// newPhotoImage is newTestImage with per-pixel noise, which compresses more
+// like a photograph than a clean gradient does.
+func newPhotoImage(width, height int) image.Image {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			n := uint8(rng.Intn(8))
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x/4) + n, G: uint8(y/3) + n, B: 128 + n, A: 255})
+		}
+	}
+	return img
+}
+
+func TestParseSpecs(t *testing.T) {
+	specs, err := ParseSpecs("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultSpecs, specs)
+
+	specs, err = ParseSpecs("thumb=100x80, hero=1920x1080")
+	require.NoError(t, err)
+	require.Equal(t, []Spec{
+		{Name: "thumb", MaxWidth: 100, MaxHeight: 80},
+		{Name: "hero", MaxWidth: 1920, MaxHeight: 1080},
+	}, specs)
+
+	for _, value := range []string{"thumb", "thumb=100", "thumb=0x10", "thumb=axb", "=10x10", "a=1x1,a=2x2"} {
+		_, err := ParseSpecs(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestFit(t *testing.T) {
+	src := newTestImage(400, 200)
+
+	resized := Fit(src, 100, 100)
+	require.Equal(t, 100, resized.Bounds().Dx())
+	require.Equal(t, 50, resized.Bounds().Dy())
+
+	resized = Fit(newTestImage(200, 400), 100, 100)
+	require.Equal(t, 50, resized.Bounds().Dx())
+	require.Equal(t, 100, resized.Bounds().Dy())
+
+	require.Same(t, src, Fit(src, 800, 800))
+}
+
+func TestGenerate(t *testing.T) {
+	src := newPhotoImage(800, 600)
+	specs := []Spec{
+		{Name: "thumbnail", MaxWidth: 150, MaxHeight: 150},
+		{Name: "medium", MaxWidth: 640, MaxHeight: 640},
+		{Name: "large", MaxWidth: 1280, MaxHeight: 1280},
+	}
+
+	renditions, err := Generate(src, FormatPNG, specs, true)
+	require.NoError(t, err)
+	// The large rendition would upscale and is skipped.
+	require.Len(t, renditions, 4)
+
+	expected := []struct {
+		name, format  string
+		width, height int
+	}{
+		{"thumbnail", FormatPNG, 150, 112},
+		{"thumbnail", FormatWebP, 150, 112},
+		{"medium", FormatPNG, 640, 480},
+		{"medium", FormatWebP, 640, 480},
+	}
+	for i, rendition := range renditions {
+		require.Equal(t, expected[i].name, rendition.Name)
+		require.Equal(t, expected[i].format, rendition.Format)
+		require.Equal(t, MimeType(expected[i].format), rendition.MimeType)
+		require.Equal(t, expected[i].width, rendition.Width)
+		require.Equal(t, expected[i].height, rendition.Height)
+
+		var config image.Config
+		switch rendition.Format {
+		case FormatPNG:
+			config, err = png.DecodeConfig(bytes.NewReader(rendition.Data))
+		case FormatWebP:
+			config, err = webp.DecodeConfig(bytes.NewReader(rendition.Data))
+			require.Less(t, len(rendition.Data), len(renditions[i-1].Data))
+		}
+		require.NoError(t, err)
+		require.Equal(t, rendition.Width, config.Width)
+		require.Equal(t, rendition.Height, config.Height)
+	}
+}
+
+func TestGenerateDropsLargerWebP(t *testing.T) {
+	// Lossless WebP cannot compete with JPEG on a photograph, so only the
+	// JPEG renditions are kept.
+	renditions, err := Generate(newPhotoImage(800, 600), FormatJPEG, DefaultSpecs, true)
+	require.NoError(t, err)
+	require.Len(t, renditions, 2)
+	for _, rendition := range renditions {
+		require.Equal(t, FormatJPEG, rendition.Format)
+
+		webpData, err := Encode(Fit(newPhotoImage(800, 600), rendition.Width, rendition.Height), FormatWebP)
+		require.NoError(t, err)
+		require.Greater(t, len(webpData), len(rendition.Data))
+
+		_, err = jpeg.DecodeConfig(bytes.NewReader(rendition.Data))
+		require.NoError(t, err)
+	}
+}
+
+func TestGeneratePNGWithoutWebP(t *testing.T) {
+	renditions, err := Generate(newTestImage(300, 300), "gif", DefaultSpecs, false)
+	require.NoError(t, err)
+	require.Len(t, renditions, 1)
+	require.Equal(t, FormatPNG, renditions[0].Format)
+
+	_, err = png.DecodeConfig(bytes.NewReader(renditions[0].Data))
+	require.NoError(t, err)
+}
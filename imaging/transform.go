@@ -0,0 +1,185 @@
+package imaging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"net/url"
+	"strconv"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	FitContain = "contain"
+	FitCover   = "cover"
+
+	// MaxTransformDimension bounds the width and height of on-the-fly
+	// transforms.
+	MaxTransformDimension = 4096
+
+	DefaultTransformQuality = 80
+)
+
+// Transform describes an on-the-fly resize, crop and conversion. A zero Width
+// or Height leaves that side unconstrained; an empty Format keeps the primary
+// format of the source.
+type Transform struct {
+	Width   int
+	Height  int
+	Fit     string
+	Format  string
+	Quality int
+}
+
+// ParseTransform reads w, h, fit, fmt and q from query values and fills in
+// defaults, so equivalent requests share one canonical form.
+func ParseTransform(values url.Values) (Transform, error) {
+	t := Transform{
+		Fit:     FitContain,
+		Quality: DefaultTransformQuality,
+	}
+
+	var err error
+	if t.Width, err = parseDimension(values.Get("w")); err != nil {
+		return t, fmt.Errorf("invalid width: %w", err)
+	}
+	if t.Height, err = parseDimension(values.Get("h")); err != nil {
+		return t, fmt.Errorf("invalid height: %w", err)
+	}
+	if t.Width == 0 && t.Height == 0 {
+		return t, fmt.Errorf("width or height is required")
+	}
+
+	if fit := values.Get("fit"); fit != "" {
+		if fit != FitContain && fit != FitCover {
+			return t, fmt.Errorf("invalid fit %q", fit)
+		}
+		t.Fit = fit
+	}
+	if t.Fit == FitCover && (t.Width == 0 || t.Height == 0) {
+		return t, fmt.Errorf("fit=cover needs both width and height")
+	}
+
+	if format := values.Get("fmt"); format != "" {
+		if format == "jpg" {
+			format = FormatJPEG
+		}
+		if format != FormatJPEG && format != FormatPNG && format != FormatWebP {
+			return t, fmt.Errorf("invalid format %q", format)
+		}
+		t.Format = format
+	}
+
+	if q := values.Get("q"); q != "" {
+		quality, err := strconv.Atoi(q)
+		if err != nil || quality < 1 || quality > 100 {
+			return t, fmt.Errorf("invalid quality %q", q)
+		}
+		t.Quality = quality
+	}
+	return t, nil
+}
+
+func parseDimension(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 || n > MaxTransformDimension {
+		return 0, fmt.Errorf("must be between 1 and %d", MaxTransformDimension)
+	}
+	return n, nil
+}
+
+// Canonical encodes the transform in a fixed order. It is what gets signed
+// and what cached results are keyed by.
+func (t Transform) Canonical() string {
+	return fmt.Sprintf("w=%d&h=%d&fit=%s&fmt=%s&q=%d", t.Width, t.Height, t.Fit, t.Format, t.Quality)
+}
+
+// Query returns the transform as URL query values, without a signature.
+func (t Transform) Query() url.Values {
+	values := url.Values{}
+	if t.Width > 0 {
+		values.Set("w", strconv.Itoa(t.Width))
+	}
+	if t.Height > 0 {
+		values.Set("h", strconv.Itoa(t.Height))
+	}
+	values.Set("fit", t.Fit)
+	if t.Format != "" {
+		values.Set("fmt", t.Format)
+	}
+	values.Set("q", strconv.Itoa(t.Quality))
+	return values
+}
+
+// Sign returns the hex HMAC-SHA256 of the transform for one media item.
+func Sign(key []byte, mediaID int64, t Transform) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%d?%s", mediaID, t.Canonical())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature was produced by Sign with the same key.
+func Verify(key []byte, mediaID int64, t Transform, signature string) bool {
+	expected, err := hex.DecodeString(Sign(key, mediaID, t))
+	if err != nil {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, got)
+}
+
+// Apply resizes src according to t. Images are never upscaled; with
+// FitCover the result is cropped around the centre to the requested aspect
+// ratio.
+func Apply(src image.Image, t Transform) image.Image {
+	bounds := src.Bounds()
+	maxWidth, maxHeight := t.Width, t.Height
+	if maxWidth == 0 {
+		maxWidth = bounds.Dx()
+	}
+	if maxHeight == 0 {
+		maxHeight = bounds.Dy()
+	}
+
+	if t.Fit != FitCover {
+		return Fit(src, maxWidth, maxHeight)
+	}
+
+	crop := coverCrop(bounds, maxWidth, maxHeight)
+	width, height := maxWidth, maxHeight
+	if crop.Dx() <= maxWidth && crop.Dy() <= maxHeight {
+		// The source is smaller than requested, so only crop it.
+		width, height = crop.Dx(), crop.Dy()
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
+
+// coverCrop returns the largest centred rectangle of bounds with the aspect
+// ratio width:height.
+func coverCrop(bounds image.Rectangle, width, height int) image.Rectangle {
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	cropWidth, cropHeight := srcWidth, srcHeight
+	if srcWidth*height > srcHeight*width {
+		cropWidth = max(1, srcHeight*width/height)
+	} else {
+		cropHeight = max(1, srcWidth*height/width)
+	}
+	x := bounds.Min.X + (srcWidth-cropWidth)/2
+	y := bounds.Min.Y + (srcHeight-cropHeight)/2
+	return image.Rect(x, y, x+cropWidth, y+cropHeight)
+}
@@ -0,0 +1,74 @@
+package imaging
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransform(t *testing.T) {
+	transform, err := ParseTransform(url.Values{"w": {"300"}})
+	require.NoError(t, err)
+	require.Equal(t, Transform{Width: 300, Fit: FitContain, Quality: DefaultTransformQuality}, transform)
+
+	transform, err = ParseTransform(url.Values{"w": {"300"}, "h": {"200"}, "fit": {"cover"}, "fmt": {"jpg"}, "q": {"60"}})
+	require.NoError(t, err)
+	require.Equal(t, Transform{Width: 300, Height: 200, Fit: FitCover, Format: FormatJPEG, Quality: 60}, transform)
+
+	parsed, err := ParseTransform(transform.Query())
+	require.NoError(t, err)
+	require.Equal(t, transform, parsed)
+
+	for _, values := range []url.Values{
+		{},
+		{"w": {"0"}},
+		{"w": {"5000"}},
+		{"w": {"abc"}},
+		{"w": {"100"}, "fit": {"stretch"}},
+		{"w": {"100"}, "fit": {"cover"}},
+		{"w": {"100"}, "fmt": {"gif"}},
+		{"w": {"100"}, "q": {"0"}},
+		{"w": {"100"}, "q": {"101"}},
+	} {
+		_, err := ParseTransform(values)
+		require.Error(t, err, values.Encode())
+	}
+}
+
+func TestSignTransform(t *testing.T) {
+	key := []byte("secret")
+	transform := Transform{Width: 300, Fit: FitContain, Quality: 80}
+
+	signature := Sign(key, 7, transform)
+	require.True(t, Verify(key, 7, transform, signature))
+	require.False(t, Verify(key, 8, transform, signature))
+	require.False(t, Verify([]byte("other"), 7, transform, signature))
+	require.False(t, Verify(key, 7, Transform{Width: 301, Fit: FitContain, Quality: 80}, signature))
+	require.False(t, Verify(key, 7, transform, "not-hex"))
+	require.False(t, Verify(key, 7, transform, ""))
+}
+
+func TestApplyTransform(t *testing.T) {
+	src := newTestImage(400, 200)
+
+	resized := Apply(src, Transform{Width: 100, Fit: FitContain})
+	require.Equal(t, 100, resized.Bounds().Dx())
+	require.Equal(t, 50, resized.Bounds().Dy())
+
+	resized = Apply(src, Transform{Height: 100, Fit: FitContain})
+	require.Equal(t, 200, resized.Bounds().Dx())
+	require.Equal(t, 100, resized.Bounds().Dy())
+
+	cropped := Apply(src, Transform{Width: 100, Height: 100, Fit: FitCover})
+	require.Equal(t, 100, cropped.Bounds().Dx())
+	require.Equal(t, 100, cropped.Bounds().Dy())
+
+	// No upscaling: a cover crop larger than the source only crops.
+	cropped = Apply(src, Transform{Width: 800, Height: 800, Fit: FitCover})
+	require.Equal(t, 200, cropped.Bounds().Dx())
+	require.Equal(t, 200, cropped.Bounds().Dy())
+
+	same := Apply(src, Transform{Width: 1000, Fit: FitContain})
+	require.Equal(t, src.Bounds(), same.Bounds())
+}
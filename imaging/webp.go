@@ -0,0 +1,426 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+)
+
+// The encoder below writes lossless WebP (VP8L) bitstreams. It applies the
+// subtract-green and a single select predictor transform, then codes every
+// pixel as a literal with one set of canonical Huffman codes. That is far from
+// what libwebp achieves, but it keeps the implementation small and pure Go.
+
+const (
+	vp8lSignature   = 0x2f
+	vp8lMaxSize     = 1 << 14
+	predictorBits   = 9
+	predictorSelect = 11
+
+	maxCodeLength           = 15
+	maxCodeLengthCodeLength = 7
+	numCodeLengthCodes      = 19
+	greenAlphabetSize       = 256 + 24
+	distanceAlphabetSize    = 40
+)
+
+var codeLengthCodeOrder = [numCodeLengthCodes]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebP writes img to w as a lossless WebP file.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxSize || height > vp8lMaxSize {
+		return fmt.Errorf("webp: invalid image size %dx%d", width, height)
+	}
+
+	pixels, hasAlpha := argbPixels(img)
+
+	bw := &bitWriter{}
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if hasAlpha {
+		bw.writeBits(1, 1)
+	} else {
+		bw.writeBits(0, 1)
+	}
+	bw.writeBits(0, 3) // version
+
+	subtractGreen(pixels)
+	bw.writeBits(1, 1) // transform present
+	bw.writeBits(2, 2) // subtract green
+
+	residuals := predictSelect(pixels, width, height)
+	bw.writeBits(1, 1) // transform present
+	bw.writeBits(0, 2) // predictor
+	bw.writeBits(predictorBits-2, 3)
+	blocksWide := subSampleSize(width, predictorBits)
+	blocksHigh := subSampleSize(height, predictorBits)
+	modes := make([]uint32, blocksWide*blocksHigh)
+	for i := range modes {
+		modes[i] = predictorSelect << 8
+	}
+	writeImageData(bw, modes, false)
+
+	bw.writeBits(0, 1) // no more transforms
+	writeImageData(bw, residuals, true)
+
+	data := bw.bytes()
+	chunkSize := len(data)
+	padding := chunkSize & 1
+
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+chunkSize+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(chunkSize))
+
+	var out bytes.Buffer
+	out.Grow(len(header) + chunkSize + padding)
+	out.Write(header)
+	out.Write(data)
+	if padding == 1 {
+		out.WriteByte(0)
+	}
+	_, err := out.WriteTo(w)
+	return err
+}
+
+func argbPixels(img image.Image) ([]uint32, bool) {
+	bounds := img.Bounds()
+	pixels := make([]uint32, 0, bounds.Dx()*bounds.Dy())
+	hasAlpha := false
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A != 0xff {
+				hasAlpha = true
+			}
+			pixels = append(pixels, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+		}
+	}
+	return pixels, hasAlpha
+}
+
+func subtractGreen(pixels []uint32) {
+	for i, p := range pixels {
+		green := (p >> 8) & 0xff
+		red := ((p >> 16) - green) & 0xff
+		blue := (p - green) & 0xff
+		pixels[i] = p&0xff00ff00 | red<<16 | blue
+	}
+}
+
+// predictSelect returns the residuals of pixels against the select predictor,
+// with the fixed rules the format mandates for the first row and column.
+func predictSelect(pixels []uint32, width, height int) []uint32 {
+	residuals := make([]uint32, len(pixels))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			var prediction uint32
+			switch {
+			case x == 0 && y == 0:
+				prediction = 0xff000000
+			case y == 0:
+				prediction = pixels[i-1]
+			case x == 0:
+				prediction = pixels[i-width]
+			default:
+				prediction = selectPredictor(pixels[i-1], pixels[i-width], pixels[i-width-1])
+			}
+			residuals[i] = subPixels(pixels[i], prediction)
+		}
+	}
+	return residuals
+}
+
+func selectPredictor(left, top, topLeft uint32) uint32 {
+	distanceLeft, distanceTop := 0, 0
+	for shift := 0; shift < 32; shift += 8 {
+		l := int((left >> shift) & 0xff)
+		t := int((top >> shift) & 0xff)
+		tl := int((topLeft >> shift) & 0xff)
+		estimate := l + t - tl
+		distanceLeft += abs(estimate - l)
+		distanceTop += abs(estimate - t)
+	}
+	if distanceLeft < distanceTop {
+		return left
+	}
+	return top
+}
+
+func subPixels(a, b uint32) uint32 {
+	alphaGreen := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	redBlue := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return alphaGreen&0xff00ff00 | redBlue&0x00ff00ff
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func subSampleSize(size, bits int) int {
+	return (size + (1 << bits) - 1) >> bits
+}
+
+// writeImageData codes pixels as literals. Only the main image carries the
+// meta prefix-code bit.
+func writeImageData(bw *bitWriter, pixels []uint32, mainImage bool) {
+	bw.writeBits(0, 1) // no color cache
+	if mainImage {
+		bw.writeBits(0, 1) // single prefix-code group
+	}
+
+	histograms := [4][]int{
+		make([]int, greenAlphabetSize),
+		make([]int, 256),
+		make([]int, 256),
+		make([]int, 256),
+	}
+	for _, p := range pixels {
+		histograms[0][(p>>8)&0xff]++
+		histograms[1][(p>>16)&0xff]++
+		histograms[2][p&0xff]++
+		histograms[3][p>>24]++
+	}
+
+	var codes [4]prefixCode
+	for i, histogram := range histograms {
+		codes[i] = writePrefixCode(bw, histogram)
+	}
+	// Backward references are never emitted, so the distance code is a dummy.
+	writePrefixCode(bw, make([]int, distanceAlphabetSize))
+
+	for _, p := range pixels {
+		codes[0].write(bw, int((p>>8)&0xff))
+		codes[1].write(bw, int((p>>16)&0xff))
+		codes[2].write(bw, int(p&0xff))
+		codes[3].write(bw, int(p>>24))
+	}
+}
+
+type prefixCode struct {
+	lengths []int
+	codes   []uint32
+}
+
+func (code prefixCode) write(bw *bitWriter, symbol int) {
+	if length := code.lengths[symbol]; length > 0 {
+		bw.writeBits(code.codes[symbol], uint(length))
+	}
+}
+
+// writePrefixCode writes the code for histogram and returns it. One or two
+// used symbols get the "simple" encoding; a single symbol then costs no bits.
+func writePrefixCode(bw *bitWriter, histogram []int) prefixCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	if len(used) == 0 {
+		used = []int{0}
+	}
+
+	if len(used) <= 2 && used[len(used)-1] < 256 {
+		bw.writeBits(1, 1) // simple code
+		bw.writeBits(uint32(len(used)-1), 1)
+		if used[0] <= 1 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(used[0]), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(used[0]), 8)
+		}
+		lengths := make([]int, len(histogram))
+		if len(used) == 2 {
+			bw.writeBits(uint32(used[1]), 8)
+			lengths[used[0]], lengths[used[1]] = 1, 1
+		}
+		return prefixCode{lengths: lengths, codes: canonicalCodes(lengths)}
+	}
+
+	lengths := huffmanLengths(histogram, maxCodeLength)
+	bw.writeBits(0, 1) // normal code
+
+	codeLengthHistogram := make([]int, numCodeLengthCodes)
+	for _, length := range lengths {
+		codeLengthHistogram[length]++
+	}
+	// A code-length code with one symbol would be read as zero-length, which
+	// the decoder cannot use to spell out the lengths. Force a second symbol.
+	if nonZero(codeLengthHistogram) < 2 {
+		if codeLengthHistogram[0] == 0 {
+			codeLengthHistogram[0] = 1
+		} else {
+			codeLengthHistogram[1] = 1
+		}
+	}
+	codeLengthLengths := huffmanLengths(codeLengthHistogram, maxCodeLengthCodeLength)
+	codeLengthCodes := canonicalCodes(codeLengthLengths)
+
+	count := numCodeLengthCodes
+	for count > 4 && codeLengthLengths[codeLengthCodeOrder[count-1]] == 0 {
+		count--
+	}
+	bw.writeBits(uint32(count-4), 4)
+	for i := 0; i < count; i++ {
+		bw.writeBits(uint32(codeLengthLengths[codeLengthCodeOrder[i]]), 3)
+	}
+	bw.writeBits(0, 1) // code lengths cover the whole alphabet
+	for _, length := range lengths {
+		bw.writeBits(codeLengthCodes[length], uint(codeLengthLengths[length]))
+	}
+
+	return prefixCode{lengths: lengths, codes: canonicalCodes(lengths)}
+}
+
+func nonZero(values []int) int {
+	n := 0
+	for _, v := range values {
+		if v > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// huffmanLengths returns code lengths no longer than limit for the symbols
+// with a non-zero count. The resulting code is always complete.
+func huffmanLengths(histogram []int, limit int) []int {
+	counts := append([]int(nil), histogram...)
+	for {
+		lengths := buildHuffmanLengths(counts)
+		longest := 0
+		for _, length := range lengths {
+			if length > longest {
+				longest = length
+			}
+		}
+		if longest <= limit {
+			return lengths
+		}
+		// Flatten the distribution and try again.
+		for i, count := range counts {
+			if count > 0 {
+				counts[i] = count/2 + 1
+			}
+		}
+	}
+}
+
+func buildHuffmanLengths(counts []int) []int {
+	type node struct {
+		weight      int
+		symbol      int
+		left, right int
+	}
+
+	lengths := make([]int, len(counts))
+	var nodes []node
+	var queue []int
+	for symbol, count := range counts {
+		if count > 0 {
+			nodes = append(nodes, node{weight: count, symbol: symbol, left: -1, right: -1})
+			queue = append(queue, len(nodes)-1)
+		}
+	}
+	if len(queue) == 1 {
+		lengths[nodes[0].symbol] = 1
+		return lengths
+	}
+
+	for len(queue) > 1 {
+		sort.SliceStable(queue, func(i, j int) bool {
+			return nodes[queue[i]].weight < nodes[queue[j]].weight
+		})
+		a, b := queue[0], queue[1]
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1, left: a, right: b})
+		queue = append(queue[2:], len(nodes)-1)
+	}
+
+	var walk func(index, depth int)
+	walk = func(index, depth int) {
+		n := nodes[index]
+		if n.left < 0 {
+			lengths[n.symbol] = depth
+			return
+		}
+		walk(n.left, depth+1)
+		walk(n.right, depth+1)
+	}
+	walk(queue[0], 0)
+	return lengths
+}
+
+// canonicalCodes assigns canonical codes and stores them bit-reversed, since
+// the bit writer emits the least significant bit first.
+func canonicalCodes(lengths []int) []uint32 {
+	var lengthCounts [maxCodeLength + 1]int
+	for _, length := range lengths {
+		lengthCounts[length]++
+	}
+	lengthCounts[0] = 0
+
+	var nextCode [maxCodeLength + 1]uint32
+	code := uint32(0)
+	for length := 1; length <= maxCodeLength; length++ {
+		code = (code + uint32(lengthCounts[length-1])) << 1
+		nextCode[length] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		codes[symbol] = reverseBits(nextCode[length], length)
+		nextCode[length]++
+	}
+	return codes
+}
+
+func reverseBits(code uint32, length int) uint32 {
+	var reversed uint32
+	for i := 0; i < length; i++ {
+		reversed = reversed<<1 | code&1
+		code >>= 1
+	}
+	return reversed
+}
+
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (bw *bitWriter) writeBits(value uint32, n uint) {
+	bw.acc |= uint64(value) << bw.nbits
+	bw.nbits += n
+	for bw.nbits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.nbits -= 8
+	}
+}
+
+func (bw *bitWriter) bytes() []byte {
+	if bw.nbits > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc, bw.nbits = 0, 0
+	}
+	return bw.buf
+}
@@ -0,0 +1,110 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	testCases := []struct {
+		name  string
+		image func() image.Image
+	}{
+		{
+			name: "SinglePixel",
+			image: func() image.Image {
+				img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+				img.SetNRGBA(0, 0, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+				return img
+			},
+		},
+		{
+			name: "Solid",
+			image: func() image.Image {
+				img := image.NewNRGBA(image.Rect(0, 0, 37, 11))
+				for i := range img.Pix {
+					img.Pix[i] = 0x80
+				}
+				return img
+			},
+		},
+		{
+			name: "Gradient",
+			image: func() image.Image {
+				img := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+				for y := 0; y < 200; y++ {
+					for x := 0; x < 300; x++ {
+						img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x + y), A: 255})
+					}
+				}
+				return img
+			},
+		},
+		{
+			name: "Noise",
+			image: func() image.Image {
+				img := image.NewNRGBA(image.Rect(0, 0, 129, 65))
+				rng.Read(img.Pix)
+				return img
+			},
+		},
+		{
+			name: "OffsetBounds",
+			image: func() image.Image {
+				img := image.NewRGBA(image.Rect(5, 7, 25, 19))
+				for y := 7; y < 19; y++ {
+					for x := 5; x < 25; x++ {
+						img.Set(x, y, color.RGBA{R: uint8(rng.Intn(4)), G: 100, B: 3, A: 255})
+					}
+				}
+				return img
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			src := tc.image()
+
+			var buf bytes.Buffer
+			require.NoError(t, EncodeWebP(&buf, src))
+
+			config, err := webp.DecodeConfig(bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			require.Equal(t, src.Bounds().Dx(), config.Width)
+			require.Equal(t, src.Bounds().Dy(), config.Height)
+
+			decoded, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			requireSamePixels(t, src, decoded)
+		})
+	}
+}
+
+func TestEncodeWebPInvalidSize(t *testing.T) {
+	err := EncodeWebP(&bytes.Buffer{}, image.NewNRGBA(image.Rect(0, 0, 0, 10)))
+	require.Error(t, err)
+}
+
+func requireSamePixels(t *testing.T, expected, actual image.Image) {
+	eb, ab := expected.Bounds(), actual.Bounds()
+	require.Equal(t, eb.Dx(), ab.Dx())
+	require.Equal(t, eb.Dy(), ab.Dy())
+	for y := 0; y < eb.Dy(); y++ {
+		for x := 0; x < eb.Dx(); x++ {
+			e := color.NRGBAModel.Convert(expected.At(eb.Min.X+x, eb.Min.Y+y))
+			a := color.NRGBAModel.Convert(actual.At(ab.Min.X+x, ab.Min.Y+y))
+			require.Equal(t, e, a, "pixel %d,%d", x, y)
+		}
+	}
+}
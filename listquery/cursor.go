@@ -0,0 +1,158 @@
+package listquery
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cursor is the keyset of the row a page starts after, or for a previous
+// page ends before: its values for each field of the sort order.
+type cursor struct {
+	values []any
+	before bool
+}
+
+// cursorToken is what an opaque cursor encodes. Sort records the order the
+// cursor was made for, so it cannot be replayed against another one.
+type cursorToken struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+	Before bool   `json:"b,omitempty"`
+}
+
+// Keyset reports whether the query's sort order supports cursors. Every
+// field in it must be non-null, since NULLs do not compare.
+func (q Query) Keyset() bool {
+	for _, s := range q.OrderBy() {
+		if q.schema.Fields[s.Field].Nullable {
+			return false
+		}
+	}
+	return true
+}
+
+// SetCursor restricts the query to the rows after the cursor's row, or
+// before it for a cursor made with before set.
+func (q *Query) SetCursor(token string) error {
+	if !q.Keyset() {
+		return errorf("cursor pagination needs a sort on fields that are never null")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return errorf("invalid cursor")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var c cursorToken
+	if err := decoder.Decode(&c); err != nil {
+		return errorf("invalid cursor")
+	}
+
+	sorts := q.OrderBy()
+	if c.Sort != sortKey(sorts) {
+		return errorf("cursor does not match the sort order")
+	}
+	if len(c.Values) != len(sorts) {
+		return errorf("invalid cursor")
+	}
+	values := make([]any, len(sorts))
+	for i, s := range sorts {
+		value, ok := decodeCursorValue(q.schema.Fields[s.Field].Type, c.Values[i])
+		if !ok {
+			return errorf("invalid cursor")
+		}
+		values[i] = value
+	}
+	q.cursor = &cursor{values: values, before: c.Before}
+	return nil
+}
+
+// Cursor returns the cursor for a row, given its value for each field of
+// the sort order. Pass before to page backwards from the row.
+func (q Query) Cursor(row map[string]any, before bool) string {
+	sorts := q.OrderBy()
+	c := cursorToken{Sort: sortKey(sorts), Values: make([]any, len(sorts)), Before: before}
+	for i, s := range sorts {
+		value := row[s.Field]
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339Nano)
+		}
+		c.Values[i] = value
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Backward reports whether the query pages backwards from a cursor. Its
+// rows then come back in reverse order, nearest to the cursor first.
+func (q Query) Backward() bool {
+	return q.cursor != nil && q.cursor.before
+}
+
+// HasCursor reports whether SetCursor was called.
+func (q Query) HasCursor() bool {
+	return q.cursor != nil
+}
+
+// keysetCondition compares rows with the cursor in sort order, expanding
+// (a, b) > (x, y) to a > x OR (a = x AND b > y) so that each field can sort
+// in its own direction.
+func (q Query) keysetCondition(args []any) (string, []any) {
+	sorts := q.OrderBy()
+	var alternatives []string
+	for i, s := range sorts {
+		var terms []string
+		for j := 0; j < i; j++ {
+			args = append(args, q.cursor.values[j])
+			terms = append(terms, q.schema.Fields[sorts[j].Field].Column+" = $"+strconv.Itoa(len(args)))
+		}
+		op := ">"
+		if s.Desc != q.cursor.before {
+			op = "<"
+		}
+		args = append(args, q.cursor.values[i])
+		terms = append(terms, q.schema.Fields[s.Field].Column+" "+op+" $"+strconv.Itoa(len(args)))
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+func sortKey(sorts []Sort) string {
+	parts := make([]string, len(sorts))
+	for i, s := range sorts {
+		parts[i] = s.Field
+		if s.Desc {
+			parts[i] = "-" + s.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func decodeCursorValue(typ Type, value any) (any, bool) {
+	switch typ {
+	case Int:
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, false
+		}
+		i, err := n.Int64()
+		return i, err == nil
+	case Time:
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	case Bool:
+		b, ok := value.(bool)
+		return b, ok
+	default:
+		s, ok := value.(string)
+		return s, ok
+	}
+}
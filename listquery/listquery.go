@@ -0,0 +1,379 @@
+// Package listquery parses the filter and sort parameters shared by the list
+// endpoints, such as
+//
+//	?filter[author_id]=3&filter[created_at][gte]=2025-01-01&sort=-changed_at,title
+//
+// and compiles them to a parameterized WHERE and ORDER BY clause. Only fields
+// whitelisted in a resource's Schema are accepted, and only their configured
+// SQL ever reaches the query; every value the client sends is passed as an
+// argument. Opaque cursors page through the same order by keyset instead of
+// offset.
+package listquery
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/lib/pq"
+)
+
+// maxInValues bounds how many values one "in" filter may list.
+const maxInValues = 100
+
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Time
+	Bool
+)
+
+type Op string
+
+const (
+	Eq       Op = "eq"
+	Ne       Op = "ne"
+	Gt       Op = "gt"
+	Gte      Op = "gte"
+	Lt       Op = "lt"
+	Lte      Op = "lte"
+	In       Op = "in"
+	Contains Op = "contains"
+)
+
+var sqlOps = map[Op]string{
+	Eq:  "=",
+	Ne:  "<>",
+	Gt:  ">",
+	Gte: ">=",
+	Lt:  "<",
+	Lte: "<=",
+}
+
+// Field is one field of a resource that clients may filter or sort on.
+type Field struct {
+	Type Type
+	// Column is the SQL the field compiles to, normally a column name.
+	Column string
+	// Exists filters through a join table instead of Column. It is an EXISTS
+	// subquery with a %s where the comparison goes, so only Eq and In apply.
+	Exists string
+
+	Filterable bool
+	Sortable   bool
+	// Nullable fields cannot be part of a cursor's keyset.
+	Nullable bool
+}
+
+// Schema whitelists the fields of one resource.
+type Schema struct {
+	Fields map[string]Field
+	// DefaultSort applies when the request has no sort parameter.
+	DefaultSort []Sort
+	// Tiebreaker is a unique sortable field appended to every sort, so pages
+	// do not overlap when the requested fields have equal values.
+	Tiebreaker string
+}
+
+type Condition struct {
+	Field string
+	Op    Op
+	Value any
+}
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Query is a parsed list request. Conditions are ANDed.
+type Query struct {
+	schema     Schema
+	cursor     *cursor
+	Conditions []Condition
+	Sort       []Sort
+}
+
+// Error is a problem with the request's parameters, safe to show the client.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func errorf(format string, args ...any) error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// Parse reads the filter[field] or filter[field][op] and sort parameters
+// from values. A filter without an operator means eq; a sort field prefixed
+// with - sorts descending.
+func Parse(values url.Values, schema Schema) (Query, error) {
+	query := Query{schema: schema}
+
+	// Go maps are unordered; sorting the keys keeps the argument order stable.
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		vals := values[key]
+		name, op, ok := parseFilterKey(key)
+		if !ok {
+			return Query{}, errorf("invalid filter parameter %q", key)
+		}
+		field, ok := schema.Fields[name]
+		if !ok || !field.Filterable {
+			return Query{}, errorf("cannot filter on %q", name)
+		}
+		if !field.allows(op) {
+			return Query{}, errorf("unsupported operator %q for %q", op, name)
+		}
+		for _, raw := range vals {
+			condition, err := parseCondition(name, field, op, raw)
+			if err != nil {
+				return Query{}, err
+			}
+			query.Conditions = append(query.Conditions, condition)
+		}
+	}
+
+	if param := values.Get("sort"); param != "" {
+		for _, part := range strings.Split(param, ",") {
+			s := Sort{Field: strings.TrimSpace(part)}
+			if strings.HasPrefix(s.Field, "-") {
+				s.Field, s.Desc = s.Field[1:], true
+			}
+			field, ok := schema.Fields[s.Field]
+			if !ok || !field.Sortable {
+				return Query{}, errorf("cannot sort on %q", s.Field)
+			}
+			query.Sort = append(query.Sort, s)
+		}
+	}
+	return query, nil
+}
+
+// Where adds a condition the server imposes, for example a visibility rule.
+// The field must be in the schema but need not be Filterable.
+func (q *Query) Where(field string, op Op, value any) {
+	q.Conditions = append(q.Conditions, Condition{Field: field, Op: op, Value: value})
+}
+
+// Filter compiles the query. Its WHERE clause numbers arguments from $1.
+// After SetCursor it also holds the keyset condition, and for a backward
+// cursor the order is reversed.
+func (q Query) Filter() db.ListFilter {
+	filter := q.CountFilter()
+	if q.cursor != nil {
+		var condition string
+		condition, filter.Args = q.keysetCondition(filter.Args)
+		if filter.Where == "TRUE" {
+			filter.Where = condition
+		} else {
+			filter.Where += " AND " + condition
+		}
+	}
+
+	var orderBy []string
+	for _, s := range q.OrderBy() {
+		column := q.schema.Fields[s.Field].Column
+		if s.Desc != q.Backward() {
+			orderBy = append(orderBy, column+" DESC")
+		} else {
+			orderBy = append(orderBy, column+" ASC")
+		}
+	}
+	filter.OrderBy = strings.Join(orderBy, ", ")
+	return filter
+}
+
+// CountFilter is the WHERE clause of Filter without the cursor, which
+// matches every row of the list rather than one page.
+func (q Query) CountFilter() db.ListFilter {
+	var (
+		where []string
+		args  []any
+	)
+	for _, condition := range q.Conditions {
+		field := q.schema.Fields[condition.Field]
+		if day, ok := condition.Value.(dayRange); ok {
+			args = append(args, day.start, day.end)
+			start, end := "$"+strconv.Itoa(len(args)-1), "$"+strconv.Itoa(len(args))
+			if condition.Op == Ne {
+				where = append(where, fmt.Sprintf("(%s < %s OR %s >= %s)", field.Column, start, field.Column, end))
+			} else {
+				where = append(where, fmt.Sprintf("(%s >= %s AND %s < %s)", field.Column, start, field.Column, end))
+			}
+			continue
+		}
+		value := condition.Value
+		if condition.Op == In {
+			value = pq.Array(value)
+		}
+		args = append(args, value)
+		placeholder := "$" + strconv.Itoa(len(args))
+
+		var comparison string
+		switch condition.Op {
+		case In:
+			comparison = "= ANY(" + placeholder + ")"
+		case Contains:
+			comparison = "ILIKE " + placeholder
+		default:
+			comparison = sqlOps[condition.Op] + " " + placeholder
+		}
+		if field.Exists != "" {
+			where = append(where, fmt.Sprintf(field.Exists, comparison))
+		} else {
+			where = append(where, field.Column+" "+comparison)
+		}
+	}
+
+	filter := db.ListFilter{Where: "TRUE", Args: args}
+	if len(where) > 0 {
+		filter.Where = strings.Join(where, " AND ")
+	}
+	return filter
+}
+
+// OrderBy is the sort the query compiles to: the requested or default sort,
+// ending with the schema's tiebreaker.
+func (q Query) OrderBy() []Sort {
+	sorts := q.Sort
+	if len(sorts) == 0 {
+		sorts = q.schema.DefaultSort
+	}
+	sorts = append([]Sort(nil), sorts...)
+	if q.schema.Tiebreaker == "" {
+		return sorts
+	}
+	desc := false
+	for _, s := range sorts {
+		if s.Field == q.schema.Tiebreaker {
+			return sorts
+		}
+		desc = s.Desc
+	}
+	return append(sorts, Sort{Field: q.schema.Tiebreaker, Desc: desc})
+}
+
+func parseFilterKey(key string) (string, Op, bool) {
+	rest := strings.TrimPrefix(key, "filter[")
+	name, rest, ok := strings.Cut(rest, "]")
+	if !ok || name == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return name, Eq, true
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") {
+		return "", "", false
+	}
+	return name, Op(rest[1 : len(rest)-1]), true
+}
+
+func (f Field) allows(op Op) bool {
+	if f.Exists != "" {
+		return op == Eq || op == In
+	}
+	switch op {
+	case Eq, Ne:
+		return true
+	case Gt, Gte, Lt, Lte:
+		return f.Type == Int || f.Type == Time
+	case In:
+		return f.Type == Int || f.Type == String
+	case Contains:
+		return f.Type == String
+	default:
+		return false
+	}
+}
+
+func parseCondition(name string, field Field, op Op, raw string) (Condition, error) {
+	if op == In {
+		parts := strings.Split(raw, ",")
+		if len(parts) > maxInValues {
+			return Condition{}, errorf("too many values for %q", name)
+		}
+		if field.Type == Int {
+			ids := make([]int64, len(parts))
+			for i, part := range parts {
+				id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+				if err != nil {
+					return Condition{}, errorf("invalid value for %q", name)
+				}
+				ids[i] = id
+			}
+			return Condition{Field: name, Op: op, Value: ids}, nil
+		}
+		return Condition{Field: name, Op: op, Value: parts}, nil
+	}
+
+	switch field.Type {
+	case Int:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return Condition{}, errorf("invalid value for %q", name)
+		}
+		return Condition{Field: name, Op: op, Value: n}, nil
+	case Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return Condition{}, errorf("invalid value for %q", name)
+		}
+		return Condition{Field: name, Op: op, Value: b}, nil
+	case Time:
+		return parseTimeCondition(name, op, raw)
+	default:
+		if op == Contains {
+			return Condition{Field: name, Op: op, Value: "%" + escapeLike(raw) + "%"}, nil
+		}
+		return Condition{Field: name, Op: op, Value: raw}, nil
+	}
+}
+
+// dayRange is the value of an eq or ne condition on a plain date: the
+// instants from start up to but not including end.
+type dayRange struct {
+	start, end time.Time
+}
+
+// parseTimeCondition accepts an RFC 3339 timestamp or a plain date. A plain
+// date stands for the whole day, so lte and gt compare against the next day,
+// and eq and ne against the range between the two.
+func parseTimeCondition(name string, op Op, raw string) (Condition, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return Condition{Field: name, Op: op, Value: t}, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return Condition{}, errorf("invalid value for %q", name)
+	}
+	switch op {
+	case Eq, Ne:
+		return Condition{Field: name, Op: op, Value: dayRange{start: t, end: t.AddDate(0, 0, 1)}}, nil
+	case Lte:
+		op, t = Lt, t.AddDate(0, 0, 1)
+	case Gt:
+		op, t = Gte, t.AddDate(0, 0, 1)
+	}
+	return Condition{Field: name, Op: op, Value: t}, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
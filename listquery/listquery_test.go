@@ -0,0 +1,268 @@
+package listquery
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+var testSchema = Schema{
+	Fields: map[string]Field{
+		"id":         {Type: Int, Column: "id", Filterable: true, Sortable: true},
+		"title":      {Type: String, Column: "title", Filterable: true, Sortable: true},
+		"author_id":  {Type: Int, Exists: "EXISTS (SELECT 1 FROM user_posts up WHERE up.post_id = posts.id AND up.user_id %s)", Filterable: true},
+		"featured":   {Type: Bool, Column: "featured", Filterable: true},
+		"status":     {Type: String, Column: "status"},
+		"created_at": {Type: Time, Column: "created_at", Filterable: true, Sortable: true},
+	},
+	DefaultSort: []Sort{{Field: "created_at", Desc: true}},
+	Tiebreaker:  "id",
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		expected db.ListFilter
+	}{
+		{
+			name:     "Defaults",
+			query:    "",
+			expected: db.ListFilter{Where: "TRUE", OrderBy: "created_at DESC, id DESC"},
+		},
+		{
+			name:  "FiltersAndSort",
+			query: "filter[author_id]=3&filter[created_at][gte]=2025-01-01&sort=-created_at,title",
+			expected: db.ListFilter{
+				Where:   "EXISTS (SELECT 1 FROM user_posts up WHERE up.post_id = posts.id AND up.user_id = $1) AND created_at >= $2",
+				Args:    []interface{}{int64(3), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+				OrderBy: "created_at DESC, title ASC, id ASC",
+			},
+		},
+		{
+			name:  "DateOnlyUpperBoundCoversTheDay",
+			query: "filter[created_at][lte]=2025-01-31",
+			expected: db.ListFilter{
+				Where:   "created_at < $1",
+				Args:    []interface{}{time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+				OrderBy: "created_at DESC, id DESC",
+			},
+		},
+		{
+			name:  "DateOnlyEqualityMatchesTheDay",
+			query: "filter[created_at]=2025-01-31&filter[id]=4",
+			expected: db.ListFilter{
+				Where:   "(created_at >= $1 AND created_at < $2) AND id = $3",
+				Args:    []interface{}{time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), int64(4)},
+				OrderBy: "created_at DESC, id DESC",
+			},
+		},
+		{
+			name:  "DateOnlyInequalityExcludesTheDay",
+			query: "filter[created_at][ne]=2025-01-31",
+			expected: db.ListFilter{
+				Where:   "(created_at < $1 OR created_at >= $2)",
+				Args:    []interface{}{time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+				OrderBy: "created_at DESC, id DESC",
+			},
+		},
+		{
+			name:  "TimestampEqualityIsExact",
+			query: "filter[created_at]=2025-01-31T10:00:00Z",
+			expected: db.ListFilter{
+				Where:   "created_at = $1",
+				Args:    []interface{}{time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)},
+				OrderBy: "created_at DESC, id DESC",
+			},
+		},
+		{
+			name:  "In",
+			query: "filter[id][in]=1,2,3&sort=id",
+			expected: db.ListFilter{
+				Where:   "id = ANY($1)",
+				Args:    []interface{}{pq.Array([]int64{1, 2, 3})},
+				OrderBy: "id ASC",
+			},
+		},
+		{
+			name:  "ContainsEscapesWildcards",
+			query: "filter[title][contains]=100%25_sure&filter[featured]=true",
+			expected: db.ListFilter{
+				Where:   "featured = $1 AND title ILIKE $2",
+				Args:    []interface{}{true, `%100\%\_sure%`},
+				OrderBy: "created_at DESC, id DESC",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			query, err := Parse(values, testSchema)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, query.Filter())
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{name: "UnknownField", query: "filter[password]=x"},
+		{name: "NotFilterable", query: "filter[status]=draft"},
+		{name: "MalformedKey", query: "filter[title=x"},
+		{name: "UnknownOperator", query: "filter[title][regex]=x"},
+		{name: "OperatorForWrongType", query: "filter[title][gt]=x"},
+		{name: "ExistsOnlyEquality", query: "filter[author_id][ne]=3"},
+		{name: "InvalidInt", query: "filter[id]=abc"},
+		{name: "InvalidTime", query: "filter[created_at][gte]=yesterday"},
+		{name: "InvalidBool", query: "filter[featured]=maybe"},
+		{name: "UnknownSortField", query: "sort=content"},
+		{name: "NotSortable", query: "sort=-author_id"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			_, err = Parse(values, testSchema)
+			var queryErr *Error
+			require.ErrorAs(t, err, &queryErr)
+		})
+	}
+}
+
+func TestQueryWhere(t *testing.T) {
+	query, err := Parse(url.Values{"filter[title]": {"Go"}}, testSchema)
+	require.NoError(t, err)
+
+	query.Where("status", Eq, "published")
+	require.Equal(t, db.ListFilter{
+		Where:   "title = $1 AND status = $2",
+		Args:    []interface{}{"Go", "published"},
+		OrderBy: "created_at DESC, id DESC",
+	}, query.Filter())
+}
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	row := map[string]any{"id": int64(42), "title": "Go", "created_at": createdAt}
+
+	testCases := []struct {
+		name     string
+		sort     string
+		before   bool
+		expected db.ListFilter
+	}{
+		{
+			name:   "Forward",
+			sort:   "-created_at",
+			before: false,
+			expected: db.ListFilter{
+				Where:   "((created_at < $1) OR (created_at = $2 AND id < $3))",
+				Args:    []interface{}{createdAt, createdAt, int64(42)},
+				OrderBy: "created_at DESC, id DESC",
+			},
+		},
+		{
+			name:   "BackwardReversesTheOrder",
+			sort:   "-created_at",
+			before: true,
+			expected: db.ListFilter{
+				Where:   "((created_at > $1) OR (created_at = $2 AND id > $3))",
+				Args:    []interface{}{createdAt, createdAt, int64(42)},
+				OrderBy: "created_at ASC, id ASC",
+			},
+		},
+		{
+			name:   "MixedDirections",
+			sort:   "title,-id",
+			before: false,
+			expected: db.ListFilter{
+				Where:   "((title > $1) OR (title = $2 AND id < $3))",
+				Args:    []interface{}{"Go", "Go", int64(42)},
+				OrderBy: "title ASC, id DESC",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			values := url.Values{"sort": {tc.sort}}
+			query, err := Parse(values, testSchema)
+			require.NoError(t, err)
+			token := query.Cursor(row, tc.before)
+
+			query, err = Parse(values, testSchema)
+			require.NoError(t, err)
+			require.NoError(t, query.SetCursor(token))
+			require.True(t, query.HasCursor())
+			require.Equal(t, tc.before, query.Backward())
+			require.Equal(t, tc.expected, query.Filter())
+			require.Equal(t, db.ListFilter{Where: "TRUE"}, query.CountFilter())
+		})
+	}
+}
+
+func TestCursorErrors(t *testing.T) {
+	row := map[string]any{"id": int64(42), "title": "Go", "created_at": time.Now()}
+	query, err := Parse(url.Values{}, testSchema)
+	require.NoError(t, err)
+	token := query.Cursor(row, false)
+
+	testCases := []struct {
+		name   string
+		schema Schema
+		sort   string
+		token  string
+	}{
+		{name: "Malformed", schema: testSchema, token: "not-a-cursor"},
+		{name: "OtherSort", schema: testSchema, sort: "title", token: token},
+		{name: "NullableSort", schema: nullableSchema(), sort: "-created_at", token: token},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			values := url.Values{}
+			if tc.sort != "" {
+				values.Set("sort", tc.sort)
+			}
+			query, err := Parse(values, tc.schema)
+			require.NoError(t, err)
+
+			err = query.SetCursor(tc.token)
+			var queryErr *Error
+			require.ErrorAs(t, err, &queryErr)
+			require.False(t, query.HasCursor())
+		})
+	}
+}
+
+func nullableSchema() Schema {
+	fields := make(map[string]Field, len(testSchema.Fields))
+	for name, field := range testSchema.Fields {
+		fields[name] = field
+	}
+	field := fields["created_at"]
+	field.Nullable = true
+	fields["created_at"] = field
+	return Schema{Fields: fields, DefaultSort: testSchema.DefaultSort, Tiebreaker: testSchema.Tiebreaker}
+}
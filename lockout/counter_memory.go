@@ -0,0 +1,81 @@
+package lockout
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryCounter keeps failures in process. It suits a single instance or
+// development; counts are lost on restart and not shared.
+type MemoryCounter struct {
+	mu      sync.Mutex
+	entries map[Key]Entry
+}
+
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{entries: make(map[Key]Entry)}
+}
+
+func (c *MemoryCounter) Get(ctx context.Context, key Key) (Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		return entry, nil
+	}
+	return Entry{Key: key}, nil
+}
+
+func (c *MemoryCounter) Increment(ctx context.Context, key Key, resetBefore time.Time) (Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || entry.LastFailureAt.Before(resetBefore) {
+		entry = Entry{Key: key}
+	}
+	entry.Failures++
+	entry.LastFailureAt = time.Now()
+	c.entries[key] = entry
+	return entry, nil
+}
+
+func (c *MemoryCounter) Reset(ctx context.Context, key Key) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	return nil
+}
+
+func (c *MemoryCounter) List(ctx context.Context, kind Kind, minFailures int32, since time.Time) ([]Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := []Entry{}
+	for key, entry := range c.entries {
+		if key.Kind == kind && entry.Failures >= minFailures && entry.LastFailureAt.After(since) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastFailureAt.After(entries[j].LastFailureAt)
+	})
+	return entries, nil
+}
+
+func (c *MemoryCounter) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var purged int64
+	for key, entry := range c.entries {
+		if entry.LastFailureAt.Before(before) {
+			delete(c.entries, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,82 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+// PostgresCounter keeps failures in the login_failures table so every
+// instance of the API counts the same attempts.
+type PostgresCounter struct {
+	store db.Querier
+}
+
+func NewPostgresCounter(store db.Querier) *PostgresCounter {
+	return &PostgresCounter{store: store}
+}
+
+func (c *PostgresCounter) Get(ctx context.Context, key Key) (Entry, error) {
+	failure, err := c.store.GetLoginFailure(ctx, db.GetLoginFailureParams{
+		Kind:    string(key.Kind),
+		Subject: key.Subject,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entry{Key: key}, nil
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	return toEntry(failure), nil
+}
+
+func (c *PostgresCounter) Increment(ctx context.Context, key Key, resetBefore time.Time) (Entry, error) {
+	failure, err := c.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Kind:        string(key.Kind),
+		Subject:     key.Subject,
+		ResetBefore: resetBefore,
+	})
+	if err != nil {
+		return Entry{}, err
+	}
+	return toEntry(failure), nil
+}
+
+func (c *PostgresCounter) Reset(ctx context.Context, key Key) error {
+	return c.store.DeleteLoginFailure(ctx, db.DeleteLoginFailureParams{
+		Kind:    string(key.Kind),
+		Subject: key.Subject,
+	})
+}
+
+func (c *PostgresCounter) List(ctx context.Context, kind Kind, minFailures int32, since time.Time) ([]Entry, error) {
+	failures, err := c.store.ListLoginLockouts(ctx, db.ListLoginLockoutsParams{
+		Kind:        string(kind),
+		MinFailures: minFailures,
+		Since:       since,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, len(failures))
+	for i, failure := range failures {
+		entries[i] = toEntry(failure)
+	}
+	return entries, nil
+}
+
+func (c *PostgresCounter) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	return c.store.DeleteExpiredLoginFailures(ctx, before)
+}
+
+func toEntry(failure db.LoginFailure) Entry {
+	return Entry{
+		Key:           Key{Kind: Kind(failure.Kind), Subject: failure.Subject},
+		Failures:      failure.Failures,
+		LastFailureAt: failure.LastFailureAt,
+	}
+}
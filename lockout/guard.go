@@ -0,0 +1,171 @@
+package lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+// Policy decides how long a key has to wait after its failures.
+type Policy struct {
+	// MaxFailures locks the key out once reached. Zero turns the policy off.
+	MaxFailures int32
+	// BaseDelay is the wait after the first failure; it doubles with every
+	// further failure until the key is locked out.
+	BaseDelay time.Duration
+	// LockoutDuration is how long a key stays locked out, and the cap on
+	// the exponential wait.
+	LockoutDuration time.Duration
+	// Window is how long failures are remembered. A failure after a quiet
+	// Window starts the count over.
+	Window time.Duration
+}
+
+// Delay returns how long to wait after the last of failures.
+func (policy Policy) Delay(failures int32) time.Duration {
+	if policy.MaxFailures <= 0 || failures <= 0 {
+		return 0
+	}
+	if failures >= policy.MaxFailures {
+		return policy.LockoutDuration
+	}
+
+	delay := policy.BaseDelay
+	for i := int32(1); i < failures && delay < policy.LockoutDuration; i++ {
+		delay *= 2
+	}
+	return min(delay, policy.LockoutDuration)
+}
+
+// Lockout is a key that has reached its policy's MaxFailures and is still
+// locked out.
+type Lockout struct {
+	Entry
+	LockedUntil time.Time
+}
+
+// Guard applies a policy per kind of key to a Counter.
+type Guard struct {
+	counter  Counter
+	policies map[Kind]Policy
+}
+
+func NewGuard(counter Counter, policies map[Kind]Policy) *Guard {
+	return &Guard{counter: counter, policies: policies}
+}
+
+// NewGuardFromConfig builds the guard described by the LOGIN_* and
+// MFA_MAX_FAILURES_* settings. A pending login token has no backoff, but its
+// lockout lasts as long as the token does, so reaching its maximum burns it.
+func NewGuardFromConfig(config util.Config, counter Counter) *Guard {
+	policy := Policy{
+		BaseDelay:       config.LoginBackoffBase,
+		LockoutDuration: config.LoginLockoutDuration,
+		Window:          config.LoginFailureWindow,
+	}
+
+	usernamePolicy := policy
+	usernamePolicy.MaxFailures = int32(config.LoginMaxFailuresPerUsername)
+	ipPolicy := policy
+	ipPolicy.MaxFailures = int32(config.LoginMaxFailuresPerIP)
+	mfaUserPolicy := policy
+	mfaUserPolicy.MaxFailures = int32(config.MFAMaxFailuresPerUser)
+	mfaTokenPolicy := Policy{
+		MaxFailures:     int32(config.MFAMaxFailuresPerToken),
+		LockoutDuration: config.MFATokenDuration,
+		Window:          config.MFATokenDuration,
+	}
+
+	return NewGuard(counter, map[Kind]Policy{
+		KindUsername: usernamePolicy,
+		KindIP:       ipPolicy,
+		KindMFAUser:  mfaUserPolicy,
+		KindMFAToken: mfaTokenPolicy,
+	})
+}
+
+// Check returns how long the caller must wait before trying keys again, or
+// zero when an attempt is allowed now.
+func (guard *Guard) Check(ctx context.Context, keys ...Key) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range keys {
+		policy := guard.policies[key.Kind]
+		if policy.MaxFailures <= 0 {
+			continue
+		}
+
+		entry, err := guard.counter.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, time.Until(entry.LastFailureAt.Add(policy.Delay(guard.failures(policy, entry)))))
+	}
+	return max(wait, 0), nil
+}
+
+// Fail records a failed attempt against keys and returns how long the caller
+// must now wait.
+func (guard *Guard) Fail(ctx context.Context, keys ...Key) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range keys {
+		policy := guard.policies[key.Kind]
+		if policy.MaxFailures <= 0 {
+			continue
+		}
+
+		entry, err := guard.counter.Increment(ctx, key, time.Now().Add(-policy.Window))
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, time.Until(entry.LastFailureAt.Add(policy.Delay(entry.Failures))))
+	}
+	return max(wait, 0), nil
+}
+
+// Reset clears the failures of key, after a successful login or when an
+// administrator lifts a lockout.
+func (guard *Guard) Reset(ctx context.Context, key Key) error {
+	return guard.counter.Reset(ctx, key)
+}
+
+// Lockouts lists the keys that are locked out right now.
+func (guard *Guard) Lockouts(ctx context.Context) ([]Lockout, error) {
+	lockouts := []Lockout{}
+	for _, kind := range Kinds {
+		policy := guard.policies[kind]
+		if policy.MaxFailures <= 0 {
+			continue
+		}
+
+		entries, err := guard.counter.List(ctx, kind, policy.MaxFailures, time.Now().Add(-policy.LockoutDuration))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			lockouts = append(lockouts, Lockout{
+				Entry:       entry,
+				LockedUntil: entry.LastFailureAt.Add(policy.LockoutDuration),
+			})
+		}
+	}
+	return lockouts, nil
+}
+
+// PurgeExpired drops counters that no longer affect any login.
+func (guard *Guard) PurgeExpired(ctx context.Context) (int64, error) {
+	var keep time.Duration
+	for _, policy := range guard.policies {
+		keep = max(keep, policy.Window, policy.LockoutDuration)
+	}
+	return guard.counter.PurgeBefore(ctx, time.Now().Add(-keep))
+}
+
+// failures is the count Check applies. Failures older than the window no
+// longer count, though the next failure is what resets the stored counter.
+func (guard *Guard) failures(policy Policy, entry Entry) int32 {
+	if policy.Window > 0 && entry.LastFailureAt.Before(time.Now().Add(-policy.Window)) {
+		return 0
+	}
+	return entry.Failures
+}
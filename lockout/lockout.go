@@ -0,0 +1,120 @@
+// Package lockout slows down and then stops password guessing. Failed logins
+// are counted per username and per client IP, and failed second-factor codes
+// per user and per pending login; each failure adds an exponentially growing
+// wait before the next attempt, and too many failures lock the key out for a
+// while.
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+// Kind is what a failure counter is kept for.
+type Kind string
+
+const (
+	KindUsername Kind = "username"
+	KindIP       Kind = "ip"
+	KindMFAUser  Kind = "mfa_user"
+	KindMFAToken Kind = "mfa_token"
+)
+
+// Kinds lists every kind of counter.
+var Kinds = []Kind{KindUsername, KindIP, KindMFAUser, KindMFAToken}
+
+// Key identifies one failure counter.
+type Key struct {
+	Kind    Kind
+	Subject string
+}
+
+func UsernameKey(username string) Key {
+	return Key{Kind: KindUsername, Subject: username}
+}
+
+func IPKey(ip string) Key {
+	return Key{Kind: KindIP, Subject: ip}
+}
+
+// MFAUserKey counts the second-factor failures of a user across all of their
+// pending logins.
+func MFAUserKey(userID int64) Key {
+	return Key{Kind: KindMFAUser, Subject: strconv.FormatInt(userID, 10)}
+}
+
+// MFATokenKey counts the second-factor failures made with one mfa_pending
+// token.
+func MFATokenKey(tokenID uuid.UUID) Key {
+	return Key{Kind: KindMFAToken, Subject: tokenID.String()}
+}
+
+// Entry is the state of one failure counter.
+type Entry struct {
+	Key
+	Failures      int32
+	LastFailureAt time.Time
+}
+
+// Counter stores failure counts.
+type Counter interface {
+	// Get returns the counter for key, or an entry with no failures.
+	Get(ctx context.Context, key Key) (Entry, error)
+
+	// Increment records a failure. A counter whose last failure happened
+	// before resetBefore starts over at one.
+	Increment(ctx context.Context, key Key, resetBefore time.Time) (Entry, error)
+
+	Reset(ctx context.Context, key Key) error
+
+	// List returns counters of kind with at least minFailures failures, the
+	// last of them after since, most recent first.
+	List(ctx context.Context, kind Kind, minFailures int32, since time.Time) ([]Entry, error)
+
+	// PurgeBefore drops counters whose last failure happened before the given
+	// time and reports how many were removed.
+	PurgeBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// NewCounter returns the counter selected by config.LoginLockoutDriver.
+func NewCounter(config util.Config, store db.Querier) (Counter, error) {
+	switch config.LoginLockoutDriver {
+	case "", "postgres":
+		return NewPostgresCounter(store), nil
+	case "memory":
+		return NewMemoryCounter(), nil
+	default:
+		return nil, fmt.Errorf("unknown login lockout driver %q", config.LoginLockoutDriver)
+	}
+}
+
+// RunCleanup forgets counters that no longer affect logins every interval
+// until ctx is cancelled.
+func RunCleanup(ctx context.Context, guard *Guard, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := guard.PurgeExpired(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Println("login lockout cleanup:", err)
+		}
+		if purged > 0 {
+			log.Printf("login lockout cleanup: purged %d expired entries", purged)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = Policy{
+	MaxFailures:     4,
+	BaseDelay:       time.Second,
+	LockoutDuration: time.Minute,
+	Window:          time.Hour,
+}
+
+func TestPolicyDelay(t *testing.T) {
+	testCases := []struct {
+		policy   Policy
+		failures int32
+		delay    time.Duration
+	}{
+		{testPolicy, 0, 0},
+		{testPolicy, 1, time.Second},
+		{testPolicy, 2, 2 * time.Second},
+		{testPolicy, 3, 4 * time.Second},
+		{testPolicy, 4, time.Minute},
+		{testPolicy, 9, time.Minute},
+		{Policy{MaxFailures: 20, BaseDelay: time.Second, LockoutDuration: 10 * time.Second}, 10, 10 * time.Second},
+		{Policy{BaseDelay: time.Second, LockoutDuration: time.Minute}, 5, 0},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.delay, tc.policy.Delay(tc.failures), "%+v after %d failures", tc.policy, tc.failures)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	counter := NewMemoryCounter()
+	guard := NewGuard(counter, map[Kind]Policy{
+		KindUsername: testPolicy,
+		KindIP:       {MaxFailures: 0},
+	})
+	user := UsernameKey("alice")
+	ip := IPKey("203.0.113.7")
+
+	wait, err := guard.Check(ctx, user, ip)
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	wait, err = guard.Fail(ctx, user, ip)
+	require.NoError(t, err)
+	require.InDelta(t, time.Second, wait, float64(100*time.Millisecond))
+
+	wait, err = guard.Check(ctx, user, ip)
+	require.NoError(t, err)
+	require.Positive(t, wait)
+
+	// The IP policy is turned off, so nothing is counted for it.
+	entry, err := counter.Get(ctx, ip)
+	require.NoError(t, err)
+	require.Zero(t, entry.Failures)
+
+	for i := 0; i < 3; i++ {
+		wait, err = guard.Fail(ctx, user)
+		require.NoError(t, err)
+	}
+	require.InDelta(t, time.Minute, wait, float64(time.Second))
+
+	lockouts, err := guard.Lockouts(ctx)
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	require.Equal(t, user, lockouts[0].Key)
+	require.Equal(t, int32(4), lockouts[0].Failures)
+	require.WithinDuration(t, time.Now().Add(time.Minute), lockouts[0].LockedUntil, time.Second)
+
+	require.NoError(t, guard.Reset(ctx, user))
+	wait, err = guard.Check(ctx, user)
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	lockouts, err = guard.Lockouts(ctx)
+	require.NoError(t, err)
+	require.Empty(t, lockouts)
+}
+
+func TestGuardWindow(t *testing.T) {
+	ctx := context.Background()
+	counter := NewMemoryCounter()
+	guard := NewGuard(counter, map[Kind]Policy{KindUsername: testPolicy})
+	user := UsernameKey("bob")
+
+	counter.entries[user] = Entry{Key: user, Failures: 4, LastFailureAt: time.Now().Add(-2 * time.Hour)}
+
+	// Failures older than the window no longer count, and the next failure
+	// starts the counter over.
+	wait, err := guard.Check(ctx, user)
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	_, err = guard.Fail(ctx, user)
+	require.NoError(t, err)
+	entry, err := counter.Get(ctx, user)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), entry.Failures)
+
+	counter.entries[UsernameKey("old")] = Entry{Key: UsernameKey("old"), Failures: 1, LastFailureAt: time.Now().Add(-2 * time.Hour)}
+	purged, err := guard.PurgeExpired(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+}
+
+func TestPostgresCounter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	counter := NewPostgresCounter(store)
+	key := UsernameKey("alice")
+	resetBefore := time.Now().Add(-time.Hour)
+	failure := db.LoginFailure{Kind: "username", Subject: "alice", Failures: 3, LastFailureAt: time.Now()}
+
+	store.EXPECT().
+		RecordLoginFailure(gomock.Any(), gomock.Eq(db.RecordLoginFailureParams{Kind: "username", Subject: "alice", ResetBefore: resetBefore})).
+		Times(1).
+		Return(failure, nil)
+	store.EXPECT().
+		GetLoginFailure(gomock.Any(), gomock.Eq(db.GetLoginFailureParams{Kind: "ip", Subject: "203.0.113.7"})).
+		Times(1).
+		Return(db.LoginFailure{}, sql.ErrNoRows)
+	store.EXPECT().
+		DeleteLoginFailure(gomock.Any(), gomock.Eq(db.DeleteLoginFailureParams{Kind: "username", Subject: "alice"})).
+		Times(1).
+		Return(nil)
+
+	entry, err := counter.Increment(context.Background(), key, resetBefore)
+	require.NoError(t, err)
+	require.Equal(t, key, entry.Key)
+	require.Equal(t, int32(3), entry.Failures)
+
+	entry, err = counter.Get(context.Background(), IPKey("203.0.113.7"))
+	require.NoError(t, err)
+	require.Equal(t, IPKey("203.0.113.7"), entry.Key)
+	require.Zero(t, entry.Failures)
+
+	require.NoError(t, counter.Reset(context.Background(), key))
+}
+
+func TestNewCounter(t *testing.T) {
+	counter, err := NewCounter(util.Config{LoginLockoutDriver: "memory"}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &MemoryCounter{}, counter)
+
+	counter, err = NewCounter(util.Config{LoginLockoutDriver: "postgres"}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &PostgresCounter{}, counter)
+
+	_, err = NewCounter(util.Config{LoginLockoutDriver: "redis"}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogSender writes messages to an io.Writer instead of delivering them. It is
+// meant for development and tests, where the verification links it prints can
+// be followed by hand.
+type LogSender struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+func NewLogSender(w io.Writer, from string) *LogSender {
+	return &LogSender{
+		w:    w,
+		from: from,
+	}
+}
+
+// NewFileSender appends messages to the file at path, creating it if needed.
+func NewFileSender(path string, from string) (*LogSender, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mail log: %w", err)
+	}
+	return NewLogSender(file, from), nil
+}
+
+func (sender *LogSender) Send(ctx context.Context, msg Message) error {
+	data, err := format(sender.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	if _, err := fmt.Fprintf(sender.w, "----- mail -----\r\n%s----- end mail -----\r\n", data); err != nil {
+		return fmt.Errorf("failed to write mail log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers email.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the sender selected by config.MailerDriver.
+func New(config util.Config) (Sender, error) {
+	switch config.MailerDriver {
+	case "", "log":
+		if config.MailerLogPath == "" {
+			return NewLogSender(os.Stdout, config.MailerFrom), nil
+		}
+		return NewFileSender(config.MailerLogPath, config.MailerFrom)
+	case "smtp":
+		return NewSMTPSender(SMTPConfig{
+			Host:     config.MailerSMTPHost,
+			Port:     config.MailerSMTPPort,
+			Username: config.MailerSMTPUsername,
+			Password: config.MailerSMTPPassword,
+			From:     config.MailerFrom,
+		})
+	default:
+		return nil, fmt.Errorf("unknown mailer driver %q", config.MailerDriver)
+	}
+}
+
+// format renders msg as an RFC 5322 message with CRLF line endings. Header
+// values containing line breaks are rejected so callers cannot inject headers.
+func format(from string, msg Message, now time.Time) ([]byte, error) {
+	for _, value := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("mail header must not contain line breaks")
+		}
+	}
+	if msg.To == "" {
+		return nil, fmt.Errorf("mail recipient must not be empty")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String()), nil
+}
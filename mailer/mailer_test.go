@@ -0,0 +1,133 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogSender(t *testing.T) {
+	var buf bytes.Buffer
+	sender := NewLogSender(&buf, "CMS <no-reply@example.com>")
+
+	err := sender.Send(context.Background(), Message{
+		To:      "reader@example.com",
+		Subject: "Welcome",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, "From: CMS <no-reply@example.com>\r\n")
+	require.Contains(t, out, "To: reader@example.com\r\n")
+	require.Contains(t, out, "Subject: Welcome\r\n")
+	require.Contains(t, out, "line one\r\nline two\r\n")
+}
+
+func TestFormatRejectsHeaderInjection(t *testing.T) {
+	_, err := format("no-reply@example.com", Message{
+		To:      "reader@example.com",
+		Subject: "Hi\r\nBcc: victim@example.com",
+	}, time.Now())
+	require.Error(t, err)
+
+	_, err = format("no-reply@example.com", Message{Subject: "Hi"}, time.Now())
+	require.Error(t, err)
+}
+
+func TestSMTPSender(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go serveFakeSMTP(listener, received)
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	sender, err := NewSMTPSender(SMTPConfig{
+		Host: host,
+		Port: portNumber,
+		From: "CMS <no-reply@example.com>",
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = sender.Send(ctx, Message{
+		To:      "reader@example.com",
+		Subject: "Welcome",
+		Body:    "hello",
+	})
+	require.NoError(t, err)
+
+	transcript := <-received
+	require.Contains(t, transcript, "MAIL FROM:<no-reply@example.com>")
+	require.Contains(t, transcript, "RCPT TO:<reader@example.com>")
+	require.Contains(t, transcript, "Subject: Welcome")
+	require.Contains(t, transcript, "hello")
+}
+
+func TestNewSMTPSenderRequiresHost(t *testing.T) {
+	_, err := NewSMTPSender(SMTPConfig{From: "no-reply@example.com"})
+	require.Error(t, err)
+}
+
+// serveFakeSMTP accepts one connection, answers just enough of the protocol
+// for net/smtp, and sends everything the client wrote on received.
+func serveFakeSMTP(listener net.Listener, received chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var transcript strings.Builder
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP")
+	inData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		transcript.WriteString(line)
+
+		if inData {
+			if line == ".\r\n" {
+				inData = false
+				reply("250 OK")
+			}
+			continue
+		}
+
+		switch command := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250 localhost")
+		case command == "DATA":
+			inData = true
+			reply("354 End data with <CR><LF>.<CR><LF>")
+		case command == "QUIT":
+			reply("221 Bye")
+			received <- transcript.String()
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+	received <- transcript.String()
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/go-live-cms/go-live-cms/api"
 	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/scheduler"
+	"github.com/go-live-cms/go-live-cms/search"
 	"github.com/go-live-cms/go-live-cms/util"
 
 	_ "github.com/lib/pq"
@@ -26,15 +33,43 @@ func main() {
 	}
 	log.Println("✅ Database connected successfully")
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store := db.NewStore(conn)
+
+	indexer, err := search.New(config)
+	if err != nil {
+		log.Fatal("❌ Cannot set up search:", err)
+	}
+	if indexer != nil {
+		log.Println("🔎 Indexing content changes with", config.SearchDriver)
+		store = search.NewIndexingStore(store, indexer)
+	}
+
+	var wg sync.WaitGroup
+	if config.SchedulerEnabled {
+		log.Println("⏰ Starting post scheduler, polling every", config.SchedulerInterval)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler.New(store, config.SchedulerInterval).Run(ctx)
+		}()
+	}
+
 	log.Println("🔧 Setting up server...")
-	server, err := api.NewServer(config, db.NewStore(conn))
+	server, err := api.NewServer(config, store, indexer)
 	if err != nil {
 		log.Fatal("❌ Cannot set up server:", err)
 	}
 
 	log.Println("🌐 Starting Go Live CMS API on port", config.APIPort)
-	err = server.Start(config.ServerAddress)
+	err = server.Start(ctx, config.ServerAddress)
 	if err != nil {
 		log.Fatal("cannot start server:", err)
 	}
+
+	stop()
+	wg.Wait()
+	log.Println("👋 Go Live CMS stopped")
 }
@@ -0,0 +1,80 @@
+// Package oidc implements the relying-party side of OpenID Connect: the
+// authorization code flow with PKCE, and ID token verification against the
+// keys the provider publishes.
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultScopes are requested when a provider does not list its own.
+var defaultScopes = []string{"openid", "email", "profile"}
+
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Config describes one identity provider. Providers are configured as a JSON
+// array in OIDC_PROVIDERS.
+type Config struct {
+	// Name identifies the provider in URLs such as /auth/oidc/:provider/login.
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes"`
+
+	// RoleClaim names the ID token claim, a string or list of strings, whose
+	// values are looked up in Roles to pick the role of a new user.
+	RoleClaim   string            `json:"role_claim"`
+	Roles       map[string]string `json:"roles"`
+	DefaultRole string            `json:"default_role"`
+}
+
+// ParseConfigs parses the JSON list of providers. An empty string configures
+// no providers.
+func ParseConfigs(raw string) ([]Config, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var configs []Config
+	if err := json.Unmarshal([]byte(raw), &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse providers: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range configs {
+		config := &configs[i]
+		if !providerNamePattern.MatchString(config.Name) {
+			return nil, fmt.Errorf("invalid provider name %q", config.Name)
+		}
+		if seen[config.Name] {
+			return nil, fmt.Errorf("duplicate provider %q", config.Name)
+		}
+		seen[config.Name] = true
+
+		if config.Issuer == "" || config.ClientID == "" {
+			return nil, fmt.Errorf("provider %q needs an issuer and a client_id", config.Name)
+		}
+		config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+		if len(config.Scopes) == 0 {
+			config.Scopes = defaultScopes
+		}
+		if !contains(config.Scopes, "openid") {
+			config.Scopes = append([]string{"openid"}, config.Scopes...)
+		}
+	}
+	return configs, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
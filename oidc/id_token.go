@@ -0,0 +1,118 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IDToken holds the verified claims of an ID token.
+type IDToken struct {
+	Issuer          string
+	Subject         string
+	Audience        []string
+	AuthorizedParty string
+	Expiry          time.Time
+	IssuedAt        time.Time
+	Nonce           string
+
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+
+	// Claims holds every claim, for provider-specific ones such as groups.
+	Claims map[string]any
+}
+
+func parseIDToken(payload []byte) (*IDToken, error) {
+	var claims struct {
+		Issuer            string          `json:"iss"`
+		Subject           string          `json:"sub"`
+		Audience          json.RawMessage `json:"aud"`
+		AuthorizedParty   string          `json:"azp"`
+		Expiry            json.Number     `json:"exp"`
+		IssuedAt          json.Number     `json:"iat"`
+		Nonce             string          `json:"nonce"`
+		Email             string          `json:"email"`
+		EmailVerified     any             `json:"email_verified"`
+		Name              string          `json:"name"`
+		PreferredUsername string          `json:"preferred_username"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	var all map[string]any
+	if err := json.Unmarshal(payload, &all); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	audience, err := parseAudience(claims.Audience)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := parseNumericDate(claims.Expiry)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid exp", ErrInvalidToken)
+	}
+	issuedAt, err := parseNumericDate(claims.IssuedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid iat", ErrInvalidToken)
+	}
+
+	return &IDToken{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Audience:          audience,
+		AuthorizedParty:   claims.AuthorizedParty,
+		Expiry:            expiry,
+		IssuedAt:          issuedAt,
+		Nonce:             claims.Nonce,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+		Claims:            all,
+	}, nil
+}
+
+// StringsClaim returns a claim that is either a string or a list of strings.
+// Anything else yields nil.
+func (t *IDToken) StringsClaim(name string) []string {
+	switch value := t.Claims[name].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		var values []string
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// parseAudience accepts both forms RFC 7519 allows for aud.
+func parseAudience(raw json.RawMessage) ([]string, error) {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("%w: invalid aud", ErrInvalidToken)
+	}
+	return list, nil
+}
+
+func parseNumericDate(n json.Number) (time.Time, error) {
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
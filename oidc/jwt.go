@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidToken = errors.New("invalid ID token")
+
+type jwsHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// signedToken is a compact JWS split into its parts.
+type signedToken struct {
+	header       jwsHeader
+	signingInput string
+	payload      []byte
+	signature    []byte
+}
+
+func parseSignedToken(raw string) (*signedToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	var header jwsHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed payload", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	return &signedToken{
+		header:       header,
+		signingInput: parts[0] + "." + parts[1],
+		payload:      payload,
+		signature:    signature,
+	}, nil
+}
+
+// verify checks the signature with key. Only asymmetric algorithms are
+// accepted: "none" and HMAC would let anyone holding the client secret, or
+// nobody at all, mint tokens.
+func (t *signedToken) verify(key publicKey) error {
+	if key.alg != "" && key.alg != t.header.Alg {
+		return fmt.Errorf("%w: key %q is not for %s", ErrInvalidToken, key.kid, t.header.Alg)
+	}
+
+	var hash crypto.Hash
+	switch t.header.Alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, t.header.Alg)
+	}
+	h := hash.New()
+	h.Write([]byte(t.signingInput))
+	digest := h.Sum(nil)
+
+	switch t.header.Alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key %q is not an RSA key", ErrInvalidToken, key.kid)
+		}
+		var err error
+		if t.header.Alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(rsaKey, hash, digest, t.signature)
+		} else {
+			err = rsa.VerifyPSS(rsaKey, hash, digest, t.signature, nil)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	case "ES":
+		ecKey, ok := key.key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key %q is not an EC key", ErrInvalidToken, key.kid)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if size != hash.Size() {
+			return fmt.Errorf("%w: key %q is not for %s", ErrInvalidToken, key.kid, t.header.Alg)
+		}
+		if len(t.signature) != 2*size {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// leeway tolerates clock drift between us and the provider.
+	leeway = time.Minute
+	// keyRefreshInterval limits how often an unknown key ID makes us fetch
+	// the key set again, so forged tokens cannot hammer the provider.
+	keyRefreshInterval = time.Minute
+	// maxResponseSize bounds what we read from the provider.
+	maxResponseSize = 1 << 20
+)
+
+// metadata is the part of the discovery document the flow needs.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to one identity provider. Its discovery document and keys
+// are fetched on first use and cached.
+type Provider struct {
+	Config Config
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          []publicKey
+	keysFetchedAt time.Time
+}
+
+// NewProvider returns a provider for config. A nil client uses a default one
+// with a timeout.
+func NewProvider(config Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{Config: config, client: client}
+}
+
+// AuthCodeURL returns the provider URL that starts the authorization code
+// flow. state, nonce and the PKCE challenge come back in the callback and the
+// ID token.
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURI, state, nonce, codeChallenge string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(md.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.Config.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", strings.Join(p.Config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange trades an authorization code for tokens and returns the raw ID
+// token. The caller must still verify it.
+func (p *Provider) Exchange(ctx context.Context, redirectURI, code, codeVerifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.Config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.Config.ClientSecret != "" {
+		// RFC 6749 section 2.3.1 form-encodes the credentials before
+		// base64, which SetBasicAuth alone does not.
+		req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+	}
+
+	var rsp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &rsp)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		if rsp.Error != "" {
+			return "", fmt.Errorf("token request failed: %s: %s", rsp.Error, rsp.ErrorDescription)
+		}
+		return "", fmt.Errorf("token request failed with status %d", status)
+	}
+	if rsp.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return rsp.IDToken, nil
+}
+
+// Verify checks an ID token's signature against the provider's keys and its
+// issuer, audience, lifetime and nonce.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := parseSignedToken(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.key(ctx, token.header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := token.verify(key); err != nil {
+		return nil, err
+	}
+
+	idToken, err := parseIDToken(token.payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case idToken.Issuer != md.Issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, idToken.Issuer)
+	case !contains(idToken.Audience, p.Config.ClientID):
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidToken)
+	case len(idToken.Audience) > 1 && idToken.AuthorizedParty != p.Config.ClientID:
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidToken)
+	case idToken.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	case now.After(idToken.Expiry.Add(leeway)):
+		return nil, fmt.Errorf("%w: token has expired", ErrInvalidToken)
+	case idToken.IssuedAt.After(now.Add(leeway)):
+		return nil, fmt.Errorf("%w: token issued in the future", ErrInvalidToken)
+	case idToken.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+	return idToken, nil
+}
+
+// discover fetches the discovery document once. Failures are not cached, so
+// a provider that was down at startup is retried on the next login.
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Config.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var md metadata
+	status, err := p.doJSON(req, &md)
+	if err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery failed with status %d", status)
+	}
+	// OpenID Connect Discovery section 4.3: the document must be for the
+	// issuer we asked about.
+	if strings.TrimSuffix(md.Issuer, "/") != p.Config.Issuer {
+		return nil, fmt.Errorf("discovery returned issuer %q, want %q", md.Issuer, p.Config.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	p.metadata = &md
+	return p.metadata, nil
+}
+
+// key returns the signing key with kid, fetching the key set again when kid
+// is unknown so the provider can rotate keys.
+func (p *Provider) key(ctx context.Context, kid string) (publicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := findKey(p.keys, kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keyRefreshInterval {
+		return publicKey{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.metadata.JWKSURI, nil)
+	if err != nil {
+		return publicKey{}, err
+	}
+	var raw json.RawMessage
+	status, err := p.doJSON(req, &raw)
+	if err != nil {
+		return publicKey{}, fmt.Errorf("key set request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return publicKey{}, fmt.Errorf("key set request failed with status %d", status)
+	}
+	keys, err := parseKeySet(raw)
+	if err != nil {
+		return publicKey{}, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := findKey(p.keys, kid); ok {
+		return key, nil
+	}
+	return publicKey{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+}
+
+// findKey looks a key up by ID. A token without a key ID matches only when
+// the set holds a single key.
+func findKey(keys []publicKey, kid string) (publicKey, bool) {
+	if kid == "" {
+		if len(keys) == 1 {
+			return keys[0], true
+		}
+		return publicKey{}, false
+	}
+	for _, key := range keys {
+		if key.kid == kid {
+			return key, true
+		}
+	}
+	return publicKey{}, false
+}
+
+// doJSON sends req and decodes the response body into v whatever the status,
+// since error responses from the token endpoint are JSON too.
+func (p *Provider) doJSON(req *http.Request, v any) (int, error) {
+	rsp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(rsp.Body, maxResponseSize))
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && rsp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("invalid response: %w", err)
+	}
+	return rsp.StatusCode, nil
+}
@@ -0,0 +1,195 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-live-cms/go-live-cms/oidc/oidctest"
+)
+
+const testRedirectURI = "http://cms.example.com/api/v1/auth/oidc/test/callback"
+
+func newTestProvider(t *testing.T) (*oidctest.Provider, *Provider) {
+	fake := oidctest.NewProvider("cms", "s3cret:&")
+	t.Cleanup(fake.Close)
+
+	configs, err := ParseConfigs(`[{"name":"test","issuer":"` + fake.Issuer + `/","client_id":"cms","client_secret":"s3cret:&"}]`)
+	require.NoError(t, err)
+	return fake, NewProvider(configs[0], nil)
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	fake, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, testRedirectURI, "state-1", "nonce-1", CodeChallenge(verifier))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(authURL, fake.Issuer+"/authorize?"))
+
+	query, err := url.ParseQuery(strings.SplitN(authURL, "?", 2)[1])
+	require.NoError(t, err)
+	require.Equal(t, "state-1", query.Get("state"))
+	require.Equal(t, "openid email profile", query.Get("scope"))
+
+	code, err := fake.Authorize(authURL, map[string]any{
+		"sub":            "user-1",
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"groups":         []string{"cms-admins", "staff"},
+	})
+	require.NoError(t, err)
+
+	rawIDToken, err := provider.Exchange(ctx, testRedirectURI, code, verifier)
+	require.NoError(t, err)
+
+	idToken, err := provider.Verify(ctx, rawIDToken, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, "user-1", idToken.Subject)
+	require.Equal(t, "ada@example.com", idToken.Email)
+	require.True(t, idToken.EmailVerified)
+	require.Equal(t, []string{"cms-admins", "staff"}, idToken.StringsClaim("groups"))
+
+	// Codes are single use.
+	_, err = provider.Exchange(ctx, testRedirectURI, code, verifier)
+	require.Error(t, err)
+}
+
+func TestExchangeRequiresCodeVerifier(t *testing.T) {
+	fake, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(ctx, testRedirectURI, "state", "nonce", CodeChallenge(verifier))
+	require.NoError(t, err)
+	code, err := fake.Authorize(authURL, map[string]any{"sub": "user-1"})
+	require.NoError(t, err)
+
+	other, err := NewCodeVerifier()
+	require.NoError(t, err)
+	_, err = provider.Exchange(ctx, testRedirectURI, code, other)
+	require.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	fake, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	valid := fake.IDToken(map[string]any{"sub": "user-1", "nonce": "nonce"})
+	parts := strings.Split(valid, ".")
+
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "WrongNonce",
+			token: fake.IDToken(map[string]any{"sub": "user-1", "nonce": "other"}),
+		},
+		{
+			name:  "WrongAudience",
+			token: fake.IDToken(map[string]any{"sub": "user-1", "nonce": "nonce", "aud": "someone-else"}),
+		},
+		{
+			name:  "UntrustedAuthorizedParty",
+			token: fake.IDToken(map[string]any{"sub": "user-1", "nonce": "nonce", "aud": []string{"cms", "other"}, "azp": "other"}),
+		},
+		{
+			name:  "WrongIssuer",
+			token: fake.IDToken(map[string]any{"sub": "user-1", "nonce": "nonce", "iss": "https://evil.example.com"}),
+		},
+		{
+			name:  "Expired",
+			token: fake.IDToken(map[string]any{"sub": "user-1", "nonce": "nonce", "exp": time.Now().Add(-time.Hour).Unix()}),
+		},
+		{
+			name:  "MissingSubject",
+			token: fake.IDToken(map[string]any{"nonce": "nonce"}),
+		},
+		{
+			name:  "TamperedPayload",
+			token: parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin","nonce":"nonce"}`)) + "." + parts[2],
+		},
+		{
+			name:  "AlgNone",
+			token: base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".",
+		},
+		{
+			name:  "Malformed",
+			token: "not-a-token",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := provider.Verify(ctx, tc.token, "nonce")
+			require.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	_, err := provider.Verify(ctx, valid, "nonce")
+	require.NoError(t, err)
+}
+
+func TestVerifyAfterKeyRotation(t *testing.T) {
+	fake, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	_, err := provider.Verify(ctx, fake.IDToken(map[string]any{"sub": "user-1"}), "")
+	require.NoError(t, err)
+
+	fake.RotateKey()
+	rotated := fake.IDToken(map[string]any{"sub": "user-1"})
+
+	// Unknown key IDs only trigger a refetch once the refresh interval has
+	// passed, so a flood of forged tokens cannot hammer the provider.
+	_, err = provider.Verify(ctx, rotated, "")
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	provider.keysFetchedAt = time.Now().Add(-keyRefreshInterval)
+	_, err = provider.Verify(ctx, rotated, "")
+	require.NoError(t, err)
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636 appendix B.
+	require.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+	require.Len(t, verifier, 43)
+}
+
+func TestParseConfigs(t *testing.T) {
+	configs, err := ParseConfigs("")
+	require.NoError(t, err)
+	require.Empty(t, configs)
+
+	configs, err = ParseConfigs(`[{"name":"corp","issuer":"https://idp.example.com/","client_id":"cms","scopes":["email","groups"],"role_claim":"groups","roles":{"cms-admins":"admin"}}]`)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	require.Equal(t, "https://idp.example.com", configs[0].Issuer)
+	require.Equal(t, []string{"openid", "email", "groups"}, configs[0].Scopes)
+	require.Equal(t, "admin", configs[0].Roles["cms-admins"])
+
+	for _, raw := range []string{
+		`not json`,
+		`[{"name":"Corp Login","issuer":"https://idp.example.com","client_id":"cms"}]`,
+		`[{"name":"corp","client_id":"cms"}]`,
+		`[{"name":"corp","issuer":"https://a.example.com","client_id":"cms"},{"name":"corp","issuer":"https://b.example.com","client_id":"cms"}]`,
+	} {
+		_, err := ParseConfigs(raw)
+		require.Error(t, err, raw)
+	}
+}
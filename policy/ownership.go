@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"errors"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+var (
+	ErrNotPostAuthor    = errors.New("only the post's authors, moderators or admins can edit this post")
+	ErrNotPrimaryAuthor = errors.New("only the post's primary author, moderators or admins can delete this post")
+	ErrNotMediaOwner    = errors.New("only the uploader, moderators or admins can modify this media")
+)
+
+// Actor is the authenticated user a policy decision is made for.
+type Actor struct {
+	UserID int64
+	Role   string
+}
+
+func NewActor(user db.User) Actor {
+	return Actor{
+		UserID: user.ID,
+		Role:   user.Role,
+	}
+}
+
+// CanEditPost allows the primary author, any co-author listed in user_posts and staff.
+func CanEditPost(actor Actor, post db.Post, authors []db.UserPost) error {
+	if IsStaff(actor.Role) || post.UserID == actor.UserID {
+		return nil
+	}
+
+	for _, author := range authors {
+		if author.UserID == actor.UserID {
+			return nil
+		}
+	}
+
+	return ErrNotPostAuthor
+}
+
+// CanDeletePost allows only the primary author and staff; co-authors may not delete.
+func CanDeletePost(actor Actor, post db.Post) error {
+	if IsStaff(actor.Role) || post.UserID == actor.UserID {
+		return nil
+	}
+	return ErrNotPrimaryAuthor
+}
+
+// CanModifyMedia allows the uploader and staff to update or delete a media item.
+func CanModifyMedia(actor Actor, media db.Medium) error {
+	if IsStaff(actor.Role) || media.UserID == actor.UserID {
+		return nil
+	}
+	return ErrNotMediaOwner
+}
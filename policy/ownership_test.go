@@ -0,0 +1,41 @@
+package policy
+
+import (
+	"testing"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanEditPost(t *testing.T) {
+	post := db.Post{ID: 1, UserID: 10}
+	authors := []db.UserPost{
+		{PostID: 1, UserID: 10, Order: 0},
+		{PostID: 1, UserID: 11, Order: 1},
+	}
+
+	require.NoError(t, CanEditPost(Actor{UserID: 10, Role: RoleUser}, post, authors))
+	require.NoError(t, CanEditPost(Actor{UserID: 11, Role: RoleUser}, post, authors))
+	require.NoError(t, CanEditPost(Actor{UserID: 12, Role: RoleModerator}, post, authors))
+	require.NoError(t, CanEditPost(Actor{UserID: 13, Role: RoleAdmin}, post, authors))
+	require.ErrorIs(t, CanEditPost(Actor{UserID: 14, Role: RoleUser}, post, authors), ErrNotPostAuthor)
+	require.ErrorIs(t, CanEditPost(Actor{UserID: 11, Role: RoleUser}, post, nil), ErrNotPostAuthor)
+}
+
+func TestCanDeletePost(t *testing.T) {
+	post := db.Post{ID: 1, UserID: 10}
+
+	require.NoError(t, CanDeletePost(Actor{UserID: 10, Role: RoleUser}, post))
+	require.NoError(t, CanDeletePost(Actor{UserID: 12, Role: RoleModerator}, post))
+	require.NoError(t, CanDeletePost(Actor{UserID: 13, Role: "Admin"}, post))
+	require.ErrorIs(t, CanDeletePost(Actor{UserID: 11, Role: RoleUser}, post), ErrNotPrimaryAuthor)
+}
+
+func TestCanModifyMedia(t *testing.T) {
+	media := db.Medium{ID: 1, UserID: 10}
+
+	require.NoError(t, CanModifyMedia(Actor{UserID: 10, Role: RoleUser}, media))
+	require.NoError(t, CanModifyMedia(Actor{UserID: 12, Role: RoleModerator}, media))
+	require.NoError(t, CanModifyMedia(Actor{UserID: 13, Role: RoleAdmin}, media))
+	require.ErrorIs(t, CanModifyMedia(Actor{UserID: 11, Role: RoleUser}, media), ErrNotMediaOwner)
+}
@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+type Permission string
+
+const (
+	PermissionUsersCreate     Permission = "users:create"
+	PermissionUsersRead       Permission = "users:read"
+	PermissionUsersUpdateSelf Permission = "users:update_self"
+	PermissionUsersUpdate     Permission = "users:update"
+	PermissionUsersManageRole Permission = "users:manage_role"
+	PermissionUsersDelete     Permission = "users:delete"
+
+	PermissionPostsCreate  Permission = "posts:create"
+	PermissionPostsUpdate  Permission = "posts:update"
+	PermissionPostsDelete  Permission = "posts:delete"
+	PermissionPostsPublish Permission = "posts:publish"
+
+	PermissionTaxonomiesCreate Permission = "taxonomies:create"
+	PermissionTaxonomiesUpdate Permission = "taxonomies:update"
+	PermissionTaxonomiesDelete Permission = "taxonomies:delete"
+
+	PermissionMediaCreate Permission = "media:create"
+	PermissionMediaUpdate Permission = "media:update"
+	PermissionMediaDelete Permission = "media:delete"
+)
+
+var userPermissions = []Permission{
+	PermissionUsersUpdateSelf,
+	PermissionPostsCreate,
+	PermissionPostsUpdate,
+	PermissionPostsDelete,
+	PermissionTaxonomiesCreate,
+	PermissionMediaCreate,
+	PermissionMediaUpdate,
+	PermissionMediaDelete,
+}
+
+var moderatorPermissions = append([]Permission{
+	PermissionUsersRead,
+	PermissionPostsPublish,
+	PermissionTaxonomiesUpdate,
+	PermissionTaxonomiesDelete,
+}, userPermissions...)
+
+var adminPermissions = append([]Permission{
+	PermissionUsersCreate,
+	PermissionUsersUpdate,
+	PermissionUsersManageRole,
+	PermissionUsersDelete,
+}, moderatorPermissions...)
+
+// rolePermissions is the role-to-permission matrix.
+var rolePermissions = map[string][]Permission{
+	RoleUser:      userPermissions,
+	RoleModerator: moderatorPermissions,
+	RoleAdmin:     adminPermissions,
+}
+
+// roleRank orders roles from least to most privileged.
+var roleRank = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// HighestRole returns the most privileged of roles, ignoring unknown ones. It
+// returns "" when none is known.
+func HighestRole(roles []string) string {
+	highest := ""
+	for _, role := range roles {
+		role = NormalizeRole(role)
+		if roleRank[role] > roleRank[highest] {
+			highest = role
+		}
+	}
+	return highest
+}
+
+// NormalizeRole lowercases a stored role; the users table defaults to 'User'.
+func NormalizeRole(role string) string {
+	return strings.ToLower(role)
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[NormalizeRole(role)] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff reports whether the role may act on content owned by other users.
+func IsStaff(role string) bool {
+	role = NormalizeRole(role)
+	return role == RoleModerator || role == RoleAdmin
+}
+
+// ParseRoles parses a comma-separated list of role names such as
+// "admin,moderator". Blank entries are skipped; unknown roles are an error.
+func ParseRoles(list string) ([]string, error) {
+	var roles []string
+	for _, field := range strings.Split(list, ",") {
+		role := NormalizeRole(strings.TrimSpace(field))
+		if role == "" {
+			continue
+		}
+		if _, ok := rolePermissions[role]; !ok {
+			return nil, fmt.Errorf("unknown role %q", role)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
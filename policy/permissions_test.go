@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolePermissions(t *testing.T) {
+	testCases := []struct {
+		role       string
+		permission Permission
+		allowed    bool
+	}{
+		{RoleUser, PermissionPostsCreate, true},
+		{RoleUser, PermissionUsersUpdateSelf, true},
+		{RoleUser, PermissionUsersUpdate, false},
+		{RoleUser, PermissionUsersManageRole, false},
+		{RoleUser, PermissionUsersDelete, false},
+		{RoleUser, PermissionUsersRead, false},
+		{RoleUser, PermissionTaxonomiesDelete, false},
+		{RoleUser, PermissionPostsPublish, false},
+		{RoleModerator, PermissionPostsPublish, true},
+		{RoleModerator, PermissionTaxonomiesDelete, true},
+		{RoleModerator, PermissionUsersRead, true},
+		{RoleModerator, PermissionUsersDelete, false},
+		{RoleModerator, PermissionUsersManageRole, false},
+		{RoleAdmin, PermissionUsersDelete, true},
+		{RoleAdmin, PermissionUsersManageRole, true},
+		{RoleAdmin, PermissionMediaDelete, true},
+		{"Admin", PermissionUsersDelete, true},
+		{"User", PermissionPostsCreate, true},
+		{"unknown", PermissionPostsCreate, false},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.allowed, HasPermission(tc.role, tc.permission), "%s %s", tc.role, tc.permission)
+	}
+}
+
+func TestParseRoles(t *testing.T) {
+	roles, err := ParseRoles(" Admin, moderator ,,")
+	require.NoError(t, err)
+	require.Equal(t, []string{RoleAdmin, RoleModerator}, roles)
+
+	roles, err = ParseRoles("")
+	require.NoError(t, err)
+	require.Empty(t, roles)
+
+	_, err = ParseRoles("admin,superuser")
+	require.Error(t, err)
+}
+
+func TestHighestRole(t *testing.T) {
+	require.Equal(t, RoleAdmin, HighestRole([]string{RoleUser, "Admin", RoleModerator}))
+	require.Equal(t, RoleModerator, HighestRole([]string{"superuser", RoleModerator}))
+	require.Equal(t, "", HighestRole([]string{"superuser"}))
+	require.Equal(t, "", HighestRole(nil))
+}
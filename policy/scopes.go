@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope limits what an API key may do on top of its owner's role. A key acts
+// as its owner, so it can never do more than the owner's role allows.
+type Scope string
+
+const (
+	ScopePostsRead       Scope = "posts:read"
+	ScopePostsWrite      Scope = "posts:write"
+	ScopeMediaWrite      Scope = "media:write"
+	ScopeTaxonomiesWrite Scope = "taxonomies:write"
+	ScopeUsersRead       Scope = "users:read"
+	ScopeUsersWrite      Scope = "users:write"
+)
+
+// Scopes lists every scope an API key can be granted.
+var Scopes = []Scope{
+	ScopePostsRead,
+	ScopePostsWrite,
+	ScopeMediaWrite,
+	ScopeTaxonomiesWrite,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+}
+
+// permissionScopes is the scope an API key needs for each permission.
+var permissionScopes = map[Permission]Scope{
+	PermissionUsersCreate:     ScopeUsersWrite,
+	PermissionUsersRead:       ScopeUsersRead,
+	PermissionUsersUpdateSelf: ScopeUsersWrite,
+	PermissionUsersUpdate:     ScopeUsersWrite,
+	PermissionUsersManageRole: ScopeUsersWrite,
+	PermissionUsersDelete:     ScopeUsersWrite,
+
+	PermissionPostsCreate:  ScopePostsWrite,
+	PermissionPostsUpdate:  ScopePostsWrite,
+	PermissionPostsDelete:  ScopePostsWrite,
+	PermissionPostsPublish: ScopePostsWrite,
+
+	PermissionTaxonomiesCreate: ScopeTaxonomiesWrite,
+	PermissionTaxonomiesUpdate: ScopeTaxonomiesWrite,
+	PermissionTaxonomiesDelete: ScopeTaxonomiesWrite,
+
+	PermissionMediaCreate: ScopeMediaWrite,
+	PermissionMediaUpdate: ScopeMediaWrite,
+	PermissionMediaDelete: ScopeMediaWrite,
+}
+
+// ScopeForPermission returns the scope an API key needs to use permission.
+func ScopeForPermission(permission Permission) (Scope, bool) {
+	scope, ok := permissionScopes[permission]
+	return scope, ok
+}
+
+// HasScope reports whether granted contains scope.
+func HasScope(granted []string, scope Scope) bool {
+	for _, s := range granted {
+		if Scope(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes validates a list of requested scope names, dropping duplicates.
+func ParseScopes(names []string) ([]string, error) {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isScope(name) {
+			return nil, fmt.Errorf("unknown scope %q", name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		scopes = append(scopes, name)
+	}
+	return scopes, nil
+}
+
+func isScope(name string) bool {
+	for _, scope := range Scopes {
+		if string(scope) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEveryPermissionHasScope(t *testing.T) {
+	for _, permissions := range rolePermissions {
+		for _, permission := range permissions {
+			_, ok := ScopeForPermission(permission)
+			require.True(t, ok, "permission %s has no scope", permission)
+		}
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	granted := []string{"posts:read", "media:write"}
+
+	require.True(t, HasScope(granted, ScopePostsRead))
+	require.True(t, HasScope(granted, ScopeMediaWrite))
+	require.False(t, HasScope(granted, ScopePostsWrite))
+	require.False(t, HasScope(nil, ScopePostsRead))
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes([]string{"posts:read", " Posts:Write ", "posts:read"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"posts:read", "posts:write"}, scopes)
+
+	_, err = ParseScopes([]string{"posts:admin"})
+	require.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+const (
+	PostStatusDraft         = "draft"
+	PostStatusPendingReview = "pending_review"
+	PostStatusScheduled     = "scheduled"
+	PostStatusPublished     = "published"
+	PostStatusArchived      = "archived"
+)
+
+// PostStatuses lists every status a post can be in.
+var PostStatuses = []string{
+	PostStatusDraft,
+	PostStatusPendingReview,
+	PostStatusScheduled,
+	PostStatusPublished,
+	PostStatusArchived,
+}
+
+type PostTransition string
+
+const (
+	TransitionSubmit    PostTransition = "submit"
+	TransitionApprove   PostTransition = "approve"
+	TransitionReject    PostTransition = "reject"
+	TransitionPublish   PostTransition = "publish"
+	TransitionUnpublish PostTransition = "unpublish"
+)
+
+var (
+	ErrNotReviewer       = errors.New("only moderators or admins can review and publish posts")
+	ErrCannotUnpublish   = errors.New("only the post's primary author, moderators or admins can unpublish this post")
+	ErrPostNotVisible    = errors.New("post is not published")
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrInvalidSchedule   = errors.New("unpublish_at must be after publish_at")
+)
+
+// postTransitions maps each transition to the states it may start from and
+// the state it leads to.
+var postTransitions = map[PostTransition]map[string]string{
+	TransitionSubmit: {
+		PostStatusDraft: PostStatusPendingReview,
+	},
+	TransitionApprove: {
+		PostStatusPendingReview: PostStatusPublished,
+	},
+	TransitionReject: {
+		PostStatusPendingReview: PostStatusDraft,
+	},
+	TransitionPublish: {
+		PostStatusDraft:         PostStatusPublished,
+		PostStatusPendingReview: PostStatusPublished,
+		PostStatusScheduled:     PostStatusPublished,
+		PostStatusArchived:      PostStatusPublished,
+	},
+	TransitionUnpublish: {
+		PostStatusPublished: PostStatusArchived,
+		PostStatusScheduled: PostStatusDraft,
+	},
+}
+
+func IsValidPostStatus(status string) bool {
+	for _, s := range PostStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// NextPostStatus returns the status a post in status from ends up in after
+// transition, or ErrInvalidTransition.
+func NextPostStatus(transition PostTransition, from string) (string, error) {
+	to, ok := postTransitions[transition][from]
+	if !ok {
+		return "", fmt.Errorf("%w: cannot %s a post that is %s", ErrInvalidTransition, transition, from)
+	}
+	return to, nil
+}
+
+// ScheduledPostStatus defers a transition into published until the post's
+// publish_at when that lies in the future. Publishing a post that is already
+// scheduled publishes it immediately.
+func ScheduledPostStatus(post db.Post, to string, now time.Time) string {
+	if to != PostStatusPublished || post.Status == PostStatusScheduled {
+		return to
+	}
+	if post.PublishAt.Valid && post.PublishAt.Time.After(now) {
+		return PostStatusScheduled
+	}
+	return to
+}
+
+// ValidatePostSchedule checks that an embargo ends after the post goes live.
+func ValidatePostSchedule(publishAt, unpublishAt *time.Time) error {
+	if publishAt != nil && unpublishAt != nil && !unpublishAt.After(*publishAt) {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// CanTransitionPost lets authors submit their own drafts, reviewers approve,
+// reject and publish, and the primary author or a reviewer take a post down.
+func CanTransitionPost(actor Actor, post db.Post, authors []db.UserPost, transition PostTransition) error {
+	switch transition {
+	case TransitionSubmit:
+		return CanEditPost(actor, post, authors)
+	case TransitionApprove, TransitionReject, TransitionPublish:
+		if HasPermission(actor.Role, PermissionPostsPublish) {
+			return nil
+		}
+		return ErrNotReviewer
+	case TransitionUnpublish:
+		if HasPermission(actor.Role, PermissionPostsPublish) || post.UserID == actor.UserID {
+			return nil
+		}
+		return ErrCannotUnpublish
+	}
+	return fmt.Errorf("%w: unknown transition %q", ErrInvalidTransition, transition)
+}
+
+// CanViewAllPosts reports whether the actor may list posts in any status.
+func CanViewAllPosts(actor Actor) bool {
+	return HasPermission(actor.Role, PermissionPostsPublish)
+}
+
+// CanViewPost allows everyone to see published posts; anything else is only
+// visible to its authors and reviewers.
+func CanViewPost(actor *Actor, post db.Post, authors []db.UserPost) error {
+	if post.Status == PostStatusPublished {
+		return nil
+	}
+	if actor == nil {
+		return ErrPostNotVisible
+	}
+	if CanViewAllPosts(*actor) || CanEditPost(*actor, post, authors) == nil {
+		return nil
+	}
+	return ErrPostNotVisible
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextPostStatus(t *testing.T) {
+	testCases := []struct {
+		transition PostTransition
+		from       string
+		to         string
+	}{
+		{TransitionSubmit, PostStatusDraft, PostStatusPendingReview},
+		{TransitionApprove, PostStatusPendingReview, PostStatusPublished},
+		{TransitionReject, PostStatusPendingReview, PostStatusDraft},
+		{TransitionPublish, PostStatusDraft, PostStatusPublished},
+		{TransitionPublish, PostStatusArchived, PostStatusPublished},
+		{TransitionUnpublish, PostStatusPublished, PostStatusArchived},
+		{TransitionUnpublish, PostStatusScheduled, PostStatusDraft},
+	}
+	for _, tc := range testCases {
+		to, err := NextPostStatus(tc.transition, tc.from)
+		require.NoError(t, err, "%s from %s", tc.transition, tc.from)
+		require.Equal(t, tc.to, to)
+	}
+
+	invalid := []struct {
+		transition PostTransition
+		from       string
+	}{
+		{TransitionSubmit, PostStatusPublished},
+		{TransitionApprove, PostStatusDraft},
+		{TransitionReject, PostStatusPublished},
+		{TransitionPublish, PostStatusPublished},
+		{TransitionUnpublish, PostStatusDraft},
+		{"delete", PostStatusDraft},
+	}
+	for _, tc := range invalid {
+		_, err := NextPostStatus(tc.transition, tc.from)
+		require.ErrorIs(t, err, ErrInvalidTransition, "%s from %s", tc.transition, tc.from)
+	}
+}
+
+func TestScheduledPostStatus(t *testing.T) {
+	now := time.Now()
+	future := sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+	past := sql.NullTime{Time: now.Add(-time.Hour), Valid: true}
+
+	draft := db.Post{Status: PostStatusDraft}
+	require.Equal(t, PostStatusPublished, ScheduledPostStatus(draft, PostStatusPublished, now))
+
+	draft.PublishAt = past
+	require.Equal(t, PostStatusPublished, ScheduledPostStatus(draft, PostStatusPublished, now))
+
+	draft.PublishAt = future
+	require.Equal(t, PostStatusScheduled, ScheduledPostStatus(draft, PostStatusPublished, now))
+	require.Equal(t, PostStatusPendingReview, ScheduledPostStatus(draft, PostStatusPendingReview, now))
+
+	scheduled := db.Post{Status: PostStatusScheduled, PublishAt: future}
+	require.Equal(t, PostStatusPublished, ScheduledPostStatus(scheduled, PostStatusPublished, now))
+}
+
+func TestValidatePostSchedule(t *testing.T) {
+	publishAt := time.Now()
+	later := publishAt.Add(time.Hour)
+
+	require.NoError(t, ValidatePostSchedule(nil, nil))
+	require.NoError(t, ValidatePostSchedule(&publishAt, nil))
+	require.NoError(t, ValidatePostSchedule(nil, &later))
+	require.NoError(t, ValidatePostSchedule(&publishAt, &later))
+	require.ErrorIs(t, ValidatePostSchedule(&later, &publishAt), ErrInvalidSchedule)
+	require.ErrorIs(t, ValidatePostSchedule(&publishAt, &publishAt), ErrInvalidSchedule)
+}
+
+func TestCanTransitionPost(t *testing.T) {
+	post := db.Post{ID: 1, UserID: 10}
+	authors := []db.UserPost{
+		{PostID: 1, UserID: 10, Order: 0},
+		{PostID: 1, UserID: 11, Order: 1},
+	}
+	author := Actor{UserID: 10, Role: RoleUser}
+	coAuthor := Actor{UserID: 11, Role: RoleUser}
+	stranger := Actor{UserID: 12, Role: RoleUser}
+	moderator := Actor{UserID: 13, Role: RoleModerator}
+
+	require.NoError(t, CanTransitionPost(author, post, authors, TransitionSubmit))
+	require.NoError(t, CanTransitionPost(coAuthor, post, authors, TransitionSubmit))
+	require.ErrorIs(t, CanTransitionPost(stranger, post, authors, TransitionSubmit), ErrNotPostAuthor)
+
+	for _, transition := range []PostTransition{TransitionApprove, TransitionReject, TransitionPublish} {
+		require.ErrorIs(t, CanTransitionPost(author, post, authors, transition), ErrNotReviewer)
+		require.NoError(t, CanTransitionPost(moderator, post, authors, transition))
+	}
+
+	require.NoError(t, CanTransitionPost(author, post, authors, TransitionUnpublish))
+	require.NoError(t, CanTransitionPost(moderator, post, authors, TransitionUnpublish))
+	require.ErrorIs(t, CanTransitionPost(coAuthor, post, authors, TransitionUnpublish), ErrCannotUnpublish)
+}
+
+func TestCanViewPost(t *testing.T) {
+	authors := []db.UserPost{{PostID: 1, UserID: 10}}
+	published := db.Post{ID: 1, UserID: 10, Status: PostStatusPublished}
+	draft := db.Post{ID: 1, UserID: 10, Status: PostStatusDraft}
+
+	require.NoError(t, CanViewPost(nil, published, nil))
+	require.ErrorIs(t, CanViewPost(nil, draft, authors), ErrPostNotVisible)
+	require.NoError(t, CanViewPost(&Actor{UserID: 10, Role: RoleUser}, draft, authors))
+	require.NoError(t, CanViewPost(&Actor{UserID: 13, Role: RoleModerator}, draft, authors))
+	require.ErrorIs(t, CanViewPost(&Actor{UserID: 12, Role: RoleUser}, draft, authors), ErrPostNotVisible)
+}
@@ -0,0 +1,82 @@
+// Package scheduler runs the background worker that moves posts through their
+// publish_at and unpublish_at times.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+// DefaultBatchSize is how many posts a single query claims. Rows are locked
+// with FOR UPDATE SKIP LOCKED, so several instances can poll the same database
+// without publishing a post twice.
+const DefaultBatchSize = 100
+
+type Scheduler struct {
+	store     db.Store
+	interval  time.Duration
+	batchSize int32
+}
+
+func New(store db.Store, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		store:     store,
+		interval:  interval,
+		batchSize: DefaultBatchSize,
+	}
+}
+
+// Run polls every interval until ctx is cancelled. It returns once the batch
+// in progress, if any, has finished.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Println("scheduler:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce publishes every post whose publish_at has passed and archives every
+// post whose unpublish_at has passed, a batch at a time.
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	published, err := s.drain(ctx, s.store.PublishDuePosts)
+	if published > 0 {
+		log.Printf("scheduler: published %d scheduled posts", published)
+	}
+	if err != nil {
+		return err
+	}
+
+	archived, err := s.drain(ctx, s.store.ArchiveExpiredPosts)
+	if archived > 0 {
+		log.Printf("scheduler: archived %d expired posts", archived)
+	}
+	return err
+}
+
+func (s *Scheduler) drain(ctx context.Context, next func(context.Context, int32) ([]db.Post, error)) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		posts, err := next(ctx, s.batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += len(posts)
+		if len(posts) < int(s.batchSize) {
+			break
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	s := New(store, time.Minute)
+	s.batchSize = 2
+
+	gomock.InOrder(
+		store.EXPECT().PublishDuePosts(gomock.Any(), int32(2)).Return([]db.Post{{ID: 1}, {ID: 2}}, nil),
+		store.EXPECT().PublishDuePosts(gomock.Any(), int32(2)).Return([]db.Post{{ID: 3}}, nil),
+		store.EXPECT().ArchiveExpiredPosts(gomock.Any(), int32(2)).Return([]db.Post{}, nil),
+	)
+
+	require.NoError(t, s.RunOnce(context.Background()))
+}
+
+func TestRunOnceStopsOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	s := New(store, time.Minute)
+
+	store.EXPECT().PublishDuePosts(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+	store.EXPECT().ArchiveExpiredPosts(gomock.Any(), gomock.Any()).Times(0)
+
+	require.Error(t, s.RunOnce(context.Background()))
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	s := New(store, time.Millisecond)
+
+	store.EXPECT().PublishDuePosts(gomock.Any(), gomock.Any()).Return([]db.Post{}, nil).AnyTimes()
+	store.EXPECT().ArchiveExpiredPosts(gomock.Any(), gomock.Any()).Return([]db.Post{}, nil).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop after cancel")
+	}
+}
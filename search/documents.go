@@ -0,0 +1,83 @@
+package search
+
+import (
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+// schema describes how a collection is searched.
+type schema struct {
+	// searchable fields, most important first.
+	searchable []string
+	filterable []string
+	// highlighted fields are returned with matches marked.
+	highlighted []string
+}
+
+var schemas = map[Kind]schema{
+	KindPosts: {
+		searchable:  []string{"title", "description", "content"},
+		filterable:  []string{"status", "language", "user_id", "author_ids", "taxonomy_ids", "created_at"},
+		highlighted: []string{"title", "content"},
+	},
+	KindMedia: {
+		searchable:  []string{"name", "alt", "description"},
+		filterable:  []string{"user_id", "mime_type", "created_at"},
+		highlighted: []string{"name"},
+	},
+	KindTaxonomies: {
+		searchable:  []string{"name", "description"},
+		filterable:  []string{},
+		highlighted: []string{"name"},
+	},
+}
+
+// PostDocument builds the index entry for a post. Dates are Unix seconds so
+// engines can compare them as numbers.
+func PostDocument(post db.Post, authorIDs, taxonomyIDs []int64) Document {
+	return Document{
+		ID: post.ID,
+		Fields: map[string]any{
+			"title":        post.Title,
+			"description":  post.Description,
+			"content":      post.Content,
+			"url":          post.Url,
+			"status":       post.Status,
+			"language":     post.Language,
+			"user_id":      post.UserID,
+			"author_ids":   nonNil(authorIDs),
+			"taxonomy_ids": nonNil(taxonomyIDs),
+			"created_at":   post.CreatedAt.Unix(),
+		},
+	}
+}
+
+func MediaDocument(media db.Medium) Document {
+	return Document{
+		ID: media.ID,
+		Fields: map[string]any{
+			"name":        media.Name,
+			"alt":         media.Alt,
+			"description": media.Description,
+			"mime_type":   media.MimeType,
+			"user_id":     media.UserID,
+			"created_at":  media.CreatedAt.Unix(),
+		},
+	}
+}
+
+func TaxonomyDocument(taxonomy db.Taxonomy) Document {
+	return Document{
+		ID: taxonomy.ID,
+		Fields: map[string]any{
+			"name":        taxonomy.Name,
+			"description": taxonomy.Description,
+		},
+	}
+}
+
+func nonNil(ids []int64) []int64 {
+	if ids == nil {
+		return []int64{}
+	}
+	return ids
+}
@@ -0,0 +1,331 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxResponseSize bounds how much of a Meilisearch response is read.
+	maxResponseSize  = 8 << 20
+	taskPollInterval = 50 * time.Millisecond
+	taskTimeout      = 30 * time.Second
+	snippetWords     = 30
+)
+
+type MeilisearchConfig struct {
+	URL    string
+	APIKey string
+	// IndexPrefix is prepended to each collection name, so several sites
+	// can share one Meilisearch instance.
+	IndexPrefix string
+	Client      *http.Client
+}
+
+// MeilisearchIndexer stores documents in Meilisearch, one index per
+// collection. Index settings are applied the first time a collection is
+// used by this process.
+type MeilisearchIndexer struct {
+	config MeilisearchConfig
+	client *http.Client
+
+	mu         sync.Mutex
+	configured map[Kind]bool
+}
+
+func NewMeilisearchIndexer(config MeilisearchConfig) (Indexer, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Meilisearch URL %q", config.URL)
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &MeilisearchIndexer{
+		config:     config,
+		client:     client,
+		configured: make(map[Kind]bool),
+	}, nil
+}
+
+func (m *MeilisearchIndexer) Index(ctx context.Context, kind Kind, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := m.ensureIndex(ctx, kind); err != nil {
+		return err
+	}
+
+	body := make([]map[string]any, len(docs))
+	for i, doc := range docs {
+		fields := make(map[string]any, len(doc.Fields)+1)
+		for name, value := range doc.Fields {
+			fields[name] = value
+		}
+		fields["id"] = doc.ID
+		body[i] = fields
+	}
+	return m.do(ctx, http.MethodPost, m.indexPath(kind)+"/documents?primaryKey=id", body, nil)
+}
+
+func (m *MeilisearchIndexer) Delete(ctx context.Context, kind Kind, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return m.do(ctx, http.MethodPost, m.indexPath(kind)+"/documents/delete-batch", ids, nil)
+}
+
+func (m *MeilisearchIndexer) Clear(ctx context.Context, kind Kind) error {
+	if err := m.ensureIndex(ctx, kind); err != nil {
+		return err
+	}
+	return m.do(ctx, http.MethodDelete, m.indexPath(kind)+"/documents", nil, nil)
+}
+
+// Meilisearch returns the indexed text as it is, so matches are delimited
+// with control characters that markHighlights turns into <mark> tags once
+// the rest has been escaped.
+const (
+	highlightPreTag  = "\x02"
+	highlightPostTag = "\x03"
+)
+
+// markHighlights escapes text returned by Meilisearch for HTML and marks the
+// matches it delimited.
+func markHighlights(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, highlightPreTag, "<mark>")
+	return strings.ReplaceAll(text, highlightPostTag, "</mark>")
+}
+
+type meilisearchSearchRequest struct {
+	Q                     string   `json:"q"`
+	Offset                int      `json:"offset"`
+	Limit                 int      `json:"limit"`
+	Filter                []string `json:"filter,omitempty"`
+	Facets                []string `json:"facets,omitempty"`
+	AttributesToRetrieve  []string `json:"attributesToRetrieve"`
+	AttributesToHighlight []string `json:"attributesToHighlight"`
+	AttributesToCrop      []string `json:"attributesToCrop,omitempty"`
+	CropLength            int      `json:"cropLength,omitempty"`
+	HighlightPreTag       string   `json:"highlightPreTag"`
+	HighlightPostTag      string   `json:"highlightPostTag"`
+	ShowRankingScore      bool     `json:"showRankingScore"`
+}
+
+type meilisearchSearchResponse struct {
+	Hits []struct {
+		ID           int64          `json:"id"`
+		Formatted    map[string]any `json:"_formatted"`
+		RankingScore float64        `json:"_rankingScore"`
+	} `json:"hits"`
+	EstimatedTotalHits int64                       `json:"estimatedTotalHits"`
+	FacetDistribution  map[string]map[string]int64 `json:"facetDistribution"`
+}
+
+func (m *MeilisearchIndexer) Query(ctx context.Context, kind Kind, query Query) (Result, error) {
+	schema, ok := schemas[kind]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown search collection %q", kind)
+	}
+	if err := m.ensureIndex(ctx, kind); err != nil {
+		return Result{}, err
+	}
+
+	req := meilisearchSearchRequest{
+		Q:                     query.Text,
+		Offset:                query.Offset,
+		Limit:                 query.Limit,
+		Facets:                query.Facets,
+		AttributesToRetrieve:  []string{"id"},
+		AttributesToHighlight: schema.highlighted,
+		HighlightPreTag:       highlightPreTag,
+		HighlightPostTag:      highlightPostTag,
+		ShowRankingScore:      true,
+	}
+	if kind == KindPosts {
+		req.AttributesToCrop = []string{"content"}
+		req.CropLength = snippetWords
+	}
+	for _, filter := range query.Filters {
+		expr, err := meilisearchFilter(filter)
+		if err != nil {
+			return Result{}, err
+		}
+		req.Filter = append(req.Filter, expr)
+	}
+
+	var rsp meilisearchSearchResponse
+	if err := m.do(ctx, http.MethodPost, m.indexPath(kind)+"/search", req, &rsp); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Hits:   make([]Hit, len(rsp.Hits)),
+		Total:  rsp.EstimatedTotalHits,
+		Facets: rsp.FacetDistribution,
+	}
+	for i, hit := range rsp.Hits {
+		highlights := make(map[string]string, len(schema.highlighted))
+		for _, field := range schema.highlighted {
+			if text, ok := hit.Formatted[field].(string); ok {
+				highlights[field] = markHighlights(text)
+			}
+		}
+		result.Hits[i] = Hit{ID: hit.ID, Score: hit.RankingScore, Highlights: highlights}
+	}
+	return result, nil
+}
+
+// meilisearchFilter renders a filter in Meilisearch's filter syntax.
+func meilisearchFilter(filter Filter) (string, error) {
+	switch filter.Op {
+	case OpEqual, OpGreaterEqual, OpLess:
+	default:
+		return "", fmt.Errorf("unsupported filter operator %q", filter.Op)
+	}
+
+	var value string
+	switch v := filter.Value.(type) {
+	case int:
+		value = strconv.Itoa(v)
+	case int64:
+		value = strconv.FormatInt(v, 10)
+	case string:
+		value = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+	default:
+		return "", fmt.Errorf("unsupported filter value %T", filter.Value)
+	}
+	return filter.Field + " " + filter.Op + " " + value, nil
+}
+
+// ensureIndex applies the collection's settings once, waiting for them to
+// take effect so the first filtered query does not fail.
+func (m *MeilisearchIndexer) ensureIndex(ctx context.Context, kind Kind) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.configured[kind] {
+		return nil
+	}
+	schema, ok := schemas[kind]
+	if !ok {
+		return fmt.Errorf("unknown search collection %q", kind)
+	}
+
+	settings := map[string]any{
+		"searchableAttributes": schema.searchable,
+		"filterableAttributes": schema.filterable,
+	}
+	var task struct {
+		TaskUID int64 `json:"taskUid"`
+	}
+	if err := m.do(ctx, http.MethodPatch, m.indexPath(kind)+"/settings", settings, &task); err != nil {
+		return err
+	}
+	if err := m.waitForTask(ctx, task.TaskUID); err != nil {
+		return err
+	}
+	m.configured[kind] = true
+	return nil
+}
+
+func (m *MeilisearchIndexer) waitForTask(ctx context.Context, uid int64) error {
+	ctx, cancel := context.WithTimeout(ctx, taskTimeout)
+	defer cancel()
+
+	for {
+		var task struct {
+			Status string `json:"status"`
+			Error  *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := m.do(ctx, http.MethodGet, "/tasks/"+strconv.FormatInt(uid, 10), nil, &task); err != nil {
+			return err
+		}
+		switch task.Status {
+		case "succeeded":
+			return nil
+		case "failed", "canceled":
+			if task.Error != nil {
+				return fmt.Errorf("meilisearch task %d %s: %s", uid, task.Status, task.Error.Message)
+			}
+			return fmt.Errorf("meilisearch task %d %s", uid, task.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for meilisearch task %d: %w", uid, ctx.Err())
+		case <-time.After(taskPollInterval):
+		}
+	}
+}
+
+func (m *MeilisearchIndexer) indexPath(kind Kind) string {
+	return "/indexes/" + url.PathEscape(m.config.IndexPrefix+string(kind))
+}
+
+// do sends body as JSON and decodes a successful response into v.
+func (m *MeilisearchIndexer) do(ctx context.Context, method, path string, body, v any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, m.config.URL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if m.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.config.APIKey)
+	}
+
+	rsp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("meilisearch request failed: %w", err)
+	}
+	defer rsp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rsp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode >= http.StatusMultipleChoices {
+		var apiErr struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("meilisearch: %s (%s)", apiErr.Message, apiErr.Code)
+		}
+		return fmt.Errorf("meilisearch request failed with status %d", rsp.StatusCode)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid meilisearch response: %w", err)
+	}
+	return nil
+}
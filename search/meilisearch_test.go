@@ -0,0 +1,160 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMeilisearch answers the handful of Meilisearch endpoints the indexer
+// uses and records what it was sent.
+type fakeMeilisearch struct {
+	t        *testing.T
+	settings map[string]any
+	indexed  []map[string]any
+	search   meilisearchSearchRequest
+}
+
+func (f *fakeMeilisearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer masterKey" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"The provided API key is invalid.","code":"invalid_api_key"}`))
+		return
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "PATCH /indexes/test_posts/settings":
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&f.settings))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"taskUid":1}`))
+	case "GET /tasks/1":
+		w.Write([]byte(`{"uid":1,"status":"succeeded"}`))
+	case "POST /indexes/test_posts/documents":
+		require.Equal(f.t, "id", r.URL.Query().Get("primaryKey"))
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&f.indexed))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"taskUid":2}`))
+	case "POST /indexes/test_posts/search":
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&f.search))
+		w.Write([]byte(`{
+			"hits": [{"id": 42, "_rankingScore": 0.9, "_formatted": {"id": "42", "title": "\u0002Go\u0003 tips", "content": "…idiomatic <b>\u0002Go\u0003</b>"}}],
+			"estimatedTotalHits": 1,
+			"facetDistribution": {"status": {"published": 1}}
+		}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Index not found.","code":"index_not_found"}`))
+	}
+}
+
+func TestMeilisearchIndexer(t *testing.T) {
+	fake := &fakeMeilisearch{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	index, err := NewMeilisearchIndexer(MeilisearchConfig{URL: server.URL + "/", APIKey: "masterKey", IndexPrefix: "test_"})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	err = index.Index(ctx, KindPosts, PostDocument(testPost(), []int64{7}, []int64{3}))
+	require.NoError(t, err)
+	require.Equal(t, []any{"status", "language", "user_id", "author_ids", "taxonomy_ids", "created_at"}, fake.settings["filterableAttributes"])
+	require.Len(t, fake.indexed, 1)
+	require.Equal(t, float64(42), fake.indexed[0]["id"])
+	require.Equal(t, "Go tips", fake.indexed[0]["title"])
+
+	result, err := index.Query(ctx, KindPosts, Query{
+		Text: "go",
+		Filters: []Filter{
+			{Field: "status", Op: OpEqual, Value: `say "hi"`},
+			{Field: "created_at", Op: OpGreaterEqual, Value: int64(1700000000)},
+		},
+		Facets: []string{"status"},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{`status = "say \"hi\""`, "created_at >= 1700000000"}, fake.search.Filter)
+	require.Equal(t, []string{"content"}, fake.search.AttributesToCrop)
+
+	require.Equal(t, int64(1), result.Total)
+	require.Equal(t, map[string]map[string]int64{"status": {"published": 1}}, result.Facets)
+	require.Len(t, result.Hits, 1)
+	require.Equal(t, int64(42), result.Hits[0].ID)
+	require.Equal(t, 0.9, result.Hits[0].Score)
+	require.Equal(t, "<mark>Go</mark> tips", result.Hits[0].Highlights["title"])
+	require.Equal(t, "…idiomatic &lt;b&gt;<mark>Go</mark>&lt;/b&gt;", result.Hits[0].Highlights["content"])
+}
+
+func TestMeilisearchIndexerErrors(t *testing.T) {
+	server := httptest.NewServer(&fakeMeilisearch{t: t})
+	defer server.Close()
+
+	_, err := NewMeilisearchIndexer(MeilisearchConfig{URL: "localhost:7700"})
+	require.Error(t, err)
+
+	index, err := NewMeilisearchIndexer(MeilisearchConfig{URL: server.URL, APIKey: "wrong", IndexPrefix: "test_"})
+	require.NoError(t, err)
+	err = index.Clear(context.Background(), KindPosts)
+	require.EqualError(t, err, "meilisearch: The provided API key is invalid. (invalid_api_key)")
+
+	index, err = NewMeilisearchIndexer(MeilisearchConfig{URL: server.URL, APIKey: "masterKey", IndexPrefix: "test_"})
+	require.NoError(t, err)
+	_, err = index.Query(context.Background(), KindPosts, Query{Filters: []Filter{{Field: "status", Op: "!=", Value: "draft"}}})
+	require.Error(t, err)
+}
+
+// TestMeilisearchIndexerIntegration runs against a real Meilisearch, for
+// example one started with `make meilisearch`.
+func TestMeilisearchIndexerIntegration(t *testing.T) {
+	url := os.Getenv("SEARCH_MEILISEARCH_TEST_URL")
+	if url == "" {
+		t.Skip("SEARCH_MEILISEARCH_TEST_URL is not set")
+	}
+
+	index, err := NewMeilisearchIndexer(MeilisearchConfig{
+		URL:         url,
+		APIKey:      envOrDefault("SEARCH_MEILISEARCH_TEST_API_KEY", "masterKey"),
+		IndexPrefix: "test_" + strconv.FormatInt(time.Now().UnixNano(), 36) + "_",
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	post := testPost()
+	require.NoError(t, index.Clear(ctx, KindPosts))
+	require.NoError(t, index.Index(ctx, KindPosts, PostDocument(post, []int64{7}, []int64{3})))
+
+	// Documents are indexed asynchronously, so poll until the post shows up.
+	// "idomatic" is misspelled on purpose to exercise typo tolerance.
+	var result Result
+	require.Eventually(t, func() bool {
+		result, err = index.Query(ctx, KindPosts, Query{
+			Text:    "idomatic",
+			Filters: []Filter{{Field: "taxonomy_ids", Op: OpEqual, Value: int64(3)}},
+			Facets:  []string{"status"},
+			Limit:   10,
+		})
+		return err == nil && len(result.Hits) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+	require.Equal(t, post.ID, result.Hits[0].ID)
+	require.Equal(t, int64(1), result.Facets["status"]["published"])
+
+	require.NoError(t, index.Delete(ctx, KindPosts, post.ID))
+	require.Eventually(t, func() bool {
+		result, err = index.Query(ctx, KindPosts, Query{Text: "idiomatic", Limit: 10})
+		return err == nil && len(result.Hits) == 0
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
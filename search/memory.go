@@ -0,0 +1,276 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// MemoryIndexer keeps documents in this process and matches words by prefix.
+// It has none of a real engine's typo tolerance and is meant for tests and
+// single-instance development.
+type MemoryIndexer struct {
+	mu   sync.RWMutex
+	docs map[Kind]map[int64]Document
+}
+
+func NewMemoryIndexer() *MemoryIndexer {
+	return &MemoryIndexer{docs: make(map[Kind]map[int64]Document)}
+}
+
+func (m *MemoryIndexer) Index(ctx context.Context, kind Kind, docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.docs[kind] == nil {
+		m.docs[kind] = make(map[int64]Document)
+	}
+	for _, doc := range docs {
+		m.docs[kind][doc.ID] = doc
+	}
+	return nil
+}
+
+func (m *MemoryIndexer) Delete(ctx context.Context, kind Kind, ids ...int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		delete(m.docs[kind], id)
+	}
+	return nil
+}
+
+func (m *MemoryIndexer) Clear(ctx context.Context, kind Kind) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.docs, kind)
+	return nil
+}
+
+// Get returns the stored document, for tests checking what was indexed.
+func (m *MemoryIndexer) Get(kind Kind, id int64) (Document, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	doc, ok := m.docs[kind][id]
+	return doc, ok
+}
+
+func (m *MemoryIndexer) Query(ctx context.Context, kind Kind, query Query) (Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	schema, ok := schemas[kind]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown search collection %q", kind)
+	}
+	terms := tokenize(query.Text)
+
+	var hits []Hit
+	result := Result{Facets: make(map[string]map[string]int64)}
+	for _, doc := range m.docs[kind] {
+		if !matchesFilters(doc, query.Filters) {
+			continue
+		}
+		score, ok := scoreDocument(doc, schema, terms)
+		if !ok {
+			continue
+		}
+
+		highlights := make(map[string]string, len(schema.highlighted))
+		for _, field := range schema.highlighted {
+			if text, ok := doc.Fields[field].(string); ok {
+				highlights[field] = highlight(text, terms)
+			}
+		}
+		hits = append(hits, Hit{ID: doc.ID, Score: score, Highlights: highlights})
+
+		for _, facet := range query.Facets {
+			countFacet(result.Facets, facet, doc.Fields[facet])
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID > hits[j].ID
+	})
+
+	result.Total = int64(len(hits))
+	start := min(query.Offset, len(hits))
+	end := len(hits)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, len(hits))
+	}
+	result.Hits = hits[start:end]
+	return result, nil
+}
+
+// scoreDocument requires every term to start a word in some searchable field
+// and weighs each match by how important the field is.
+func scoreDocument(doc Document, schema schema, terms []string) (float64, bool) {
+	if len(terms) == 0 {
+		return 0, true
+	}
+
+	var score float64
+	for _, term := range terms {
+		best := 0
+		for i, field := range schema.searchable {
+			text, _ := doc.Fields[field].(string)
+			if matchesTerm(tokenize(text), term) {
+				best = max(best, len(schema.searchable)-i)
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		score += float64(best)
+	}
+	return score / float64(len(terms)*len(schema.searchable)), true
+}
+
+func matchesTerm(words []string, term string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// highlight escapes text for HTML and wraps each word starting with one of
+// terms in <mark> tags.
+func highlight(text string, terms []string) string {
+	var b strings.Builder
+	word := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !word(runes[i]) {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && word(runes[j]) {
+			j++
+		}
+		w := string(runes[i:j])
+		if matchesAnyPrefix(strings.ToLower(w), terms) {
+			b.WriteString("<mark>" + w + "</mark>")
+		} else {
+			b.WriteString(w)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func matchesAnyPrefix(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesFilters(doc Document, filters []Filter) bool {
+	for _, filter := range filters {
+		if !matchesFilter(doc.Fields[filter.Field], filter) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesFilter(value any, filter Filter) bool {
+	if ids, ok := value.([]int64); ok {
+		if filter.Op != OpEqual {
+			return false
+		}
+		for _, id := range ids {
+			if compare(id, filter.Value) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch filter.Op {
+	case OpEqual:
+		return compare(value, filter.Value) == 0
+	case OpGreaterEqual:
+		c := compare(value, filter.Value)
+		return c == 0 || c == 1
+	case OpLess:
+		return compare(value, filter.Value) == -1
+	default:
+		return false
+	}
+}
+
+// compare orders two numbers, or reports whether two other values are equal.
+// It returns 2 for values that cannot be compared.
+func compare(a, b any) int {
+	x, aNumber := number(a)
+	y, bNumber := number(b)
+	if aNumber && bNumber {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		default:
+			return 0
+		}
+	}
+	if !aNumber && !bNumber && fmt.Sprint(a) == fmt.Sprint(b) {
+		return 0
+	}
+	return 2
+}
+
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func countFacet(facets map[string]map[string]int64, field string, value any) {
+	if facets[field] == nil {
+		facets[field] = make(map[string]int64)
+	}
+	if ids, ok := value.([]int64); ok {
+		for _, id := range ids {
+			facets[field][fmt.Sprint(id)]++
+		}
+		return
+	}
+	if value != nil {
+		facets[field][fmt.Sprint(value)]++
+	}
+}
@@ -0,0 +1,130 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIndexerQuery(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndexer()
+
+	err := index.Index(ctx, KindPosts,
+		Document{ID: 1, Fields: map[string]any{
+			"title": "Go tips", "content": "Write idiomatic code", "status": "published",
+			"language": "english", "author_ids": []int64{7}, "taxonomy_ids": []int64{3}, "created_at": int64(1000),
+		}},
+		Document{ID: 2, Fields: map[string]any{
+			"title": "Cooking", "content": "Good food goes with Go", "status": "published",
+			"language": "english", "author_ids": []int64{8}, "taxonomy_ids": []int64{}, "created_at": int64(2000),
+		}},
+		Document{ID: 3, Fields: map[string]any{
+			"title": "Gophers", "content": "Draft about go", "status": "draft",
+			"language": "english", "author_ids": []int64{7}, "taxonomy_ids": []int64{3}, "created_at": int64(3000),
+		}},
+	)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		query   Query
+		ids     []int64
+		checkFn func(t *testing.T, result Result)
+	}{
+		{
+			name:  "TitleMatchesRankFirst",
+			query: Query{Text: "go", Filters: []Filter{{Field: "status", Op: OpEqual, Value: "published"}}},
+			ids:   []int64{1, 2},
+			checkFn: func(t *testing.T, result Result) {
+				require.Equal(t, "<mark>Go</mark> tips", result.Hits[0].Highlights["title"])
+				require.Equal(t, "<mark>Good</mark> food <mark>goes</mark> with <mark>Go</mark>", result.Hits[1].Highlights["content"])
+			},
+		},
+		{
+			name:  "EveryTermMustMatch",
+			query: Query{Text: "go idiomatic"},
+			ids:   []int64{1},
+		},
+		{
+			name:  "ListFilter",
+			query: Query{Text: "go", Filters: []Filter{{Field: "author_ids", Op: OpEqual, Value: int64(7)}}},
+			ids:   []int64{3, 1},
+		},
+		{
+			name: "DateRange",
+			query: Query{Filters: []Filter{
+				{Field: "created_at", Op: OpGreaterEqual, Value: int64(2000)},
+				{Field: "created_at", Op: OpLess, Value: int64(3000)},
+			}},
+			ids: []int64{2},
+		},
+		{
+			name:  "Facets",
+			query: Query{Text: "go", Facets: []string{"status", "taxonomy_ids"}},
+			ids:   []int64{3, 1, 2},
+			checkFn: func(t *testing.T, result Result) {
+				require.Equal(t, map[string]int64{"published": 2, "draft": 1}, result.Facets["status"])
+				require.Equal(t, map[string]int64{"3": 2}, result.Facets["taxonomy_ids"])
+			},
+		},
+		{
+			name:  "Paging",
+			query: Query{Text: "go", Limit: 1, Offset: 1},
+			ids:   []int64{1},
+			checkFn: func(t *testing.T, result Result) {
+				require.Equal(t, int64(3), result.Total)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := index.Query(ctx, KindPosts, tc.query)
+			require.NoError(t, err)
+
+			ids := make([]int64, len(result.Hits))
+			for i, hit := range result.Hits {
+				ids[i] = hit.ID
+			}
+			require.Equal(t, tc.ids, ids)
+			if tc.checkFn != nil {
+				tc.checkFn(t, result)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexerDeleteAndClear(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndexer()
+
+	require.NoError(t, index.Index(ctx, KindTaxonomies,
+		Document{ID: 1, Fields: map[string]any{"name": "golang"}},
+		Document{ID: 2, Fields: map[string]any{"name": "gophers"}},
+	))
+
+	require.NoError(t, index.Delete(ctx, KindTaxonomies, 1))
+	_, ok := index.Get(KindTaxonomies, 1)
+	require.False(t, ok)
+	_, ok = index.Get(KindTaxonomies, 2)
+	require.True(t, ok)
+
+	require.NoError(t, index.Clear(ctx, KindTaxonomies))
+	result, err := index.Query(ctx, KindTaxonomies, Query{Text: "go"})
+	require.NoError(t, err)
+	require.Empty(t, result.Hits)
+
+	_, err = index.Query(ctx, Kind("users"), Query{})
+	require.Error(t, err)
+}
+
+func TestHighlightEscapesHTML(t *testing.T) {
+	require.Equal(t,
+		`&lt;img src=x onerror=&#34;<mark>go</mark>()&#34;&gt; &amp; <mark>Go</mark>`,
+		highlight(`<img src=x onerror="go()"> & Go`, []string{"go"}),
+	)
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+// RebuildBatchSize is how many rows Rebuild reads and indexes at a time.
+const RebuildBatchSize = 500
+
+// Rebuild clears every collection and indexes all posts, media and
+// taxonomies from the database. It returns how many documents of each kind
+// were indexed.
+func Rebuild(ctx context.Context, store db.Store, indexer Indexer) (map[Kind]int, error) {
+	counts := make(map[Kind]int, len(Kinds))
+	for _, kind := range Kinds {
+		if err := indexer.Clear(ctx, kind); err != nil {
+			return counts, fmt.Errorf("failed to clear %s: %w", kind, err)
+		}
+	}
+
+	for offset := int32(0); ; offset += RebuildBatchSize {
+		posts, err := store.ListPosts(ctx, db.ListPostsParams{Limit: RebuildBatchSize, Offset: offset})
+		if err != nil {
+			return counts, fmt.Errorf("failed to list posts: %w", err)
+		}
+		docs := make([]Document, len(posts))
+		for i, post := range posts {
+			docs[i], err = LoadPostDocument(ctx, store, post.ID)
+			if err != nil {
+				return counts, fmt.Errorf("failed to load post %d: %w", post.ID, err)
+			}
+		}
+		if err := indexer.Index(ctx, KindPosts, docs...); err != nil {
+			return counts, fmt.Errorf("failed to index posts: %w", err)
+		}
+		counts[KindPosts] += len(docs)
+		if len(posts) < RebuildBatchSize {
+			break
+		}
+	}
+
+	for offset := int32(0); ; offset += RebuildBatchSize {
+		media, err := store.ListMedia(ctx, db.ListMediaParams{Limit: RebuildBatchSize, Offset: offset})
+		if err != nil {
+			return counts, fmt.Errorf("failed to list media: %w", err)
+		}
+		docs := make([]Document, len(media))
+		for i, m := range media {
+			docs[i] = MediaDocument(m)
+		}
+		if err := indexer.Index(ctx, KindMedia, docs...); err != nil {
+			return counts, fmt.Errorf("failed to index media: %w", err)
+		}
+		counts[KindMedia] += len(docs)
+		if len(media) < RebuildBatchSize {
+			break
+		}
+	}
+
+	for offset := int32(0); ; offset += RebuildBatchSize {
+		taxonomies, err := store.ListTaxonomies(ctx, db.ListTaxonomiesParams{Limit: RebuildBatchSize, Offset: offset})
+		if err != nil {
+			return counts, fmt.Errorf("failed to list taxonomies: %w", err)
+		}
+		docs := make([]Document, len(taxonomies))
+		for i, taxonomy := range taxonomies {
+			docs[i] = TaxonomyDocument(taxonomy)
+		}
+		if err := indexer.Index(ctx, KindTaxonomies, docs...); err != nil {
+			return counts, fmt.Errorf("failed to index taxonomies: %w", err)
+		}
+		counts[KindTaxonomies] += len(docs)
+		if len(taxonomies) < RebuildBatchSize {
+			break
+		}
+	}
+
+	return counts, nil
+}
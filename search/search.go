@@ -0,0 +1,104 @@
+// Package search keeps an external search engine in step with the posts,
+// media and taxonomies in the database. Postgres full-text search needs none
+// of this; an Indexer is only configured for engines with typo tolerance and
+// faceting that Postgres lacks.
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+// Kind names the collection a document belongs to.
+type Kind string
+
+const (
+	KindPosts      Kind = "posts"
+	KindMedia      Kind = "media"
+	KindTaxonomies Kind = "taxonomies"
+)
+
+// Kinds lists every collection that is indexed.
+var Kinds = []Kind{KindPosts, KindMedia, KindTaxonomies}
+
+// Document is one record in an index. Fields holds both the text that is
+// searched and the attributes queries filter and facet on.
+type Document struct {
+	ID     int64
+	Fields map[string]any
+}
+
+// Filter operators.
+const (
+	OpEqual        = "="
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+)
+
+// Filter restricts a query to documents whose field compares to Value. On a
+// list field, OpEqual matches when any element equals Value.
+type Filter struct {
+	Field string
+	Op    string
+	Value any
+}
+
+// Query is a full-text query against one collection. Filters are ANDed.
+// Facets names the fields to count values of across all matches.
+type Query struct {
+	Text    string
+	Filters []Filter
+	Facets  []string
+	Limit   int
+	Offset  int
+}
+
+// Hit is a matching document. Highlights holds searchable fields as HTML:
+// the text is escaped and the matched words are wrapped in <mark> tags.
+type Hit struct {
+	ID         int64
+	Score      float64
+	Highlights map[string]string
+}
+
+// Result is one page of hits, best first. Facets maps each requested field
+// to the number of matches per value.
+type Result struct {
+	Hits   []Hit
+	Total  int64
+	Facets map[string]map[string]int64
+}
+
+// Indexer stores documents in a search engine and queries them.
+type Indexer interface {
+	// Index adds the documents, replacing any with the same ID.
+	Index(ctx context.Context, kind Kind, docs ...Document) error
+
+	Delete(ctx context.Context, kind Kind, ids ...int64) error
+
+	Query(ctx context.Context, kind Kind, query Query) (Result, error)
+
+	// Clear removes every document of kind.
+	Clear(ctx context.Context, kind Kind) error
+}
+
+// New returns the indexer selected by config.SearchDriver, or nil when
+// search is left to Postgres.
+func New(config util.Config) (Indexer, error) {
+	switch config.SearchDriver {
+	case "", "postgres":
+		return nil, nil
+	case "meilisearch":
+		return NewMeilisearchIndexer(MeilisearchConfig{
+			URL:         config.SearchMeilisearchURL,
+			APIKey:      config.SearchMeilisearchAPIKey,
+			IndexPrefix: config.SearchIndexPrefix,
+		})
+	case "memory":
+		return NewMemoryIndexer(), nil
+	default:
+		return nil, fmt.Errorf("unknown search driver %q", config.SearchDriver)
+	}
+}
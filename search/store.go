@@ -0,0 +1,350 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+// IndexingStore wraps a db.Store and pushes every post, media item and
+// taxonomy it writes to an Indexer once the write has committed. The
+// database stays the source of truth: indexing errors are logged rather than
+// failing a write that already succeeded, and `cmd/searchindex` rebuilds an
+// index that has drifted.
+type IndexingStore struct {
+	db.Store
+	indexer Indexer
+}
+
+func NewIndexingStore(store db.Store, indexer Indexer) db.Store {
+	return &IndexingStore{Store: store, indexer: indexer}
+}
+
+func (store *IndexingStore) CreatePostTx(ctx context.Context, arg db.CreatePostTxParams) (db.CreatePostTxResult, error) {
+	result, err := store.Store.CreatePostTx(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, result.Post.ID)
+	}
+	return result, err
+}
+
+func (store *IndexingStore) CreatePostWithTaxonomiesTx(ctx context.Context, arg db.CreatePostWithTaxonomiesTxParams) (db.CreatePostWithTaxonomiesTxResult, error) {
+	result, err := store.Store.CreatePostWithTaxonomiesTx(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, result.Post.ID)
+	}
+	return result, err
+}
+
+func (store *IndexingStore) CreatePostWithMediaTx(ctx context.Context, arg db.CreatePostWithMediaTxParams) (db.CreatePostWithMediaTxResult, error) {
+	result, err := store.Store.CreatePostWithMediaTx(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, result.Post.ID)
+	}
+	return result, err
+}
+
+func (store *IndexingStore) UpdatePostTx(ctx context.Context, arg db.UpdatePostTxParams) (db.UpdatePostTxResult, error) {
+	result, err := store.Store.UpdatePostTx(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, result.Post.ID)
+	}
+	return result, err
+}
+
+func (store *IndexingStore) UpdatePostTaxonomiesTx(ctx context.Context, arg db.UpdatePostTaxonomiesTxParams) error {
+	err := store.Store.UpdatePostTaxonomiesTx(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, arg.PostID)
+	}
+	return err
+}
+
+func (store *IndexingStore) DeletePostTx(ctx context.Context, arg db.DeletePostTxParams) error {
+	err := store.Store.DeletePostTx(ctx, arg)
+	if err == nil {
+		store.delete(ctx, KindPosts, arg.ID)
+	}
+	return err
+}
+
+func (store *IndexingStore) UpdatePostStatus(ctx context.Context, arg db.UpdatePostStatusParams) (db.Post, error) {
+	post, err := store.Store.UpdatePostStatus(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, post.ID)
+	}
+	return post, err
+}
+
+func (store *IndexingStore) UpdatePostSchedule(ctx context.Context, arg db.UpdatePostScheduleParams) (db.Post, error) {
+	post, err := store.Store.UpdatePostSchedule(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, post.ID)
+	}
+	return post, err
+}
+
+func (store *IndexingStore) PublishDuePosts(ctx context.Context, limit int32) ([]db.Post, error) {
+	posts, err := store.Store.PublishDuePosts(ctx, limit)
+	if err == nil {
+		store.indexPosts(ctx, postIDs(posts)...)
+	}
+	return posts, err
+}
+
+func (store *IndexingStore) ArchiveExpiredPosts(ctx context.Context, limit int32) ([]db.Post, error) {
+	posts, err := store.Store.ArchiveExpiredPosts(ctx, limit)
+	if err == nil {
+		store.indexPosts(ctx, postIDs(posts)...)
+	}
+	return posts, err
+}
+
+func (store *IndexingStore) UpdatePostMediaTx(ctx context.Context, arg db.UpdatePostMediaTxParams) error {
+	err := store.Store.UpdatePostMediaTx(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, arg.PostID)
+	}
+	return err
+}
+
+// UpdateUserTx re-indexes the user's posts when the username they carry
+// changes.
+func (store *IndexingStore) UpdateUserTx(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+	result, err := store.Store.UpdateUserTx(ctx, arg)
+	if err == nil && arg.Username != "" {
+		postIDs, err := store.Store.ListPostIDsByUser(ctx, arg.ID)
+		if err != nil {
+			log.Printf("search: failed to list posts of user %d: %v", arg.ID, err)
+		}
+		store.indexPosts(ctx, postIDs...)
+	}
+	return result, err
+}
+
+// DeleteUserTx removes the user's posts and media from the index. Posts the
+// user only co-authored are re-indexed, since they lose an author.
+func (store *IndexingStore) DeleteUserTx(ctx context.Context, arg db.DeleteUserTxParams) error {
+	postIDs, mediaIDs := store.userContent(ctx, arg.ID)
+	err := store.Store.DeleteUserTx(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, postIDs...)
+		store.delete(ctx, KindMedia, mediaIDs...)
+	}
+	return err
+}
+
+// DeleteUserWithTransferTx re-indexes the posts and media handed over to
+// another user.
+func (store *IndexingStore) DeleteUserWithTransferTx(ctx context.Context, arg db.DeleteUserWithTransferTxParams) error {
+	postIDs, mediaIDs := store.userContent(ctx, arg.UserID)
+	err := store.Store.DeleteUserWithTransferTx(ctx, arg)
+	if err == nil {
+		store.indexPosts(ctx, postIDs...)
+		store.indexMedia(ctx, mediaIDs...)
+	}
+	return err
+}
+
+func (store *IndexingStore) CreateMedia(ctx context.Context, arg db.CreateMediaParams) (db.Medium, error) {
+	media, err := store.Store.CreateMedia(ctx, arg)
+	if err == nil {
+		store.index(ctx, KindMedia, MediaDocument(media))
+	}
+	return media, err
+}
+
+func (store *IndexingStore) CreateMediaAndLinkTx(ctx context.Context, arg db.CreateMediaAndLinkTxParams) (db.CreateMediaAndLinkTxResult, error) {
+	result, err := store.Store.CreateMediaAndLinkTx(ctx, arg)
+	if err == nil {
+		store.index(ctx, KindMedia, MediaDocument(result.Media))
+	}
+	return result, err
+}
+
+func (store *IndexingStore) CreateMediaWithRenditionsTx(ctx context.Context, arg db.CreateMediaWithRenditionsTxParams) (db.CreateMediaWithRenditionsTxResult, error) {
+	result, err := store.Store.CreateMediaWithRenditionsTx(ctx, arg)
+	if err == nil {
+		store.index(ctx, KindMedia, MediaDocument(result.Media))
+	}
+	return result, err
+}
+
+func (store *IndexingStore) UpdateMedia(ctx context.Context, arg db.UpdateMediaParams) (db.Medium, error) {
+	media, err := store.Store.UpdateMedia(ctx, arg)
+	if err == nil {
+		store.index(ctx, KindMedia, MediaDocument(media))
+	}
+	return media, err
+}
+
+func (store *IndexingStore) DeleteMediaTx(ctx context.Context, arg db.DeleteMediaTxParams) error {
+	err := store.Store.DeleteMediaTx(ctx, arg)
+	if err == nil {
+		store.delete(ctx, KindMedia, arg.MediaID)
+	}
+	return err
+}
+
+func (store *IndexingStore) CreateTaxonomy(ctx context.Context, arg db.CreateTaxonomyParams) (db.Taxonomy, error) {
+	taxonomy, err := store.Store.CreateTaxonomy(ctx, arg)
+	if err == nil {
+		store.index(ctx, KindTaxonomies, TaxonomyDocument(taxonomy))
+	}
+	return taxonomy, err
+}
+
+func (store *IndexingStore) CreateTaxonomyAndLinkTx(ctx context.Context, arg db.CreateTaxonomyAndLinkTxParams) (db.CreateTaxonomyAndLinkTxResult, error) {
+	result, err := store.Store.CreateTaxonomyAndLinkTx(ctx, arg)
+	if err == nil {
+		store.index(ctx, KindTaxonomies, TaxonomyDocument(result.Taxonomy))
+		store.indexPosts(ctx, arg.PostID)
+	}
+	return result, err
+}
+
+func (store *IndexingStore) UpdateTaxonomy(ctx context.Context, arg db.UpdateTaxonomyParams) (db.Taxonomy, error) {
+	taxonomy, err := store.Store.UpdateTaxonomy(ctx, arg)
+	if err == nil {
+		store.index(ctx, KindTaxonomies, TaxonomyDocument(taxonomy))
+	}
+	return taxonomy, err
+}
+
+// DeleteTaxonomy re-indexes the posts that carried the taxonomy, since the
+// index filters posts on their taxonomy IDs.
+func (store *IndexingStore) DeleteTaxonomy(ctx context.Context, arg db.DeleteTaxonomyParams) (int64, error) {
+	postIDs := store.taxonomyPosts(ctx, arg.ID)
+	rows, err := store.Store.DeleteTaxonomy(ctx, arg)
+	if err == nil && rows > 0 {
+		store.delete(ctx, KindTaxonomies, arg.ID)
+		store.indexPosts(ctx, postIDs...)
+	}
+	return rows, err
+}
+
+func (store *IndexingStore) DeleteTaxonomyTx(ctx context.Context, arg db.DeleteTaxonomyTxParams) error {
+	postIDs := store.taxonomyPosts(ctx, arg.ID)
+	err := store.Store.DeleteTaxonomyTx(ctx, arg)
+	if err == nil {
+		store.delete(ctx, KindTaxonomies, arg.ID)
+		store.indexPosts(ctx, postIDs...)
+	}
+	return err
+}
+
+// indexPosts re-reads each post with its authors and taxonomies, since the
+// index filters on both. Posts that no longer exist are removed.
+func (store *IndexingStore) indexPosts(ctx context.Context, ids ...int64) {
+	docs := make([]Document, 0, len(ids))
+	var gone []int64
+	for _, id := range ids {
+		doc, err := LoadPostDocument(ctx, store.Store, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			gone = append(gone, id)
+			continue
+		}
+		if err != nil {
+			log.Printf("search: failed to load post %d for indexing: %v", id, err)
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	store.index(ctx, KindPosts, docs...)
+	store.delete(ctx, KindPosts, gone...)
+}
+
+func (store *IndexingStore) indexMedia(ctx context.Context, ids ...int64) {
+	if len(ids) == 0 {
+		return
+	}
+	media, err := store.Store.ListMediaByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("search: failed to load media %v for indexing: %v", ids, err)
+		return
+	}
+	docs := make([]Document, len(media))
+	for i, medium := range media {
+		docs[i] = MediaDocument(medium)
+	}
+	store.index(ctx, KindMedia, docs...)
+}
+
+// userContent lists the posts a user owns or co-authored and the media they
+// own. It is read before a write that changes them, which may leave nothing
+// to find afterwards.
+func (store *IndexingStore) userContent(ctx context.Context, userID int64) (postIDs, mediaIDs []int64) {
+	postIDs, err := store.Store.ListPostIDsByUser(ctx, userID)
+	if err != nil {
+		log.Printf("search: failed to list posts of user %d: %v", userID, err)
+	}
+	mediaIDs, err = store.Store.ListMediaIDsByUser(ctx, userID)
+	if err != nil {
+		log.Printf("search: failed to list media of user %d: %v", userID, err)
+	}
+	return postIDs, mediaIDs
+}
+
+func (store *IndexingStore) taxonomyPosts(ctx context.Context, taxonomyID int64) []int64 {
+	postIDs, err := store.Store.ListPostIDsByTaxonomy(ctx, taxonomyID)
+	if err != nil {
+		log.Printf("search: failed to list posts of taxonomy %d: %v", taxonomyID, err)
+	}
+	return postIDs
+}
+
+func (store *IndexingStore) index(ctx context.Context, kind Kind, docs ...Document) {
+	if len(docs) == 0 {
+		return
+	}
+	if err := store.indexer.Index(ctx, kind, docs...); err != nil {
+		log.Printf("search: failed to index %d %s: %v", len(docs), kind, err)
+	}
+}
+
+func (store *IndexingStore) delete(ctx context.Context, kind Kind, ids ...int64) {
+	if len(ids) == 0 {
+		return
+	}
+	if err := store.indexer.Delete(ctx, kind, ids...); err != nil {
+		log.Printf("search: failed to remove %s %v from the index: %v", kind, ids, err)
+	}
+}
+
+// LoadPostDocument reads a post, its authors and its taxonomies and builds
+// its index entry.
+func LoadPostDocument(ctx context.Context, store db.Querier, id int64) (Document, error) {
+	post, err := store.GetPost(ctx, id)
+	if err != nil {
+		return Document{}, err
+	}
+	authors, err := store.ListPostAuthors(ctx, id)
+	if err != nil {
+		return Document{}, err
+	}
+	taxonomies, err := store.GetPostTaxonomies(ctx, id)
+	if err != nil {
+		return Document{}, err
+	}
+
+	authorIDs := make([]int64, len(authors))
+	for i, author := range authors {
+		authorIDs[i] = author.UserID
+	}
+	taxonomyIDs := make([]int64, len(taxonomies))
+	for i, taxonomy := range taxonomies {
+		taxonomyIDs[i] = taxonomy.ID
+	}
+	return PostDocument(post, authorIDs, taxonomyIDs), nil
+}
+
+func postIDs(posts []db.Post) []int64 {
+	ids := make([]int64, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
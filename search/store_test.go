@@ -0,0 +1,233 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+)
+
+func testPost() db.Post {
+	return db.Post{
+		ID:        42,
+		Title:     "Go tips",
+		Content:   "Write idiomatic code",
+		UserID:    7,
+		Status:    "published",
+		Language:  "english",
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestIndexingStoreIndexesPosts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	post := testPost()
+	mock := mockdb.NewMockStore(ctrl)
+	mock.EXPECT().
+		UpdatePostStatus(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(post, nil)
+	mock.EXPECT().GetPost(gomock.Any(), post.ID).Times(1).Return(post, nil)
+	mock.EXPECT().
+		ListPostAuthors(gomock.Any(), post.ID).
+		Times(1).
+		Return([]db.UserPost{{PostID: post.ID, UserID: 7}, {PostID: post.ID, UserID: 8}}, nil)
+	mock.EXPECT().
+		GetPostTaxonomies(gomock.Any(), post.ID).
+		Times(1).
+		Return([]db.Taxonomy{{ID: 3, Name: "golang"}}, nil)
+
+	index := NewMemoryIndexer()
+	store := NewIndexingStore(mock, index)
+
+	_, err := store.UpdatePostStatus(context.Background(), db.UpdatePostStatusParams{ID: post.ID, Status: "published"})
+	require.NoError(t, err)
+
+	doc, ok := index.Get(KindPosts, post.ID)
+	require.True(t, ok)
+	require.Equal(t, post.Title, doc.Fields["title"])
+	require.Equal(t, []int64{7, 8}, doc.Fields["author_ids"])
+	require.Equal(t, []int64{3}, doc.Fields["taxonomy_ids"])
+	require.Equal(t, post.CreatedAt.Unix(), doc.Fields["created_at"])
+}
+
+func TestIndexingStoreSkipsFailedWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockdb.NewMockStore(ctrl)
+	mock.EXPECT().
+		UpdateMedia(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.Medium{}, sql.ErrNoRows)
+	mock.EXPECT().
+		DeletePostTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(sql.ErrConnDone)
+
+	index := NewMemoryIndexer()
+	require.NoError(t, index.Index(context.Background(), KindPosts, PostDocument(testPost(), nil, nil)))
+	store := NewIndexingStore(mock, index)
+
+	_, err := store.UpdateMedia(context.Background(), db.UpdateMediaParams{ID: 1})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, ok := index.Get(KindMedia, 1)
+	require.False(t, ok)
+
+	err = store.DeletePostTx(context.Background(), db.DeletePostTxParams{ID: 42})
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	_, ok = index.Get(KindPosts, 42)
+	require.True(t, ok)
+}
+
+func TestIndexingStoreDeletes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	post := testPost()
+	mock := mockdb.NewMockStore(ctrl)
+	mock.EXPECT().ListPostIDsByTaxonomy(gomock.Any(), int64(3)).Times(1).Return([]int64{post.ID}, nil)
+	mock.EXPECT().
+		DeleteTaxonomyTx(gomock.Any(), db.DeleteTaxonomyTxParams{ID: 3}).
+		Times(1).
+		Return(nil)
+	mock.EXPECT().GetPost(gomock.Any(), post.ID).Times(1).Return(post, nil)
+	mock.EXPECT().
+		ListPostAuthors(gomock.Any(), post.ID).
+		Times(1).
+		Return([]db.UserPost{{PostID: post.ID, UserID: 7}}, nil)
+	mock.EXPECT().GetPostTaxonomies(gomock.Any(), post.ID).Times(1).Return([]db.Taxonomy{}, nil)
+
+	index := NewMemoryIndexer()
+	ctx := context.Background()
+	require.NoError(t, index.Index(ctx, KindTaxonomies, TaxonomyDocument(db.Taxonomy{ID: 3, Name: "golang"})))
+	require.NoError(t, index.Index(ctx, KindPosts, PostDocument(post, []int64{7}, []int64{3})))
+	store := NewIndexingStore(mock, index)
+
+	require.NoError(t, store.DeleteTaxonomyTx(ctx, db.DeleteTaxonomyTxParams{ID: 3}))
+	_, ok := index.Get(KindTaxonomies, 3)
+	require.False(t, ok)
+	doc, ok := index.Get(KindPosts, post.ID)
+	require.True(t, ok)
+	require.Empty(t, doc.Fields["taxonomy_ids"])
+}
+
+func TestIndexingStoreDeletesUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	owned := testPost()
+	coAuthored := testPost()
+	coAuthored.ID = 43
+	coAuthored.UserID = 8
+
+	mock := mockdb.NewMockStore(ctrl)
+	mock.EXPECT().ListPostIDsByUser(gomock.Any(), int64(7)).Times(1).Return([]int64{owned.ID, coAuthored.ID}, nil)
+	mock.EXPECT().ListMediaIDsByUser(gomock.Any(), int64(7)).Times(1).Return([]int64{5}, nil)
+	mock.EXPECT().DeleteUserTx(gomock.Any(), db.DeleteUserTxParams{ID: 7}).Times(1).Return(nil)
+	mock.EXPECT().GetPost(gomock.Any(), owned.ID).Times(1).Return(db.Post{}, sql.ErrNoRows)
+	mock.EXPECT().GetPost(gomock.Any(), coAuthored.ID).Times(1).Return(coAuthored, nil)
+	mock.EXPECT().
+		ListPostAuthors(gomock.Any(), coAuthored.ID).
+		Times(1).
+		Return([]db.UserPost{{PostID: coAuthored.ID, UserID: 8}}, nil)
+	mock.EXPECT().GetPostTaxonomies(gomock.Any(), coAuthored.ID).Times(1).Return([]db.Taxonomy{}, nil)
+
+	index := NewMemoryIndexer()
+	ctx := context.Background()
+	require.NoError(t, index.Index(ctx, KindPosts,
+		PostDocument(owned, []int64{7}, nil),
+		PostDocument(coAuthored, []int64{7, 8}, nil),
+	))
+	require.NoError(t, index.Index(ctx, KindMedia, MediaDocument(db.Medium{ID: 5, UserID: 7})))
+	store := NewIndexingStore(mock, index)
+
+	require.NoError(t, store.DeleteUserTx(ctx, db.DeleteUserTxParams{ID: 7}))
+	_, ok := index.Get(KindPosts, owned.ID)
+	require.False(t, ok)
+	doc, ok := index.Get(KindPosts, coAuthored.ID)
+	require.True(t, ok)
+	require.Equal(t, []int64{8}, doc.Fields["author_ids"])
+	_, ok = index.Get(KindMedia, 5)
+	require.False(t, ok)
+}
+
+func TestIndexingStoreTransfersUserContent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	transferred := testPost()
+	transferred.UserID = 1
+
+	mock := mockdb.NewMockStore(ctrl)
+	mock.EXPECT().ListPostIDsByUser(gomock.Any(), int64(7)).Times(1).Return([]int64{transferred.ID}, nil)
+	mock.EXPECT().ListMediaIDsByUser(gomock.Any(), int64(7)).Times(1).Return([]int64{5}, nil)
+	mock.EXPECT().
+		DeleteUserWithTransferTx(gomock.Any(), db.DeleteUserWithTransferTxParams{UserID: 7, TransferToID: 1}).
+		Times(1).
+		Return(nil)
+	mock.EXPECT().GetPost(gomock.Any(), transferred.ID).Times(1).Return(transferred, nil)
+	mock.EXPECT().
+		ListPostAuthors(gomock.Any(), transferred.ID).
+		Times(1).
+		Return([]db.UserPost{{PostID: transferred.ID, UserID: 1}}, nil)
+	mock.EXPECT().GetPostTaxonomies(gomock.Any(), transferred.ID).Times(1).Return([]db.Taxonomy{}, nil)
+	mock.EXPECT().ListMediaByIDs(gomock.Any(), []int64{5}).Times(1).Return([]db.Medium{{ID: 5, UserID: 1}}, nil)
+
+	index := NewMemoryIndexer()
+	store := NewIndexingStore(mock, index)
+
+	ctx := context.Background()
+	require.NoError(t, store.DeleteUserWithTransferTx(ctx, db.DeleteUserWithTransferTxParams{UserID: 7, TransferToID: 1}))
+	doc, ok := index.Get(KindPosts, transferred.ID)
+	require.True(t, ok)
+	require.Equal(t, int64(1), doc.Fields["user_id"])
+	doc, ok = index.Get(KindMedia, 5)
+	require.True(t, ok)
+	require.Equal(t, int64(1), doc.Fields["user_id"])
+}
+
+func TestRebuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	post := testPost()
+	mock := mockdb.NewMockStore(ctrl)
+	mock.EXPECT().
+		ListPosts(gomock.Any(), db.ListPostsParams{Limit: RebuildBatchSize, Offset: 0}).
+		Times(1).
+		Return([]db.Post{post}, nil)
+	mock.EXPECT().GetPost(gomock.Any(), post.ID).Times(1).Return(post, nil)
+	mock.EXPECT().ListPostAuthors(gomock.Any(), post.ID).Times(1).Return(nil, nil)
+	mock.EXPECT().GetPostTaxonomies(gomock.Any(), post.ID).Times(1).Return(nil, nil)
+	mock.EXPECT().
+		ListMedia(gomock.Any(), db.ListMediaParams{Limit: RebuildBatchSize, Offset: 0}).
+		Times(1).
+		Return([]db.Medium{{ID: 5, Name: "logo.png"}}, nil)
+	mock.EXPECT().
+		ListTaxonomies(gomock.Any(), db.ListTaxonomiesParams{Limit: RebuildBatchSize, Offset: 0}).
+		Times(1).
+		Return([]db.Taxonomy{}, nil)
+
+	index := NewMemoryIndexer()
+	require.NoError(t, index.Index(context.Background(), KindMedia, Document{ID: 99, Fields: map[string]any{"name": "stale"}}))
+
+	counts, err := Rebuild(context.Background(), mock, index)
+	require.NoError(t, err)
+	require.Equal(t, map[Kind]int{KindPosts: 1, KindMedia: 1, KindTaxonomies: 0}, counts)
+
+	_, ok := index.Get(KindMedia, 99)
+	require.False(t, ok)
+	_, ok = index.Get(KindMedia, 5)
+	require.True(t, ok)
+	_, ok = index.Get(KindPosts, post.ID)
+	require.True(t, ok)
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-live-cms/go-live-cms/util"
+)
+
+var (
+	ErrNotFound   = fmt.Errorf("object not found")
+	ErrInvalidKey = fmt.Errorf("invalid object key")
+)
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Backend stores media files under slash-separated keys.
+type Backend interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (ObjectInfo, error)
+
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+
+	Delete(ctx context.Context, key string) error
+
+	// DeletePrefix removes every object whose key lies below prefix, which is
+	// given without a trailing slash. Finding nothing there is not an error.
+	DeletePrefix(ctx context.Context, prefix string) error
+
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// New returns the backend selected by config.StorageDriver.
+func New(config util.Config) (Backend, error) {
+	switch config.StorageDriver {
+	case "", "local":
+		return NewLocalBackend(config.StorageLocalPath, config.StoragePublicURL)
+	case "s3":
+		publicURL := config.StoragePublicURL
+		if !strings.Contains(publicURL, "://") {
+			// A bare path such as the local default "/uploads" means nothing to a bucket.
+			publicURL = ""
+		}
+		return NewS3Backend(S3Config{
+			Endpoint:        config.StorageS3Endpoint,
+			Bucket:          config.StorageS3Bucket,
+			Region:          config.StorageS3Region,
+			AccessKeyID:     config.StorageS3AccessKeyID,
+			SecretAccessKey: config.StorageS3SecretAccessKey,
+			UseSSL:          config.StorageS3UseSSL,
+			PathStyle:       config.StorageS3PathStyle,
+			PresignExpiry:   config.StorageS3PresignExpiry,
+			PublicURL:       publicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.StorageDriver)
+	}
+}
+
+// keyExtensions maps the content types uploads are accepted as to the
+// extension their keys get. The local driver serves files by extension, so
+// it must follow the sniffed type rather than the client's file name.
+var keyExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+	"audio/mpeg":      ".mp3",
+}
+
+// NewKey builds a unique key of the form "2006/01/<random><ext>" for an
+// uploaded file, taking the extension from its sniffed content type. Types
+// without a known extension get none and are served as binary data.
+func NewKey(now time.Time, contentType string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	ext := keyExtensions[contentType]
+	return fmt.Sprintf("%s/%s%s", now.UTC().Format("2006/01"), hex.EncodeToString(buf), ext), nil
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBackend keeps objects as plain files below a root directory.
+type LocalBackend struct {
+	root      string
+	publicURL string
+}
+
+func NewLocalBackend(root string, publicURL string) (Backend, error) {
+	if root == "" {
+		return nil, fmt.Errorf("local storage path must not be empty")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalBackend{
+		root:      root,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}, nil
+}
+
+func (backend *LocalBackend) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(backend.root, filepath.FromSlash(key)), nil
+}
+
+func (backend *LocalBackend) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (ObjectInfo, error) {
+	name, err := backend.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	// Write to a temporary file first so readers never observe a partial object.
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if size >= 0 && written != size {
+		return ObjectInfo{}, fmt.Errorf("short write: wrote %d of %d bytes", written, size)
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	return backend.Stat(ctx, key)
+}
+
+func (backend *LocalBackend) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	info, err := backend.Stat(ctx, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	name, _ := backend.path(key)
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, ObjectInfo{}, mapError(err)
+	}
+	return file, info, nil
+}
+
+func (backend *LocalBackend) Delete(ctx context.Context, key string) error {
+	name, err := backend.path(key)
+	if err != nil {
+		return err
+	}
+	return mapError(os.Remove(name))
+}
+
+func (backend *LocalBackend) DeletePrefix(ctx context.Context, prefix string) error {
+	name, err := backend.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(name)
+}
+
+func (backend *LocalBackend) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	name, err := backend.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	stat, err := os.Stat(name)
+	if err != nil {
+		return ObjectInfo{}, mapError(err)
+	}
+	if stat.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return ObjectInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: contentType,
+		ModTime:     stat.ModTime(),
+	}, nil
+}
+
+func (backend *LocalBackend) URL(ctx context.Context, key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return backend.publicURL + "/" + strings.Join(parts, "/"), nil
+}
+
+func mapError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBackend(t *testing.T) {
+	root := t.TempDir()
+	backend, err := NewLocalBackend(root, "/uploads/")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key := "2026/01/hello world.txt"
+	content := []byte("hello storage")
+
+	info, err := backend.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "text/plain")
+	require.NoError(t, err)
+	require.Equal(t, key, info.Key)
+	require.Equal(t, int64(len(content)), info.Size)
+	require.WithinDuration(t, time.Now(), info.ModTime, time.Minute)
+
+	_, err = os.Stat(filepath.Join(root, "2026", "01", "hello world.txt"))
+	require.NoError(t, err)
+
+	reader, info, err := backend.Get(ctx, key)
+	require.NoError(t, err)
+	stored, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, content, stored)
+	require.Contains(t, info.ContentType, "text/plain")
+
+	url, err := backend.URL(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, "/uploads/2026/01/hello%20world.txt", url)
+
+	require.NoError(t, backend.Delete(ctx, key))
+
+	_, err = backend.Stat(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, _, err = backend.Get(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, backend.Delete(ctx, key), ErrNotFound)
+}
+
+func TestLocalBackendDeletePrefix(t *testing.T) {
+	backend, err := NewLocalBackend(t.TempDir(), "/uploads")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, key := range []string{"transforms/7/a.jpg", "transforms/7/b.webp", "transforms/70/c.jpg"} {
+		_, err := backend.Put(ctx, key, bytes.NewReader([]byte("x")), 1, "image/jpeg")
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, backend.DeletePrefix(ctx, "transforms/7"))
+	_, err = backend.Stat(ctx, "transforms/7/a.jpg")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = backend.Stat(ctx, "transforms/7/b.webp")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = backend.Stat(ctx, "transforms/70/c.jpg")
+	require.NoError(t, err)
+
+	require.NoError(t, backend.DeletePrefix(ctx, "transforms/7"))
+	require.ErrorIs(t, backend.DeletePrefix(ctx, "../transforms"), ErrInvalidKey)
+}
+
+func TestLocalBackendShortWrite(t *testing.T) {
+	backend, err := NewLocalBackend(t.TempDir(), "/uploads")
+	require.NoError(t, err)
+
+	_, err = backend.Put(context.Background(), "a.txt", bytes.NewReader([]byte("abc")), 10, "text/plain")
+	require.Error(t, err)
+
+	_, err = backend.Stat(context.Background(), "a.txt")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalBackendInvalidKey(t *testing.T) {
+	backend, err := NewLocalBackend(t.TempDir(), "/uploads")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b", "a/./b", `a\b`} {
+		_, err := backend.Put(ctx, key, bytes.NewReader(nil), 0, "text/plain")
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+
+		_, err = backend.URL(ctx, key)
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	now := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+
+	key1, err := NewKey(now, "image/jpeg")
+	require.NoError(t, err)
+	require.Regexp(t, `^2026/03/[0-9a-f]{32}\.jpg$`, key1)
+
+	key2, err := NewKey(now, "image/jpeg")
+	require.NoError(t, err)
+	require.NotEqual(t, key1, key2)
+
+	require.NoError(t, validateKey(key1))
+
+	key3, err := NewKey(now, "text/html")
+	require.NoError(t, err)
+	require.Regexp(t, `^2026/03/[0-9a-f]{32}$`, key3)
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config holds the settings for an S3-compatible object store such as
+// AWS S3, MinIO or SeaweedFS.
+type S3Config struct {
+	Endpoint        string
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+	PathStyle       bool
+	// PresignExpiry makes URL return presigned GET URLs valid for this long.
+	// Leave it zero for public buckets.
+	PresignExpiry time.Duration
+	// PublicURL optionally replaces the endpoint in unsigned URLs, e.g. a CDN.
+	PublicURL string
+}
+
+// S3Backend keeps objects in a single bucket of an S3-compatible store.
+type S3Backend struct {
+	client        *minio.Client
+	bucket        string
+	presignExpiry time.Duration
+	publicURL     string
+}
+
+func NewS3Backend(config S3Config) (Backend, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage requires an endpoint and a bucket")
+	}
+	if config.PresignExpiry > 7*24*time.Hour {
+		return nil, fmt.Errorf("s3 presign expiry must not exceed 7 days")
+	}
+
+	endpoint, secure := config.Endpoint, config.UseSSL
+	if strings.Contains(endpoint, "://") {
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+		}
+		endpoint, secure = parsed.Host, parsed.Scheme == "https"
+	}
+
+	region := config.Region
+	if region == "" {
+		// Without a region the client has to ask the server before it can sign anything.
+		region = "us-east-1"
+	}
+
+	lookup := minio.BucketLookupAuto
+	if config.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
+		Secure:       secure,
+		Region:       region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	publicURL := strings.TrimSuffix(config.PublicURL, "/")
+	if publicURL == "" {
+		endpointURL := *client.EndpointURL()
+		if config.PathStyle {
+			endpointURL.Path = "/" + config.Bucket
+		} else {
+			endpointURL.Host = config.Bucket + "." + endpointURL.Host
+		}
+		publicURL = strings.TrimSuffix(endpointURL.String(), "/")
+	}
+
+	return &S3Backend{
+		client:        client,
+		bucket:        config.Bucket,
+		presignExpiry: config.PresignExpiry,
+		publicURL:     publicURL,
+	}, nil
+}
+
+func (backend *S3Backend) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := backend.client.PutObject(ctx, backend.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: contentType,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+func (backend *S3Backend) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	object, err := backend.client.GetObject(ctx, backend.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, mapS3Error(err)
+	}
+	// GetObject is lazy; Stat performs the request and surfaces missing keys.
+	stat, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, ObjectInfo{}, mapS3Error(err)
+	}
+	return object, toObjectInfo(stat), nil
+}
+
+func (backend *S3Backend) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return mapS3Error(backend.client.RemoveObject(ctx, backend.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (backend *S3Backend) DeletePrefix(ctx context.Context, prefix string) error {
+	if err := validateKey(prefix); err != nil {
+		return err
+	}
+	// Cancelling stops the listing when a removal fails part way.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := backend.client.ListObjects(ctx, backend.bucket, minio.ListObjectsOptions{Prefix: prefix + "/", Recursive: true})
+	for object := range objects {
+		if object.Err != nil {
+			return mapS3Error(object.Err)
+		}
+		if err := backend.client.RemoveObject(ctx, backend.bucket, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			return mapS3Error(err)
+		}
+	}
+	return nil
+}
+
+func (backend *S3Backend) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return ObjectInfo{}, err
+	}
+	stat, err := backend.client.StatObject(ctx, backend.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, mapS3Error(err)
+	}
+	return toObjectInfo(stat), nil
+}
+
+func (backend *S3Backend) URL(ctx context.Context, key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if backend.presignExpiry > 0 {
+		presigned, err := backend.client.PresignedGetObject(ctx, backend.bucket, key, backend.presignExpiry, nil)
+		if err != nil {
+			return "", err
+		}
+		return presigned.String(), nil
+	}
+
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return backend.publicURL + "/" + strings.Join(parts, "/"), nil
+}
+
+func toObjectInfo(stat minio.ObjectInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:         stat.Key,
+		Size:        stat.Size,
+		ContentType: stat.ContentType,
+		ModTime:     stat.LastModified,
+	}
+}
+
+func mapS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3BackendURL(t *testing.T) {
+	ctx := context.Background()
+	key := "2026/01/hello world.png"
+
+	testCases := []struct {
+		name     string
+		config   S3Config
+		expected string
+	}{
+		{
+			name:     "PathStyle",
+			config:   S3Config{Endpoint: "http://localhost:9000", Bucket: "media", PathStyle: true},
+			expected: "http://localhost:9000/media/2026/01/hello%20world.png",
+		},
+		{
+			name:     "VirtualHost",
+			config:   S3Config{Endpoint: "s3.eu-central-1.amazonaws.com", Bucket: "media", UseSSL: true},
+			expected: "https://media.s3.eu-central-1.amazonaws.com/2026/01/hello%20world.png",
+		},
+		{
+			name:     "PublicURL",
+			config:   S3Config{Endpoint: "http://localhost:9000", Bucket: "media", PublicURL: "https://cdn.example.com/"},
+			expected: "https://cdn.example.com/2026/01/hello%20world.png",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			backend, err := NewS3Backend(tc.config)
+			require.NoError(t, err)
+
+			url, err := backend.URL(ctx, key)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, url)
+		})
+	}
+}
+
+func TestS3BackendPresignedURL(t *testing.T) {
+	backend, err := NewS3Backend(S3Config{
+		Endpoint:        "http://localhost:9000",
+		Bucket:          "media",
+		AccessKeyID:     "minioadmin",
+		SecretAccessKey: "minioadmin",
+		PathStyle:       true,
+		PresignExpiry:   10 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	presigned, err := backend.URL(context.Background(), "2026/01/cover.png")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(presigned)
+	require.NoError(t, err)
+	require.Equal(t, "/media/2026/01/cover.png", parsed.Path)
+	require.Equal(t, "600", parsed.Query().Get("X-Amz-Expires"))
+	require.NotEmpty(t, parsed.Query().Get("X-Amz-Signature"))
+}
+
+func TestNewS3BackendInvalidConfig(t *testing.T) {
+	_, err := NewS3Backend(S3Config{Bucket: "media"})
+	require.Error(t, err)
+
+	_, err = NewS3Backend(S3Config{Endpoint: "localhost:9000"})
+	require.Error(t, err)
+
+	_, err = NewS3Backend(S3Config{Endpoint: "localhost:9000", Bucket: "media", PresignExpiry: 8 * 24 * time.Hour})
+	require.Error(t, err)
+}
+
+// TestS3BackendIntegration runs against a real S3-compatible server, e.g. the
+// MinIO container started by `make minio`. It is skipped unless
+// STORAGE_S3_TEST_ENDPOINT is set.
+func TestS3BackendIntegration(t *testing.T) {
+	endpoint := os.Getenv("STORAGE_S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_S3_TEST_ENDPOINT is not set")
+	}
+
+	config := S3Config{
+		Endpoint:        endpoint,
+		Bucket:          "golive-cms-test",
+		AccessKeyID:     envOrDefault("STORAGE_S3_TEST_ACCESS_KEY_ID", "minioadmin"),
+		SecretAccessKey: envOrDefault("STORAGE_S3_TEST_SECRET_ACCESS_KEY", "minioadmin"),
+		PathStyle:       true,
+		PresignExpiry:   time.Minute,
+	}
+	backend, err := NewS3Backend(config)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	client := backend.(*S3Backend).client
+	exists, err := client.BucketExists(ctx, config.Bucket)
+	require.NoError(t, err)
+	if !exists {
+		require.NoError(t, client.MakeBucket(ctx, config.Bucket, minio.MakeBucketOptions{}))
+	}
+
+	key, err := NewKey(time.Now(), "integration.txt")
+	require.NoError(t, err)
+	content := []byte(gofakeit.Paragraph(2, 3, 10, " "))
+
+	info, err := backend.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "text/plain")
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), info.Size)
+
+	stat, err := backend.Stat(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), stat.Size)
+	require.Equal(t, "text/plain", stat.ContentType)
+
+	reader, _, err := backend.Get(ctx, key)
+	require.NoError(t, err)
+	stored, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, content, stored)
+
+	presigned, err := backend.URL(ctx, key)
+	require.NoError(t, err)
+	response, err := http.Get(presigned)
+	require.NoError(t, err)
+	downloaded, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, content, downloaded)
+
+	require.NoError(t, backend.Delete(ctx, key))
+
+	_, err = backend.Stat(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, _, err = backend.Get(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	prefix := "integration/" + gofakeit.UUID()
+	for _, name := range []string{"a.txt", "nested/b.txt"} {
+		_, err := backend.Put(ctx, prefix+"/"+name, bytes.NewReader(content), int64(len(content)), "text/plain")
+		require.NoError(t, err)
+	}
+	require.NoError(t, backend.DeletePrefix(ctx, prefix))
+	_, err = backend.Stat(ctx, prefix+"/nested/b.txt")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
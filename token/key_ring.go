@@ -0,0 +1,139 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Keys are exchanged in PASERK form: "k4.secret." or "k4.public." followed by
+// the base64url key. Key IDs are PASERK "k4.pid." identifiers, which are
+// derived from the public key so they never need to be configured.
+const (
+	paserkSecretPrefix = "k4.secret."
+	paserkPublicPrefix = "k4.public."
+	paserkPIDPrefix    = "k4.pid."
+	// pidHashSize is the BLAKE2b output size PASERK uses for key IDs.
+	pidHashSize = 33
+)
+
+// PublicKey is a verification key and its ID.
+type PublicKey struct {
+	ID  string
+	Key ed25519.PublicKey
+}
+
+// PASERK returns the key serialized as "k4.public.<base64url>".
+func (key PublicKey) PASERK() string {
+	return paserkPublicPrefix + base64.RawURLEncoding.EncodeToString(key.Key)
+}
+
+// KeyRing holds the key new tokens are signed with and every key tokens are
+// still accepted from. Rotating keys means signing with a new key while the
+// old public key stays in the ring until tokens signed with it have expired.
+type KeyRing struct {
+	signingKey ed25519.PrivateKey
+	signingID  string
+	publicKeys []PublicKey
+}
+
+// NewKeyRing builds a key ring from a k4.secret signing key and any number of
+// k4.public keys that should still verify. The signing key's public half is
+// always included.
+func NewKeyRing(signingKey string, verificationKeys []string) (*KeyRing, error) {
+	privateKey, err := parseSecretKey(signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+
+	signing := newPublicKey(privateKey.Public().(ed25519.PublicKey))
+	ring := &KeyRing{
+		signingKey: privateKey,
+		signingID:  signing.ID,
+		publicKeys: []PublicKey{signing},
+	}
+
+	for _, encoded := range verificationKeys {
+		publicKey, err := parsePublicKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid verification key: %w", err)
+		}
+		if _, ok := ring.publicKey(publicKey.ID); ok {
+			continue
+		}
+		ring.publicKeys = append(ring.publicKeys, publicKey)
+	}
+	return ring, nil
+}
+
+// PublicKeys lists the keys tokens are accepted from, the signing key first.
+func (ring *KeyRing) PublicKeys() []PublicKey {
+	return append([]PublicKey(nil), ring.publicKeys...)
+}
+
+func (ring *KeyRing) publicKey(id string) (PublicKey, bool) {
+	for _, key := range ring.publicKeys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return PublicKey{}, false
+}
+
+// GenerateSigningKey returns a new Ed25519 key pair in PASERK form.
+func GenerateSigningKey() (secret string, public PublicKey, err error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", PublicKey{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return paserkSecretPrefix + base64.RawURLEncoding.EncodeToString(privateKey), newPublicKey(publicKey), nil
+}
+
+func newPublicKey(key ed25519.PublicKey) PublicKey {
+	publicKey := PublicKey{Key: key}
+	publicKey.ID = paserkID(publicKey.PASERK())
+	return publicKey
+}
+
+// paserkID computes the k4.pid identifier of a serialized public key.
+func paserkID(paserk string) string {
+	hash, _ := blake2b.New(pidHashSize, nil)
+	hash.Write([]byte(paserkPIDPrefix))
+	hash.Write([]byte(paserk))
+	return paserkPIDPrefix + base64.RawURLEncoding.EncodeToString(hash.Sum(nil))
+}
+
+func parseSecretKey(encoded string) (ed25519.PrivateKey, error) {
+	encoded = strings.TrimSpace(encoded)
+	if !strings.HasPrefix(encoded, paserkSecretPrefix) {
+		return nil, fmt.Errorf("expected a %s key", strings.TrimSuffix(paserkSecretPrefix, "."))
+	}
+	key, err := base64.RawURLEncoding.DecodeString(encoded[len(paserkSecretPrefix):])
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("malformed %s key", strings.TrimSuffix(paserkSecretPrefix, "."))
+	}
+
+	// The secret key embeds its public half; reject keys where the two
+	// disagree rather than sign tokens nobody can verify.
+	privateKey := ed25519.PrivateKey(key)
+	if !ed25519.NewKeyFromSeed(privateKey.Seed()).Equal(privateKey) {
+		return nil, fmt.Errorf("public half of the key does not match its seed")
+	}
+	return privateKey, nil
+}
+
+func parsePublicKey(encoded string) (PublicKey, error) {
+	encoded = strings.TrimSpace(encoded)
+	if !strings.HasPrefix(encoded, paserkPublicPrefix) {
+		return PublicKey{}, fmt.Errorf("expected a %s key", strings.TrimSuffix(paserkPublicPrefix, "."))
+	}
+	key, err := base64.RawURLEncoding.DecodeString(encoded[len(paserkPublicPrefix):])
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return PublicKey{}, fmt.Errorf("malformed %s key", strings.TrimSuffix(paserkPublicPrefix, "."))
+	}
+	return newPublicKey(key), nil
+}
@@ -1,11 +1,55 @@
 package token
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-live-cms/go-live-cms/util"
+	"github.com/google/uuid"
+)
 
 type Maker interface {
-	CreateToken(userID int64, username string, duration time.Duration) (string, error)
+	// CreateToken issues an access token for a login session. Revoking the
+	// session revokes every access token issued for it.
+	CreateToken(sessionID uuid.UUID, userID int64, username string, duration time.Duration) (string, error)
+
+	// CreateRefreshToken issues a refresh token whose payload ID is the
+	// session it belongs to, so every rotation of that session shares the ID.
+	CreateRefreshToken(sessionID uuid.UUID, userID int64, username string, duration time.Duration) (string, error)
 
-	CreateRefreshToken(userID int64, username string, duration time.Duration) (string, error)
+	// CreateMFAToken issues the short-lived "mfa_pending" token a password
+	// login returns when a second factor is still needed.
+	CreateMFAToken(userID int64, username string, duration time.Duration) (string, error)
 
 	VerifyToken(token string) (*Payload, error)
 }
+
+// KeyPublisher is implemented by makers whose tokens can be verified with
+// public keys, so the keys can be handed to other services.
+type KeyPublisher interface {
+	PublicKeys() []PublicKey
+}
+
+// New returns the maker selected by config.TokenDriver.
+func New(config util.Config) (Maker, error) {
+	switch config.TokenDriver {
+	case "", "v2.local":
+		return NewPasetoMaker(config.TokenSymmetricKey)
+	case "v4.public":
+		var verificationKeys []string
+		for _, key := range strings.Split(config.TokenVerificationKeys, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				verificationKeys = append(verificationKeys, key)
+			}
+		}
+
+		keys, err := NewKeyRing(config.TokenSigningKey, verificationKeys)
+		if err != nil {
+			return nil, err
+		}
+		return NewPublicPasetoMaker(keys), nil
+	default:
+		return nil, fmt.Errorf("unknown token driver %q", config.TokenDriver)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/aead/chacha20poly1305"
+	"github.com/google/uuid"
 	"github.com/o1egl/paseto"
 )
 
@@ -24,16 +25,23 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	return maker, nil
 }
 
-func (maker *PasetoMaker) CreateToken(userID int64, username string, duration time.Duration) (string, error) {
+func (maker *PasetoMaker) CreateToken(sessionID uuid.UUID, userID int64, username string, duration time.Duration) (string, error) {
 	payload, err := NewPayload(userID, username, duration, "access")
 	if err != nil {
 		return "", err
 	}
+	payload.SessionID = sessionID
 	return maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
 }
 
-func (maker *PasetoMaker) CreateRefreshToken(userID int64, username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(userID, username, duration, "refresh")
+func (maker *PasetoMaker) CreateRefreshToken(sessionID uuid.UUID, userID int64, username string, duration time.Duration) (string, error) {
+	payload := NewPayloadWithID(sessionID, userID, username, duration, "refresh")
+	payload.SessionID = sessionID
+	return maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
+}
+
+func (maker *PasetoMaker) CreateMFAToken(userID int64, username string, duration time.Duration) (string, error) {
+	payload, err := NewPayload(userID, username, duration, "mfa_pending")
 	if err != nil {
 		return "", err
 	}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,7 +17,8 @@ func TestPasetoMaker(t *testing.T) {
 	duration := time.Minute
 
 	t.Run("CreateAccessToken", func(t *testing.T) {
-		token, err := maker.CreateToken(userID, username, duration)
+		sessionID := uuid.New()
+		token, err := maker.CreateToken(sessionID, userID, username, duration)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 
@@ -27,13 +29,16 @@ func TestPasetoMaker(t *testing.T) {
 		require.Equal(t, userID, payload.UserID)
 		require.Equal(t, username, payload.Username)
 		require.Equal(t, "access", payload.TokenType)
+		require.Equal(t, sessionID, payload.SessionID)
+		require.NotEqual(t, sessionID, payload.ID)
 		require.WithinDuration(t, time.Now(), payload.IssuedAt, time.Second)
 		require.WithinDuration(t, time.Now().Add(duration), payload.ExpiredAt, time.Second)
 	})
 
 	t.Run("CreateRefreshToken", func(t *testing.T) {
 		refreshDuration := time.Hour * 24 * 7
-		token, err := maker.CreateRefreshToken(userID, username, refreshDuration)
+		sessionID := uuid.New()
+		token, err := maker.CreateRefreshToken(sessionID, userID, username, refreshDuration)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 
@@ -44,12 +49,29 @@ func TestPasetoMaker(t *testing.T) {
 		require.Equal(t, userID, payload.UserID)
 		require.Equal(t, username, payload.Username)
 		require.Equal(t, "refresh", payload.TokenType)
+		require.Equal(t, sessionID, payload.ID)
 		require.WithinDuration(t, time.Now(), payload.IssuedAt, time.Second)
 		require.WithinDuration(t, time.Now().Add(refreshDuration), payload.ExpiredAt, time.Second)
+
+		rotated, err := maker.CreateRefreshToken(sessionID, userID, username, refreshDuration)
+		require.NoError(t, err)
+		require.NotEqual(t, token, rotated)
+	})
+
+	t.Run("CreateMFAToken", func(t *testing.T) {
+		token, err := maker.CreateMFAToken(userID, username, duration)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+
+		payload, err := maker.VerifyToken(token)
+		require.NoError(t, err)
+		require.Equal(t, userID, payload.UserID)
+		require.Equal(t, "mfa_pending", payload.TokenType)
+		require.WithinDuration(t, time.Now().Add(duration), payload.ExpiredAt, time.Second)
 	})
 
 	t.Run("ExpiredToken", func(t *testing.T) {
-		token, err := maker.CreateToken(userID, username, -time.Minute)
+		token, err := maker.CreateToken(uuid.New(), userID, username, -time.Minute)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 
@@ -67,10 +89,10 @@ func TestPasetoMaker(t *testing.T) {
 	})
 
 	t.Run("TokenTypes", func(t *testing.T) {
-		accessToken, err := maker.CreateToken(userID, username, duration)
+		accessToken, err := maker.CreateToken(uuid.New(), userID, username, duration)
 		require.NoError(t, err)
 
-		refreshToken, err := maker.CreateRefreshToken(userID, username, duration)
+		refreshToken, err := maker.CreateRefreshToken(uuid.New(), userID, username, duration)
 		require.NoError(t, err)
 
 		require.NotEqual(t, accessToken, refreshToken)
@@ -128,7 +150,7 @@ func TestTokenSecurity(t *testing.T) {
 	username := "testuser"
 	duration := time.Minute
 
-	token, err := maker1.CreateToken(userID, username, duration)
+	token, err := maker1.CreateToken(uuid.New(), userID, username, duration)
 	require.NoError(t, err)
 
 	payload, err := maker2.VerifyToken(token)
@@ -147,7 +169,7 @@ func BenchmarkPasetoMaker_CreateToken(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, err := maker.CreateToken(userID, username, duration)
+		_, err := maker.CreateToken(uuid.New(), userID, username, duration)
 		require.NoError(b, err)
 	}
 }
@@ -160,7 +182,7 @@ func BenchmarkPasetoMaker_VerifyToken(b *testing.B) {
 	username := "testuser"
 	duration := time.Minute
 
-	token, err := maker.CreateToken(userID, username, duration)
+	token, err := maker.CreateToken(uuid.New(), userID, username, duration)
 	require.NoError(b, err)
 
 	b.ResetTimer()
@@ -19,6 +19,10 @@ type Payload struct {
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 	TokenType string    `json:"token_type"`
+	// SessionID is the login session an access or refresh token was issued
+	// for, so revoking the session revokes its tokens too. It is the zero
+	// UUID for tokens that do not belong to a session.
+	SessionID uuid.UUID `json:"session_id"`
 }
 
 func NewPayload(userID int64, username string, duration time.Duration, tokenType string) (*Payload, error) {
@@ -27,7 +31,13 @@ func NewPayload(userID int64, username string, duration time.Duration, tokenType
 		return nil, err
 	}
 
-	payload := &Payload{
+	return NewPayloadWithID(tokenID, userID, username, duration, tokenType), nil
+}
+
+// NewPayloadWithID builds a payload around an ID chosen by the caller, such as
+// the session a refresh token belongs to.
+func NewPayloadWithID(tokenID uuid.UUID, userID int64, username string, duration time.Duration, tokenType string) *Payload {
+	return &Payload{
 		ID:        tokenID,
 		UserID:    userID,
 		Username:  username,
@@ -35,24 +45,14 @@ func NewPayload(userID int64, username string, duration time.Duration, tokenType
 		ExpiredAt: time.Now().Add(duration),
 		TokenType: tokenType,
 	}
-	return payload, nil
 }
 
+// Valid only checks what can be decided from the token itself. Revocation
+// needs shared state and is checked separately through a Revoker.
 func (payload *Payload) Valid() error {
 	if time.Now().After(payload.ExpiredAt) {
 		return ErrExpiredToken
 	}
 
-	// TODO: Implement token blacklisting when Redis/database is available
-	/* if isTokenBlacklisted(payload.ID) {
-	    return ErrInvalidToken
-	} */
-
 	return nil
 }
-
-// TODO: Implement this function with Redis or database
-/* func isTokenBlacklisted(tokenID uuid.UUID) bool {
-	// Check Redis or database for blacklisted tokens
-	return false
-} */
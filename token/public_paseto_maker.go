@@ -0,0 +1,112 @@
+package token
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PublicPasetoMaker issues v4.public tokens signed with Ed25519. Anyone with
+// the public keys can verify them, so other services do not need a shared
+// secret. Each token names its key in a {"kid": ...} footer.
+type PublicPasetoMaker struct {
+	keys *KeyRing
+}
+
+func NewPublicPasetoMaker(keys *KeyRing) Maker {
+	return &PublicPasetoMaker{keys: keys}
+}
+
+// tokenFooter is the footer of every v4.public token.
+type tokenFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// publicClaims adds the registered PASETO claims to the payload, so generic
+// PASETO libraries can check expiry without knowing the CMS fields.
+type publicClaims struct {
+	*Payload
+	Jti string `json:"jti"`
+	Sub string `json:"sub"`
+	Iat string `json:"iat"`
+	Exp string `json:"exp"`
+}
+
+func (maker *PublicPasetoMaker) CreateToken(sessionID uuid.UUID, userID int64, username string, duration time.Duration) (string, error) {
+	payload, err := NewPayload(userID, username, duration, "access")
+	if err != nil {
+		return "", err
+	}
+	payload.SessionID = sessionID
+	return maker.sign(payload)
+}
+
+func (maker *PublicPasetoMaker) CreateRefreshToken(sessionID uuid.UUID, userID int64, username string, duration time.Duration) (string, error) {
+	payload := NewPayloadWithID(sessionID, userID, username, duration, "refresh")
+	payload.SessionID = sessionID
+	return maker.sign(payload)
+}
+
+func (maker *PublicPasetoMaker) CreateMFAToken(userID int64, username string, duration time.Duration) (string, error) {
+	payload, err := NewPayload(userID, username, duration, "mfa_pending")
+	if err != nil {
+		return "", err
+	}
+	return maker.sign(payload)
+}
+
+func (maker *PublicPasetoMaker) VerifyToken(token string) (*Payload, error) {
+	message, signature, footerJSON, err := splitV4Public(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var footer tokenFooter
+	if err := json.Unmarshal(footerJSON, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
+	key, ok := maker.keys.publicKey(footer.KeyID)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	if err := verifyV4Public(key.Key, message, signature, footerJSON); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	if err := json.Unmarshal(message, payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// PublicKeys lists the keys this maker's tokens can be verified with.
+func (maker *PublicPasetoMaker) PublicKeys() []PublicKey {
+	return maker.keys.PublicKeys()
+}
+
+func (maker *PublicPasetoMaker) sign(payload *Payload) (string, error) {
+	message, err := json.Marshal(publicClaims{
+		Payload: payload,
+		Jti:     payload.ID.String(),
+		Sub:     strconv.FormatInt(payload.UserID, 10),
+		Iat:     payload.IssuedAt.UTC().Format(time.RFC3339),
+		Exp:     payload.ExpiredAt.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	footer, err := json.Marshal(tokenFooter{KeyID: maker.keys.signingID})
+	if err != nil {
+		return "", err
+	}
+	return signV4Public(maker.keys.signingKey, message, footer), nil
+}
@@ -0,0 +1,191 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-live-cms/go-live-cms/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyRing(t *testing.T, verificationKeys ...string) (*KeyRing, PublicKey) {
+	secret, public, err := GenerateSigningKey()
+	require.NoError(t, err)
+
+	ring, err := NewKeyRing(secret, verificationKeys)
+	require.NoError(t, err)
+	return ring, public
+}
+
+func TestPublicPasetoMaker(t *testing.T) {
+	ring, public := newTestKeyRing(t)
+	maker := NewPublicPasetoMaker(ring)
+
+	userID := int64(123)
+	username := "testuser"
+	duration := time.Minute
+
+	t.Run("CreateAccessToken", func(t *testing.T) {
+		sessionID := uuid.New()
+		token, err := maker.CreateToken(sessionID, userID, username, duration)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(token, "v4.public."))
+
+		payload, err := maker.VerifyToken(token)
+		require.NoError(t, err)
+		require.Equal(t, userID, payload.UserID)
+		require.Equal(t, username, payload.Username)
+		require.Equal(t, "access", payload.TokenType)
+		require.Equal(t, sessionID, payload.SessionID)
+		require.WithinDuration(t, time.Now().Add(duration), payload.ExpiredAt, time.Second)
+	})
+
+	t.Run("CreateRefreshToken", func(t *testing.T) {
+		sessionID := uuid.New()
+		token, err := maker.CreateRefreshToken(sessionID, userID, username, duration)
+		require.NoError(t, err)
+
+		payload, err := maker.VerifyToken(token)
+		require.NoError(t, err)
+		require.Equal(t, "refresh", payload.TokenType)
+		require.Equal(t, sessionID, payload.ID)
+	})
+
+	t.Run("CreateMFAToken", func(t *testing.T) {
+		token, err := maker.CreateMFAToken(userID, username, duration)
+		require.NoError(t, err)
+
+		payload, err := maker.VerifyToken(token)
+		require.NoError(t, err)
+		require.Equal(t, "mfa_pending", payload.TokenType)
+	})
+
+	t.Run("KeyIDFooter", func(t *testing.T) {
+		token, err := maker.CreateToken(uuid.New(), userID, username, duration)
+		require.NoError(t, err)
+
+		parts := strings.Split(token, ".")
+		require.Len(t, parts, 4)
+		footer, err := base64.RawURLEncoding.DecodeString(parts[3])
+		require.NoError(t, err)
+		require.JSONEq(t, `{"kid":"`+public.ID+`"}`, string(footer))
+		require.True(t, strings.HasPrefix(public.ID, "k4.pid."))
+	})
+
+	t.Run("ExpiredToken", func(t *testing.T) {
+		token, err := maker.CreateToken(uuid.New(), userID, username, -time.Minute)
+		require.NoError(t, err)
+
+		payload, err := maker.VerifyToken(token)
+		require.EqualError(t, err, ErrExpiredToken.Error())
+		require.Nil(t, payload)
+	})
+
+	t.Run("TamperedFooter", func(t *testing.T) {
+		token, err := maker.CreateToken(uuid.New(), userID, username, duration)
+		require.NoError(t, err)
+
+		// Point the footer at the same key with different JSON spacing; the
+		// footer is signed, so this must still fail.
+		parts := strings.Split(token, ".")
+		parts[3] = base64.RawURLEncoding.EncodeToString([]byte(`{ "kid": "` + public.ID + `" }`))
+
+		payload, err := maker.VerifyToken(strings.Join(parts, "."))
+		require.EqualError(t, err, ErrInvalidToken.Error())
+		require.Nil(t, payload)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		for _, token := range []string{"invalid.token.here", "v4.public.", "v4.public.AAAA.e30", "v2.local.AAAA"} {
+			payload, err := maker.VerifyToken(token)
+			require.EqualError(t, err, ErrInvalidToken.Error(), token)
+			require.Nil(t, payload)
+		}
+	})
+}
+
+func TestPublicPasetoMakerKeyRotation(t *testing.T) {
+	oldRing, oldPublic := newTestKeyRing(t)
+	oldMaker := NewPublicPasetoMaker(oldRing)
+
+	oldToken, err := oldMaker.CreateToken(uuid.New(), 1, "testuser", time.Minute)
+	require.NoError(t, err)
+
+	// The new signing key still accepts tokens from the old one while it is
+	// listed as a verification key.
+	newRing, newPublic := newTestKeyRing(t, oldPublic.PASERK())
+	newMaker := NewPublicPasetoMaker(newRing)
+
+	payload, err := newMaker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), payload.UserID)
+
+	keys := newMaker.(KeyPublisher).PublicKeys()
+	require.Len(t, keys, 2)
+	require.Equal(t, newPublic.ID, keys[0].ID)
+	require.Equal(t, oldPublic.ID, keys[1].ID)
+
+	// Once the old key is dropped, its tokens stop verifying.
+	unrelatedRing, _ := newTestKeyRing(t)
+	payload, err = NewPublicPasetoMaker(unrelatedRing).VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestNewKeyRing(t *testing.T) {
+	secret, public, err := GenerateSigningKey()
+	require.NoError(t, err)
+
+	_, err = NewKeyRing(public.PASERK(), nil)
+	require.Error(t, err)
+
+	_, err = NewKeyRing(secret, []string{secret})
+	require.Error(t, err)
+
+	_, err = NewKeyRing("k4.secret.AAAA", nil)
+	require.Error(t, err)
+
+	ring, err := NewKeyRing(secret, []string{public.PASERK()})
+	require.NoError(t, err)
+	require.Len(t, ring.PublicKeys(), 1)
+}
+
+func TestNewMaker(t *testing.T) {
+	secret, _, err := GenerateSigningKey()
+	require.NoError(t, err)
+
+	maker, err := New(util.Config{TokenSymmetricKey: "12345678901234567890123456789012"})
+	require.NoError(t, err)
+	require.IsType(t, &PasetoMaker{}, maker)
+
+	maker, err = New(util.Config{TokenDriver: "v4.public", TokenSigningKey: secret})
+	require.NoError(t, err)
+	require.IsType(t, &PublicPasetoMaker{}, maker)
+
+	_, err = New(util.Config{TokenDriver: "v4.public"})
+	require.Error(t, err)
+
+	_, err = New(util.Config{TokenDriver: "jwt"})
+	require.Error(t, err)
+}
+
+// TestSignV4Public checks the signing code against test vector 4-S-1 from the
+// PASETO specification.
+func TestSignV4Public(t *testing.T) {
+	secret, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+
+	message := []byte(`{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`)
+	token := signV4Public(ed25519.PrivateKey(secret), message, nil)
+	require.Equal(t, "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA", token)
+
+	gotMessage, signature, footer, err := splitV4Public(token)
+	require.NoError(t, err)
+	require.Equal(t, message, gotMessage)
+	require.NoError(t, verifyV4Public(ed25519.PrivateKey(secret).Public().(ed25519.PublicKey), gotMessage, signature, footer))
+}
@@ -0,0 +1,73 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/util"
+	"github.com/google/uuid"
+)
+
+// Revoker records tokens that must stop working before they expire. IDs are
+// either a token's own ID or the session it was issued for.
+type Revoker interface {
+	// Revoke rejects id until expiresAt. Past that point every token it could
+	// match has expired anyway, so the entry can be forgotten.
+	Revoke(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+
+	IsRevoked(ctx context.Context, id uuid.UUID) (bool, error)
+
+	// PurgeExpired drops entries whose expiry has passed and reports how many
+	// were removed.
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// NewRevoker returns the revoker selected by config.TokenRevocationDriver.
+func NewRevoker(config util.Config, store db.Querier) (Revoker, error) {
+	switch config.TokenRevocationDriver {
+	case "", "postgres":
+		return NewCachedRevoker(NewPostgresRevoker(store), config.TokenRevocationCacheSize, config.TokenRevocationCacheTTL), nil
+	case "memory":
+		return NewMemoryRevoker(), nil
+	default:
+		return nil, fmt.Errorf("unknown token revocation driver %q", config.TokenRevocationDriver)
+	}
+}
+
+// IsPayloadRevoked reports whether the token itself or the session it was
+// issued for has been revoked.
+func IsPayloadRevoked(ctx context.Context, revoker Revoker, payload *Payload) (bool, error) {
+	revoked, err := revoker.IsRevoked(ctx, payload.ID)
+	if err != nil || revoked {
+		return revoked, err
+	}
+	if payload.SessionID == uuid.Nil || payload.SessionID == payload.ID {
+		return false, nil
+	}
+	return revoker.IsRevoked(ctx, payload.SessionID)
+}
+
+// RunCleanup purges expired revocations every interval until ctx is cancelled.
+func RunCleanup(ctx context.Context, revoker Revoker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := revoker.PurgeExpired(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Println("token revocation cleanup:", err)
+		}
+		if purged > 0 {
+			log.Printf("token revocation cleanup: purged %d expired entries", purged)
+		}
+	}
+}
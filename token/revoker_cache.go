@@ -0,0 +1,129 @@
+package token
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CachedRevoker puts an LRU cache in front of another revoker so the auth
+// middleware does not hit the database on every request.
+//
+// A revocation is permanent until it expires, so a positive answer is cached
+// for as long as the entry lives. A negative answer is only cached for ttl:
+// revocations made through this instance update the cache at once, but those
+// made by another instance become visible once the negative entry expires.
+type CachedRevoker struct {
+	next Revoker
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	order *list.List
+	items map[uuid.UUID]*list.Element
+}
+
+type cacheEntry struct {
+	id         uuid.UUID
+	revoked    bool
+	validUntil time.Time
+}
+
+func NewCachedRevoker(next Revoker, size int, ttl time.Duration) *CachedRevoker {
+	if size <= 0 {
+		size = 1
+	}
+	return &CachedRevoker{
+		next:  next,
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		items: make(map[uuid.UUID]*list.Element),
+	}
+}
+
+func (r *CachedRevoker) Revoke(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	if err := r.next.Revoke(ctx, id, expiresAt); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store(id, true, expiresAt)
+	return nil
+}
+
+func (r *CachedRevoker) IsRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	if elem, ok := r.items[id]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if time.Now().Before(entry.validUntil) {
+			r.order.MoveToFront(elem)
+			r.mu.Unlock()
+			return entry.revoked, nil
+		}
+		r.remove(elem)
+	}
+	r.mu.Unlock()
+
+	revoked, err := r.next.IsRevoked(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	// A negative answer is never cached over a revocation that raced in while
+	// the lookup was running.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if elem, ok := r.items[id]; ok && elem.Value.(*cacheEntry).revoked {
+		return true, nil
+	}
+	if revoked {
+		// The backend does not say when the entry expires; keep it for the
+		// negative TTL and ask again after that.
+		r.store(id, true, time.Now().Add(r.ttl))
+	} else if r.ttl > 0 {
+		r.store(id, false, time.Now().Add(r.ttl))
+	}
+	return revoked, nil
+}
+
+func (r *CachedRevoker) PurgeExpired(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	now := time.Now()
+	for elem := r.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if !now.Before(elem.Value.(*cacheEntry).validUntil) {
+			r.remove(elem)
+		}
+		elem = prev
+	}
+	r.mu.Unlock()
+
+	return r.next.PurgeExpired(ctx)
+}
+
+// store must be called with mu held.
+func (r *CachedRevoker) store(id uuid.UUID, revoked bool, validUntil time.Time) {
+	if elem, ok := r.items[id]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.revoked = revoked
+		entry.validUntil = validUntil
+		r.order.MoveToFront(elem)
+		return
+	}
+
+	r.items[id] = r.order.PushFront(&cacheEntry{id: id, revoked: revoked, validUntil: validUntil})
+	for r.order.Len() > r.size {
+		r.remove(r.order.Back())
+	}
+}
+
+// remove must be called with mu held.
+func (r *CachedRevoker) remove(elem *list.Element) {
+	r.order.Remove(elem)
+	delete(r.items, elem.Value.(*cacheEntry).id)
+}
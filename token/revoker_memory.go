@@ -0,0 +1,53 @@
+package token
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryRevoker keeps revocations in process. It suits a single instance or
+// development; revocations are lost on restart and not shared.
+type MemoryRevoker struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]time.Time
+}
+
+func NewMemoryRevoker() *MemoryRevoker {
+	return &MemoryRevoker{entries: make(map[uuid.UUID]time.Time)}
+}
+
+func (r *MemoryRevoker) Revoke(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.entries[id]; !ok || expiresAt.After(current) {
+		r.entries[id] = expiresAt
+	}
+	return nil
+}
+
+func (r *MemoryRevoker) IsRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expiresAt, ok := r.entries[id]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (r *MemoryRevoker) PurgeExpired(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var purged int64
+	for id, expiresAt := range r.entries {
+		if !now.Before(expiresAt) {
+			delete(r.entries, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,34 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/google/uuid"
+)
+
+// PostgresRevoker keeps revocations in the revoked_tokens table so every
+// instance of the API sees them.
+type PostgresRevoker struct {
+	store db.Querier
+}
+
+func NewPostgresRevoker(store db.Querier) *PostgresRevoker {
+	return &PostgresRevoker{store: store}
+}
+
+func (r *PostgresRevoker) Revoke(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	return r.store.RevokeToken(ctx, db.RevokeTokenParams{
+		ID:        id,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (r *PostgresRevoker) IsRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	return r.store.IsTokenRevoked(ctx, id)
+}
+
+func (r *PostgresRevoker) PurgeExpired(ctx context.Context) (int64, error) {
+	return r.store.DeleteExpiredRevokedTokens(ctx)
+}
@@ -0,0 +1,211 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/go-live-cms/go-live-cms/db/mock"
+	db "github.com/go-live-cms/go-live-cms/db/sqlc"
+	"github.com/go-live-cms/go-live-cms/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRevoker records how often the cache falls through to it.
+type countingRevoker struct {
+	*MemoryRevoker
+	lookups int
+}
+
+func (r *countingRevoker) IsRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.lookups++
+	return r.MemoryRevoker.IsRevoked(ctx, id)
+}
+
+func TestMemoryRevoker(t *testing.T) {
+	ctx := context.Background()
+	revoker := NewMemoryRevoker()
+	id := uuid.New()
+
+	revoked, err := revoker.IsRevoked(ctx, id)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, revoker.Revoke(ctx, id, time.Now().Add(time.Minute)))
+	revoked, err = revoker.IsRevoked(ctx, id)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// An earlier expiry does not shorten an existing revocation.
+	require.NoError(t, revoker.Revoke(ctx, id, time.Now().Add(-time.Minute)))
+	revoked, err = revoker.IsRevoked(ctx, id)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	expired := uuid.New()
+	require.NoError(t, revoker.Revoke(ctx, expired, time.Now().Add(-time.Second)))
+	revoked, err = revoker.IsRevoked(ctx, expired)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	purged, err := revoker.PurgeExpired(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+}
+
+func TestCachedRevoker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CachesNegativeLookups", func(t *testing.T) {
+		backend := &countingRevoker{MemoryRevoker: NewMemoryRevoker()}
+		revoker := NewCachedRevoker(backend, 10, time.Minute)
+		id := uuid.New()
+
+		for i := 0; i < 3; i++ {
+			revoked, err := revoker.IsRevoked(ctx, id)
+			require.NoError(t, err)
+			require.False(t, revoked)
+		}
+		require.Equal(t, 1, backend.lookups)
+	})
+
+	t.Run("RevokeOverridesCachedNegative", func(t *testing.T) {
+		backend := &countingRevoker{MemoryRevoker: NewMemoryRevoker()}
+		revoker := NewCachedRevoker(backend, 10, time.Minute)
+		id := uuid.New()
+
+		revoked, err := revoker.IsRevoked(ctx, id)
+		require.NoError(t, err)
+		require.False(t, revoked)
+
+		require.NoError(t, revoker.Revoke(ctx, id, time.Now().Add(time.Minute)))
+		revoked, err = revoker.IsRevoked(ctx, id)
+		require.NoError(t, err)
+		require.True(t, revoked)
+		require.Equal(t, 1, backend.lookups)
+	})
+
+	t.Run("NegativeEntriesExpire", func(t *testing.T) {
+		backend := &countingRevoker{MemoryRevoker: NewMemoryRevoker()}
+		revoker := NewCachedRevoker(backend, 10, time.Millisecond)
+		id := uuid.New()
+
+		_, err := revoker.IsRevoked(ctx, id)
+		require.NoError(t, err)
+
+		// Another instance revokes the ID directly in the shared backend.
+		require.NoError(t, backend.Revoke(ctx, id, time.Now().Add(time.Minute)))
+		time.Sleep(5 * time.Millisecond)
+
+		revoked, err := revoker.IsRevoked(ctx, id)
+		require.NoError(t, err)
+		require.True(t, revoked)
+		require.Equal(t, 2, backend.lookups)
+	})
+
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		backend := &countingRevoker{MemoryRevoker: NewMemoryRevoker()}
+		revoker := NewCachedRevoker(backend, 2, time.Minute)
+		first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+		for _, id := range []uuid.UUID{first, second, first, third} {
+			_, err := revoker.IsRevoked(ctx, id)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 3, backend.lookups)
+
+		_, err := revoker.IsRevoked(ctx, first)
+		require.NoError(t, err)
+		require.Equal(t, 3, backend.lookups)
+
+		_, err = revoker.IsRevoked(ctx, second)
+		require.NoError(t, err)
+		require.Equal(t, 4, backend.lookups)
+	})
+
+	t.Run("EvictedRevocationIsStillRevoked", func(t *testing.T) {
+		revoker := NewCachedRevoker(NewMemoryRevoker(), 1, time.Minute)
+		id := uuid.New()
+
+		require.NoError(t, revoker.Revoke(ctx, id, time.Now().Add(time.Minute)))
+		_, err := revoker.IsRevoked(ctx, uuid.New())
+		require.NoError(t, err)
+
+		revoked, err := revoker.IsRevoked(ctx, id)
+		require.NoError(t, err)
+		require.True(t, revoked)
+	})
+}
+
+func TestPostgresRevoker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	revoker := NewPostgresRevoker(store)
+	id := uuid.New()
+	expiresAt := time.Now().Add(time.Minute)
+
+	store.EXPECT().
+		RevokeToken(gomock.Any(), gomock.Eq(db.RevokeTokenParams{ID: id, ExpiresAt: expiresAt})).
+		Times(1).
+		Return(nil)
+	store.EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Eq(id)).
+		Times(1).
+		Return(true, nil)
+	store.EXPECT().
+		DeleteExpiredRevokedTokens(gomock.Any()).
+		Times(1).
+		Return(int64(4), nil)
+
+	require.NoError(t, revoker.Revoke(context.Background(), id, expiresAt))
+
+	revoked, err := revoker.IsRevoked(context.Background(), id)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	purged, err := revoker.PurgeExpired(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(4), purged)
+}
+
+func TestIsPayloadRevoked(t *testing.T) {
+	ctx := context.Background()
+	revoker := NewMemoryRevoker()
+
+	payload, err := NewPayload(1, "user", time.Minute, "access")
+	require.NoError(t, err)
+	payload.SessionID = uuid.New()
+
+	revoked, err := IsPayloadRevoked(ctx, revoker, payload)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, revoker.Revoke(ctx, payload.SessionID, time.Now().Add(time.Minute)))
+	revoked, err = IsPayloadRevoked(ctx, revoker, payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	other, err := NewPayload(1, "user", time.Minute, "access")
+	require.NoError(t, err)
+	require.NoError(t, revoker.Revoke(ctx, other.ID, time.Now().Add(time.Minute)))
+	revoked, err = IsPayloadRevoked(ctx, revoker, other)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestNewRevoker(t *testing.T) {
+	revoker, err := NewRevoker(util.Config{TokenRevocationDriver: "memory"}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &MemoryRevoker{}, revoker)
+
+	revoker, err = NewRevoker(util.Config{TokenRevocationDriver: "postgres", TokenRevocationCacheSize: 10}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &CachedRevoker{}, revoker)
+
+	_, err = NewRevoker(util.Config{TokenRevocationDriver: "redis"}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRFC6238Vectors checks the SHA-1 test vectors from RFC 6238 appendix B.
+func TestRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, v := range vectors {
+		step := Step(time.Unix(v.unix, 0))
+		require.Equal(t, v.code, hotp(key, uint64(step), 8), "time %d", v.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+	require.Len(t, code, Digits)
+
+	step, err := Validate(secret, code, now)
+	require.NoError(t, err)
+	require.Equal(t, Step(now), step)
+
+	// A code from the previous period is still accepted to tolerate drift.
+	previous, err := Code(secret, now.Add(-Period))
+	require.NoError(t, err)
+	step, err = Validate(secret, previous, now)
+	require.NoError(t, err)
+	require.Equal(t, Step(now)-1, step)
+
+	stale, err := Code(secret, now.Add(-3*Period))
+	require.NoError(t, err)
+	if stale != code && stale != previous {
+		_, err = Validate(secret, stale, now)
+		require.ErrorIs(t, err, ErrInvalidCode)
+	}
+
+	_, err = Validate(secret, "12345", now)
+	require.ErrorIs(t, err, ErrInvalidCode)
+}
+
+func TestValidateRejectsBadSecret(t *testing.T) {
+	_, err := Validate("not base32!", "123456", time.Now())
+	require.Error(t, err)
+}
+
+func TestURI(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	uri, err := url.Parse(URI("GoLive CMS", "admin@example.com", secret))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/GoLive CMS:admin@example.com", uri.Path)
+	require.Equal(t, secret, uri.Query().Get("secret"))
+	require.Equal(t, "GoLive CMS", uri.Query().Get("issuer"))
+	require.Equal(t, "6", uri.Query().Get("digits"))
+}
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomToken(t *testing.T) {
+	token1, err := RandomToken(32)
+	require.NoError(t, err)
+	require.Len(t, token1, 43)
+	require.NotContains(t, token1, "=")
+
+	token2, err := RandomToken(32)
+	require.NoError(t, err)
+	require.NotEqual(t, token1, token2)
+}
+
+func TestHashToken(t *testing.T) {
+	token, err := RandomToken(32)
+	require.NoError(t, err)
+
+	hash := HashToken(token)
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, HashToken(token))
+	require.NotEqual(t, hash, HashToken(token+"x"))
+}
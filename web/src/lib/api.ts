@@ -22,6 +22,26 @@ console.log("Environment debug:", {
 
 console.log("API_BASE:", API_BASE);
 
+// Stored files are served relative to the API host, not the site. Pages are
+// rendered on the server too, so always resolve against the public API URL.
+export function mediaUrl(path: string): string {
+  if (!path || /^https?:\/\//.test(path)) return path;
+  const base = import.meta.env.PUBLIC_API_URL || "http://localhost:8080/api/v1";
+  return new URL(path, base).toString();
+}
+
+// In cookie mode the API keeps the tokens in HttpOnly cookies, and requests
+// that change something must echo the CSRF cookie in a header.
+export const AUTH_COOKIES = import.meta.env.PUBLIC_AUTH_COOKIES === "true";
+
+function csrfHeader(method: string): Record<string, string> {
+  if (!AUTH_COOKIES || method === "GET" || typeof document === "undefined") {
+    return {};
+  }
+  const match = document.cookie.match(/(?:^|;\s*)glc_csrf_token=([^;]*)/);
+  return match ? { "X-CSRF-Token": decodeURIComponent(match[1]) } : {};
+}
+
 interface ApiOptions {
   token?: string;
   method?: string;
@@ -33,7 +53,10 @@ interface ApiResponse<T> {
     count: number;
     limit: number;
     offset: number;
-    total: number;
+    total?: number;
+    total_estimated?: boolean;
+    next_cursor?: string | null;
+    prev_cursor?: string | null;
   };
   posts?: T[];
   taxonomies?: T[];
@@ -49,7 +72,9 @@ export async function apiCall(endpoint: string, options: ApiOptions = {}) {
     headers: {
       "Content-Type": "application/json",
       ...(token && { Authorization: `Bearer ${token}` }),
+      ...csrfHeader(method),
     },
+    ...(AUTH_COOKIES && { credentials: "include" as RequestCredentials }),
   };
 
   if (body) {
@@ -68,10 +93,12 @@ export async function apiCall(endpoint: string, options: ApiOptions = {}) {
 
       if (refreshed) {
         const newToken = authManager.getAccessToken();
-        if (newToken) {
+        if (newToken || AUTH_COOKIES) {
+          // Refreshing replaces the CSRF cookie as well as the tokens.
           config.headers = {
             ...config.headers,
-            Authorization: `Bearer ${newToken}`,
+            ...(newToken && { Authorization: `Bearer ${newToken}` }),
+            ...csrfHeader(method),
           };
           const retryResponse = await fetch(url, config);
           if (retryResponse.ok) {
@@ -99,20 +126,93 @@ export const api = {
   login: (credentials: { username: string; password: string }) =>
     apiCall("/auth/login", { method: "POST", body: credentials }),
 
-  renewAccessToken: (data: { refresh_token: string }) =>
+  // Both take no body in cookie mode, where the refresh token is a cookie.
+  renewAccessToken: (data?: { refresh_token: string }) =>
     apiCall("/auth/refresh", { method: "POST", body: data }),
 
-  logout: (data: { refresh_token: string }) =>
+  logout: (data?: { refresh_token: string }) =>
     apiCall("/auth/logout", { method: "POST", body: data }),
-  getPosts: async () => {
-    const response: ApiResponse<any> = await apiCall("/posts");
+
+  register: (data: {
+    username: string;
+    email: string;
+    full_name: string;
+    password: string;
+  }) => apiCall("/auth/register", { method: "POST", body: data }),
+
+  verifyEmail: (token: string) =>
+    apiCall("/auth/verify-email", { method: "POST", body: { token } }),
+
+  resendVerificationEmail: (email: string) =>
+    apiCall("/auth/verify-email/resend", { method: "POST", body: { email } }),
+
+  forgotPassword: (email: string) =>
+    apiCall("/auth/password/forgot", { method: "POST", body: { email } }),
+
+  resetPassword: (data: { token: string; password: string }) =>
+    apiCall("/auth/password/reset", { method: "POST", body: data }),
+
+  verifyMFA: (data: { mfa_token: string; code: string }) =>
+    apiCall("/auth/mfa/verify", { method: "POST", body: data }),
+
+  // params takes list query parameters such as
+  // { "filter[author_id]": "3", sort: "-changed_at,title" }.
+  getPosts: async (params: Record<string, string> = {}) => {
+    const query = new URLSearchParams(params).toString();
+    const response: ApiResponse<any> = await apiCall(
+      query ? `/posts?${query}` : "/posts"
+    );
+    return {
+      data: response.posts || [],
+      meta: response.meta || { count: 0, limit: 10, offset: 0, total: 0 },
+    };
+  },
+  // Searches one language at a time (filters.language, default english).
+  // title_highlight and snippet are escaped HTML with matches in <mark> tags.
+  searchPosts: async (query: string, filters: Record<string, string> = {}) => {
+    const params = new URLSearchParams({ ...filters, q: query });
+    const response: ApiResponse<any> = await apiCall(`/posts/search?${params}`);
     return {
       data: response.posts || [],
       meta: response.meta || { count: 0, limit: 10, offset: 0, total: 0 },
     };
   },
-  getPost: (id: string) => apiCall(`/posts/${id}`),
+  // include embeds related resources, e.g. ["taxonomies", "media", "authors"].
+  getPost: (id: string, include: string[] = []) =>
+    apiCall(
+      include.length
+        ? `/posts/${id}?include=${include.join(",")}`
+        : `/posts/${id}`
+    ),
   getPostsByUser: (userId: string) => apiCall(`/posts/user/${userId}`),
+  transitionPost: (
+    id: number,
+    action: "submit" | "approve" | "reject" | "publish" | "unpublish",
+    token?: string
+  ) => apiCall(`/posts/${id}/${action}`, { method: "POST", token }),
+  schedulePost: (
+    id: number,
+    schedule: { publish_at: string | null; unpublish_at: string | null },
+    token?: string
+  ) =>
+    apiCall(`/posts/${id}/schedule`, { method: "PUT", body: schedule, token }),
+  getPostRevisions: (id: number, token?: string) =>
+    apiCall(`/posts/${id}/revisions`, { token }),
+  diffPostRevisions: (
+    id: number,
+    revision: number,
+    from?: number,
+    token?: string
+  ) =>
+    apiCall(
+      `/posts/${id}/revisions/${revision}/diff${from ? `?from=${from}` : ""}`,
+      { token }
+    ),
+  restorePostRevision: (id: number, revision: number, token?: string) =>
+    apiCall(`/posts/${id}/revisions/${revision}/restore`, {
+      method: "POST",
+      token,
+    }),
 
   getUsers: async (token?: string) => {
     const response: ApiResponse<any> = await apiCall("/users", { token });
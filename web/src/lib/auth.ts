@@ -1,4 +1,4 @@
-import { api } from "./api";
+import { api, AUTH_COOKIES } from "./api";
 
 export interface AuthState {
   isAuthenticated: boolean;
@@ -10,6 +10,7 @@ export interface AuthState {
 export class AuthManager {
   private static instance: AuthManager;
   private state: AuthState;
+  private refreshing = false;
 
   private constructor() {
     this.state = this.getStoredAuth();
@@ -37,7 +38,9 @@ export class AuthManager {
     const user = localStorage.getItem("user");
 
     return {
-      isAuthenticated: !!accessToken,
+      // In cookie mode the tokens are never visible here, so the stored
+      // user is what marks a login.
+      isAuthenticated: AUTH_COOKIES ? !!user : !!accessToken,
       user: user ? JSON.parse(user) : null,
       accessToken,
       refreshToken,
@@ -47,23 +50,24 @@ export class AuthManager {
   async login(
     username: string,
     password: string
-  ): Promise<{ success: boolean; error?: string }> {
+  ): Promise<{
+    success: boolean;
+    error?: string;
+    mfaToken?: string;
+    mfaEnrollmentRequired?: boolean;
+  }> {
     try {
       const response = await api.login({ username, password });
 
-      this.state = {
-        isAuthenticated: true,
-        user: response.user,
-        accessToken: response.access_token,
-        refreshToken: response.refresh_token,
-      };
-
-      if (typeof window !== "undefined") {
-        localStorage.setItem("access_token", response.access_token);
-        localStorage.setItem("refresh_token", response.refresh_token);
-        localStorage.setItem("user", JSON.stringify(response.user));
+      if (response.mfa_required) {
+        return {
+          success: false,
+          mfaToken: response.mfa_token,
+          mfaEnrollmentRequired: response.mfa_enrollment_required,
+        };
       }
 
+      this.storeLogin(response);
       return { success: true };
     } catch (error) {
       return {
@@ -73,9 +77,44 @@ export class AuthManager {
     }
   }
 
+  async verifyMFA(
+    mfaToken: string,
+    code: string
+  ): Promise<{ success: boolean; error?: string }> {
+    try {
+      const response = await api.verifyMFA({ mfa_token: mfaToken, code });
+      this.storeLogin(response);
+      return { success: true };
+    } catch (error) {
+      return {
+        success: false,
+        error: error instanceof Error ? error.message : "Verification failed",
+      };
+    }
+  }
+
+  private storeLogin(response: any): void {
+    this.state = {
+      isAuthenticated: true,
+      user: response.user,
+      accessToken: response.access_token,
+      refreshToken: response.refresh_token,
+    };
+
+    if (typeof window !== "undefined") {
+      if (!AUTH_COOKIES) {
+        localStorage.setItem("access_token", response.access_token);
+        localStorage.setItem("refresh_token", response.refresh_token);
+      }
+      localStorage.setItem("user", JSON.stringify(response.user));
+    }
+  }
+
   async logout(): Promise<void> {
     try {
-      if (this.state.refreshToken) {
+      if (AUTH_COOKIES) {
+        await api.logout();
+      } else if (this.state.refreshToken) {
         await api.logout({ refresh_token: this.state.refreshToken });
       }
     } catch (error) {
@@ -101,6 +140,21 @@ export class AuthManager {
   }
 
   async refreshAccessToken(): Promise<boolean> {
+    if (AUTH_COOKIES) {
+      // A failed refresh answers 401 itself; don't try to refresh that.
+      if (this.refreshing || !this.state.isAuthenticated) return false;
+      this.refreshing = true;
+      try {
+        await api.renewAccessToken();
+        return true;
+      } catch (error) {
+        this.clearAuth();
+        return false;
+      } finally {
+        this.refreshing = false;
+      }
+    }
+
     if (!this.state.refreshToken) return false;
 
     try {
@@ -109,9 +163,11 @@ export class AuthManager {
       });
 
       this.state.accessToken = response.access_token;
+      this.state.refreshToken = response.refresh_token;
 
       if (typeof window !== "undefined") {
         localStorage.setItem("access_token", response.access_token);
+        localStorage.setItem("refresh_token", response.refresh_token);
       }
 
       return true;
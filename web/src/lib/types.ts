@@ -6,8 +6,17 @@ export interface User {
   role: string;
   created_at: string;
   password_changed_at?: string;
+  email_verified_at?: string;
+  version: number;
 }
 
+export type PostStatus =
+  | "draft"
+  | "pending_review"
+  | "scheduled"
+  | "published"
+  | "archived";
+
 export interface Post {
   id: number;
   title: string;
@@ -16,24 +25,79 @@ export interface Post {
   user_id: number;
   username: string;
   url: string;
+  status: PostStatus;
+  published_at?: string;
+  publish_at?: string;
+  unpublish_at?: string;
   created_at: string;
   changed_at: string;
+  version: number;
+}
+
+export interface PostRevision {
+  post_id: number;
+  revision: number;
+  title: string;
+  description: string;
+  content: string;
+  url: string;
+  user_id: number | null;
+  username: string;
+  restored_from?: number;
+  created_at: string;
+}
+
+export interface DiffLine {
+  op: "equal" | "insert" | "delete";
+  text: string;
+  old_line?: number;
+  new_line?: number;
+}
+
+export interface PostRevisionDiff {
+  post_id: number;
+  from: number;
+  to: number;
+  changed: boolean;
+  fields: Record<"title" | "description" | "content" | "url", DiffLine[]>;
 }
 
 export interface Taxonomy {
   id: number;
   name: string;
   description: string;
+  version: number;
 }
 
-export interface Media {
-  id: number;
-  filename: string;
-  original_name: string;
+export interface MediaRendition {
+  url: string;
+  width: number;
+  height: number;
   mime_type: string;
   size: number;
-  path: string;
+}
+
+export interface SrcsetEntry {
   url: string;
+  width: number;
+  mime_type: string;
+}
+
+export interface Media {
+  id: number;
+  name: string;
+  description: string;
+  alt: string;
+  media_path: string;
   user_id: number;
+  size: number;
+  mime_type: string;
+  checksum?: string;
+  width?: number;
+  height?: number;
+  renditions?: Record<string, MediaRendition>;
+  srcset?: SrcsetEntry[];
   created_at: string;
+  changed_at: string;
+  version: number;
 }